@@ -86,28 +86,20 @@ func (ab *AnnualBillingJob) AnnualBilling() error {
 		totalCostsCents := int(math.Ceil(membershipCosts))
 		// any regular costs are accured towards monthly billing, no need to charge anything here
 		regularCostsCents := 0
-		stmt, err := ab.db.Prepare("INSERT INTO users_invoices (`cents`, `membership_costs`, `status`, `user_id`, `workspace_id`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, ?, ?, ?)")
-		if err != nil {
-			helpers.Log(logrus.ErrorLevel, "could not prepare query..\r\n")
-			helpers.Log(logrus.ErrorLevel, err.Error())
-			continue
-		}
-		defer stmt.Close()
-
-		res, err := stmt.Exec(regularCostsCents, totalCostsCents, "INCOMPLETE", workspace.CreatorId, workspace.Id, currentTime, currentTime)
+		invoiceId, err := createOpenInvoice(ab.db, &models.Invoice{
+			CreatedAt:       currentTime,
+			Source:          "SUBSCRIPTION",
+			UserID:          workspace.CreatorId,
+			WorkspaceID:     workspace.Id,
+			Cents:           int64(regularCostsCents),
+			MembershipCosts: int64(totalCostsCents),
+		})
 		if err != nil {
 			helpers.Log(logrus.ErrorLevel, "error creating invoice..\r\n")
 			helpers.Log(logrus.ErrorLevel, err.Error())
 			continue
 		}
 
-		invoiceId, err := res.LastInsertId()
-		if err != nil {
-			helpers.Log(logrus.ErrorLevel, "could not get insert id..\r\n")
-			helpers.Log(logrus.ErrorLevel, err.Error())
-			continue
-		}
-
 		helpers.Log(logrus.InfoLevel, "Charging recurringly with card..\r\n")
 		invoice := models.UserInvoice{
 			Id:          int(invoiceId),
@@ -120,13 +112,7 @@ func (ab *AnnualBillingJob) AnnualBilling() error {
 			helpers.Log(logrus.ErrorLevel, "error charging user..\r\n")
 			helpers.Log(logrus.ErrorLevel, err.Error())
 
-			stmt, err := ab.db.Prepare("UPDATE users_invoices SET status = 'INCOMPLETE', source = 'CARD', cents_collected = 0.0 WHERE id = ?")
-			if err != nil {
-				helpers.Log(logrus.ErrorLevel, "could not prepare query..\r\n")
-				continue
-			}
-
-			_, err = stmt.Exec(invoiceId)
+			err = markInvoiceAttemptFailed(ab.db, invoiceId, "CARD", currentTime)
 			if err != nil {
 				helpers.Log(logrus.ErrorLevel, "error updating invoice....\r\n")
 				helpers.Log(logrus.ErrorLevel, err.Error())
@@ -136,21 +122,9 @@ func (ab *AnnualBillingJob) AnnualBilling() error {
 			continue
 		}
 
-		confNumber, err := utils.CreateInvoiceConfirmationNumber()
-		if err != nil {
-			helpers.Log(logrus.ErrorLevel, "error while generating confirmation number: "+err.Error())
-			continue
-		}
-
-		stmt, err = ab.db.Prepare("UPDATE users_invoices SET status = 'COMPLETE', source ='CARD', cents_collected = ?, confirmation_number = ? WHERE id = ?")
-		if err != nil {
-			helpers.Log(logrus.ErrorLevel, "could not prepare query..\r\n")
-			continue
-		}
-
-		_, err = stmt.Exec(totalCostsCents, confNumber, invoiceId)
+		err = markInvoicePaid(ab.db, invoiceId, "CARD", int64(totalCostsCents), currentTime)
 		if err != nil {
-			helpers.Log(logrus.ErrorLevel, "error updating debit..\r\n")
+			helpers.Log(logrus.ErrorLevel, "error updating invoice..\r\n")
 			helpers.Log(logrus.ErrorLevel, err.Error())
 			continue
 		}
@@ -10,6 +10,7 @@ import (
 	helpers "github.com/Lineblocs/go-helpers"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"lineblocs.com/scheduler/internal/invoice"
 	"lineblocs.com/scheduler/mocks"
 )
 
@@ -212,26 +213,14 @@ func TestAnnualBilling(t *testing.T) {
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).
 				AddRow(worksSpaceUsers))
 
-		// Mock expectations for the INSERT into users_invoices
+		// Mock expectations for the invoice, opened for collection
 		membershipCosts := float64(0) * float64(worksSpaceUsers)
-		totalCostsCents := int(math.Ceil(membershipCosts))
-		invoiceStatus := "INCOMPLETE"
-		regularCostsCents := 0
-
-		// Mock expectations for the INSERT into users_invoices
-		sqlQuery := "INSERT INTO users_invoices (`cents`, `membership_costs`, `status`, `user_id`, `workspace_id`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, ?, ?, ?)"
-		mockSql.ExpectPrepare(regexp.QuoteMeta(sqlQuery)).
-			ExpectExec().
-			WithArgs(regularCostsCents, totalCostsCents, invoiceStatus, testWorkspace.CreatorId, testWorkspace.Id, sqlmock.AnyArg(), sqlmock.AnyArg()).
-			WillReturnResult(sqlmock.NewResult(1, 1))
-
-		// Mock expectations for the LastInsertId
-		sqlInsertId := "UPDATE users_invoices SET status = 'COMPLETE', source ='CARD', cents_collected = ?, confirmation_number = ? WHERE id = ?"
-		escapedInsertId := regexp.QuoteMeta(sqlInsertId)
-		mockSql.ExpectPrepare(escapedInsertId).
-			ExpectExec().
-			WithArgs(totalCostsCents, sqlmock.AnyArg(), 1).
-			WillReturnError(errors.New("failed to update users_invoices"))
+		totalCostsCents := int64(math.Ceil(membershipCosts))
+		expectCreateOpenInvoice(mockSql, 1, int64(0), int64(0), int64(0), int64(0), int64(0), totalCostsCents, int64(0), int64(0), int64(0),
+			invoice.Draft, "INCOMPLETE", testWorkspace.CreatorId, testWorkspace.Id, nil, nil, nil, nil, nil, nil, nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg(), "SUBSCRIPTION", "")
+
+		// Mock expectations for the payment
+		expectMarkInvoicePaid(mockSql, 1, "CARD", totalCostsCents, errors.New("failed to update users_invoices"))
 
 		job := NewAnnualBillingJob(db, mockWorkspace, mockPayment)
 		err = job.AnnualBilling()
@@ -281,26 +270,14 @@ func TestAnnualBilling(t *testing.T) {
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).
 				AddRow(worksSpaceUsers))
 
-		// Mock expectations for the INSERT into users_invoices
+		// Mock expectations for the invoice, opened for collection
 		membershipCosts := float64(0) * float64(worksSpaceUsers)
-		totalCostsCents := int(math.Ceil(membershipCosts))
-		invoiceStatus := "INCOMPLETE"
-		regularCostsCents := 0
-
-		// Mock expectations for the INSERT into users_invoices
-		sqlQuery := "INSERT INTO users_invoices (`cents`, `membership_costs`, `status`, `user_id`, `workspace_id`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, ?, ?, ?)"
-		mockSql.ExpectPrepare(regexp.QuoteMeta(sqlQuery)).
-			ExpectExec().
-			WithArgs(regularCostsCents, totalCostsCents, invoiceStatus, testWorkspace.CreatorId, testWorkspace.Id, sqlmock.AnyArg(), sqlmock.AnyArg()).
-			WillReturnResult(sqlmock.NewResult(1, 1))
-
-		// Mock expectations for the LastInsertId
-		sqlInsertId := "UPDATE users_invoices SET status = 'INCOMPLETE', source = 'CARD', cents_collected = 0.0 WHERE id = ?"
-		escapedInsertId := regexp.QuoteMeta(sqlInsertId)
-		mockSql.ExpectPrepare(escapedInsertId).
-			ExpectExec().
-			WithArgs(1).
-			WillReturnResult(sqlmock.NewResult(1, 1))
+		totalCostsCents := int64(math.Ceil(membershipCosts))
+		expectCreateOpenInvoice(mockSql, 1, int64(0), int64(0), int64(0), int64(0), int64(0), totalCostsCents, int64(0), int64(0), int64(0),
+			invoice.Draft, "INCOMPLETE", testWorkspace.CreatorId, testWorkspace.Id, nil, nil, nil, nil, nil, nil, nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg(), "SUBSCRIPTION", "")
+
+		// Mock expectations for the failed charge
+		expectMarkInvoiceAttemptFailed(mockSql, 1, "CARD")
 
 		job := NewAnnualBillingJob(db, mockWorkspace, mockPayment)
 		err = job.AnnualBilling()
@@ -350,26 +327,14 @@ func TestAnnualBilling(t *testing.T) {
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).
 				AddRow(worksSpaceUsers))
 
-		// Mock expectations for the INSERT into users_invoices
+		// Mock expectations for the invoice, opened for collection
 		membershipCosts := float64(0) * float64(worksSpaceUsers)
-		totalCostsCents := int(math.Ceil(membershipCosts))
-		invoiceStatus := "INCOMPLETE"
-		regularCostsCents := 0
-
-		// Mock expectations for the INSERT into users_invoices
-		sqlQuery := "INSERT INTO users_invoices (`cents`, `membership_costs`, `status`, `user_id`, `workspace_id`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, ?, ?, ?)"
-		mockSql.ExpectPrepare(regexp.QuoteMeta(sqlQuery)).
-			ExpectExec().
-			WithArgs(regularCostsCents, totalCostsCents, invoiceStatus, testWorkspace.CreatorId, testWorkspace.Id, sqlmock.AnyArg(), sqlmock.AnyArg()).
-			WillReturnResult(sqlmock.NewResult(1, 1))
-
-		// Mock expectations for the LastInsertId
-		sqlInsertId := "UPDATE users_invoices SET status = 'COMPLETE', source ='CARD', cents_collected = ?, confirmation_number = ? WHERE id = ?"
-		escapedInsertId := regexp.QuoteMeta(sqlInsertId)
-		mockSql.ExpectPrepare(escapedInsertId).
-			ExpectExec().
-			WithArgs(totalCostsCents, sqlmock.AnyArg(), 1).
-			WillReturnResult(sqlmock.NewResult(1, 1))
+		totalCostsCents := int64(math.Ceil(membershipCosts))
+		expectCreateOpenInvoice(mockSql, 1, int64(0), int64(0), int64(0), int64(0), int64(0), totalCostsCents, int64(0), int64(0), int64(0),
+			invoice.Draft, "INCOMPLETE", testWorkspace.CreatorId, testWorkspace.Id, nil, nil, nil, nil, nil, nil, nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg(), "SUBSCRIPTION", "")
+
+		// Mock expectations for the payment
+		expectMarkInvoicePaid(mockSql, 1, "CARD", totalCostsCents, nil)
 
 		job := NewAnnualBillingJob(db, mockWorkspace, mockPayment)
 		err = job.AnnualBilling()
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"time"
+
+	utils "lineblocs.com/scheduler/utils"
+)
+
+// top up the credits of pay-as-you-go workspaces whose balance fell below their auto-recharge threshold and print the report
+func AutoTopUp(args []string) error {
+	flags := flag.NewFlagSet("auto_topup", flag.ContinueOnError)
+	staleAfter := flags.Duration("stale-after", 15*time.Minute, "charge top-ups again once they have been pending this long")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		return err
+	}
+
+	billingSvc, closeQueue := newPublishingBillingService(db)
+	defer closeQueue()
+
+	report, err := billingSvc.RunAutoTopUps(time.Now(), *staleAfter)
+	if err != nil {
+		return err
+	}
+
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
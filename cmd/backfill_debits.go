@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"lineblocs.com/scheduler/repository"
+	utils "lineblocs.com/scheduler/utils"
+)
+
+// mark the debits billed before invoices claimed their debits, so the next invoice does not bill them again
+func BackfillDebits(args []string) error {
+	flags := flag.NewFlagSet("backfill_debits", flag.ContinueOnError)
+	before := flags.String("before", "", "first day billed by invoices that claim their debits (YYYY-MM-DD)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *before == "" {
+		return fmt.Errorf("usage: backfill_debits -before YYYY-MM-DD")
+	}
+	cutover, err := time.Parse(time.DateOnly, *before)
+	if err != nil {
+		return fmt.Errorf("invalid before date: %w", err)
+	}
+
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		return err
+	}
+
+	marked, err := repository.NewInvoiceRepository().BackfillBilledDebits(db, cutover)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Marked %d debits created before %s as billed\n", marked, *before)
+	return nil
+}
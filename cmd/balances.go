@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"time"
+
+	"lineblocs.com/scheduler/internal/billing"
+	utils "lineblocs.com/scheduler/utils"
+)
+
+// update the running balances of prepaid workspaces, publish cutoff and restore events and print the report
+func EnforceBalances(args []string) error {
+	flags := flag.NewFlagSet("enforce_balances", flag.ContinueOnError)
+	resyncAfter := flags.Duration("resync-after", billing.BalanceResyncAfter, "recompute running balances from the whole ledger once they are this old")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		return err
+	}
+
+	billingSvc, closeQueue := newPublishingBillingService(db)
+	defer closeQueue()
+
+	report, err := billingSvc.EnforceBalances(time.Now(), *resyncAfter)
+	if err != nil {
+		return err
+	}
+
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
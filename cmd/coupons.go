@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+
+	"lineblocs.com/scheduler/internal/billing"
+	"lineblocs.com/scheduler/repository"
+	utils "lineblocs.com/scheduler/utils"
+)
+
+// redeem a coupon for a workspace so it is applied from its next invoice on
+func RedeemCoupon(args []string) error {
+	flags := flag.NewFlagSet("redeem_coupon", flag.ContinueOnError)
+	workspaceID := flags.Int("workspace", 0, "workspace ID to redeem the coupon for")
+	code := flags.String("code", "", "coupon code")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *workspaceID == 0 || *code == "" {
+		return fmt.Errorf("usage: redeem_coupon -workspace ID -code CODE")
+	}
+
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		return err
+	}
+
+	billingSvc := billing.NewBillingService(db, repository.NewWorkspaceRepository(db), repository.NewPaymentRepository(db))
+	redemptionID, err := billingSvc.RedeemCoupon(*workspaceID, *code)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Redeemed coupon %s for workspace %d (redemption %d)\n", *code, *workspaceID, redemptionID)
+	return nil
+}
@@ -161,10 +161,18 @@ func runBillingDistributor(scheduleType string) {
 			s.provider_subscription_id
 		FROM subscriptions s
 		JOIN workspaces w ON s.workspace_id = w.id
-		WHERE s.status = 'ACTIVE' AND s.billing_cycle = ?
+		WHERE s.status = 'ACTIVE' AND s.billing_cycle IN (?, ?)
 	`
 
-	rows, err := db.QueryContext(ctx, query, queryTerm)
+	// The monthly run also queues ANNUAL subscriptions on purpose: the worker bills them on the annual_overage
+	// term, which charges no membership and only the usage of the past month. Their membership is prepaid
+	// on the annual run, which queues ANNUAL subscriptions only.
+	overageTerm := queryTerm
+	if queryTerm == "MONTHLY" {
+		overageTerm = "ANNUAL"
+	}
+
+	rows, err := db.QueryContext(ctx, query, queryTerm, overageTerm)
 	if err != nil {
 		log.Printf("[%s] DB Query Error: %v", scheduleType, err)
 		return
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"lineblocs.com/scheduler/internal/billing"
+	models "lineblocs.com/scheduler/models"
+	"lineblocs.com/scheduler/repository"
+	utils "lineblocs.com/scheduler/utils"
+)
+
+// estimate a workspace's bill without writing anything and print the breakdown
+func Estimate(args []string) error {
+	flags := flag.NewFlagSet("estimate", flag.ContinueOnError)
+	workspaceID := flags.Int("workspace", 0, "workspace ID to estimate")
+	from := flags.String("from", "", "first day of the period (YYYY-MM-DD), defaults to the start of the billing term")
+	to := flags.String("to", "", "day after the last day of the period (YYYY-MM-DD), defaults to now")
+	billingType := flags.String("type", "MONTHLY", "billing run to estimate, MONTHLY or ANNUAL")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *workspaceID == 0 {
+		return fmt.Errorf("usage: estimate -workspace ID [-from YYYY-MM-DD] [-to YYYY-MM-DD] [-type MONTHLY|ANNUAL]")
+	}
+
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		return err
+	}
+
+	billingSvc := billing.NewBillingService(db, repository.NewWorkspaceRepository(db), repository.NewPaymentRepository(db))
+	estimate, err := billingSvc.Estimate(models.EstimateTask{
+		BillingType: *billingType,
+		StartDate:   *from,
+		EndDate:     *to,
+		WorkspaceID: *workspaceID,
+	})
+	if err != nil {
+		return err
+	}
+
+	out, err := json.MarshalIndent(estimate, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
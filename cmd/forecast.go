@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+
+	"lineblocs.com/scheduler/internal/billing"
+	"lineblocs.com/scheduler/repository"
+	utils "lineblocs.com/scheduler/utils"
+)
+
+// project every workspace's month-end bill, email the ones over their limits and print the report
+func ForecastBills(args []string) error {
+	thresholdDefault, _ := strconv.ParseInt(utils.Config("FORECAST_THRESHOLD_CENTS"), 10, 64)
+	overPriorDefault, _ := strconv.ParseFloat(utils.Config("FORECAST_OVER_PRIOR_PERCENT"), 64)
+
+	flags := flag.NewFlagSet("forecast_bills", flag.ContinueOnError)
+	thresholdCents := flags.Int64("threshold-cents", thresholdDefault, "email workspaces projected over this many cents, 0 to disable")
+	overPriorPercent := flags.Float64("over-prior-percent", overPriorDefault, "email workspaces projected this many percent over last month, 0 to disable")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		return err
+	}
+
+	billingSvc := billing.NewBillingService(db, repository.NewWorkspaceRepository(db), repository.NewPaymentRepository(db))
+	report, err := billingSvc.RunForecasts(time.Now(), billing.ForecastConfig{
+		ThresholdCents:   *thresholdCents,
+		OverPriorPercent: *overPriorPercent,
+	})
+	if err != nil {
+		return err
+	}
+
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"time"
+
+	"lineblocs.com/scheduler/internal/billing"
+	"lineblocs.com/scheduler/repository"
+	utils "lineblocs.com/scheduler/utils"
+)
+
+// email the owners of unpaid net terms invoices as they come due and print the report
+func SendInvoiceReminders(args []string) error {
+	scheduleDefault := utils.Config("INVOICE_REMINDER_DAYS")
+	if scheduleDefault == "" {
+		scheduleDefault = "-3,0,7,14,30"
+	}
+
+	flags := flag.NewFlagSet("invoice_reminders", flag.ContinueOnError)
+	scheduleFlag := flags.String("days", scheduleDefault, "comma separated days from the due date to remind on, negative days are before it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	schedule, err := billing.ParseReminderSchedule(*scheduleFlag)
+	if err != nil {
+		return err
+	}
+
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		return err
+	}
+
+	billingSvc := billing.NewBillingService(db, repository.NewWorkspaceRepository(db), repository.NewPaymentRepository(db))
+	report, err := billingSvc.SendInvoiceReminders(time.Now(), schedule)
+	if err != nil {
+		return err
+	}
+
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"lineblocs.com/scheduler/internal/invoice"
+	models "lineblocs.com/scheduler/models"
+	"lineblocs.com/scheduler/repository"
+	utils "lineblocs.com/scheduler/utils"
+)
+
+// createOpenInvoice inserts an invoice and opens it for collection
+func createOpenInvoice(db *sql.DB, inv *models.Invoice) (int64, error) {
+	invoiceRepository := repository.NewInvoiceRepository()
+
+	var invoiceID int64
+	err := repository.NewUnitOfWork(db).Do(func(tx repository.Executor) error {
+		var err error
+		invoiceID, err = invoiceRepository.CreateInvoice(tx, inv)
+		if err != nil {
+			return err
+		}
+		return invoiceRepository.TransitionInvoice(tx, invoiceID, invoice.Open, "finalized", inv.CreatedAt)
+	})
+
+	return invoiceID, err
+}
+
+// markInvoicePaid records the payment and moves the invoice to paid
+func markInvoicePaid(db *sql.DB, invoiceID int64, source string, cents int64, at time.Time) error {
+	confNumber, err := utils.CreateInvoiceConfirmationNumber()
+	if err != nil {
+		return err
+	}
+
+	invoiceRepository := repository.NewInvoiceRepository()
+	return repository.NewUnitOfWork(db).Do(func(tx repository.Executor) error {
+		if err := invoiceRepository.RecordPayment(tx, invoiceID, source, cents, confNumber, at); err != nil {
+			return err
+		}
+		return invoiceRepository.TransitionInvoice(tx, invoiceID, invoice.Paid, fmt.Sprintf("paid with %s", strings.ToLower(source)), at)
+	})
+}
+
+// markInvoiceAttemptFailed counts a failed charge, leaving the invoice open
+func markInvoiceAttemptFailed(db *sql.DB, invoiceID int64, source string, at time.Time) error {
+	return repository.NewInvoiceRepository().RecordFailedAttempt(db, invoiceID, source, at)
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"database/sql/driver"
+	"regexp"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"lineblocs.com/scheduler/internal/invoice"
+)
+
+// expectInvoiceTransition mocks the state lookup, update and event written by a transition
+func expectInvoiceTransition(mockSql sqlmock.Sqlmock, invoiceID int64, from, to invoice.Status) {
+	mockSql.ExpectQuery(regexp.QuoteMeta("SELECT state, status FROM users_invoices WHERE id = ? FOR UPDATE")).
+		WithArgs(invoiceID).
+		WillReturnRows(sqlmock.NewRows([]string{"state", "status"}).AddRow(string(from), from.Legacy()))
+	mockSql.ExpectExec(regexp.QuoteMeta("UPDATE users_invoices SET state = ?, status = ?, updated_at = ? WHERE id = ?")).
+		WithArgs(to, to.Legacy(), sqlmock.AnyArg(), invoiceID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockSql.ExpectExec(regexp.QuoteMeta("INSERT INTO invoice_events")).
+		WithArgs(invoiceID, from, to, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+// expectCreateOpenInvoice mocks createOpenInvoice inserting a draft invoice and opening it
+func expectCreateOpenInvoice(mockSql sqlmock.Sqlmock, invoiceID int64, args ...driver.Value) {
+	mockSql.ExpectBegin()
+	mockSql.ExpectExec(regexp.QuoteMeta("INSERT INTO users_invoices")).
+		WithArgs(args...).
+		WillReturnResult(sqlmock.NewResult(invoiceID, 1))
+	mockSql.ExpectExec(regexp.QuoteMeta("INSERT INTO invoice_events")).
+		WithArgs(invoiceID, invoice.Status(""), invoice.Draft, "created", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectInvoiceTransition(mockSql, invoiceID, invoice.Draft, invoice.Open)
+	mockSql.ExpectCommit()
+}
+
+// expectMarkInvoicePaid mocks markInvoicePaid, failing the payment update when err is set
+func expectMarkInvoicePaid(mockSql sqlmock.Sqlmock, invoiceID int64, source string, cents int64, err error) {
+	mockSql.ExpectBegin()
+	payment := mockSql.ExpectExec(regexp.QuoteMeta("UPDATE users_invoices SET source = ?, cents_collected = COALESCE(cents_collected, 0) + ?")).
+		WithArgs(source, cents, sqlmock.AnyArg(), sqlmock.AnyArg(), invoiceID)
+	if err != nil {
+		payment.WillReturnError(err)
+		mockSql.ExpectRollback()
+		return
+	}
+	payment.WillReturnResult(sqlmock.NewResult(0, 1))
+	expectInvoiceTransition(mockSql, invoiceID, invoice.Open, invoice.Paid)
+	mockSql.ExpectCommit()
+}
+
+// expectMarkInvoiceAttemptFailed mocks markInvoiceAttemptFailed
+func expectMarkInvoiceAttemptFailed(mockSql sqlmock.Sqlmock, invoiceID int64, source string) {
+	mockSql.ExpectExec(regexp.QuoteMeta("UPDATE users_invoices SET source = ?, last_attempted = ?")).
+		WithArgs(source, sqlmock.AnyArg(), invoiceID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+
+	"lineblocs.com/scheduler/internal/billing"
+	"lineblocs.com/scheduler/internal/latefee"
+	"lineblocs.com/scheduler/repository"
+	utils "lineblocs.com/scheduler/utils"
+)
+
+// charge late fees and interest on overdue invoices, email their owners and print the report
+func ApplyLateFees(args []string) error {
+	flatDefault, _ := strconv.ParseInt(utils.Config("LATE_FEE_FLAT_CENTS"), 10, 64)
+	percentDefault, _ := strconv.ParseFloat(utils.Config("LATE_FEE_MONTHLY_PERCENT"), 64)
+	graceDefault, _ := strconv.Atoi(utils.Config("LATE_FEE_GRACE_DAYS"))
+	capCentsDefault, _ := strconv.ParseInt(utils.Config("LATE_FEE_CAP_CENTS"), 10, 64)
+	capPercentDefault, _ := strconv.ParseFloat(utils.Config("LATE_FEE_CAP_PERCENT"), 64)
+	modeDefault := utils.Config("LATE_FEE_MODE")
+	if modeDefault == "" {
+		modeDefault = billing.LateFeeLineItem
+	}
+
+	flags := flag.NewFlagSet("late_fees", flag.ContinueOnError)
+	flatCents := flags.Int64("flat-cents", flatDefault, "fee charged once when the grace period ends, 0 to disable")
+	monthlyPercent := flags.Float64("monthly-percent", percentDefault, "interest charged on the unpaid balance for each month overdue, 0 to disable")
+	graceDays := flags.Int("grace-days", graceDefault, "days after the due date before anything is charged")
+	capCents := flags.Int64("cap-cents", capCentsDefault, "most late charges one invoice can get, 0 for no cap")
+	capPercent := flags.Float64("cap-percent", capPercentDefault, "most late charges one invoice can get as a percentage of what it billed, 0 for no cap")
+	mode := flags.String("mode", modeDefault, "line_item adds the charges to the overdue invoice, invoice bills them on a linked invoice")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		return err
+	}
+
+	billingSvc := billing.NewBillingService(db, repository.NewWorkspaceRepository(db), repository.NewPaymentRepository(db))
+	report, err := billingSvc.ApplyLateFees(time.Now(), billing.LateFeeConfig{
+		Mode: *mode,
+		Policy: latefee.Policy{
+			FlatCents:      *flatCents,
+			MonthlyPercent: *monthlyPercent,
+			GraceDays:      *graceDays,
+			CapCents:       *capCents,
+			CapPercent:     *capPercent,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
@@ -239,25 +239,23 @@ func (mb *MonthlyBillingJob) MonthlyBilling() error {
 			totalCosts))
 
 		helpers.Log(logrus.InfoLevel, fmt.Sprintf("Creating invoice for user %d, on workspace %d, plan type %s\r\n", user.Id, workspace.Id, workspace.Plan))
-		stmt, err := mb.db.Prepare("INSERT INTO users_invoices (`cents`, `call_costs`, `recording_costs`, `fax_costs`, `membership_costs`, `number_costs`, `status`, `user_id`, `workspace_id`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
-		if err != nil {
-			helpers.Log(logrus.ErrorLevel, "could not prepare query..\r\n")
-			helpers.Log(logrus.ErrorLevel, err.Error())
-			continue
-		}
-		defer stmt.Close()
-		res, err := stmt.Exec(cents, callTolls, recordingCosts, faxCosts, membershipCosts, monthlyNumberRentals, "INCOMPLETE", workspace.CreatorId, workspace.Id, currentTime, currentTime)
+		invoiceId, err := createOpenInvoice(mb.db, &models.Invoice{
+			CreatedAt:       currentTime,
+			Source:          "SUBSCRIPTION",
+			UserID:          workspace.CreatorId,
+			WorkspaceID:     workspace.Id,
+			Cents:           int64(math.Ceil(totalCosts)),
+			CallCosts:       int64(callTolls),
+			RecordingCosts:  int64(recordingCosts),
+			FaxCosts:        int64(faxCosts),
+			MembershipCosts: int64(membershipCosts),
+			NumberCosts:     int64(monthlyNumberRentals),
+		})
 		if err != nil {
 			helpers.Log(logrus.ErrorLevel, "error creating invoice..\r\n")
 			helpers.Log(logrus.ErrorLevel, err.Error())
 			continue
 		}
-		invoiceId, err := res.LastInsertId()
-		if err != nil {
-			helpers.Log(logrus.ErrorLevel, "could not get insert id..\r\n")
-			helpers.Log(logrus.ErrorLevel, err.Error())
-			continue
-		}
 		helpers.Log(logrus.InfoLevel, fmt.Sprintf("Charging user %d, on workspace %d, plan type %s\r\n", user.Id, workspace.Id, workspace.Plan))
 
 		// try to charge the debit
@@ -274,37 +272,14 @@ func (mb *MonthlyBillingJob) MonthlyBilling() error {
 			if remainingBalance >= totalCosts { //user has enough credits
 				helpers.Log(logrus.InfoLevel, "User has enough credits. Charging balance\r\n")
 
-				confNumber, err := utils.CreateInvoiceConfirmationNumber()
+				err = markInvoicePaid(mb.db, invoiceId, "CREDITS", int64(math.Ceil(totalCosts)), currentTime)
 				if err != nil {
-					helpers.Log(logrus.ErrorLevel, "error while generating confirmation number: "+err.Error())
-					continue
-				}
-
-				stmt, err := mb.db.Prepare("UPDATE users_invoices SET status = 'COMPLETE', source ='CREDITS', cents_collected = ?, confirmation_number = ? WHERE id = ?")
-				if err != nil {
-					helpers.Log(logrus.ErrorLevel, "could not prepare query..\r\n")
-					continue
-				}
-				_, err = stmt.Exec(totalCosts, confNumber, invoiceId)
-				if err != nil {
-					helpers.Log(logrus.ErrorLevel, "error updating debit..\r\n")
+					helpers.Log(logrus.ErrorLevel, "error updating invoice..\r\n")
 					helpers.Log(logrus.ErrorLevel, err.Error())
 					continue
 				}
 			} else {
 				helpers.Log(logrus.InfoLevel, "User does not have enough credits. Charging any payment sources\r\n")
-				// update debit to reflect exactly how much we can charge
-				stmt, err := mb.db.Prepare("UPDATE users_invoices SET status = 'INCOMPLETE', source ='CREDITS', cents_collected = ? WHERE id = ?")
-				if err != nil {
-					helpers.Log(logrus.ErrorLevel, "could not prepare query..\r\n")
-					continue
-				}
-				_, err = stmt.Exec(charge, invoiceId)
-				if err != nil {
-					helpers.Log(logrus.ErrorLevel, "error updating debit..\r\n")
-					helpers.Log(logrus.ErrorLevel, err.Error())
-					continue
-				}
 				// try to charge the rest using a card
 				helpers.Log(logrus.InfoLevel, "Charging remainder with card..\r\n")
 
@@ -316,28 +291,17 @@ func (mb *MonthlyBillingJob) MonthlyBilling() error {
 				err = mb.paymentRepository.ChargeCustomer(billingParams, user, workspace, &invoice)
 				if err != nil {
 					// could not charge card.
-					// update invoice record and mark as outstanding
-					stmt, err = mb.db.Prepare("UPDATE users_invoices SET source = 'CARD', status = 'INCOMPLETE', num_attempts = 1, last_attempted = ? WHERE id = ?")
-					if err != nil {
-						helpers.Log(logrus.ErrorLevel, "could not prepare query..\r\n")
-						continue
-					}
-					_, err = stmt.Exec(currentTime, invoiceId)
+					// leave the invoice open as outstanding
+					err = markInvoiceAttemptFailed(mb.db, invoiceId, "CARD", currentTime)
 					if err != nil {
-						helpers.Log(logrus.ErrorLevel, "error updating debit..\r\n")
+						helpers.Log(logrus.ErrorLevel, "error updating invoice..\r\n")
 						helpers.Log(logrus.ErrorLevel, err.Error())
-						continue
 					}
 					continue
 				}
-				stmt, err = mb.db.Prepare("UPDATE users_invoices SET status = 'COMPLETE', source ='CARD', cents_collected = ?, last_attempted = ?, num_attempts = 1 WHERE id = ?")
+				err = markInvoicePaid(mb.db, invoiceId, "CARD", int64(cents), currentTime)
 				if err != nil {
-					helpers.Log(logrus.ErrorLevel, "could not prepare query..\r\n")
-					continue
-				}
-				_, err = stmt.Exec(totalCosts, currentTime, invoiceId)
-				if err != nil {
-					helpers.Log(logrus.ErrorLevel, "error updating debit..\r\n")
+					helpers.Log(logrus.ErrorLevel, "error updating invoice..\r\n")
 					helpers.Log(logrus.ErrorLevel, err.Error())
 					continue
 				}
@@ -354,12 +318,7 @@ func (mb *MonthlyBillingJob) MonthlyBilling() error {
 			if err != nil {
 				helpers.Log(logrus.ErrorLevel, "error charging user..\r\n")
 				helpers.Log(logrus.ErrorLevel, err.Error())
-				stmt, err := mb.db.Prepare("UPDATE users_invoices SET status = 'INCOMPLETE', source = 'CARD', cents_collected = 0.0 WHERE id = ?")
-				if err != nil {
-					helpers.Log(logrus.ErrorLevel, "could not prepare query..\r\n")
-					continue
-				}
-				_, err = stmt.Exec(invoiceId)
+				err = markInvoiceAttemptFailed(mb.db, invoiceId, "CARD", currentTime)
 				if err != nil {
 					helpers.Log(logrus.ErrorLevel, "error updating invoice....\r\n")
 					helpers.Log(logrus.ErrorLevel, err.Error())
@@ -369,20 +328,9 @@ func (mb *MonthlyBillingJob) MonthlyBilling() error {
 				continue
 			}
 
-			confNumber, err := utils.CreateInvoiceConfirmationNumber()
-			if err != nil {
-				helpers.Log(logrus.ErrorLevel, "error while generating confirmation number: "+err.Error())
-				continue
-			}
-
-			stmt, err := mb.db.Prepare("UPDATE users_invoices SET status = 'COMPLETE', source ='CARD', cents_collected = ?, confirmation_number = ? WHERE id = ?")
-			if err != nil {
-				helpers.Log(logrus.ErrorLevel, "could not prepare query..\r\n")
-				continue
-			}
-			_, err = stmt.Exec(totalCosts, confNumber, invoiceId)
+			err = markInvoicePaid(mb.db, invoiceId, "CARD", int64(cents), currentTime)
 			if err != nil {
-				helpers.Log(logrus.ErrorLevel, "error updating debit..\r\n")
+				helpers.Log(logrus.ErrorLevel, "error updating invoice..\r\n")
 				helpers.Log(logrus.ErrorLevel, err.Error())
 				continue
 			}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"math"
 	"regexp"
 	"testing"
 	"time"
@@ -11,6 +12,7 @@ import (
 	helpers "github.com/Lineblocs/go-helpers"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"lineblocs.com/scheduler/internal/invoice"
 	"lineblocs.com/scheduler/mocks"
 )
 
@@ -224,19 +226,11 @@ func TestMonthlyBilling(t *testing.T) {
 				AddRow(1, time.Now()))
 
 		// Mock expectations for invoices
-		invoiceQuery := "INSERT INTO users_invoices (`cents`, `call_costs`, `recording_costs`, `fax_costs`, `membership_costs`, `number_costs`, `status`, `user_id`, `workspace_id`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
-		mockSql.ExpectPrepare(regexp.QuoteMeta(invoiceQuery)).
-			ExpectExec().
-			WithArgs(float64(1000), float64(0), float64(0), float64(0), membershipCost, float64(monthlyCost), "INCOMPLETE", testUser.Id, testWorkspace.Id, sqlmock.AnyArg(), sqlmock.AnyArg()).
-			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectCreateOpenInvoice(mockSql, 1, sqlmock.AnyArg(), sqlmock.AnyArg(), int64(0), int64(0), int64(0), int64(membershipCost), int64(monthlyCost), int64(0), int64(0),
+			invoice.Draft, "INCOMPLETE", testUser.Id, testWorkspace.Id, nil, nil, nil, nil, nil, nil, nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg(), "SUBSCRIPTION", "")
 
-		// Mock expectations for the LastInsertId
-		sqlInsertId := "UPDATE users_invoices SET status = 'COMPLETE', source ='CARD', cents_collected = ?, confirmation_number = ? WHERE id = ?"
-		escapedInsertId := regexp.QuoteMeta(sqlInsertId)
-		mockSql.ExpectPrepare(escapedInsertId).
-			ExpectExec().
-			WithArgs(totalCostCents, sqlmock.AnyArg(), 1).
-			WillReturnResult(sqlmock.NewResult(1, 1))
+		// Mock expectations for the payment
+		expectMarkInvoicePaid(mockSql, 1, "CARD", int64(math.Ceil(float64(totalCostCents))), nil)
 
 		job := NewMonthlyBillingJob(db, mockWorkspace, mockPayment)
 		err = job.MonthlyBilling()
@@ -330,19 +324,11 @@ func TestMonthlyBilling(t *testing.T) {
 				AddRow(1, time.Now()))
 
 		// Mock expectations for invoices
-		invoiceQuery := "INSERT INTO users_invoices (`cents`, `call_costs`, `recording_costs`, `fax_costs`, `membership_costs`, `number_costs`, `status`, `user_id`, `workspace_id`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
-		mockSql.ExpectPrepare(regexp.QuoteMeta(invoiceQuery)).
-			ExpectExec().
-			WithArgs(float64(1000), float64(extraCallCost), float64(0), float64(0), membershipCost, float64(0), "INCOMPLETE", testUser.Id, testWorkspace.Id, sqlmock.AnyArg(), sqlmock.AnyArg()).
-			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectCreateOpenInvoice(mockSql, 1, sqlmock.AnyArg(), sqlmock.AnyArg(), int64(extraCallCost), int64(0), int64(0), int64(membershipCost), int64(0), int64(0), int64(0),
+			invoice.Draft, "INCOMPLETE", testUser.Id, testWorkspace.Id, nil, nil, nil, nil, nil, nil, nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg(), "SUBSCRIPTION", "")
 
-		// Mock expectations for the LastInsertId
-		sqlInsertId := "UPDATE users_invoices SET status = 'COMPLETE', source ='CARD', cents_collected = ?, confirmation_number = ? WHERE id = ?"
-		escapedInsertId := regexp.QuoteMeta(sqlInsertId)
-		mockSql.ExpectPrepare(escapedInsertId).
-			ExpectExec().
-			WithArgs(totalCostCents, sqlmock.AnyArg(), 1).
-			WillReturnResult(sqlmock.NewResult(1, 1))
+		// Mock expectations for the payment
+		expectMarkInvoicePaid(mockSql, 1, "CARD", int64(math.Ceil(float64(totalCostCents))), nil)
 
 		job := NewMonthlyBillingJob(db, mockWorkspace, mockPayment)
 		err = job.MonthlyBilling()
@@ -497,18 +483,10 @@ func testMonthlyBillingSetup(mockSql sqlmock.Sqlmock, mockWorkspace *mocks.Works
 	// Mock expectations for invoices
 	memberShipCost := (float64(sampleData.WorkspaceUsers) * float64(sampleData.Membership))
 	ExtraCallCost := float64(sampleData.Cents) * (sampleData.ExtraCallCost / 1000)
-	invoiceQuery := "INSERT INTO users_invoices (`cents`, `call_costs`, `recording_costs`, `fax_costs`, `membership_costs`, `number_costs`, `status`, `user_id`, `workspace_id`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
-	mockSql.ExpectPrepare(regexp.QuoteMeta(invoiceQuery)).
-		ExpectExec().
-		WithArgs(float64(sampleData.Cents), float64(ExtraCallCost), float64(0), float64(0), memberShipCost, float64(0), "INCOMPLETE", sampleData.User.Id, sampleData.Workspace.Id, sqlmock.AnyArg(), sqlmock.AnyArg()).
-		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectCreateOpenInvoice(mockSql, 1, sqlmock.AnyArg(), sqlmock.AnyArg(), int64(ExtraCallCost), int64(0), int64(0), int64(memberShipCost), int64(0), int64(0), int64(0),
+		invoice.Draft, "INCOMPLETE", sampleData.User.Id, sampleData.Workspace.Id, nil, nil, nil, nil, nil, nil, nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg(), "SUBSCRIPTION", "")
 
-	// Mock expectations for the LastInsertId
+	// Mock expectations for the payment
 	totalCost := memberShipCost + ExtraCallCost
-	sqlInsertId := "UPDATE users_invoices SET status = 'COMPLETE', source ='CARD', cents_collected = ?, confirmation_number = ? WHERE id = ?"
-	escapedInsertId := regexp.QuoteMeta(sqlInsertId)
-	mockSql.ExpectPrepare(escapedInsertId).
-		ExpectExec().
-		WithArgs(totalCost, sqlmock.AnyArg(), sqlmock.AnyArg()).
-		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectMarkInvoicePaid(mockSql, 1, "CARD", int64(math.Ceil(float64(totalCost))), nil)
 }
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"time"
+
+	"lineblocs.com/scheduler/internal/suspension"
+	utils "lineblocs.com/scheduler/utils"
+)
+
+// restrict or suspend workspaces with unpaid invoices, reactivate the ones that caught up and print the report
+func EnforcePaymentStatus(args []string) error {
+	defaults := suspension.PolicyFromConfig(utils.Config)
+
+	flags := flag.NewFlagSet("enforce_payment_status", flag.ContinueOnError)
+	restrictAttempts := flags.Int("restrict-attempts", defaults.Restrict.FailedAttempts, "restrict workspaces with an invoice that failed this many collection attempts, 0 to disable")
+	restrictDays := flags.Int("restrict-days", defaults.Restrict.DaysOverdue, "restrict workspaces with an invoice this many days overdue, 0 to disable")
+	suspendAttempts := flags.Int("suspend-attempts", defaults.Suspend.FailedAttempts, "suspend workspaces with an invoice that failed this many collection attempts, 0 to disable")
+	suspendDays := flags.Int("suspend-days", defaults.Suspend.DaysOverdue, "suspend workspaces with an invoice this many days overdue, 0 to disable")
+	numbers := flags.String("numbers", defaults.Numbers, "what suspension does to the workspace's numbers: keep, hold or release")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		return err
+	}
+
+	billingSvc, closeQueue := newPublishingBillingService(db)
+	defer closeQueue()
+
+	report, err := billingSvc.EnforcePaymentStatus(time.Now(), suspension.Policy{
+		Numbers:  *numbers,
+		Restrict: suspension.Rule{FailedAttempts: *restrictAttempts, DaysOverdue: *restrictDays},
+		Suspend:  suspension.Rule{FailedAttempts: *suspendAttempts, DaysOverdue: *suspendDays},
+	})
+	if err != nil {
+		return err
+	}
+
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
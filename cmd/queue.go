@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"database/sql"
+	"os"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/scheduler/internal/billing"
+	"lineblocs.com/scheduler/repository"
+)
+
+// queuePublisher publishes messages for the app to RabbitMQ
+type queuePublisher struct {
+	channel *amqp.Channel
+}
+
+func (p *queuePublisher) Publish(queue string, message []byte) error {
+	return p.channel.Publish("", queue, false, false, amqp.Publishing{
+		ContentType: "application/json",
+		Body:        message,
+	})
+}
+
+// newPublishingBillingService creates a billing service that publishes its events to the queue at QUEUE_URL.
+// When the queue cannot be reached the events are only recorded in the database. The returned func closes the connection.
+func newPublishingBillingService(db *sql.DB) (*billing.BillingService, func()) {
+	wRepo := repository.NewWorkspaceRepository(db)
+	pRepo := repository.NewPaymentRepository(db)
+
+	conn, err := amqp.Dial(os.Getenv("QUEUE_URL"))
+	if err != nil {
+		helpers.Log(logrus.WarnLevel, "queue unavailable, events will not be published: "+err.Error())
+		return billing.NewBillingService(db, wRepo, pRepo), func() {}
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		helpers.Log(logrus.WarnLevel, "queue unavailable, events will not be published: "+err.Error())
+		return billing.NewBillingService(db, wRepo, pRepo), func() {}
+	}
+
+	return billing.NewBillingServiceWithPublisher(db, wRepo, pRepo, &queuePublisher{channel: ch}), func() {
+		ch.Close()
+		conn.Close()
+	}
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"time"
+
+	"lineblocs.com/scheduler/internal/billing"
+	"lineblocs.com/scheduler/repository"
+	utils "lineblocs.com/scheduler/utils"
+)
+
+// settle card charges left pending in the billing outbox and print the report
+func ReconcileCharges(args []string) error {
+	flags := flag.NewFlagSet("reconcile_charges", flag.ContinueOnError)
+	olderThan := flags.Duration("older-than", 15*time.Minute, "only reconcile charges pending for at least this long")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		return err
+	}
+
+	billingSvc := billing.NewBillingService(db, repository.NewWorkspaceRepository(db), repository.NewPaymentRepository(db))
+	report, err := billingSvc.ReconcileOutbox(*olderThan)
+	if err != nil {
+		return err
+	}
+
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"lineblocs.com/scheduler/internal/billing"
+	"lineblocs.com/scheduler/internal/ratedeck"
+	models "lineblocs.com/scheduler/models"
+	"lineblocs.com/scheduler/repository"
+	utils "lineblocs.com/scheduler/utils"
+)
+
+// re-rate the call debits of a workspace under a rate deck version and print the report
+func Rerate(args []string) error {
+	flags := flag.NewFlagSet("rerate", flag.ContinueOnError)
+	workspaceID := flags.Int("workspace", 0, "workspace ID to re-rate")
+	from := flags.String("from", "", "first day to re-rate (YYYY-MM-DD)")
+	to := flags.String("to", "", "day after the last day to re-rate (YYYY-MM-DD)")
+	version := flags.String("version", "", "rate deck version to rate calls with")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *workspaceID == 0 || *from == "" || *to == "" || *version == "" {
+		return fmt.Errorf("usage: rerate -workspace ID -from YYYY-MM-DD -to YYYY-MM-DD -version VERSION")
+	}
+
+	deck, err := ratedeck.LoadVersion(utils.Config("RATE_DECK_DIR"), *version)
+	if err != nil {
+		return err
+	}
+
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		return err
+	}
+
+	billingSvc := billing.NewBillingService(db, repository.NewWorkspaceRepository(db), repository.NewPaymentRepository(db))
+	report, err := billingSvc.Rerate(models.RerateTask{
+		RateVersion: *version,
+		StartDate:   *from,
+		EndDate:     *to,
+		WorkspaceID: *workspaceID,
+	}, deck)
+	if err != nil {
+		return err
+	}
+
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"lineblocs.com/scheduler/internal/billing"
+	"lineblocs.com/scheduler/repository"
+	utils "lineblocs.com/scheduler/utils"
+)
+
+// void an invoice, giving back what was collected on it, and print the report
+func VoidInvoice(args []string) error {
+	return voidOrReissueInvoice("void-invoice", args, false)
+}
+
+// void an invoice and bill its period again, then print the report
+func ReissueInvoice(args []string) error {
+	return voidOrReissueInvoice("reissue-invoice", args, true)
+}
+
+func voidOrReissueInvoice(name string, args []string, reissue bool) error {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	invoiceID := flags.Int64("invoice", 0, "invoice ID to void")
+	reason := flags.String("reason", "", "why the invoice is voided, recorded in its history")
+	toCredits := flags.Bool("credit", false, "credit card payments to the workspace's balance instead of refunding the card")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *invoiceID == 0 || *reason == "" {
+		return fmt.Errorf("usage: %s -invoice ID -reason REASON [-credit]", name)
+	}
+
+	db, err := utils.GetDBConnection()
+	if err != nil {
+		return err
+	}
+
+	billingSvc := billing.NewBillingService(db, repository.NewWorkspaceRepository(db), repository.NewPaymentRepository(db))
+	var report *billing.VoidReport
+	if reissue {
+		report, err = billingSvc.ReissueInvoice(*invoiceID, *reason, *toCredits)
+	} else {
+		report, err = billingSvc.VoidInvoice(*invoiceID, *reason, *toCredits)
+	}
+	if report != nil {
+		out, marshalErr := json.MarshalIndent(report, "", "  ")
+		if marshalErr != nil {
+			return marshalErr
+		}
+		fmt.Println(string(out))
+	}
+	return err
+}
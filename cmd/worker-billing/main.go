@@ -7,6 +7,8 @@ import (
 
 	helpers "github.com/Lineblocs/go-helpers"
 	"lineblocs.com/scheduler/internal/billing"
+	"lineblocs.com/scheduler/internal/ratedeck"
+	"lineblocs.com/scheduler/internal/suspension"
 	"lineblocs.com/scheduler/models"
 	"lineblocs.com/scheduler/repository"
 	"lineblocs.com/scheduler/utils"
@@ -47,6 +49,17 @@ func main() {
 
 	publisher := &RabbitMQPublisher{channel: ch}
 	billingSvc := billing.NewBillingServiceWithPublisher(db, wRepo, pRepo, publisher)
+	billingSvc.UsePaymentStatusPolicy(suspension.PolicyFromConfig(utils.Config))
+
+	// Optional destination rate deck used to rate or verify call tolls
+	if rateDeckPath := os.Getenv("RATE_DECK_PATH"); rateDeckPath != "" {
+		deck, err := ratedeck.LoadFile(rateDeckPath, os.Getenv("RATE_DECK_VERSION"))
+		if err != nil {
+			panic(err)
+		}
+		billingSvc.UseRateDeck(deck, os.Getenv("RATE_DECK_MODE"))
+		log.Printf("Loaded rate deck %s from %s", deck.Version, rateDeckPath)
+	}
 
 	// Prefetch(1) ensures the worker doesn't hog all tasks if one is slow
 	ch.Qos(1, 0, false)
@@ -55,6 +68,26 @@ func main() {
 		panic(err)
 	}
 
+	rerateQueue, err := ch.QueueDeclare("rerate_tasks", true, false, false, false, nil)
+	if err != nil {
+		panic(err)
+	}
+	rerateMsgs, err := ch.Consume(rerateQueue.Name, "", false, false, false, false, nil)
+	if err != nil {
+		panic(err)
+	}
+	go consumeRerateTasks(billingSvc, rerateMsgs)
+
+	estimateQueue, err := ch.QueueDeclare("billing_estimates", true, false, false, false, nil)
+	if err != nil {
+		panic(err)
+	}
+	estimateMsgs, err := ch.Consume(estimateQueue.Name, "", false, false, false, false, nil)
+	if err != nil {
+		panic(err)
+	}
+	go consumeEstimateTasks(billingSvc, ch, estimateMsgs)
+
 	log.Println("Worker ready. Waiting for tasks...")
 
 	for d := range msgs {
@@ -69,4 +102,76 @@ func main() {
 			d.Ack(false)
 		}
 	}
-}
\ No newline at end of file
+}
+
+func consumeRerateTasks(billingSvc *billing.BillingService, msgs <-chan amqp.Delivery) {
+	for d := range msgs {
+		var task models.RerateTask
+		if err := json.Unmarshal(d.Body, &task); err != nil {
+			log.Printf("Error decoding rerate task: %v", err)
+			d.Ack(false) // Drop malformed messages
+			continue
+		}
+
+		deck, err := ratedeck.LoadVersion(os.Getenv("RATE_DECK_DIR"), task.RateVersion)
+		if err != nil {
+			log.Printf("Error loading rate deck %s for workspace %d: %v", task.RateVersion, task.WorkspaceID, err)
+			d.Ack(false) // Retrying cannot fix a missing deck
+			continue
+		}
+
+		// Re-running is safe: earlier adjustments are taken into account
+		report, err := billingSvc.Rerate(task, deck)
+		if err != nil {
+			log.Printf("Error re-rating workspace %d: %v", task.WorkspaceID, err)
+			d.Nack(false, false) // Requeueing would retry a failing task forever; re-run it with the rerate command
+			continue
+		}
+
+		body, _ := json.Marshal(report)
+		log.Printf("Rerate report for workspace %d: %s", task.WorkspaceID, body)
+		d.Ack(false)
+	}
+}
+
+// EstimateReply is the response to an EstimateTask, Error is set when no estimate could be made
+type EstimateReply struct {
+	Estimate *billing.Estimate `json:"estimate,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// consumeEstimateTasks answers estimate requests on the queue named in ReplyTo
+func consumeEstimateTasks(billingSvc *billing.BillingService, ch *amqp.Channel, msgs <-chan amqp.Delivery) {
+	for d := range msgs {
+		var reply EstimateReply
+		var task models.EstimateTask
+		if err := json.Unmarshal(d.Body, &task); err != nil {
+			reply.Error = "malformed estimate task: " + err.Error()
+		} else if estimate, err := billingSvc.Estimate(task); err != nil {
+			log.Printf("Error estimating workspace %d: %v", task.WorkspaceID, err)
+			reply.Error = err.Error()
+		} else {
+			reply.Estimate = estimate
+		}
+
+		if d.ReplyTo == "" {
+			log.Printf("Dropping estimate for workspace %d without a reply queue", task.WorkspaceID)
+			d.Ack(false)
+			continue
+		}
+
+		// estimates have no side effects, so failures are answered instead of retried
+		body, _ := json.Marshal(reply)
+		err := ch.Publish("", d.ReplyTo, false, false, amqp.Publishing{
+			ContentType:   "application/json",
+			CorrelationId: d.CorrelationId,
+			Body:          body,
+		})
+		if err != nil {
+			log.Printf("Error replying to estimate for workspace %d: %v", task.WorkspaceID, err)
+			d.Nack(false, true)
+			continue
+		}
+		d.Ack(false)
+	}
+}
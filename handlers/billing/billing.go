@@ -9,6 +9,8 @@ import (
 
 type BillingHandler interface {
 	ChargeCustomer(user *helpers.User, workspace *helpers.Workspace, invoice *models.UserInvoice) error
+	// RefundPayment refunds part or all of an earlier charge and returns the gateway's refund ID
+	RefundPayment(paymentReference string, cents int64, idempotencyKey string) (string, error)
 }
 
 type Billing struct {
@@ -31,3 +31,8 @@ func (hndl *BraintreeBillingHandler) ChargeCustomer(user *helpers.User, workspac
 	// todo: implement handler
 	return errors.New("not implemented yet")
 }
+
+func (hndl *BraintreeBillingHandler) RefundPayment(paymentReference string, cents int64, idempotencyKey string) (string, error) {
+	// todo: implement handler
+	return "", errors.New("not implemented yet")
+}
@@ -8,6 +8,7 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/stripe/stripe-go/v72"
 	"github.com/stripe/stripe-go/v72/paymentintent"
+	"github.com/stripe/stripe-go/v72/refund"
 	"github.com/sirupsen/logrus"
 	models "lineblocs.com/scheduler/models"
 	"database/sql"
@@ -75,8 +76,11 @@ func (hndl *StripeBillingHandler) ChargeCustomer(user *helpers.User, workspace *
         StatementDescriptorSuffix: stripe.String(descriptorSuffix),
     }
 
-    // Apply the custom idempotency key
-    idempotencyKey := createIdempotencyKey(workspace.Id, amountCents)
+    // Apply the custom idempotency key, reusing the invoice's key so a reconciled retry is not charged twice
+    idempotencyKey := invoice.IdempotencyKey
+    if idempotencyKey == "" {
+        idempotencyKey = createIdempotencyKey(workspace.Id, amountCents)
+    }
 	helpers.Log(logrus.InfoLevel, fmt.Sprintf("Using idempotency key: %s for PaymentIntent creation", idempotencyKey))
 	params.SetIdempotencyKey(idempotencyKey)
 
@@ -89,6 +93,27 @@ func (hndl *StripeBillingHandler) ChargeCustomer(user *helpers.User, workspace *
     }
 
     helpers.Log(logrus.InfoLevel, fmt.Sprintf("Stripe PaymentIntent processed. ID: %s Status: %s", res.ID, res.Status))
+    invoice.PaymentReference = res.ID
 
     return nil
 }
+
+// RefundPayment refunds an amount of a PaymentIntent created by ChargeCustomer
+func (hndl *StripeBillingHandler) RefundPayment(paymentReference string, cents int64, idempotencyKey string) (string, error) {
+    stripe.Key = hndl.StripeKey
+
+    params := &stripe.RefundParams{
+        PaymentIntent: stripe.String(paymentReference),
+        Amount:        stripe.Int64(cents),
+    }
+    params.SetIdempotencyKey(idempotencyKey)
+
+    res, err := refund.New(params)
+    if err != nil {
+        helpers.Log(logrus.ErrorLevel, fmt.Sprintf("Stripe Refund Failed: %v", err))
+        return "", err
+    }
+
+    helpers.Log(logrus.InfoLevel, fmt.Sprintf("Stripe Refund processed. ID: %s Status: %s", res.ID, res.Status))
+    return res.ID, nil
+}
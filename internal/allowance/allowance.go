@@ -0,0 +1,89 @@
+package allowance
+
+import "sort"
+
+// Policy is how a plan lets unused minutes roll over into later billing cycles
+type Policy struct {
+	// CapSeconds limits the seconds carried forward in total, 0 is no limit
+	CapSeconds int64
+	// ExpiryCycles is how many later cycles rolled over seconds can be used in, 0 disables rollover
+	ExpiryCycles int
+}
+
+// Bucket is unused allowance carried from one cycle, usable for CyclesLeft more cycles
+type Bucket struct {
+	Seconds    int64 `json:"seconds"`
+	CyclesLeft int   `json:"cycles_left"`
+}
+
+// Period is the allowance of one billing cycle: what was available, what calls used and what is carried forward
+type Period struct {
+	IncludedSeconds       int64    `json:"included_seconds"`
+	CarriedInSeconds      int64    `json:"carried_in_seconds"`
+	ConsumedSeconds       int64    `json:"consumed_seconds"`
+	CarriedForwardSeconds int64    `json:"carried_forward_seconds"`
+	ExpiredSeconds        int64    `json:"expired_seconds"`
+	Balance               []Bucket `json:"balance"`
+}
+
+// Seconds sums the seconds in a balance
+func Seconds(balance []Bucket) int64 {
+	var total int64
+	for _, bucket := range balance {
+		total += bucket.Seconds
+	}
+	return total
+}
+
+// Close works out a cycle's allowance once its calls are rated. Consumption draws on the
+// carried buckets closest to expiring first and then on the cycle's own included seconds.
+// What is left of the carried buckets ages by one cycle, and the unused included seconds
+// become a new bucket, up to the policy's cap. Everything else expires.
+func Close(policy Policy, carried []Bucket, includedSeconds, consumedSeconds int64) Period {
+	period := Period{
+		IncludedSeconds:  includedSeconds,
+		CarriedInSeconds: Seconds(carried),
+		Balance:          make([]Bucket, 0),
+	}
+	period.ConsumedSeconds = min(max(consumedSeconds, 0), period.IncludedSeconds+period.CarriedInSeconds)
+
+	toDraw := period.ConsumedSeconds
+	for _, bucket := range sortByExpiry(carried) {
+		drawn := min(toDraw, bucket.Seconds)
+		toDraw -= drawn
+		bucket.Seconds -= drawn
+		bucket.CyclesLeft--
+
+		if bucket.Seconds > 0 && bucket.CyclesLeft > 0 && policy.ExpiryCycles > 0 {
+			period.Balance = append(period.Balance, bucket)
+		} else {
+			period.ExpiredSeconds += bucket.Seconds
+		}
+	}
+
+	unused := includedSeconds - toDraw
+	if policy.ExpiryCycles > 0 && unused > 0 {
+		rolled := unused
+		if policy.CapSeconds > 0 {
+			rolled = min(rolled, max(policy.CapSeconds-Seconds(period.Balance), 0))
+		}
+		if rolled > 0 {
+			period.Balance = append(period.Balance, Bucket{Seconds: rolled, CyclesLeft: policy.ExpiryCycles})
+		}
+		unused -= rolled
+	}
+	period.ExpiredSeconds += unused
+
+	period.CarriedForwardSeconds = Seconds(period.Balance)
+	return period
+}
+
+// sortByExpiry orders a copy of the buckets by the cycles they have left, soonest first
+func sortByExpiry(buckets []Bucket) []Bucket {
+	sorted := make([]Bucket, len(buckets))
+	copy(sorted, buckets)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CyclesLeft < sorted[j].CyclesLeft
+	})
+	return sorted
+}
@@ -0,0 +1,77 @@
+package allowance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClose(t *testing.T) {
+	t.Parallel()
+
+	rollover := Policy{CapSeconds: 6000, ExpiryCycles: 2}
+
+	testCases := []struct {
+		Name     string
+		Policy   Policy
+		Carried  []Bucket
+		Included int64
+		Consumed int64
+		Expected Period
+	}{
+		{
+			Name:     "without rollover unused seconds expire",
+			Included: 6000,
+			Consumed: 1000,
+			Expected: Period{IncludedSeconds: 6000, ConsumedSeconds: 1000, ExpiredSeconds: 5000, Balance: []Bucket{}},
+		},
+		{
+			Name:     "unused seconds roll over for the policy's cycles",
+			Policy:   rollover,
+			Included: 6000,
+			Consumed: 1000,
+			Expected: Period{IncludedSeconds: 6000, ConsumedSeconds: 1000, CarriedForwardSeconds: 5000, Balance: []Bucket{{Seconds: 5000, CyclesLeft: 2}}},
+		},
+		{
+			Name:     "carried seconds are used before the included seconds",
+			Policy:   rollover,
+			Carried:  []Bucket{{Seconds: 3000, CyclesLeft: 2}, {Seconds: 1000, CyclesLeft: 1}},
+			Included: 6000,
+			Consumed: 2500,
+			Expected: Period{
+				IncludedSeconds: 6000, CarriedInSeconds: 4000, ConsumedSeconds: 2500, CarriedForwardSeconds: 6000, ExpiredSeconds: 1500,
+				Balance: []Bucket{{Seconds: 1500, CyclesLeft: 1}, {Seconds: 4500, CyclesLeft: 2}},
+			},
+		},
+		{
+			Name:     "carried seconds expire after their last cycle",
+			Policy:   rollover,
+			Carried:  []Bucket{{Seconds: 1000, CyclesLeft: 1}},
+			Included: 6000,
+			Consumed: 6000,
+			Expected: Period{IncludedSeconds: 6000, CarriedInSeconds: 1000, ConsumedSeconds: 6000, CarriedForwardSeconds: 1000, Balance: []Bucket{{Seconds: 1000, CyclesLeft: 2}}},
+		},
+		{
+			Name:     "rollover is capped",
+			Policy:   Policy{CapSeconds: 2000, ExpiryCycles: 3},
+			Included: 6000,
+			Expected: Period{IncludedSeconds: 6000, CarriedForwardSeconds: 2000, ExpiredSeconds: 4000, Balance: []Bucket{{Seconds: 2000, CyclesLeft: 3}}},
+		},
+		{
+			Name:     "overage beyond the allowance consumes all of it",
+			Policy:   rollover,
+			Carried:  []Bucket{{Seconds: 500, CyclesLeft: 1}},
+			Included: 6000,
+			Consumed: 9000,
+			Expected: Period{IncludedSeconds: 6000, CarriedInSeconds: 500, ConsumedSeconds: 6500, Balance: []Bucket{}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.Expected, Close(tc.Policy, tc.Carried, tc.Included, tc.Consumed))
+		})
+	}
+}
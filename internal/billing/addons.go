@@ -0,0 +1,47 @@
+package billing
+
+import (
+	"fmt"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/scheduler/models"
+)
+
+// planWithAddOns returns a copy of the plan whose minute, recording and fax allowances include
+// what the subscription's add-ons bring, so rating draws on both alike
+func planWithAddOns(plan *helpers.ServicePlan, addOns []models.AddOn) *helpers.ServicePlan {
+	withAddOns := *plan
+	for _, addOn := range addOns {
+		withAddOns.MinutesPerMonth += addOn.Minutes * float64(addOn.Quantity)
+		withAddOns.RecordingSpace += addOn.RecordingMB * recordingSpaceUnitsPerMB * float64(addOn.Quantity)
+		withAddOns.Fax += addOn.FaxPages * addOn.Quantity
+	}
+	return &withAddOns
+}
+
+// calculateAddOnCosts bills the recurring price of each add-on for the term, one line item per add-on
+func (s *BillingService) calculateAddOnCosts(data *BillingData, costs *BillingCosts, logger *logrus.Entry) {
+	for _, addOn := range data.AddOns {
+		cents := data.Term.AddOnCosts(addOn)
+		if cents == 0 {
+			continue
+		}
+
+		costs.AddOnCosts += cents
+		costs.LineItems = append(costs.LineItems, models.InvoiceLineItem{
+			PeriodStart: data.BillingPeriodStart,
+			PeriodEnd:   data.BillingPeriodEnd,
+			Category:    models.LineItemAddOn,
+			Description: fmt.Sprintf("%s x %d", addOn.Name, addOn.Quantity),
+			Unit:        "units",
+			ReferenceID: addOn.ProductID,
+			Quantity:    float64(addOn.Quantity),
+			Cents:       cents,
+		})
+	}
+
+	if costs.AddOnCosts > 0 {
+		logger.Infof("Workspace add-on costs are %d", costs.AddOnCosts)
+	}
+}
@@ -0,0 +1,83 @@
+package billing
+
+import (
+	"testing"
+	"time"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"lineblocs.com/scheduler/internal/pricing"
+	"lineblocs.com/scheduler/models"
+)
+
+func TestPlanWithAddOns(t *testing.T) {
+	t.Parallel()
+
+	// 1 GB of recording space, in the kilobytes go-helpers uses
+	plan := &helpers.ServicePlan{MinutesPerMonth: 1000, RecordingSpace: 1 << 20, Fax: 50}
+	addOns := []models.AddOn{
+		{Name: "Minute bundle", Quantity: 2, Minutes: 500},
+		{Name: "Storage pack", Quantity: 1, RecordingMB: 10240},
+		{Name: "Fax pages", Quantity: 3, FaxPages: 100},
+	}
+
+	withAddOns := planWithAddOns(plan, addOns)
+	assert.Equal(t, 2000.0, withAddOns.MinutesPerMonth)
+	assert.Equal(t, float64(11<<20), withAddOns.RecordingSpace, "the 10 GB pack is added in kilobytes")
+	assert.Equal(t, 350, withAddOns.Fax)
+	assert.Equal(t, 1000.0, plan.MinutesPerMonth, "the plan itself is left unchanged")
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	usage := ComputeStorageUsage(nil, withAddOns, pricing.Flat(10), start, start.AddDate(0, 1, 0))
+	assert.InDelta(t, 11, usage.IncludedGBMonths, 0.0001, "the pack grants the storage it advertises")
+}
+
+func TestAddOnCosts(t *testing.T) {
+	t.Parallel()
+
+	addOn := models.AddOn{Quantity: 2, MonthlyCents: 500}
+
+	testCases := []struct {
+		Name     string
+		Term     BillingTerm
+		AddOn    models.AddOn
+		Expected int64
+	}{
+		{Name: "monthly", Term: monthlyTerm{}, AddOn: addOn, Expected: 1000},
+		{Name: "annual without an annual price", Term: annualPrepaidTerm{}, AddOn: addOn, Expected: 12000},
+		{Name: "annual with an annual price", Term: annualPrepaidTerm{}, AddOn: models.AddOn{Quantity: 2, MonthlyCents: 500, AnnualCents: 5000}, Expected: 10000},
+		{Name: "annual overage was prepaid", Term: annualOverageTerm{}, AddOn: addOn, Expected: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.Expected, tc.Term.AddOnCosts(tc.AddOn))
+		})
+	}
+}
+
+func TestCalculateAddOnCosts(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	data := &BillingData{
+		Term:               monthlyTerm{},
+		BillingPeriodStart: start,
+		BillingPeriodEnd:   start.AddDate(0, 1, 0),
+		AddOns: []models.AddOn{
+			{Name: "Premium support", ProductID: 4, Quantity: 1, MonthlyCents: 2500},
+			{Name: "Extra channels", ProductID: 5, Quantity: 3, MonthlyCents: 400},
+		},
+	}
+	costs := &BillingCosts{}
+
+	(&BillingService{}).calculateAddOnCosts(data, costs, logrus.WithField("test", t.Name()))
+	assert.Equal(t, int64(3700), costs.AddOnCosts)
+	assert.Equal(t, []models.InvoiceLineItem{
+		{PeriodStart: start, PeriodEnd: data.BillingPeriodEnd, Category: models.LineItemAddOn, Description: "Premium support x 1", Unit: "units", ReferenceID: 4, Quantity: 1, Cents: 2500},
+		{PeriodStart: start, PeriodEnd: data.BillingPeriodEnd, Category: models.LineItemAddOn, Description: "Extra channels x 3", Unit: "units", ReferenceID: 5, Quantity: 3, Cents: 1200},
+	}, costs.LineItems)
+}
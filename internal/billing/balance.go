@@ -0,0 +1,178 @@
+package billing
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/scheduler/models"
+	"lineblocs.com/scheduler/repository"
+)
+
+// BalanceResyncAfter is how long a running balance is kept up to date from new debits and credits
+// before it is recomputed from the whole ledger
+const BalanceResyncAfter = 24 * time.Hour
+
+// BalanceReport summarizes a balance enforcement run
+type BalanceReport struct {
+	CutOff   []int `json:"cut_off"`
+	Restored []int `json:"restored"`
+	Failed   []int `json:"failed"`
+	Checked  int   `json:"checked"`
+}
+
+// EnforceBalances updates the running balance of every workspace on a pay-as-you-go plan and publishes a
+// cutoff event for the ones that crossed their credit limit, and a restore event for the ones back above it.
+func (s *BillingService) EnforceBalances(at time.Time, resyncAfter time.Duration) (*BalanceReport, error) {
+	logger := logrus.WithField("component", "balance")
+
+	plans, err := s.paymentRepository.GetServicePlans()
+	if err != nil {
+		logger.WithError(err).Error("error getting service plans")
+		return nil, err
+	}
+	prepaid := make([]string, 0)
+	for _, plan := range plans {
+		if plan.PayAsYouGo {
+			prepaid = append(prepaid, plan.KeyName)
+		}
+	}
+
+	workspaceIDs, err := s.balanceRepository.GetWorkspacesOnPlans(s.db, prepaid)
+	if err != nil {
+		logger.WithError(err).Error("error getting prepaid workspaces")
+		return nil, err
+	}
+
+	report := &BalanceReport{}
+	for _, workspaceID := range workspaceIDs {
+		report.Checked++
+		workspaceLogger := logger.WithField("workspace_id", workspaceID)
+
+		event, err := s.UpdateBalance(workspaceID, at, resyncAfter)
+		if err != nil {
+			workspaceLogger.WithError(err).Error("error updating balance")
+			report.Failed = append(report.Failed, workspaceID)
+			continue
+		}
+		if event == nil {
+			continue
+		}
+
+		if event.Event == models.BalanceCutoff {
+			report.CutOff = append(report.CutOff, workspaceID)
+		} else {
+			report.Restored = append(report.Restored, workspaceID)
+		}
+	}
+
+	logger.Infof("Checked %d prepaid workspaces: %d cut off, %d restored, %d failed",
+		report.Checked, len(report.CutOff), len(report.Restored), len(report.Failed))
+	return report, nil
+}
+
+// UpdateBalance adds the workspace's credits and subtracts its debits and invoices paid from credits since the last update
+// from its running balance. A balance that was never started or was last recomputed resyncAfter ago is recomputed
+// from the whole ledger. It returns the event published when the workspace crossed its credit limit, or nil.
+func (s *BillingService) UpdateBalance(workspaceID int, at time.Time, resyncAfter time.Duration) (*models.BalanceEvent, error) {
+	logger := logrus.WithField("component", "balance").WithField("workspace_id", workspaceID)
+
+	var event *models.BalanceEvent
+	err := s.unitOfWork.Do(func(tx repository.Executor) error {
+		balance, err := s.balanceRepository.LockBalance(tx, workspaceID)
+		if err != nil {
+			return err
+		}
+
+		if balance == nil || at.Sub(balance.SyncedAt) >= resyncAfter {
+			balance, err = s.resyncBalance(tx, workspaceID, balance, at)
+		} else {
+			err = s.addLedgerChanges(tx, balance)
+		}
+		if err != nil {
+			return err
+		}
+
+		// a workspace without a credit limit is cut off once it has no credits left
+		cutOff := balance.BalanceCents <= -balance.CreditLimitCents
+		if cutOff != balance.CutOff {
+			balance.CutOff = cutOff
+			event = &models.BalanceEvent{
+				CreatedAt:        at,
+				Event:            models.BalanceRestore,
+				WorkspaceID:      workspaceID,
+				BalanceCents:     balance.BalanceCents,
+				CreditLimitCents: balance.CreditLimitCents,
+			}
+			if cutOff {
+				event.Event = models.BalanceCutoff
+			}
+		}
+		return s.balanceRepository.SaveBalance(tx, *balance, at)
+	})
+	if err != nil || event == nil {
+		return nil, err
+	}
+
+	logger.Infof("Balance of %d cents against a credit limit of %d cents, publishing %s", event.BalanceCents, event.CreditLimitCents, event.Event)
+	s.publishBalanceEvent(event, logger)
+	return event, nil
+}
+
+// resyncBalance recomputes the balance from the whole ledger, the way go-helpers computes the remaining balance.
+// Each sum is read with what it counted, so rows written meanwhile are counted by the next update.
+func (s *BillingService) resyncBalance(tx repository.Executor, workspaceID int, balance *models.WorkspaceBalance, at time.Time) (*models.WorkspaceBalance, error) {
+	if balance == nil {
+		balance = &models.WorkspaceBalance{WorkspaceID: workspaceID}
+	}
+
+	balance.BalanceCents = 0
+	balance.LastDebitID, balance.LastCreditID, balance.CreditInvoiceCents = 0, 0, 0
+	if err := s.addLedgerChanges(tx, balance); err != nil {
+		return nil, err
+	}
+
+	balance.SyncedAt = at
+	return balance, nil
+}
+
+// addLedgerChanges adds the credits and subtracts the debits written since the balance was last updated, and
+// subtracts the invoices paid from credits since then
+func (s *BillingService) addLedgerChanges(tx repository.Executor, balance *models.WorkspaceBalance) error {
+	debits, lastDebitID, err := s.balanceRepository.SumNewDebits(tx, balance.WorkspaceID, balance.LastDebitID)
+	if err != nil {
+		return err
+	}
+	credits, lastCreditID, err := s.balanceRepository.SumNewCredits(tx, balance.WorkspaceID, balance.LastCreditID)
+	if err != nil {
+		return err
+	}
+	creditInvoices, err := s.balanceRepository.SumCreditInvoices(tx, balance.WorkspaceID)
+	if err != nil {
+		return err
+	}
+
+	balance.BalanceCents += credits - debits - (creditInvoices - balance.CreditInvoiceCents)
+	balance.LastDebitID = lastDebitID
+	balance.LastCreditID = lastCreditID
+	balance.CreditInvoiceCents = creditInvoices
+	return nil
+}
+
+// publishBalanceEvent tells the call platform to block or allow new outbound calls. The state is already
+// saved in workspace_balances, so a lost event is not published again until the workspace crosses back.
+func (s *BillingService) publishBalanceEvent(event *models.BalanceEvent, logger *logrus.Entry) {
+	if s.rabbitmqPublisher == nil {
+		return
+	}
+
+	messageBytes, err := json.Marshal(event)
+	if err != nil {
+		logger.WithError(err).Error("error marshaling balance event")
+		return
+	}
+
+	if err := s.rabbitmqPublisher.Publish("workspace_balance", messageBytes); err != nil {
+		logger.WithError(err).Error("error publishing balance event")
+	}
+}
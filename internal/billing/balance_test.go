@@ -0,0 +1,84 @@
+package billing
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"lineblocs.com/scheduler/mocks"
+	"lineblocs.com/scheduler/models"
+)
+
+func TestEnforceBalances(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, 6, 1, 3, 0, 0, 0, time.UTC)
+	syncedAt := at.Add(-time.Hour)
+
+	paymentRepository := mocks.NewPaymentRepository(t)
+	paymentRepository.On("GetServicePlans").Return([]helpers.ServicePlan{{KeyName: "starter"}, {KeyName: "payg", PayAsYouGo: true}}, nil)
+
+	balanceRepository := mocks.NewBalanceRepository(t)
+	balanceRepository.On("GetWorkspacesOnPlans", mock.Anything, []string{"payg"}).Return([]int{3, 4, 5}, nil)
+
+	// 3 spends past its credit limit and is cut off, counting debits already invoiced
+	balanceRepository.On("LockBalance", nil, 3).Return(&models.WorkspaceBalance{SyncedAt: syncedAt, WorkspaceID: 3, LastDebitID: 40, LastCreditID: 8, CreditInvoiceCents: 400, BalanceCents: 200, CreditLimitCents: 100}, nil)
+	balanceRepository.On("SumNewDebits", nil, 3, int64(40)).Return(int64(350), int64(44), nil)
+	balanceRepository.On("SumNewCredits", nil, 3, int64(8)).Return(int64(0), int64(8), nil)
+	balanceRepository.On("SumCreditInvoices", nil, 3).Return(int64(400), nil)
+	balanceRepository.On("SaveBalance", nil, models.WorkspaceBalance{SyncedAt: syncedAt, WorkspaceID: 3, LastDebitID: 44, LastCreditID: 8, CreditInvoiceCents: 400, BalanceCents: -150, CreditLimitCents: 100, CutOff: true}, at).Return(nil)
+
+	// 4 was topped up and is restored
+	balanceRepository.On("LockBalance", nil, 4).Return(&models.WorkspaceBalance{SyncedAt: syncedAt, WorkspaceID: 4, LastDebitID: 50, LastCreditID: 9, BalanceCents: -20, CutOff: true}, nil)
+	balanceRepository.On("SumNewDebits", nil, 4, int64(50)).Return(int64(0), int64(50), nil)
+	balanceRepository.On("SumNewCredits", nil, 4, int64(9)).Return(int64(2000), int64(12), nil)
+	balanceRepository.On("SumCreditInvoices", nil, 4).Return(int64(0), nil)
+	balanceRepository.On("SaveBalance", nil, models.WorkspaceBalance{SyncedAt: syncedAt, WorkspaceID: 4, LastDebitID: 50, LastCreditID: 12, BalanceCents: 1980}, at).Return(nil)
+
+	// 5 has no running balance yet and starts from the whole ledger, less an invoice paid from credits
+	balanceRepository.On("LockBalance", nil, 5).Return(nil, nil)
+	balanceRepository.On("SumNewDebits", nil, 5, int64(0)).Return(int64(300), int64(60), nil)
+	balanceRepository.On("SumNewCredits", nil, 5, int64(0)).Return(int64(1500), int64(14), nil)
+	balanceRepository.On("SumCreditInvoices", nil, 5).Return(int64(500), nil)
+	balanceRepository.On("SaveBalance", nil, models.WorkspaceBalance{SyncedAt: at, WorkspaceID: 5, LastDebitID: 60, LastCreditID: 14, CreditInvoiceCents: 500, BalanceCents: 700}, at).Return(nil)
+
+	unitOfWork := mocks.NewUnitOfWork(t)
+	unitOfWork.On("Do", mock.Anything).Return(runUnitOfWork)
+	publisher := &recordingPublisher{}
+
+	s := &BillingService{paymentRepository: paymentRepository, balanceRepository: balanceRepository, unitOfWork: unitOfWork, rabbitmqPublisher: publisher}
+	report, err := s.EnforceBalances(at, BalanceResyncAfter)
+	assert.NoError(t, err)
+	assert.Equal(t, &BalanceReport{CutOff: []int{3}, Restored: []int{4}, Checked: 3}, report)
+
+	if assert.Len(t, publisher.messages["workspace_balance"], 2) {
+		var event models.BalanceEvent
+		assert.NoError(t, json.Unmarshal(publisher.messages["workspace_balance"][0], &event))
+		assert.Equal(t, models.BalanceEvent{CreatedAt: at, Event: models.BalanceCutoff, WorkspaceID: 3, BalanceCents: -150, CreditLimitCents: 100}, event)
+	}
+}
+
+func TestUpdateBalanceResyncsStaleBalances(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, 6, 1, 3, 0, 0, 0, time.UTC)
+
+	// the running balance drifted and is recounted from the start of the ledger
+	balanceRepository := mocks.NewBalanceRepository(t)
+	balanceRepository.On("LockBalance", nil, 3).Return(&models.WorkspaceBalance{SyncedAt: at.Add(-BalanceResyncAfter), WorkspaceID: 3, LastDebitID: 40, LastCreditID: 8, CreditInvoiceCents: 100, BalanceCents: 200, CreditLimitCents: 500}, nil)
+	balanceRepository.On("SumNewDebits", nil, 3, int64(0)).Return(int64(900), int64(48), nil)
+	balanceRepository.On("SumNewCredits", nil, 3, int64(0)).Return(int64(1000), int64(9), nil)
+	balanceRepository.On("SumCreditInvoices", nil, 3).Return(int64(220), nil)
+	balanceRepository.On("SaveBalance", nil, models.WorkspaceBalance{SyncedAt: at, WorkspaceID: 3, LastDebitID: 48, LastCreditID: 9, CreditInvoiceCents: 220, BalanceCents: -120, CreditLimitCents: 500}, at).Return(nil)
+
+	unitOfWork := mocks.NewUnitOfWork(t)
+	unitOfWork.On("Do", mock.Anything).Return(runUnitOfWork)
+
+	s := &BillingService{balanceRepository: balanceRepository, unitOfWork: unitOfWork}
+	event, err := s.UpdateBalance(3, at, BalanceResyncAfter)
+	assert.NoError(t, err)
+	assert.Nil(t, event, "a workspace within its credit limit is not cut off")
+}
@@ -0,0 +1,37 @@
+package billing
+
+import (
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/scheduler/models"
+)
+
+// applyCatalog prices the billing data with a catalog version instead of the current prices. The
+// plan and base costs are copied. A plan missing from the catalog keeps its current prices.
+func applyCatalog(data *BillingData, catalog *models.PriceCatalog, logger *logrus.Entry) {
+	baseCosts := *data.BaseCosts
+	baseCosts.RecordingsPerByte = catalog.RecordingsPerByte
+	baseCosts.FaxPerUsed = catalog.FaxPerUsed
+	data.BaseCosts = &baseCosts
+
+	if prices, ok := catalog.Plans[data.Plan.Id]; ok {
+		plan := *data.Plan
+		plan.BaseCosts = prices.BaseCosts
+		plan.AnnualCostCents = prices.AnnualCostCents
+		plan.MinutesPerMonth = prices.MinutesPerMonth
+		plan.RecordingSpace = prices.RecordingSpace
+		plan.Fax = prices.Fax
+		data.Plan = &plan
+	} else {
+		logger.Warnf("Plan %d is not in price catalog version %d, using its current prices", data.Plan.Id, catalog.Version)
+	}
+
+	data.Catalog = catalog
+}
+
+// catalogVersion is the version of the catalog the data is rated with, 0 for current prices
+func (d *BillingData) catalogVersion() int {
+	if d.Catalog == nil {
+		return 0
+	}
+	return d.Catalog.Version
+}
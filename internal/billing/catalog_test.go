@@ -0,0 +1,46 @@
+package billing
+
+import (
+	"testing"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"lineblocs.com/scheduler/models"
+)
+
+func TestApplyCatalog(t *testing.T) {
+	t.Parallel()
+
+	logger := logrus.WithField("test", t.Name())
+	catalog := &models.PriceCatalog{
+		Version:           3,
+		RecordingsPerByte: 2,
+		FaxPerUsed:        4,
+		Plans:             map[int]models.CatalogPlan{1: {BaseCosts: 900, AnnualCostCents: 9000, MinutesPerMonth: 800, RecordingSpace: 512, Fax: 20}},
+	}
+
+	t.Run("Should rate with the catalog's plan and base costs", func(t *testing.T) {
+		t.Parallel()
+
+		plan := &helpers.ServicePlan{Id: 1, KeyName: "pro", BaseCosts: 1000, MinutesPerMonth: 1000, RecordingSpace: 1024, Fax: 50, UnlimitedFax: true}
+		data := &BillingData{Plan: plan, BaseCosts: &helpers.BaseCosts{RecordingsPerByte: 1, FaxPerUsed: 1}}
+
+		applyCatalog(data, catalog, logger)
+		assert.Equal(t, &helpers.ServicePlan{Id: 1, KeyName: "pro", BaseCosts: 900, AnnualCostCents: 9000, MinutesPerMonth: 800, RecordingSpace: 512, Fax: 20, UnlimitedFax: true}, data.Plan)
+		assert.Equal(t, &helpers.BaseCosts{RecordingsPerByte: 2, FaxPerUsed: 4}, data.BaseCosts)
+		assert.Equal(t, 1000.0, plan.BaseCosts, "the shared plan is left unchanged")
+		assert.Equal(t, 3, data.catalogVersion())
+	})
+
+	t.Run("Should keep the current prices of a plan missing from the catalog", func(t *testing.T) {
+		t.Parallel()
+
+		plan := &helpers.ServicePlan{Id: 2, BaseCosts: 1000}
+		data := &BillingData{Plan: plan, BaseCosts: &helpers.BaseCosts{}}
+
+		applyCatalog(data, catalog, logger)
+		assert.Same(t, plan, data.Plan)
+		assert.Equal(t, &helpers.BaseCosts{RecordingsPerByte: 2, FaxPerUsed: 4}, data.BaseCosts)
+	})
+}
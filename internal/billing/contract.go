@@ -0,0 +1,68 @@
+package billing
+
+import (
+	"lineblocs.com/scheduler/internal/pricing"
+	"lineblocs.com/scheduler/models"
+)
+
+// applyContract overrides the plan, base costs and rates of the billing data with the prices
+// the contract sets. The plan and rates are copied, so shared values are left unchanged.
+func applyContract(data *BillingData, contract *models.Contract) {
+	plan := *data.Plan
+	if contract.SeatCents != nil {
+		plan.BaseCosts = *contract.SeatCents
+		// the plan's annual price no longer applies; annual terms bill twelve contract months
+		plan.AnnualCostCents = 0
+	}
+	if contract.AnnualSeatCents != nil {
+		plan.AnnualCostCents = int(*contract.AnnualSeatCents)
+	}
+	if contract.MinutesPerMonth != nil {
+		plan.MinutesPerMonth = *contract.MinutesPerMonth
+	}
+	if contract.RecordingSpaceMB != nil {
+		plan.RecordingSpace = *contract.RecordingSpaceMB * recordingSpaceUnitsPerMB
+	}
+	if contract.FaxPages != nil {
+		plan.Fax = *contract.FaxPages
+	}
+	data.Plan = &plan
+
+	if contract.RecordingCentsPerGB != nil {
+		baseCosts := *data.BaseCosts
+		baseCosts.RecordingsPerByte = *contract.RecordingCentsPerGB / bytesPerGB
+		data.BaseCosts = &baseCosts
+	}
+
+	if contract.FaxCentsPerPage != nil {
+		data.FaxRates = &models.FaxRates{InboundCentsPerPage: *contract.FaxCentsPerPage, OutboundCentsPerPage: *contract.FaxCentsPerPage}
+	}
+
+	if contract.CallCentsPerMinute != nil || contract.RecordingCentsPerGB != nil || contract.FaxCentsPerPage != nil {
+		schedules := make(map[string]pricing.Schedule, len(data.PriceSchedules))
+		for category, schedule := range data.PriceSchedules {
+			schedules[category] = schedule
+		}
+		// a contract rate replaces the plan's tiers for its category
+		if contract.CallCentsPerMinute != nil {
+			schedules[pricing.Minutes] = pricing.Flat(*contract.CallCentsPerMinute)
+		}
+		if contract.RecordingCentsPerGB != nil {
+			delete(schedules, pricing.RecordingGBMonths)
+		}
+		if contract.FaxCentsPerPage != nil {
+			delete(schedules, pricing.FaxPages)
+		}
+		data.PriceSchedules = schedules
+	}
+
+	data.Contract = contract
+}
+
+// contractVersion is the version of the contract the data is priced with, 0 without one
+func (d *BillingData) contractVersion() int {
+	if d.Contract == nil {
+		return 0
+	}
+	return d.Contract.Version
+}
@@ -0,0 +1,93 @@
+package billing
+
+import (
+	"testing"
+	"time"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"lineblocs.com/scheduler/internal/pricing"
+	"lineblocs.com/scheduler/mocks"
+	"lineblocs.com/scheduler/models"
+)
+
+func TestApplyContract(t *testing.T) {
+	t.Parallel()
+
+	seatCents, minutes, callCents, faxCents := 800.0, 5000.0, 0.9, 3.0
+
+	t.Run("Should override only the prices the contract sets", func(t *testing.T) {
+		t.Parallel()
+
+		plan := &helpers.ServicePlan{BaseCosts: 1000, AnnualCostCents: 10000, MinutesPerMonth: 1000, RecordingSpace: 1024, Fax: 50}
+		baseCosts := &helpers.BaseCosts{RecordingsPerByte: 1}
+		faxRates := &models.FaxRates{InboundCentsPerPage: 1, OutboundCentsPerPage: 2}
+		minuteTiers := pricing.Schedule{Mode: pricing.Graduated, Tiers: []pricing.Tier{{UpTo: 1000, UnitCents: 2}, {UnitCents: 1}}}
+		schedules := map[string]pricing.Schedule{pricing.Minutes: minuteTiers, pricing.FaxPages: pricing.Flat(4)}
+		data := &BillingData{Plan: plan, BaseCosts: baseCosts, FaxRates: faxRates, PriceSchedules: schedules}
+		contract := &models.Contract{ID: 7, Version: 3, SeatCents: &seatCents, MinutesPerMonth: &minutes, CallCentsPerMinute: &callCents, FaxCentsPerPage: &faxCents}
+
+		applyContract(data, contract)
+
+		assert.Equal(t, &helpers.ServicePlan{BaseCosts: 800, MinutesPerMonth: 5000, RecordingSpace: 1024, Fax: 50}, data.Plan)
+		assert.Same(t, baseCosts, data.BaseCosts)
+		assert.Equal(t, &models.FaxRates{InboundCentsPerPage: 3, OutboundCentsPerPage: 3}, data.FaxRates)
+		assert.Equal(t, map[string]pricing.Schedule{pricing.Minutes: pricing.Flat(0.9)}, data.PriceSchedules)
+		assert.Equal(t, 3, data.contractVersion())
+
+		assert.Equal(t, 1000.0, plan.BaseCosts, "the shared plan is left unchanged")
+		assert.Equal(t, minuteTiers, schedules[pricing.Minutes], "the plan's schedules are left unchanged")
+	})
+
+	t.Run("Should give the contract's recording space in the plan's kilobytes", func(t *testing.T) {
+		t.Parallel()
+
+		recordingMB := 2048.0
+		data := &BillingData{Plan: &helpers.ServicePlan{RecordingSpace: 1 << 20}, BaseCosts: &helpers.BaseCosts{}}
+		applyContract(data, &models.Contract{ID: 7, Version: 3, RecordingSpaceMB: &recordingMB})
+
+		assert.Equal(t, float64(2<<20), data.Plan.RecordingSpace)
+	})
+
+	t.Run("Should keep the recording price per GB-month", func(t *testing.T) {
+		t.Parallel()
+
+		recordingCents := 25.0
+		data := &BillingData{Plan: &helpers.ServicePlan{}, BaseCosts: &helpers.BaseCosts{RecordingsPerByte: 1}}
+
+		applyContract(data, &models.Contract{RecordingCentsPerGB: &recordingCents})
+		assert.InDelta(t, 25, data.BaseCosts.RecordingsPerByte*bytesPerGB, 0.0001)
+	})
+}
+
+func TestLoadPeriodPrices(t *testing.T) {
+	t.Parallel()
+
+	end := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	minutes := 5000.0
+
+	catalog := &models.PriceCatalog{Version: 4, FaxPerUsed: 2, Plans: map[int]models.CatalogPlan{1: {BaseCosts: 1200, MinutesPerMonth: 2000}}}
+	faxRates := &models.FaxRates{InboundCentsPerPage: 2, OutboundCentsPerPage: 2}
+
+	paymentRepository := mocks.NewPaymentRepository(t)
+	paymentRepository.On("GetPriceCatalog", end).Return(catalog, nil)
+	paymentRepository.On("GetFaxRates", 2.0).Return(faxRates, nil)
+	paymentRepository.On("GetContract", 3, end).Return(&models.Contract{ID: 7, Version: 2, MinutesPerMonth: &minutes}, nil)
+
+	s := &BillingService{paymentRepository: paymentRepository}
+	data := &BillingData{
+		Workspace:        &helpers.Workspace{Id: 3},
+		Plan:             &helpers.ServicePlan{Id: 1, BaseCosts: 1000, MinutesPerMonth: 1000},
+		BaseCosts:        &helpers.BaseCosts{FaxPerUsed: 1},
+		AddOns:           []models.AddOn{{Quantity: 2, Minutes: 500}},
+		BillingPeriodEnd: end,
+	}
+
+	assert.NoError(t, s.loadPeriodPrices(data, logrus.WithField("test", t.Name())))
+	assert.Equal(t, 1200.0, data.Plan.BaseCosts, "the catalog's seat price applies")
+	assert.Equal(t, 6000.0, data.Plan.MinutesPerMonth, "the contract overrides the catalog and add-on minutes are added to it")
+	assert.Same(t, faxRates, data.FaxRates)
+	assert.Equal(t, 2, data.contractVersion())
+	assert.Equal(t, 4, data.catalogVersion())
+}
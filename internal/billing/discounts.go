@@ -0,0 +1,79 @@
+package billing
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/scheduler/internal/promotions"
+	"lineblocs.com/scheduler/repository"
+)
+
+// applyDiscounts prices the workspace's active coupons against the rated costs. It runs after
+// usage rating and before tax; the discounts are negative line items on the invoice.
+func (s *BillingService) applyDiscounts(tx repository.Executor, data *BillingData, costs *BillingCosts, logger *logrus.Entry) error {
+	redemptions, err := s.promotionRepository.GetRedemptions(tx, data.Workspace.Id)
+	if err != nil {
+		logger.WithError(err).Error("error getting coupon redemptions")
+		return err
+	}
+
+	costs.Discounts = promotions.Apply(map[string]int64{
+		promotions.CategoryMembership: costs.MembershipCosts,
+		promotions.CategoryAddOns:     costs.AddOnCosts,
+		promotions.CategoryNumbers:    costs.NumberRentalCosts,
+		promotions.CategoryCalls:      costs.CallTollsCosts,
+		promotions.CategoryRecordings: costs.RecordingCosts,
+		promotions.CategoryFax:        costs.FaxCosts,
+	}, redemptions, data.BillingPeriodEnd)
+	costs.DiscountCosts = promotions.Total(costs.Discounts, "")
+
+	if len(costs.Discounts) > 0 {
+		logger.Infof("Applied %d discounts totalling %d cents", len(costs.Discounts), costs.DiscountCosts)
+	}
+	return nil
+}
+
+// RedeemCoupon attaches a coupon to a workspace so it is applied from the next invoice on
+func (s *BillingService) RedeemCoupon(workspaceID int, code string) (int64, error) {
+	logger := logrus.WithField("component", "promotions").WithField("workspace_id", workspaceID).WithField("code", code)
+
+	var redemptionID int64
+	err := s.unitOfWork.Do(func(tx repository.Executor) error {
+		// the coupon row stays locked until the redemption is created, so concurrent redemptions
+		// cannot both count the same total and exceed the coupon's limit
+		coupon, err := s.promotionRepository.LockCouponByCode(tx, code)
+		if err != nil {
+			return err
+		}
+		if err := coupon.Validate(); err != nil {
+			return err
+		}
+
+		redeemed, err := s.promotionRepository.HasRedeemed(tx, coupon.ID, workspaceID)
+		if err != nil {
+			return err
+		}
+		if redeemed {
+			return promotions.ErrAlreadyRedeemed
+		}
+
+		count, err := s.promotionRepository.CountRedemptions(tx, coupon.ID)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if err := coupon.CanRedeem(count, now); err != nil {
+			return err
+		}
+
+		redemptionID, err = s.promotionRepository.CreateRedemption(tx, coupon.ID, workspaceID, now)
+		return err
+	})
+	if err != nil {
+		logger.WithError(err).Error("error redeeming coupon")
+		return 0, err
+	}
+
+	logger.Infof("Redeemed coupon as redemption %d", redemptionID)
+	return redemptionID, nil
+}
@@ -0,0 +1,56 @@
+package billing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"lineblocs.com/scheduler/internal/promotions"
+	"lineblocs.com/scheduler/mocks"
+)
+
+func TestRedeemCoupon(t *testing.T) {
+	t.Parallel()
+
+	coupon := &promotions.Coupon{ID: 4, Code: "SPRING", Kind: promotions.KindFixed, AmountOffCents: 500, Duration: promotions.DurationOnce, MaxRedemptions: 2}
+
+	t.Run("Should lock the coupon before counting and creating the redemption", func(t *testing.T) {
+		t.Parallel()
+
+		var calls []string
+		record := func(name string) func(mock.Arguments) {
+			return func(mock.Arguments) { calls = append(calls, name) }
+		}
+
+		unitOfWork := mocks.NewUnitOfWork(t)
+		unitOfWork.On("Do", mock.Anything).Return(runUnitOfWork)
+
+		promotionRepository := mocks.NewPromotionRepository(t)
+		promotionRepository.On("LockCouponByCode", nil, "SPRING").Run(record("lock")).Return(coupon, nil)
+		promotionRepository.On("HasRedeemed", nil, int64(4), 7).Run(record("has redeemed")).Return(false, nil)
+		promotionRepository.On("CountRedemptions", nil, int64(4)).Run(record("count")).Return(1, nil)
+		promotionRepository.On("CreateRedemption", nil, int64(4), 7, mock.Anything).Run(record("create")).Return(int64(31), nil)
+
+		s := &BillingService{unitOfWork: unitOfWork, promotionRepository: promotionRepository}
+		redemptionID, err := s.RedeemCoupon(7, "SPRING")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(31), redemptionID)
+		assert.Equal(t, []string{"lock", "has redeemed", "count", "create"}, calls)
+	})
+
+	t.Run("Should not redeem a coupon at its limit", func(t *testing.T) {
+		t.Parallel()
+
+		unitOfWork := mocks.NewUnitOfWork(t)
+		unitOfWork.On("Do", mock.Anything).Return(runUnitOfWork)
+
+		promotionRepository := mocks.NewPromotionRepository(t)
+		promotionRepository.On("LockCouponByCode", nil, "SPRING").Return(coupon, nil)
+		promotionRepository.On("HasRedeemed", nil, int64(4), 7).Return(false, nil)
+		promotionRepository.On("CountRedemptions", nil, int64(4)).Return(2, nil)
+
+		s := &BillingService{unitOfWork: unitOfWork, promotionRepository: promotionRepository}
+		_, err := s.RedeemCoupon(7, "SPRING")
+		assert.ErrorIs(t, err, promotions.ErrCouponExhausted)
+	})
+}
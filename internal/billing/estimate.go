@@ -0,0 +1,103 @@
+package billing
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/scheduler/models"
+	"lineblocs.com/scheduler/repository"
+)
+
+// Estimate is the bill a workspace would get for a period if it were invoiced now
+type Estimate struct {
+	Start       time.Time     `json:"start"`
+	End         time.Time     `json:"end"`
+	Term        string        `json:"term"`
+	Costs       *BillingCosts `json:"costs"`
+	WorkspaceID int           `json:"workspace_id"`
+}
+
+// Estimate prices a workspace's period through the same calculation as a billing run,
+// without creating rental debits, invoices or charges. Only the unbilled debits created
+// in the period are priced, so debits a run would carry over from earlier are left out.
+func (s *BillingService) Estimate(task models.EstimateTask) (*Estimate, error) {
+	logger := logrus.WithField("component", "estimate").WithField("workspace_id", task.WorkspaceID)
+
+	workspace, err := s.workspaceRepository.GetWorkspaceFromDB(task.WorkspaceID)
+	if err != nil {
+		logger.WithError(err).Error("error getting workspace")
+		return nil, err
+	}
+
+	subscriptionID, err := s.paymentRepository.GetActiveSubscriptionID(workspace.Id)
+	if err != nil {
+		logger.WithError(err).Error("error getting active subscription")
+		return nil, err
+	}
+
+	billingType := task.BillingType
+	if billingType == "" {
+		billingType = BillingCycleMonthly
+	}
+
+	data, err := s.loadBillingData(models.BillingTask{
+		BillingType:    billingType,
+		WorkspaceID:    workspace.Id,
+		CreatorID:      workspace.CreatorId,
+		SubscriptionID: subscriptionID,
+	}, logger)
+	if err != nil {
+		return nil, err
+	}
+	data.Estimate = true
+
+	// without an end date the estimate runs up to now, the bill so far
+	data.BillingPeriodEnd = data.Now
+	if task.EndDate != "" {
+		data.BillingPeriodEnd, err = time.Parse(time.DateOnly, task.EndDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end date: %w", err)
+		}
+	}
+	data.BillingPeriodStart = data.Term.PeriodStart(data.BillingPeriodEnd)
+	if task.StartDate != "" {
+		data.BillingPeriodStart, err = time.Parse(time.DateOnly, task.StartDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start date: %w", err)
+		}
+	}
+	if !data.BillingPeriodStart.Before(data.BillingPeriodEnd) {
+		return nil, fmt.Errorf("start date must be before end date")
+	}
+	if err := s.loadPeriodPrices(data, logger); err != nil {
+		return nil, err
+	}
+
+	costs, err := s.calculateCosts(s.db, data, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Estimate{
+		Start:       data.BillingPeriodStart,
+		End:         data.BillingPeriodEnd,
+		Term:        data.Term.Name(),
+		Costs:       costs,
+		WorkspaceID: workspace.Id,
+	}, nil
+}
+
+// estimateNumberRentals prices the rental charges a billing run would create for the period
+func (s *BillingService) estimateNumberRentals(ex repository.Executor, data *BillingData, costs *BillingCosts, logger *logrus.Entry) error {
+	rentals, err := s.invoiceRepository.GetNumberRentals(ex, data.Workspace.Id, data.BillingPeriodStart, data.BillingPeriodEnd)
+	if err != nil {
+		logger.WithError(err).Error("error getting number rentals")
+		return err
+	}
+
+	for _, charge := range NumberRentalCharges(rentals, data.Workspace.Id, data.BillingPeriodStart, data.BillingPeriodEnd) {
+		costs.NumberRentalCosts += charge.Cents
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package billing
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"lineblocs.com/scheduler/internal/allowance"
+	"lineblocs.com/scheduler/internal/pricing"
+	"lineblocs.com/scheduler/internal/promotions"
+	"lineblocs.com/scheduler/mocks"
+	"lineblocs.com/scheduler/models"
+)
+
+func TestEstimateNumberRentals(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	rentals := []models.NumberRental{
+		{DIDID: 11, MonthlyCost: 300, ProvisionedAt: start.AddDate(0, -3, 0)},
+		{DIDID: 12, MonthlyCost: 150, ProvisionedAt: start.AddDate(0, -1, 0)},
+		{DIDID: 13, MonthlyCost: 280, ProvisionedAt: start.AddDate(0, -1, 0), RentalBilled: true},
+	}
+
+	invoiceRepository := mocks.NewInvoiceRepository(t)
+	invoiceRepository.On("GetNumberRentals", nil, 3, start, end).Return(rentals, nil)
+
+	s := &BillingService{invoiceRepository: invoiceRepository}
+	data := &BillingData{Workspace: &helpers.Workspace{Id: 3}, BillingPeriodStart: start, BillingPeriodEnd: end, Estimate: true}
+	costs := &BillingCosts{}
+
+	assert.NoError(t, s.estimateNumberRentals(nil, data, costs, logrus.WithField("test", t.Name())))
+	assert.Equal(t, int64(450), costs.NumberRentalCosts)
+}
+
+func TestEstimate(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	workspace := &helpers.Workspace{Id: 7, CreatorId: 5, Plan: "pro"}
+	plan := helpers.ServicePlan{Id: 2, KeyName: "pro", BaseCosts: 2500}
+
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT payment_gateway FROM customizations")).
+		WillReturnRows(sqlmock.NewRows([]string{"payment_gateway"}).AddRow("stripe"))
+	sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT stripe_private_key FROM api_credentials")).
+		WillReturnRows(sqlmock.NewRows([]string{"stripe_private_key"}).AddRow("sk_test"))
+	// only the debits created in the period are priced
+	sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT id, source, module_id, cents, created_at FROM users_debits WHERE user_id = ? AND invoice_id IS NULL AND status = ? AND created_at < ? AND created_at >= ? ORDER BY id")).
+		WithArgs(5, models.DebitStatusUnbilled, "2026-04-01 00:00:00", "2026-03-01 00:00:00").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "source", "module_id", "cents", "created_at"}).
+			AddRow(1, "CALL", 11, 12, start.AddDate(0, 0, 3)))
+	sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT id, size, created_at, deleted_at FROM recordings")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "size", "created_at", "deleted_at"}))
+	sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT id, direction, pages, created_at FROM faxes")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "direction", "pages", "created_at"}))
+
+	// the mocks are strict, so any invoice, rental debit, claim, status change or allowance write fails the test
+	workspaceRepository := mocks.NewWorkspaceRepository(t)
+	workspaceRepository.On("GetWorkspaceFromDB", 7).Return(workspace, nil)
+	workspaceRepository.On("GetUserFromDB", 5).Return(&helpers.User{Id: 5}, nil)
+	workspaceRepository.On("GetWorkspaceBillingInfo", workspace).Return(&helpers.WorkspaceBillingInfo{}, nil)
+	workspaceRepository.On("GetMemberships", 7, start, end).Return([]models.Membership{{UserID: 5, JoinedAt: start.AddDate(-1, 0, 0)}}, nil)
+	workspaceRepository.On("GetCallFromDB", 11).Return(&helpers.Call{To: "15550001111", DurationNumber: 120}, nil)
+
+	paymentRepository := mocks.NewPaymentRepository(t)
+	paymentRepository.On("GetActiveSubscriptionID", 7).Return(3, nil)
+	paymentRepository.On("GetSubscription", 3).Return(&helpers.Subscription{Id: 3, CurrentPlanId: 2, BillingCycle: BillingCycleMonthly}, nil)
+	paymentRepository.On("GetServicePlans").Return([]helpers.ServicePlan{plan}, nil)
+	paymentRepository.On("GetSubscriptionAddOns", 3).Return([]models.AddOn{}, nil)
+	paymentRepository.On("GetCallRatingPlan", 2).Return(models.DefaultCallRatingPlan(), nil)
+	paymentRepository.On("GetPriceSchedules", 2).Return(map[string]pricing.Schedule{}, nil)
+	paymentRepository.On("GetRolloverPolicy", 2).Return(allowance.Policy{}, nil)
+	paymentRepository.On("GetCollectionTerms", 7).Return(&models.CollectionTerms{}, nil)
+	paymentRepository.On("GetPriceCatalog", end).Return(nil, nil)
+	paymentRepository.On("GetFaxRates", mock.Anything).Return(&models.FaxRates{}, nil)
+	paymentRepository.On("GetContract", 7, end).Return(nil, nil)
+
+	invoiceRepository := mocks.NewInvoiceRepository(t)
+	invoiceRepository.On("GetNumberRentals", db, 7, start, end).Return([]models.NumberRental{}, nil)
+
+	allowanceRepository := mocks.NewAllowanceRepository(t)
+	allowanceRepository.On("GetAllowanceBalance", db, 3).Return([]allowance.Bucket{}, nil)
+
+	promotionRepository := mocks.NewPromotionRepository(t)
+	promotionRepository.On("GetRedemptions", db, 7).Return([]promotions.Redemption{}, nil)
+
+	s := &BillingService{
+		db:                  db,
+		workspaceRepository: workspaceRepository,
+		paymentRepository:   paymentRepository,
+		invoiceRepository:   invoiceRepository,
+		allowanceRepository: allowanceRepository,
+		promotionRepository: promotionRepository,
+		unitOfWork:          mocks.NewUnitOfWork(t),
+	}
+	estimate, err := s.Estimate(models.EstimateTask{WorkspaceID: 7, StartDate: "2026-03-01", EndDate: "2026-04-01"})
+	assert.NoError(t, err)
+	assert.Equal(t, start, estimate.Start)
+	assert.Equal(t, end, estimate.End)
+	assert.Equal(t, int64(2500), estimate.Costs.MembershipCosts)
+	assert.Equal(t, int64(12), estimate.Costs.CallTollsCosts)
+	assert.Equal(t, int64(2512), estimate.Costs.TotalCosts)
+	assert.Equal(t, []int{1}, estimate.Costs.DebitIDs)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
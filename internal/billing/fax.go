@@ -0,0 +1,102 @@
+package billing
+
+import (
+	"math"
+	"time"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/scheduler/internal/pricing"
+	"lineblocs.com/scheduler/models"
+)
+
+// FaxUsage summarizes the fax pages of a period and what they cost beyond the allowance
+type FaxUsage struct {
+	InboundPages        int
+	OutboundPages       int
+	BilledInboundPages  int
+	BilledOutboundPages int
+	Cents               int64
+}
+
+// FaxRater draws fax pages down from the plan's included pages. It is shared by every billing term.
+type FaxRater struct {
+	remainingPages int
+	unlimited      bool
+}
+
+func NewFaxRater(includedPages int, unlimited bool) *FaxRater {
+	return &FaxRater{
+		remainingPages: includedPages,
+		unlimited:      unlimited,
+	}
+}
+
+// Rate returns the pages of a fax that fall outside the allowance
+func (r *FaxRater) Rate(pages int) int {
+	if r.unlimited || pages <= 0 {
+		return 0
+	}
+
+	covered := min(pages, max(r.remainingPages, 0))
+	r.remainingPages -= covered
+	return pages - covered
+}
+
+// PriceFaxPages prices the billed pages on the plan's fax tiers when it has them,
+// otherwise at the flat rate for each direction
+func PriceFaxPages(usage *FaxUsage, rates *models.FaxRates, schedules map[string]pricing.Schedule) int64 {
+	if schedule, ok := schedules[pricing.FaxPages]; ok {
+		return int64(math.Round(schedule.Price(float64(usage.BilledInboundPages + usage.BilledOutboundPages))))
+	}
+
+	cents := pricing.Flat(rates.InboundCentsPerPage).Price(float64(usage.BilledInboundPages)) +
+		pricing.Flat(rates.OutboundCentsPerPage).Price(float64(usage.BilledOutboundPages))
+	return int64(math.Round(cents))
+}
+
+// computeFaxUsage counts every page sent and received by the workspace in the period and the pages billed beyond the allowance.
+// A fax sent at the end of the period belongs to the next one. Faxes draw on the allowance in the order they were sent,
+// so the same faxes are billed on every run.
+func (s *BillingService) computeFaxUsage(workspaceID int, plan *helpers.ServicePlan, includedPages int, start, end time.Time, logger *logrus.Entry) (*FaxUsage, error) {
+	rows, err := s.db.Query("SELECT id, direction, pages, created_at FROM faxes WHERE workspace_id = ? AND created_at >= ? AND created_at < ? ORDER BY created_at, id",
+		workspaceID, start.Format(time.DateTime), end.Format(time.DateTime))
+	if err != nil {
+		logger.WithError(err).Error("error running faxes query")
+		return nil, err
+	}
+	defer rows.Close()
+
+	usage := &FaxUsage{}
+	faxRater := NewFaxRater(includedPages, plan.UnlimitedFax)
+
+	for rows.Next() {
+		var faxID int
+		var direction string
+		var pages int
+		var createdAt time.Time
+
+		if err := rows.Scan(&faxID, &direction, &pages, &createdAt); err != nil {
+			logger.WithError(err).Error("error scanning fax")
+			return nil, err
+		}
+
+		billedPages := faxRater.Rate(pages)
+		if direction == models.FaxInbound {
+			usage.InboundPages += pages
+			usage.BilledInboundPages += billedPages
+		} else {
+			usage.OutboundPages += pages
+			usage.BilledOutboundPages += billedPages
+		}
+	}
+	if err := rows.Err(); err != nil {
+		logger.WithError(err).Error("error reading faxes")
+		return nil, err
+	}
+
+	logger.Infof("Workspace faxed %d inbound and %d outbound pages, %d and %d billed beyond the allowance",
+		usage.InboundPages, usage.OutboundPages, usage.BilledInboundPages, usage.BilledOutboundPages)
+
+	return usage, nil
+}
@@ -0,0 +1,115 @@
+package billing
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"lineblocs.com/scheduler/internal/pricing"
+	"lineblocs.com/scheduler/models"
+)
+
+func TestFaxRaterRate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Should bill only the pages beyond the allowance", func(t *testing.T) {
+		t.Parallel()
+
+		rater := NewFaxRater(5, false)
+		assert.Equal(t, 0, rater.Rate(3))
+		assert.Equal(t, 2, rater.Rate(4))
+		assert.Equal(t, 6, rater.Rate(6))
+	})
+
+	t.Run("Should bill nothing on unlimited plans", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, 0, NewFaxRater(0, true).Rate(10))
+	})
+}
+
+func TestPriceFaxPages(t *testing.T) {
+	t.Parallel()
+
+	rates := &models.FaxRates{InboundCentsPerPage: 2, OutboundCentsPerPage: 5}
+	usage := &FaxUsage{BilledInboundPages: 10, BilledOutboundPages: 20}
+
+	t.Run("Should price each direction at its own rate without tiers", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, int64(20+100), PriceFaxPages(usage, rates, nil))
+	})
+
+	t.Run("Should price every billed page on the plan's tiers", func(t *testing.T) {
+		t.Parallel()
+
+		schedules := map[string]pricing.Schedule{
+			pricing.FaxPages: {Mode: pricing.Volume, Tiers: []pricing.Tier{{UpTo: 10, UnitCents: 4}, {UnitCents: 3}}},
+		}
+		assert.Equal(t, int64(90), PriceFaxPages(usage, rates, schedules))
+	})
+}
+
+func TestComputeFaxUsage(t *testing.T) {
+	t.Parallel()
+
+	logger := logrus.WithField("test", t.Name())
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	query := regexp.QuoteMeta("SELECT id, direction, pages, created_at FROM faxes WHERE workspace_id = ? AND created_at >= ? AND created_at < ? ORDER BY created_at, id")
+	columns := []string{"id", "direction", "pages", "created_at"}
+
+	t.Run("Should draw the allowance down in the order faxes were sent", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		// the 10 included pages cover the first inbound fax and half of the outbound one
+		mock.ExpectQuery(query).WithArgs(3, start.Format(time.DateTime), end.Format(time.DateTime)).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(7, models.FaxInbound, 6, start.AddDate(0, 0, 1)).
+				AddRow(4, models.FaxOutbound, 8, start.AddDate(0, 0, 2)).
+				AddRow(9, models.FaxInbound, 3, start.AddDate(0, 0, 2)))
+
+		s := &BillingService{db: db}
+		usage, err := s.computeFaxUsage(3, &helpers.ServicePlan{}, 10, start, end, logger)
+		assert.NoError(t, err)
+		assert.Equal(t, &FaxUsage{InboundPages: 9, OutboundPages: 8, BilledInboundPages: 3, BilledOutboundPages: 4}, usage)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Should not bill pages on an unlimited plan", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows(columns).AddRow(7, models.FaxOutbound, 50, start))
+
+		s := &BillingService{db: db}
+		usage, err := s.computeFaxUsage(3, &helpers.ServicePlan{UnlimitedFax: true}, 0, start, end, logger)
+		assert.NoError(t, err)
+		assert.Equal(t, &FaxUsage{OutboundPages: 50}, usage)
+	})
+
+	t.Run("Should return scan errors instead of billing a partial count", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows(columns).AddRow(7, models.FaxOutbound, "many", start))
+
+		s := &BillingService{db: db}
+		_, err = s.computeFaxUsage(3, &helpers.ServicePlan{}, 0, start, end, logger)
+		assert.Error(t, err)
+	})
+}
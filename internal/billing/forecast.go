@@ -0,0 +1,172 @@
+package billing
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/scheduler/models"
+	"lineblocs.com/scheduler/utils"
+)
+
+// ForecastConfig decides which projections are emailed to the workspace. Zero disables a check.
+type ForecastConfig struct {
+	ThresholdCents   int64
+	OverPriorPercent float64
+}
+
+// ForecastReport summarizes a forecast run
+type ForecastReport struct {
+	Alerted    []int `json:"alerted"`
+	Failed     []int `json:"failed"`
+	Forecasted int   `json:"forecasted"`
+}
+
+// RunForecasts projects the month-end bill of every workspace with an active subscription.
+// Usage is priced up to the start of the given day by Estimate and extended over the rest
+// of the month at its daily average so far. On the 1st, the month that just ended is forecast.
+func (s *BillingService) RunForecasts(at time.Time, cfg ForecastConfig) (*ForecastReport, error) {
+	logger := logrus.WithField("component", "forecast")
+
+	end := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+	lastDay := end.AddDate(0, 0, -1)
+	start := time.Date(lastDay.Year(), lastDay.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	workspaceIDs, err := s.forecastRepository.GetForecastWorkspaceIDs()
+	if err != nil {
+		logger.WithError(err).Error("error getting workspaces to forecast")
+		return nil, err
+	}
+
+	report := &ForecastReport{}
+	for _, workspaceID := range workspaceIDs {
+		workspaceLogger := logger.WithField("workspace_id", workspaceID)
+		forecast, err := s.forecastWorkspace(workspaceID, start, end, cfg, workspaceLogger)
+		if err != nil {
+			workspaceLogger.WithError(err).Error("error forecasting workspace")
+			report.Failed = append(report.Failed, workspaceID)
+			continue
+		}
+
+		report.Forecasted++
+		if forecast.Alerted {
+			report.Alerted = append(report.Alerted, workspaceID)
+		}
+	}
+
+	logger.Infof("Forecast %d workspaces, %d alerted, %d failed", report.Forecasted, len(report.Alerted), len(report.Failed))
+	return report, nil
+}
+
+func (s *BillingService) forecastWorkspace(workspaceID int, start, end time.Time, cfg ForecastConfig, logger *logrus.Entry) (*models.Forecast, error) {
+	estimate, err := s.Estimate(models.EstimateTask{
+		StartDate:   start.Format(time.DateOnly),
+		EndDate:     end.Format(time.DateOnly),
+		WorkspaceID: workspaceID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	forecast := projectForecast(estimate.Costs, start, end)
+	forecast.WorkspaceID = workspaceID
+	forecast.ForecastDate = end
+
+	priorCents, hasPrior, err := s.forecastRepository.GetPriorMonthCents(workspaceID, start, start.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+	if hasPrior {
+		forecast.PriorMonthCents = priorCents
+	}
+
+	// the workspace is alerted once per period
+	forecast.AlertReason = forecastAlert(forecast, hasPrior, cfg)
+	if forecast.AlertReason != "" {
+		alerted, err := s.forecastRepository.WasAlerted(workspaceID, forecast.PeriodStart)
+		if err != nil {
+			return nil, err
+		}
+		forecast.Alerted = !alerted
+	}
+
+	// the alert is recorded before the email is sent, so a run that stops after sending it does not send it again
+	if err := s.forecastRepository.SaveForecast(forecast); err != nil {
+		return nil, err
+	}
+	if forecast.Alerted {
+		if err := s.alertForecast(forecast, logger); err != nil {
+			logger.WithError(err).Error("error sending forecast email")
+		}
+	}
+
+	logger.Infof("Projected %d cents for the month from %d cents of usage over %d of %d days",
+		forecast.ProjectedCents, forecast.UsageToDateCents, forecast.DaysElapsed, forecast.DaysInPeriod)
+	return forecast, nil
+}
+
+// alertForecast emails the workspace owner about the projection
+func (s *BillingService) alertForecast(forecast *models.Forecast, logger *logrus.Entry) error {
+	workspace, err := s.workspaceRepository.GetWorkspaceFromDB(forecast.WorkspaceID)
+	if err != nil {
+		return err
+	}
+	user, err := s.workspaceRepository.GetUserFromDB(workspace.CreatorId)
+	if err != nil {
+		return err
+	}
+
+	args := map[string]string{
+		"projectedCents":   fmt.Sprintf("%d", forecast.ProjectedCents),
+		"usageToDateCents": fmt.Sprintf("%d", forecast.UsageToDateCents),
+		"priorMonthCents":  fmt.Sprintf("%d", forecast.PriorMonthCents),
+		"reason":           forecast.AlertReason,
+	}
+	if err := utils.DispatchEmail("Projected Bill Alert", "bill_forecast", user, workspace, args); err != nil {
+		return err
+	}
+
+	logger.Infof("Sent forecast alert: %s", forecast.AlertReason)
+	return nil
+}
+
+// projectForecast splits the costs so far into fixed fees and usage, and extends the
+// usage over the whole month at its daily average
+func projectForecast(costs *BillingCosts, start, end time.Time) *models.Forecast {
+	daysElapsed := int(end.Sub(start).Hours() / 24)
+	daysInPeriod := int(start.AddDate(0, 1, 0).Sub(start).Hours() / 24)
+	fixedCents := costs.MembershipCosts + costs.AddOnCosts + costs.NumberRentalCosts
+	usageCents := costs.CallTollsCosts + costs.RecordingCosts + costs.FaxCosts
+
+	projectedUsage := usageCents
+	if daysElapsed > 0 && daysElapsed < daysInPeriod {
+		projectedUsage = int64(math.Round(float64(usageCents) / float64(daysElapsed) * float64(daysInPeriod)))
+	}
+
+	return &models.Forecast{
+		PeriodStart:      start,
+		PeriodEnd:        start.AddDate(0, 1, 0),
+		DaysElapsed:      daysElapsed,
+		DaysInPeriod:     daysInPeriod,
+		FixedCents:       fixedCents,
+		UsageToDateCents: usageCents,
+		ProjectedCents:   fixedCents + projectedUsage,
+	}
+}
+
+// forecastAlert returns why the workspace should be told about its projection, or "" if it should not
+func forecastAlert(forecast *models.Forecast, hasPrior bool, cfg ForecastConfig) string {
+	if cfg.ThresholdCents > 0 && forecast.ProjectedCents > cfg.ThresholdCents {
+		return fmt.Sprintf("projected bill of %d cents is over the %d cents threshold", forecast.ProjectedCents, cfg.ThresholdCents)
+	}
+
+	if cfg.OverPriorPercent > 0 && hasPrior && forecast.PriorMonthCents > 0 {
+		limit := float64(forecast.PriorMonthCents) * (1 + cfg.OverPriorPercent/100)
+		if float64(forecast.ProjectedCents) > limit {
+			return fmt.Sprintf("projected bill of %d cents is more than %.0f%% over last month's %d cents", forecast.ProjectedCents, cfg.OverPriorPercent, forecast.PriorMonthCents)
+		}
+	}
+
+	return ""
+}
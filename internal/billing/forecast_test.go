@@ -0,0 +1,69 @@
+package billing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"lineblocs.com/scheduler/models"
+)
+
+func TestProjectForecast(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC)
+	costs := &BillingCosts{MembershipCosts: 1000, NumberRentalCosts: 300, CallTollsCosts: 400, RecordingCosts: 80, FaxCosts: 20}
+
+	testCases := []struct {
+		Name              string
+		End               time.Time
+		ExpectedElapsed   int
+		ExpectedProjected int64
+	}{
+		{Name: "usage is extended over the month at its daily average", End: start.AddDate(0, 0, 10), ExpectedElapsed: 10, ExpectedProjected: 1300 + 1500},
+		{Name: "a full month is not extended", End: start.AddDate(0, 1, 0), ExpectedElapsed: 30, ExpectedProjected: 1300 + 500},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			forecast := projectForecast(costs, start, tc.End)
+			assert.Equal(t, tc.ExpectedElapsed, forecast.DaysElapsed)
+			assert.Equal(t, 30, forecast.DaysInPeriod)
+			assert.Equal(t, int64(1300), forecast.FixedCents)
+			assert.Equal(t, int64(500), forecast.UsageToDateCents)
+			assert.Equal(t, tc.ExpectedProjected, forecast.ProjectedCents)
+		})
+	}
+}
+
+func TestForecastAlert(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		Name          string
+		Config        ForecastConfig
+		Projected     int64
+		Prior         int64
+		HasPrior      bool
+		ExpectedAlert bool
+	}{
+		{Name: "over the threshold", Config: ForecastConfig{ThresholdCents: 5000}, Projected: 6000, ExpectedAlert: true},
+		{Name: "under the threshold", Config: ForecastConfig{ThresholdCents: 5000}, Projected: 4000},
+		{Name: "over last month by more than the percentage", Config: ForecastConfig{OverPriorPercent: 50}, Projected: 1600, Prior: 1000, HasPrior: true, ExpectedAlert: true},
+		{Name: "over last month by less than the percentage", Config: ForecastConfig{OverPriorPercent: 50}, Projected: 1400, Prior: 1000, HasPrior: true},
+		{Name: "no prior month to compare with", Config: ForecastConfig{OverPriorPercent: 50}, Projected: 1600},
+		{Name: "checks disabled", Projected: 1000000, Prior: 1, HasPrior: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			forecast := &models.Forecast{ProjectedCents: tc.Projected, PriorMonthCents: tc.Prior}
+			reason := forecastAlert(forecast, tc.HasPrior, tc.Config)
+			assert.Equal(t, tc.ExpectedAlert, reason != "", reason)
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package billing
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/scheduler/internal/invoice"
+	"lineblocs.com/scheduler/internal/latefee"
+	"lineblocs.com/scheduler/models"
+	"lineblocs.com/scheduler/repository"
+	"lineblocs.com/scheduler/utils"
+)
+
+// How late charges are billed
+const (
+	LateFeeLineItem = "line_item" // added to the overdue invoice
+	LateFeeInvoice  = "invoice"   // billed on a separate invoice, linked in the ledger
+)
+
+// LateFeeConfig is the late fee policy and how its charges are billed
+type LateFeeConfig struct {
+	Mode   string
+	Policy latefee.Policy
+}
+
+// LateFeeReport summarizes a late fee run
+type LateFeeReport struct {
+	Charged []int64 `json:"charged"`
+	Failed  []int64 `json:"failed"`
+	Checked int     `json:"checked"`
+	Cents   int64   `json:"cents"`
+}
+
+// lateCharges are the charges applied to one overdue invoice by a run
+type lateCharges struct {
+	// Invoice is the overdue invoice as read when the charges were applied
+	Invoice      models.Invoice
+	Charges      []latefee.Charge
+	FeeInvoiceID int64
+}
+
+// ApplyLateFees charges every overdue invoice what the policy says it owes by the given time, each
+// invoice in its own unit of work. Charges already in the invoice_late_fees ledger are not charged
+// again, so the job can run as often as it likes. The workspace owner is emailed the new balance.
+func (s *BillingService) ApplyLateFees(at time.Time, cfg LateFeeConfig) (*LateFeeReport, error) {
+	logger := logrus.WithField("component", "late_fees")
+
+	if cfg.Mode != LateFeeLineItem && cfg.Mode != LateFeeInvoice {
+		return nil, fmt.Errorf("unknown late fee mode %q", cfg.Mode)
+	}
+	if err := cfg.Policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	report := &LateFeeReport{}
+	if !cfg.Policy.Enabled() {
+		logger.Info("No late fee or interest is configured")
+		return report, nil
+	}
+
+	invoices, err := s.invoiceRepository.GetOverdueInvoices(s.db, at.AddDate(0, 0, -cfg.Policy.GraceDays))
+	if err != nil {
+		logger.WithError(err).Error("error getting overdue invoices")
+		return nil, err
+	}
+
+	for _, inv := range invoices {
+		report.Checked++
+		invoiceLogger := logger.WithField("invoice_id", inv.Id).WithField("workspace_id", inv.WorkspaceID)
+
+		var applied *lateCharges
+		err := s.unitOfWork.Do(func(tx repository.Executor) error {
+			var err error
+			applied, err = s.applyLateFees(tx, inv, at, cfg)
+			return err
+		})
+		if err != nil {
+			invoiceLogger.WithError(err).Error("error applying late fees")
+			report.Failed = append(report.Failed, inv.Id)
+			continue
+		}
+		if applied == nil {
+			continue
+		}
+
+		cents := latefee.Total(applied.Charges)
+		invoiceLogger.Infof("Applied %d late charges for %d cents", len(applied.Charges), cents)
+		report.Charged = append(report.Charged, inv.Id)
+		report.Cents += cents
+
+		if err := s.notifyLateFees(applied); err != nil {
+			invoiceLogger.WithError(err).Error("error sending late fee email")
+		}
+	}
+
+	logger.Infof("Checked %d overdue invoices, charged %d for %d cents, %d failed", report.Checked, len(report.Charged), report.Cents, len(report.Failed))
+	return report, nil
+}
+
+// applyLateFees writes the charges an overdue invoice owes to the ledger and bills them. The invoice is locked
+// and read again first, so a payment recorded since it was listed lowers the interest and one that settled it,
+// or a void, skips it. Charges added to the invoice itself wait while a card charge for it is pending, since
+// that charge was recorded for the amount before them. It returns nil when nothing is owed.
+func (s *BillingService) applyLateFees(tx repository.Executor, listed models.Invoice, at time.Time, cfg LateFeeConfig) (*lateCharges, error) {
+	locked, err := s.invoiceRepository.LockOverdueInvoice(tx, listed.Id)
+	if err != nil {
+		return nil, err
+	}
+	inv := *locked
+	if !invoice.Status(inv.State).Collectable() {
+		return nil, nil
+	}
+	if cfg.Mode == LateFeeLineItem {
+		pending, err := s.invoiceRepository.HasPendingCharge(tx, inv.Id)
+		if err != nil || pending {
+			return nil, err
+		}
+	}
+
+	fees, err := s.invoiceRepository.GetLateFees(tx, inv.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	// charges added to the invoice itself are not part of what it billed and accrue no interest
+	applied := make([]latefee.Charge, 0, len(fees))
+	var onInvoice int64
+	for _, fee := range fees {
+		applied = append(applied, latefee.Charge{Kind: fee.Kind, Period: fee.Period, Cents: fee.Cents})
+		if fee.FeeInvoiceID == 0 {
+			onInvoice += fee.Cents
+		}
+	}
+	billed := inv.Cents - onInvoice
+	owed := max(billed-inv.CentsCollected, 0)
+
+	charges := latefee.Assess(cfg.Policy, inv.DueDate, at, billed, owed, applied)
+	if len(charges) == 0 {
+		return nil, nil
+	}
+
+	result := &lateCharges{Invoice: inv, Charges: charges}
+	total := latefee.Total(charges)
+	if cfg.Mode == LateFeeInvoice {
+		result.FeeInvoiceID, err = s.createLateFeeInvoice(tx, inv, total, at)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, charge := range charges {
+		created, err := s.invoiceRepository.CreateLateFee(tx, models.LateFee{
+			CreatedAt:    at,
+			Kind:         charge.Kind,
+			InvoiceID:    inv.Id,
+			FeeInvoiceID: result.FeeInvoiceID,
+			Period:       charge.Period,
+			Cents:        charge.Cents,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !created {
+			return nil, fmt.Errorf("late %s for period %d of invoice %d was applied by another run", charge.Kind, charge.Period, inv.Id)
+		}
+	}
+
+	items := lateFeeLineItems(inv, charges, inv.DueDate.AddDate(0, 0, cfg.Policy.GraceDays))
+	if cfg.Mode == LateFeeInvoice {
+		return result, s.invoiceRepository.CreateLineItems(tx, result.FeeInvoiceID, items, at)
+	}
+
+	if err := s.invoiceRepository.CreateLineItems(tx, inv.Id, items, at); err != nil {
+		return nil, err
+	}
+	return result, s.invoiceRepository.AddInvoiceCharges(tx, inv.Id, total, at)
+}
+
+// createLateFeeInvoice opens an invoice for the late charges, collected the same way as the overdue invoice.
+// Invoices sent for payment are due on receipt.
+func (s *BillingService) createLateFeeInvoice(tx repository.Executor, inv models.Invoice, cents int64, at time.Time) (int64, error) {
+	feeInvoice := &models.Invoice{
+		CreatedAt:           at,
+		Source:              models.SourceLateFee,
+		UserID:              inv.UserID,
+		WorkspaceID:         inv.WorkspaceID,
+		Cents:               cents,
+		CentsIncludingTaxes: cents,
+		CollectionMethod:    inv.CollectionMethod,
+	}
+	if inv.CollectionMethod == models.CollectionSendInvoice {
+		feeInvoice.DueDate = time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+	}
+
+	feeInvoiceID, err := s.invoiceRepository.CreateInvoice(tx, feeInvoice)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.invoiceRepository.TransitionInvoice(tx, feeInvoiceID, invoice.Open, fmt.Sprintf("late charges on invoice %d", inv.Id), at); err != nil {
+		return 0, err
+	}
+	return feeInvoiceID, nil
+}
+
+// lateFeeLineItems describes the charges, with each month of interest covering the month it was charged for
+func lateFeeLineItems(inv models.Invoice, charges []latefee.Charge, start time.Time) []models.InvoiceLineItem {
+	items := make([]models.InvoiceLineItem, 0, len(charges))
+	for _, charge := range charges {
+		item := models.InvoiceLineItem{
+			PeriodStart: start,
+			PeriodEnd:   start,
+			Category:    models.LineItemLateFee,
+			Description: fmt.Sprintf("Late fee on invoice %d", inv.Id),
+			Unit:        "fees",
+			ReferenceID: int(inv.Id),
+			Quantity:    1,
+			Cents:       charge.Cents,
+		}
+		if charge.Kind == latefee.KindInterest {
+			item.PeriodStart = start.AddDate(0, charge.Period-1, 0)
+			item.PeriodEnd = start.AddDate(0, charge.Period, 0)
+			item.Description = fmt.Sprintf("Interest on invoice %d, month %d overdue", inv.Id, charge.Period)
+			item.Unit = "months"
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// notifyLateFees emails the workspace owner the late charges and what the invoice now leaves owing
+func (s *BillingService) notifyLateFees(applied *lateCharges) error {
+	inv := applied.Invoice
+	workspace, err := s.workspaceRepository.GetWorkspaceFromDB(inv.WorkspaceID)
+	if err != nil {
+		return err
+	}
+	user, err := s.workspaceRepository.GetUserFromDB(inv.UserID)
+	if err != nil {
+		return err
+	}
+
+	cents := latefee.Total(applied.Charges)
+	args := map[string]string{
+		"invoiceId":    fmt.Sprintf("%d", inv.Id),
+		"feeCents":     fmt.Sprintf("%d", cents),
+		"balanceCents": fmt.Sprintf("%d", inv.Cents-inv.CentsCollected+cents),
+		"dueDate":      inv.DueDate.Format(time.DateOnly),
+	}
+	if applied.FeeInvoiceID != 0 {
+		args["feeInvoiceId"] = fmt.Sprintf("%d", applied.FeeInvoiceID)
+	}
+	return utils.DispatchEmail("Late Fee Applied", "late_fee", user, workspace, args)
+}
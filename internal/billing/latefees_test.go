@@ -0,0 +1,141 @@
+package billing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"lineblocs.com/scheduler/internal/invoice"
+	"lineblocs.com/scheduler/internal/latefee"
+	"lineblocs.com/scheduler/mocks"
+	"lineblocs.com/scheduler/models"
+)
+
+func TestApplyLateFees(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, 5, 20, 2, 0, 0, 0, time.UTC)
+	due := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	start := due.AddDate(0, 0, 10)
+	policy := latefee.Policy{FlatCents: 500, MonthlyPercent: 2, GraceDays: 10}
+	applied := []models.LateFee{{Kind: latefee.KindFee, InvoiceID: 9, Cents: 500}}
+
+	t.Run("Should add the charges to the overdue invoice", func(t *testing.T) {
+		t.Parallel()
+
+		// 500 of the 10500 billed is the fee, and interest is charged on the 8000 left unpaid of the rest
+		inv := models.Invoice{Id: 9, WorkspaceID: 3, UserID: 2, Cents: 10500, CentsCollected: 2000, DueDate: due, State: string(invoice.PartiallyPaid)}
+		invoiceRepository := mocks.NewInvoiceRepository(t)
+		invoiceRepository.On("LockOverdueInvoice", nil, int64(9)).Return(&inv, nil)
+		invoiceRepository.On("HasPendingCharge", nil, int64(9)).Return(false, nil)
+		invoiceRepository.On("GetLateFees", nil, int64(9)).Return(applied, nil)
+		invoiceRepository.On("CreateLateFee", nil, models.LateFee{CreatedAt: at, Kind: latefee.KindInterest, InvoiceID: 9, Period: 1, Cents: 160}).Return(true, nil)
+		invoiceRepository.On("CreateLineItems", nil, int64(9), []models.InvoiceLineItem{{
+			PeriodStart: start, PeriodEnd: start.AddDate(0, 1, 0), Category: models.LineItemLateFee,
+			Description: "Interest on invoice 9, month 1 overdue", Unit: "months", ReferenceID: 9, Quantity: 1, Cents: 160,
+		}}, at).Return(nil)
+		invoiceRepository.On("AddInvoiceCharges", nil, int64(9), int64(160), at).Return(nil)
+
+		s := &BillingService{invoiceRepository: invoiceRepository}
+		charges, err := s.applyLateFees(nil, inv, at, LateFeeConfig{Mode: LateFeeLineItem, Policy: policy})
+		assert.NoError(t, err)
+		assert.Equal(t, &lateCharges{Invoice: inv, Charges: []latefee.Charge{{Kind: latefee.KindInterest, Period: 1, Cents: 160}}}, charges)
+	})
+
+	t.Run("Should charge interest on what is owed once the invoice is locked", func(t *testing.T) {
+		t.Parallel()
+
+		// 5000 more was paid after the invoice was listed, leaving 3000 owed
+		listed := models.Invoice{Id: 9, WorkspaceID: 3, UserID: 2, Cents: 10500, CentsCollected: 2000, DueDate: due, State: string(invoice.PartiallyPaid)}
+		locked := listed
+		locked.CentsCollected = 7000
+		invoiceRepository := mocks.NewInvoiceRepository(t)
+		invoiceRepository.On("LockOverdueInvoice", nil, int64(9)).Return(&locked, nil)
+		invoiceRepository.On("HasPendingCharge", nil, int64(9)).Return(false, nil)
+		invoiceRepository.On("GetLateFees", nil, int64(9)).Return(applied, nil)
+		invoiceRepository.On("CreateLateFee", nil, models.LateFee{CreatedAt: at, Kind: latefee.KindInterest, InvoiceID: 9, Period: 1, Cents: 60}).Return(true, nil)
+		invoiceRepository.On("CreateLineItems", nil, int64(9), mock.AnythingOfType("[]models.InvoiceLineItem"), at).Return(nil)
+		invoiceRepository.On("AddInvoiceCharges", nil, int64(9), int64(60), at).Return(nil)
+
+		s := &BillingService{invoiceRepository: invoiceRepository}
+		charges, err := s.applyLateFees(nil, listed, at, LateFeeConfig{Mode: LateFeeLineItem, Policy: policy})
+		assert.NoError(t, err)
+		assert.Equal(t, &lateCharges{Invoice: locked, Charges: []latefee.Charge{{Kind: latefee.KindInterest, Period: 1, Cents: 60}}}, charges)
+	})
+
+	t.Run("Should skip an invoice paid or voided since it was listed", func(t *testing.T) {
+		t.Parallel()
+
+		for _, state := range []invoice.Status{invoice.Paid, invoice.Void, invoice.Uncollectible} {
+			listed := models.Invoice{Id: 9, WorkspaceID: 3, UserID: 2, Cents: 10000, DueDate: due, State: string(invoice.Open)}
+			locked := listed
+			locked.State = string(state)
+			invoiceRepository := mocks.NewInvoiceRepository(t)
+			invoiceRepository.On("LockOverdueInvoice", nil, int64(9)).Return(&locked, nil)
+
+			s := &BillingService{invoiceRepository: invoiceRepository}
+			charges, err := s.applyLateFees(nil, listed, at, LateFeeConfig{Mode: LateFeeLineItem, Policy: policy})
+			assert.NoError(t, err)
+			assert.Nil(t, charges, state)
+		}
+	})
+
+	t.Run("Should bill the charges on a linked invoice", func(t *testing.T) {
+		t.Parallel()
+
+		inv := models.Invoice{Id: 9, WorkspaceID: 3, UserID: 2, Cents: 10000, DueDate: due, CollectionMethod: models.CollectionSendInvoice, State: string(invoice.Open)}
+		invoiceRepository := mocks.NewInvoiceRepository(t)
+		invoiceRepository.On("LockOverdueInvoice", nil, int64(9)).Return(&inv, nil)
+		invoiceRepository.On("GetLateFees", nil, int64(9)).Return([]models.LateFee{}, nil)
+		invoiceRepository.On("CreateInvoice", nil, &models.Invoice{
+			CreatedAt: at, Source: models.SourceLateFee, UserID: 2, WorkspaceID: 3, Cents: 700, CentsIncludingTaxes: 700,
+			CollectionMethod: models.CollectionSendInvoice, DueDate: time.Date(2026, 5, 20, 0, 0, 0, 0, time.UTC),
+		}).Return(int64(12), nil)
+		invoiceRepository.On("TransitionInvoice", nil, int64(12), invoice.Open, "late charges on invoice 9", at).Return(nil)
+		invoiceRepository.On("CreateLateFee", nil, mock.MatchedBy(func(fee models.LateFee) bool { return fee.FeeInvoiceID == 12 })).Return(true, nil).Twice()
+		invoiceRepository.On("CreateLineItems", nil, int64(12), mock.AnythingOfType("[]models.InvoiceLineItem"), at).Return(nil)
+
+		s := &BillingService{invoiceRepository: invoiceRepository}
+		charges, err := s.applyLateFees(nil, inv, at, LateFeeConfig{Mode: LateFeeInvoice, Policy: policy})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(12), charges.FeeInvoiceID)
+		assert.Equal(t, int64(700), latefee.Total(charges.Charges))
+	})
+
+	t.Run("Should not raise an invoice while a card charge for it is pending", func(t *testing.T) {
+		t.Parallel()
+
+		inv := models.Invoice{Id: 9, WorkspaceID: 3, UserID: 2, Cents: 10000, DueDate: due, State: string(invoice.Open)}
+		invoiceRepository := mocks.NewInvoiceRepository(t)
+		invoiceRepository.On("LockOverdueInvoice", nil, int64(9)).Return(&inv, nil)
+		invoiceRepository.On("HasPendingCharge", nil, int64(9)).Return(true, nil)
+
+		s := &BillingService{invoiceRepository: invoiceRepository}
+		charges, err := s.applyLateFees(nil, inv, at, LateFeeConfig{Mode: LateFeeLineItem, Policy: policy})
+		assert.NoError(t, err)
+		assert.Nil(t, charges)
+	})
+
+	t.Run("Should roll back charges another run applied first", func(t *testing.T) {
+		t.Parallel()
+
+		inv := models.Invoice{Id: 9, WorkspaceID: 3, UserID: 2, Cents: 10000, DueDate: due, State: string(invoice.Open)}
+		invoiceRepository := mocks.NewInvoiceRepository(t)
+		invoiceRepository.On("LockOverdueInvoice", nil, int64(9)).Return(&inv, nil)
+		invoiceRepository.On("HasPendingCharge", nil, int64(9)).Return(false, nil)
+		invoiceRepository.On("GetLateFees", nil, int64(9)).Return([]models.LateFee{}, nil)
+		invoiceRepository.On("CreateLateFee", nil, mock.Anything).Return(false, nil).Once()
+
+		s := &BillingService{invoiceRepository: invoiceRepository}
+		_, err := s.applyLateFees(nil, inv, at, LateFeeConfig{Mode: LateFeeLineItem, Policy: policy})
+		assert.EqualError(t, err, "late fee for period 0 of invoice 9 was applied by another run")
+	})
+
+	t.Run("Should reject an unknown mode", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := (&BillingService{}).ApplyLateFees(at, LateFeeConfig{Mode: "credit_note", Policy: policy})
+		assert.EqualError(t, err, `unknown late fee mode "credit_note"`)
+	})
+}
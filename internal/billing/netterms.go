@@ -0,0 +1,178 @@
+package billing
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/scheduler/models"
+	"lineblocs.com/scheduler/repository"
+	"lineblocs.com/scheduler/utils"
+)
+
+// ReminderReport summarizes a reminder run
+type ReminderReport struct {
+	Reminded []int64 `json:"reminded"`
+	Failed   []int64 `json:"failed"`
+	Checked  int     `json:"checked"`
+}
+
+// sendsInvoice reports whether the workspace is on net terms, paying invoices it is sent instead of being charged
+func (d *BillingData) sendsInvoice() bool {
+	return d.Collection != nil && d.Collection.Method == models.CollectionSendInvoice
+}
+
+// collectionMethod is written to the invoice, empty for invoices charged automatically
+func (d *BillingData) collectionMethod() string {
+	if !d.sendsInvoice() {
+		return ""
+	}
+	return d.Collection.Method
+}
+
+// dueDate is the day an invoice issued now has to be paid by, zero for invoices charged automatically
+func (d *BillingData) dueDate() time.Time {
+	if !d.sendsInvoice() {
+		return time.Time{}
+	}
+	issued := time.Date(d.Now.Year(), d.Now.Month(), d.Now.Day(), 0, 0, 0, 0, d.Now.Location())
+	return issued.AddDate(0, 0, d.Collection.NetDays)
+}
+
+// emailInvoice sends a finalized net terms invoice to the workspace owner with its payment instructions.
+// The invoice stays open until it is paid and later reminders follow the schedule of SendInvoiceReminders.
+func (s *BillingService) emailInvoice(invoiceID int64, costs *BillingCosts, data *BillingData, logger *logrus.Entry) {
+	args := map[string]string{
+		"invoiceId":           fmt.Sprintf("%d", invoiceID),
+		"amountDueCents":      fmt.Sprintf("%d", costs.TotalCosts),
+		"dueDate":             data.dueDate().Format(time.DateOnly),
+		"netDays":             fmt.Sprintf("%d", data.Collection.NetDays),
+		"periodStart":         data.BillingPeriodStart.Format(time.DateOnly),
+		"periodEnd":           data.BillingPeriodEnd.Format(time.DateOnly),
+		"paymentInstructions": data.Collection.PaymentInstructions,
+	}
+	if err := utils.DispatchEmail("New Invoice", "invoice_issued", data.User, data.Workspace, args); err != nil {
+		logger.WithError(err).Errorf("error emailing invoice %d", invoiceID)
+		return
+	}
+
+	logger.Infof("Emailed invoice %d, due %s", invoiceID, args["dueDate"])
+}
+
+// ParseReminderSchedule reads a comma separated list of days relative to the due date, such as
+// "-3,0,7,14", where negative days are before the invoice is due
+func ParseReminderSchedule(value string) ([]int, error) {
+	schedule := make([]int, 0)
+	seen := make(map[int]bool)
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		days, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reminder day %q", field)
+		}
+		if !seen[days] {
+			seen[days] = true
+			schedule = append(schedule, days)
+		}
+	}
+
+	sort.Ints(schedule)
+	return schedule, nil
+}
+
+// reminderDue returns the latest step of the schedule reached at the given time, in days from the due date
+func reminderDue(schedule []int, due time.Time, at time.Time) (int, bool) {
+	for i := len(schedule) - 1; i >= 0; i-- {
+		if !at.Before(due.AddDate(0, 0, schedule[i])) {
+			return schedule[i], true
+		}
+	}
+	return 0, false
+}
+
+// SendInvoiceReminders emails the owners of unpaid net terms invoices as each one reaches a step of
+// the schedule. Steps are days from the due date, sorted as by ParseReminderSchedule. Only the latest
+// step reached is sent, and each step once per invoice, so a missed run does not send a backlog.
+func (s *BillingService) SendInvoiceReminders(at time.Time, schedule []int) (*ReminderReport, error) {
+	logger := logrus.WithField("component", "invoice_reminders")
+
+	report := &ReminderReport{}
+	if len(schedule) == 0 {
+		return report, nil
+	}
+
+	invoices, err := s.invoiceRepository.GetSentInvoicesDueBy(s.db, at.AddDate(0, 0, -schedule[0]))
+	if err != nil {
+		logger.WithError(err).Error("error getting invoices to remind")
+		return nil, err
+	}
+
+	for _, inv := range invoices {
+		report.Checked++
+		offset, ok := reminderDue(schedule, inv.DueDate, at)
+		if !ok {
+			continue
+		}
+
+		invoiceLogger := logger.WithField("invoice_id", inv.Id).WithField("workspace_id", inv.WorkspaceID)
+		var sent bool
+		err := s.unitOfWork.Do(func(tx repository.Executor) error {
+			var err error
+			sent, err = s.invoiceRepository.RecordReminder(tx, inv.Id, offset, at)
+			if err != nil || !sent {
+				return err
+			}
+			// an email that fails rolls back the record so the next run tries again
+			return s.remindInvoice(inv, offset, at)
+		})
+		if err != nil {
+			invoiceLogger.WithError(err).Error("error sending invoice reminder")
+			report.Failed = append(report.Failed, inv.Id)
+			continue
+		}
+		if sent {
+			invoiceLogger.Infof("Sent reminder %d days from the due date", offset)
+			report.Reminded = append(report.Reminded, inv.Id)
+		}
+	}
+
+	logger.Infof("Checked %d invoices, reminded %d, %d failed", report.Checked, len(report.Reminded), len(report.Failed))
+	return report, nil
+}
+
+// remindInvoice emails the workspace owner what is still owed on the invoice
+func (s *BillingService) remindInvoice(inv models.Invoice, offset int, at time.Time) error {
+	workspace, err := s.workspaceRepository.GetWorkspaceFromDB(inv.WorkspaceID)
+	if err != nil {
+		return err
+	}
+	user, err := s.workspaceRepository.GetUserFromDB(inv.UserID)
+	if err != nil {
+		return err
+	}
+	terms, err := s.paymentRepository.GetCollectionTerms(inv.WorkspaceID)
+	if err != nil {
+		return err
+	}
+
+	args := map[string]string{
+		"invoiceId":      fmt.Sprintf("%d", inv.Id),
+		"amountDueCents": fmt.Sprintf("%d", inv.Cents-inv.CentsCollected),
+		"dueDate":        inv.DueDate.Format(time.DateOnly),
+		"daysOverdue":    fmt.Sprintf("%d", max(int(at.Sub(inv.DueDate).Hours()/24), 0)),
+	}
+	if terms != nil {
+		args["paymentInstructions"] = terms.PaymentInstructions
+	}
+
+	if offset > 0 {
+		return utils.DispatchEmail("Invoice Overdue", "invoice_overdue", user, workspace, args)
+	}
+	return utils.DispatchEmail("Invoice Payment Reminder", "invoice_due", user, workspace, args)
+}
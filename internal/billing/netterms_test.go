@@ -0,0 +1,137 @@
+package billing
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"lineblocs.com/scheduler/mocks"
+	"lineblocs.com/scheduler/models"
+)
+
+func TestBillingDataDueDate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 5, 1, 6, 30, 0, 0, time.UTC)
+
+	t.Run("Should be due net days after the day the invoice is issued", func(t *testing.T) {
+		t.Parallel()
+
+		data := &BillingData{Now: now, Collection: &models.CollectionTerms{Method: models.CollectionSendInvoice, NetDays: 30}}
+		assert.True(t, data.sendsInvoice())
+		assert.Equal(t, models.CollectionSendInvoice, data.collectionMethod())
+		assert.Equal(t, time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC), data.dueDate())
+	})
+
+	t.Run("Should have no due date when invoices are charged", func(t *testing.T) {
+		t.Parallel()
+
+		data := &BillingData{Now: now}
+		assert.False(t, data.sendsInvoice())
+		assert.Empty(t, data.collectionMethod())
+		assert.True(t, data.dueDate().IsZero())
+	})
+}
+
+func TestParseReminderSchedule(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Should sort the days and drop duplicates", func(t *testing.T) {
+		t.Parallel()
+
+		schedule, err := ParseReminderSchedule("14, -3,0,7,0,")
+		assert.NoError(t, err)
+		assert.Equal(t, []int{-3, 0, 7, 14}, schedule)
+	})
+
+	t.Run("Should reject days that are not numbers", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := ParseReminderSchedule("-3,soon")
+		assert.EqualError(t, err, `invalid reminder day "soon"`)
+	})
+}
+
+func TestReminderDue(t *testing.T) {
+	t.Parallel()
+
+	schedule := []int{-3, 0, 7}
+	due := time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		Name           string
+		At             time.Time
+		ExpectedOffset int
+		ExpectedDue    bool
+	}{
+		{Name: "before the first step", At: time.Date(2026, 5, 27, 23, 0, 0, 0, time.UTC)},
+		{Name: "on the first step", At: time.Date(2026, 5, 28, 0, 0, 0, 0, time.UTC), ExpectedOffset: -3, ExpectedDue: true},
+		{Name: "on the due date", At: time.Date(2026, 5, 31, 9, 0, 0, 0, time.UTC), ExpectedOffset: 0, ExpectedDue: true},
+		{Name: "a missed step is passed over for the latest", At: time.Date(2026, 6, 20, 0, 0, 0, 0, time.UTC), ExpectedOffset: 7, ExpectedDue: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			offset, ok := reminderDue(schedule, due, tc.At)
+			assert.Equal(t, tc.ExpectedDue, ok)
+			assert.Equal(t, tc.ExpectedOffset, offset)
+		})
+	}
+}
+
+func TestSendInvoiceReminders(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, 6, 8, 9, 0, 0, 0, time.UTC)
+	schedule := []int{-3, 0, 7}
+	dueBy := time.Date(2026, 6, 11, 9, 0, 0, 0, time.UTC)
+	invoices := []models.Invoice{
+		{Id: 9, WorkspaceID: 3, UserID: 2, Cents: 5000, DueDate: time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{Id: 10, WorkspaceID: 4, UserID: 5, Cents: 2000, DueDate: time.Date(2026, 6, 10, 0, 0, 0, 0, time.UTC)},
+	}
+
+	t.Run("Should not send a step twice", func(t *testing.T) {
+		t.Parallel()
+
+		invoiceRepository := mocks.NewInvoiceRepository(t)
+		invoiceRepository.On("GetSentInvoicesDueBy", mock.Anything, dueBy).Return(invoices, nil)
+		invoiceRepository.On("RecordReminder", nil, int64(9), 7, at).Return(false, nil)
+		invoiceRepository.On("RecordReminder", nil, int64(10), -3, at).Return(false, nil)
+		unitOfWork := mocks.NewUnitOfWork(t)
+		unitOfWork.On("Do", mock.Anything).Return(runUnitOfWork)
+
+		s := &BillingService{invoiceRepository: invoiceRepository, unitOfWork: unitOfWork}
+		report, err := s.SendInvoiceReminders(at, schedule)
+		assert.NoError(t, err)
+		assert.Equal(t, &ReminderReport{Checked: 2}, report)
+	})
+
+	t.Run("Should report invoices whose reminder could not be sent", func(t *testing.T) {
+		t.Parallel()
+
+		invoiceRepository := mocks.NewInvoiceRepository(t)
+		invoiceRepository.On("GetSentInvoicesDueBy", mock.Anything, dueBy).Return(invoices[:1], nil)
+		invoiceRepository.On("RecordReminder", nil, int64(9), 7, at).Return(true, nil)
+		workspaceRepository := mocks.NewWorkspaceRepository(t)
+		workspaceRepository.On("GetWorkspaceFromDB", 3).Return(nil, errors.New("workspace not found"))
+		unitOfWork := mocks.NewUnitOfWork(t)
+		unitOfWork.On("Do", mock.Anything).Return(runUnitOfWork)
+
+		s := &BillingService{invoiceRepository: invoiceRepository, workspaceRepository: workspaceRepository, unitOfWork: unitOfWork}
+		report, err := s.SendInvoiceReminders(at, schedule)
+		assert.NoError(t, err)
+		assert.Equal(t, &ReminderReport{Checked: 1, Failed: []int64{9}}, report)
+	})
+
+	t.Run("Should do nothing without a schedule", func(t *testing.T) {
+		t.Parallel()
+
+		report, err := (&BillingService{}).SendInvoiceReminders(at, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, &ReminderReport{}, report)
+	})
+}
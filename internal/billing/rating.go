@@ -0,0 +1,101 @@
+package billing
+
+import (
+	"math"
+
+	"lineblocs.com/scheduler/models"
+)
+
+// CallCharge is the outcome of rating a single call against the allowance
+type CallCharge struct {
+	BillableSeconds   int64
+	AllowanceSeconds  int64
+	ChargeableSeconds int64
+	Cents             int64
+}
+
+// CallRater rounds call durations to a plan's billing increments and draws down
+// the included allowance at the same granularity. It is shared by every billing term.
+type CallRater struct {
+	plan             *models.CallRatingPlan
+	remainingSeconds int64
+}
+
+func NewCallRater(plan *models.CallRatingPlan, includedSeconds int64) *CallRater {
+	if plan == nil || plan.IncrementSeconds <= 0 {
+		plan = models.DefaultCallRatingPlan()
+	}
+	return &CallRater{
+		plan:             plan,
+		remainingSeconds: includedSeconds,
+	}
+}
+
+// IncludedSecondsFromMinutes converts a plan's minute allowance to seconds
+func IncludedSecondsFromMinutes(minutes float64) int64 {
+	return int64(math.Round(minutes * 60))
+}
+
+// BillableSeconds applies the minimum duration and increment rounding to a call duration
+func (r *CallRater) BillableSeconds(durationSeconds int) int64 {
+	if durationSeconds <= 0 {
+		return 0
+	}
+
+	minimum := int64(r.plan.MinimumSeconds)
+	duration := int64(durationSeconds)
+	if duration <= minimum {
+		return minimum
+	}
+
+	increment := int64(r.plan.IncrementSeconds)
+	remainder := duration - minimum
+	units := remainder / increment
+	leftover := remainder % increment
+
+	switch r.plan.Rounding {
+	case models.RoundingDown:
+	case models.RoundingNearest:
+		if leftover*2 >= increment {
+			units++
+		}
+	default:
+		if leftover > 0 {
+			units++
+		}
+	}
+
+	return minimum + units*increment
+}
+
+// Rate bills a call whose full cost, before any allowance, is fullCents. The
+// allowance is consumed first and only the remaining billable seconds are charged.
+func (r *CallRater) Rate(durationSeconds int, fullCents int64) CallCharge {
+	billable := r.BillableSeconds(durationSeconds)
+	charge := CallCharge{BillableSeconds: billable}
+	if billable == 0 {
+		return charge
+	}
+
+	charge.AllowanceSeconds = min(billable, max(r.remainingSeconds, 0))
+	charge.ChargeableSeconds = billable - charge.AllowanceSeconds
+	r.remainingSeconds -= charge.AllowanceSeconds
+
+	charge.Cents = int64(math.Round(float64(fullCents) * float64(charge.ChargeableSeconds) / float64(billable)))
+	return charge
+}
+
+// RateWithConnectionFee bills a call like Rate when connectionFeeCents of its full cost is a connection fee.
+// The allowance covers minutes only, so the fee is charged in full on every billable call.
+func (r *CallRater) RateWithConnectionFee(durationSeconds int, fullCents int64, connectionFeeCents int64) CallCharge {
+	charge := r.Rate(durationSeconds, fullCents-connectionFeeCents)
+	if charge.BillableSeconds > 0 {
+		charge.Cents += connectionFeeCents
+	}
+	return charge
+}
+
+// RemainingSeconds is the allowance left after the calls rated so far
+func (r *CallRater) RemainingSeconds() int64 {
+	return r.remainingSeconds
+}
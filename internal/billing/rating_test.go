@@ -0,0 +1,107 @@
+package billing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"lineblocs.com/scheduler/models"
+)
+
+func TestCallRaterBillableSeconds(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		Plan     *models.CallRatingPlan
+		Name     string
+		Duration int
+		Expected int64
+	}{
+		{Name: "60/60 rounds a partial minute up", Plan: &models.CallRatingPlan{MinimumSeconds: 60, IncrementSeconds: 60, Rounding: models.RoundingUp}, Duration: 119, Expected: 120},
+		{Name: "60/60 bills the minimum for short calls", Plan: &models.CallRatingPlan{MinimumSeconds: 60, IncrementSeconds: 60, Rounding: models.RoundingUp}, Duration: 5, Expected: 60},
+		{Name: "30/6 bills six second increments after the minimum", Plan: &models.CallRatingPlan{MinimumSeconds: 30, IncrementSeconds: 6, Rounding: models.RoundingUp}, Duration: 31, Expected: 36},
+		{Name: "1/1 bills per second", Plan: &models.CallRatingPlan{MinimumSeconds: 1, IncrementSeconds: 1, Rounding: models.RoundingUp}, Duration: 119, Expected: 119},
+		{Name: "nearest rounds down below half an increment", Plan: &models.CallRatingPlan{MinimumSeconds: 60, IncrementSeconds: 60, Rounding: models.RoundingNearest}, Duration: 89, Expected: 60},
+		{Name: "nearest rounds up at half an increment", Plan: &models.CallRatingPlan{MinimumSeconds: 60, IncrementSeconds: 60, Rounding: models.RoundingNearest}, Duration: 90, Expected: 120},
+		{Name: "down truncates partial increments", Plan: &models.CallRatingPlan{MinimumSeconds: 60, IncrementSeconds: 60, Rounding: models.RoundingDown}, Duration: 119, Expected: 60},
+		{Name: "unanswered calls are not billed", Plan: &models.CallRatingPlan{MinimumSeconds: 60, IncrementSeconds: 60, Rounding: models.RoundingUp}, Duration: 0, Expected: 0},
+		{Name: "missing plan falls back to per-minute billing", Plan: nil, Duration: 61, Expected: 120},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			rater := NewCallRater(tc.Plan, 0)
+			assert.Equal(t, tc.Expected, rater.BillableSeconds(tc.Duration))
+		})
+	}
+}
+
+func TestCallRaterRate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Should draw down the allowance at the plan granularity", func(t *testing.T) {
+		t.Parallel()
+
+		plan := &models.CallRatingPlan{MinimumSeconds: 30, IncrementSeconds: 6, Rounding: models.RoundingUp}
+		rater := NewCallRater(plan, 60)
+
+		charge := rater.Rate(31, 36)
+		assert.Equal(t, int64(36), charge.BillableSeconds)
+		assert.Equal(t, int64(36), charge.AllowanceSeconds)
+		assert.Equal(t, int64(0), charge.Cents)
+		assert.Equal(t, int64(24), rater.RemainingSeconds())
+	})
+
+	t.Run("Should charge only the seconds beyond the allowance", func(t *testing.T) {
+		t.Parallel()
+
+		rater := NewCallRater(models.DefaultCallRatingPlan(), 60)
+
+		charge := rater.Rate(119, 20)
+		assert.Equal(t, int64(120), charge.BillableSeconds)
+		assert.Equal(t, int64(60), charge.ChargeableSeconds)
+		assert.Equal(t, int64(10), charge.Cents)
+		assert.Equal(t, int64(0), rater.RemainingSeconds())
+	})
+
+	t.Run("Should charge the full cost once the allowance is exhausted", func(t *testing.T) {
+		t.Parallel()
+
+		rater := NewCallRater(models.DefaultCallRatingPlan(), 0)
+
+		charge := rater.Rate(119, 20)
+		assert.Equal(t, int64(20), charge.Cents)
+	})
+}
+
+func TestCallRaterRateWithConnectionFee(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Should charge the connection fee of a call covered by the allowance", func(t *testing.T) {
+		t.Parallel()
+
+		rater := NewCallRater(models.DefaultCallRatingPlan(), 120)
+
+		charge := rater.RateWithConnectionFee(119, 22, 10)
+		assert.Equal(t, int64(120), charge.AllowanceSeconds)
+		assert.Equal(t, int64(10), charge.Cents)
+	})
+
+	t.Run("Should prorate only the per-minute part", func(t *testing.T) {
+		t.Parallel()
+
+		rater := NewCallRater(models.DefaultCallRatingPlan(), 60)
+
+		charge := rater.RateWithConnectionFee(119, 22, 10)
+		assert.Equal(t, int64(60), charge.ChargeableSeconds)
+		assert.Equal(t, int64(16), charge.Cents)
+	})
+
+	t.Run("Should not charge unanswered calls", func(t *testing.T) {
+		t.Parallel()
+
+		charge := NewCallRater(models.DefaultCallRatingPlan(), 0).RateWithConnectionFee(0, 0, 10)
+		assert.Equal(t, int64(0), charge.Cents)
+	})
+}
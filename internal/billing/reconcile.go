@@ -0,0 +1,143 @@
+package billing
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/scheduler/internal/invoice"
+	"lineblocs.com/scheduler/models"
+	"lineblocs.com/scheduler/repository"
+	"lineblocs.com/scheduler/utils"
+)
+
+// gateways only honour idempotency keys for a limited time, after which a retry could charge twice
+const idempotencyWindow = 24 * time.Hour
+
+// InvoiceIdempotencyKey is the gateway idempotency key of the card charges for an invoice
+func InvoiceIdempotencyKey(invoiceID int64) string {
+	return fmt.Sprintf("lineblocs_invoice_%d", invoiceID)
+}
+
+// RetryIdempotencyKey is the gateway idempotency key of a retried card charge. Each attempt gets its own key,
+// so the gateway runs the charge again instead of replaying the last decline, and an attempt for a different
+// amount never reuses a key. Only the reconciler reuses it, to replay that same attempt.
+func RetryIdempotencyKey(invoiceID int64, attempt int) string {
+	return fmt.Sprintf("lineblocs_invoice_%d_attempt_%d", invoiceID, attempt)
+}
+
+// ReconcileReport lists the outbox entries settled by a reconciliation run
+type ReconcileReport struct {
+	Succeeded []int64 `json:"succeeded"`
+	Failed    []int64 `json:"failed"`
+	Review    []int64 `json:"review"`
+	Canceled  []int64 `json:"canceled"`
+	Checked   int     `json:"checked"`
+}
+
+// ReconcileOutbox settles card charges left pending by a worker that stopped between
+// charging and updating the invoice. Charges still inside the idempotency window are
+// replayed with the same key, so the gateway returns the original result instead of
+// charging again. Older charges are flagged for manual review.
+func (s *BillingService) ReconcileOutbox(olderThan time.Duration) (*ReconcileReport, error) {
+	logger := logrus.WithField("component", "reconcile_outbox")
+
+	now := time.Now()
+	entries, err := s.invoiceRepository.GetPendingOutboxEntries(s.db, now.Add(-olderThan))
+	if err != nil {
+		logger.WithError(err).Error("error loading pending outbox entries")
+		return nil, err
+	}
+
+	report := &ReconcileReport{}
+	for i := range entries {
+		entry := &entries[i]
+		report.Checked++
+		entryLogger := logger.WithField("invoice_id", entry.InvoiceID).WithField("outbox_id", entry.Id)
+
+		status, err := s.reconcileEntry(entry, now, entryLogger)
+		if err != nil {
+			entryLogger.WithError(err).Error("error reconciling outbox entry")
+			return report, err
+		}
+
+		switch status {
+		case models.OutboxSucceeded:
+			report.Succeeded = append(report.Succeeded, entry.Id)
+		case models.OutboxFailed:
+			report.Failed = append(report.Failed, entry.Id)
+		case models.OutboxCanceled:
+			report.Canceled = append(report.Canceled, entry.Id)
+		default:
+			report.Review = append(report.Review, entry.Id)
+		}
+	}
+
+	logger.Infof("Reconciled %d pending charges: %d succeeded, %d failed, %d canceled, %d need review",
+		report.Checked, len(report.Succeeded), len(report.Failed), len(report.Canceled), len(report.Review))
+
+	return report, nil
+}
+
+func (s *BillingService) reconcileEntry(entry *models.OutboxEntry, now time.Time, logger *logrus.Entry) (string, error) {
+	state, err := s.invoiceRepository.GetInvoiceState(s.db, entry.InvoiceID)
+	if err != nil {
+		return "", err
+	}
+	if state == invoice.Paid {
+		logger.Info("invoice was already settled")
+		return models.OutboxSucceeded, s.invoiceRepository.UpdateOutboxEntry(s.db, entry.Id, models.OutboxSucceeded, entry.PaymentReference, "")
+	}
+	if !state.Collectable() {
+		logger.Warnf("invoice is %s, canceling the charge", state)
+		return models.OutboxCanceled, s.invoiceRepository.UpdateOutboxEntry(s.db, entry.Id, models.OutboxCanceled, "", fmt.Sprintf("invoice is %s", state))
+	}
+
+	if now.Sub(entry.CreatedAt) >= idempotencyWindow {
+		logger.Warn("charge is outside the idempotency window, flagging for review")
+		return models.OutboxReview, s.invoiceRepository.UpdateOutboxEntry(s.db, entry.Id, models.OutboxReview, "", "charge outcome unknown")
+	}
+
+	charged, err := s.chargeOutboxEntry(entry, now, logger)
+	if err != nil {
+		return "", err
+	}
+
+	if !charged {
+		return models.OutboxFailed, nil
+	}
+	return models.OutboxSucceeded, nil
+}
+
+// chargeOutboxEntry charges an outbox entry with its own amount and key, then settles it with the invoice.
+// It reports whether the gateway took the charge; a declined charge is recorded as a failed attempt.
+func (s *BillingService) chargeOutboxEntry(entry *models.OutboxEntry, at time.Time, logger *logrus.Entry) (bool, error) {
+	user, err := s.workspaceRepository.GetUserFromDB(entry.UserID)
+	if err != nil {
+		return false, err
+	}
+	workspace, err := s.workspaceRepository.GetWorkspaceFromDB(entry.WorkspaceID)
+	if err != nil {
+		return false, err
+	}
+	billingParams, err := utils.NewDBConn(s.db).GetBillingParams()
+	if err != nil {
+		return false, err
+	}
+
+	userInvoice := models.UserInvoice{
+		Id:             int(entry.InvoiceID),
+		Cents:          int(entry.Cents),
+		InvoiceDesc:    fmt.Sprintf("LineBlocs invoice %d", entry.InvoiceID),
+		IdempotencyKey: entry.IdempotencyKey,
+	}
+	chargeErr := s.paymentRepository.ChargeCustomer(billingParams, user, workspace, &userInvoice)
+	if chargeErr != nil {
+		logger.WithError(chargeErr).Error("error charging user")
+	}
+
+	err = s.unitOfWork.Do(func(tx repository.Executor) error {
+		return s.settleCardCharge(tx, entry, userInvoice.PaymentReference, chargeErr, at, logger)
+	})
+	return err == nil && chargeErr == nil, err
+}
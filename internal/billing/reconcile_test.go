@@ -0,0 +1,53 @@
+package billing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"lineblocs.com/scheduler/internal/invoice"
+	"lineblocs.com/scheduler/mocks"
+	"lineblocs.com/scheduler/models"
+)
+
+func TestReconcileEntry(t *testing.T) {
+	t.Parallel()
+
+	logger := logrus.WithField("test", t.Name())
+	now := time.Date(2026, 6, 1, 3, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		Name           string
+		State          invoice.Status
+		ExpectedStatus string
+		ExpectedError  string
+	}{
+		{Name: "a paid invoice settles the entry", State: invoice.Paid, ExpectedStatus: models.OutboxSucceeded},
+		{Name: "a void invoice is not charged", State: invoice.Void, ExpectedStatus: models.OutboxCanceled, ExpectedError: "invoice is void"},
+		{Name: "an uncollectible invoice is not charged", State: invoice.Uncollectible, ExpectedStatus: models.OutboxCanceled, ExpectedError: "invoice is uncollectible"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			entry := &models.OutboxEntry{CreatedAt: now.Add(-time.Hour), IdempotencyKey: InvoiceIdempotencyKey(9), PaymentReference: "ch_1", Id: 4, InvoiceID: 9, WorkspaceID: 3, UserID: 2, Cents: 1500}
+			reference := "ch_1"
+			if tc.ExpectedStatus == models.OutboxCanceled {
+				reference = ""
+			}
+
+			invoiceRepository := mocks.NewInvoiceRepository(t)
+			invoiceRepository.On("GetInvoiceState", mock.Anything, int64(9)).Return(tc.State, nil)
+			invoiceRepository.On("UpdateOutboxEntry", mock.Anything, int64(4), tc.ExpectedStatus, reference, tc.ExpectedError).Return(nil)
+
+			// the strict payment mock fails the test if the card is charged
+			s := &BillingService{invoiceRepository: invoiceRepository, paymentRepository: mocks.NewPaymentRepository(t)}
+			status, err := s.reconcileEntry(entry, now, logger)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.ExpectedStatus, status)
+		})
+	}
+}
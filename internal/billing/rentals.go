@@ -0,0 +1,79 @@
+package billing
+
+import (
+	"math"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/scheduler/models"
+	"lineblocs.com/scheduler/repository"
+)
+
+// NumberRentalCharges works out what the numbers held in a period owe that is not in the rental
+// ledger yet. The monthly cost is prorated by the days of the period each number was held, from
+// its provisioning to its release. The setup fee is charged in the period a number is provisioned.
+func NumberRentalCharges(rentals []models.NumberRental, workspaceID int, start, end time.Time) []models.RentalCharge {
+	periodDays := billingDays(start, end)
+	charges := make([]models.RentalCharge, 0)
+	if periodDays == 0 {
+		return charges
+	}
+
+	for _, rental := range rentals {
+		from := rental.ProvisionedAt
+		if from.Before(start) {
+			from = start
+		}
+		to := end
+		if !rental.ReleasedAt.IsZero() && rental.ReleasedAt.Before(end) {
+			to = rental.ReleasedAt
+		}
+
+		days := min(billingDays(from, to), periodDays)
+		if !rental.RentalBilled && days > 0 {
+			charges = append(charges, models.RentalCharge{
+				PeriodStart: start,
+				PeriodEnd:   end,
+				Kind:        models.RentalKindMonthly,
+				DIDID:       rental.DIDID,
+				WorkspaceID: workspaceID,
+				Days:        days,
+				Cents:       int64(math.Round(float64(rental.MonthlyCost) * float64(days) / float64(periodDays))),
+			})
+		}
+
+		if !rental.SetupBilled && rental.SetupCents > 0 && !rental.ProvisionedAt.Before(start) {
+			charges = append(charges, models.RentalCharge{
+				PeriodStart: rental.ProvisionedAt,
+				PeriodEnd:   rental.ProvisionedAt,
+				Kind:        models.RentalKindSetup,
+				DIDID:       rental.DIDID,
+				WorkspaceID: workspaceID,
+				Cents:       rental.SetupCents,
+			})
+		}
+	}
+	return charges
+}
+
+// createNumberRentalCharges writes the period's number rentals and setup fees to the ledger as
+// debits, which processDebits then bills with the rest of the usage
+func (s *BillingService) createNumberRentalCharges(tx repository.Executor, data *BillingData, logger *logrus.Entry) error {
+	rentals, err := s.invoiceRepository.GetNumberRentals(tx, data.Workspace.Id, data.BillingPeriodStart, data.BillingPeriodEnd)
+	if err != nil {
+		logger.WithError(err).Error("error getting number rentals")
+		return err
+	}
+
+	for _, charge := range NumberRentalCharges(rentals, data.Workspace.Id, data.BillingPeriodStart, data.BillingPeriodEnd) {
+		created, err := s.invoiceRepository.CreateRentalCharge(tx, charge, data.User.Id)
+		if err != nil {
+			logger.WithError(err).Error("error creating number rental charge")
+			return err
+		}
+		if created {
+			logger.Infof("Charged %d cents %s for number %d (%d days)", charge.Cents, charge.Kind, charge.DIDID, charge.Days)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package billing
+
+import (
+	"testing"
+	"time"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"lineblocs.com/scheduler/mocks"
+	"lineblocs.com/scheduler/models"
+	"lineblocs.com/scheduler/repository"
+)
+
+func TestNumberRentalCharges(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	midMonth := time.Date(2026, 4, 16, 10, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		Name     string
+		Rental   models.NumberRental
+		Expected []models.RentalCharge
+	}{
+		{
+			Name:   "held for the whole period",
+			Rental: models.NumberRental{DIDID: 1, MonthlyCost: 300, ProvisionedAt: start.AddDate(-1, 0, 0), SetupCents: 500, SetupBilled: true},
+			Expected: []models.RentalCharge{
+				{PeriodStart: start, PeriodEnd: end, Kind: models.RentalKindMonthly, DIDID: 1, WorkspaceID: 3, Days: 30, Cents: 300},
+			},
+		},
+		{
+			Name:   "provisioned mid-month with a setup fee",
+			Rental: models.NumberRental{DIDID: 2, MonthlyCost: 300, ProvisionedAt: midMonth, SetupCents: 500},
+			Expected: []models.RentalCharge{
+				{PeriodStart: start, PeriodEnd: end, Kind: models.RentalKindMonthly, DIDID: 2, WorkspaceID: 3, Days: 15, Cents: 150},
+				{PeriodStart: midMonth, PeriodEnd: midMonth, Kind: models.RentalKindSetup, DIDID: 2, WorkspaceID: 3, Cents: 500},
+			},
+		},
+		{
+			Name:   "released mid-month",
+			Rental: models.NumberRental{DIDID: 3, MonthlyCost: 300, ProvisionedAt: start.AddDate(0, -2, 0), ReleasedAt: time.Date(2026, 4, 11, 0, 0, 0, 0, time.UTC)},
+			Expected: []models.RentalCharge{
+				{PeriodStart: start, PeriodEnd: end, Kind: models.RentalKindMonthly, DIDID: 3, WorkspaceID: 3, Days: 10, Cents: 100},
+			},
+		},
+		{
+			Name:     "rental already in the ledger",
+			Rental:   models.NumberRental{DIDID: 4, MonthlyCost: 300, ProvisionedAt: start.AddDate(0, -2, 0), RentalBilled: true},
+			Expected: []models.RentalCharge{},
+		},
+		{
+			Name:   "setup fee of a number provisioned before the period is not charged",
+			Rental: models.NumberRental{DIDID: 5, MonthlyCost: 300, ProvisionedAt: start.AddDate(0, -2, 0), SetupCents: 500},
+			Expected: []models.RentalCharge{
+				{PeriodStart: start, PeriodEnd: end, Kind: models.RentalKindMonthly, DIDID: 5, WorkspaceID: 3, Days: 30, Cents: 300},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.Expected, NumberRentalCharges([]models.NumberRental{tc.Rental}, 3, start, end))
+		})
+	}
+}
+
+func TestCreateNumberRentalChargesRerun(t *testing.T) {
+	t.Parallel()
+
+	// the rental ledger, unique on the number, kind and period start
+	ledger := make(map[models.RentalCharge]bool)
+	key := func(charge models.RentalCharge) models.RentalCharge {
+		return models.RentalCharge{DIDID: charge.DIDID, Kind: charge.Kind, PeriodStart: charge.PeriodStart}
+	}
+
+	invoiceRepository := mocks.NewInvoiceRepository(t)
+	invoiceRepository.On("GetNumberRentals", nil, 3, mock.Anything, mock.Anything).Return(
+		func(_ repository.Executor, _ int, start time.Time, _ time.Time) []models.NumberRental {
+			billed := ledger[models.RentalCharge{DIDID: 11, Kind: models.RentalKindMonthly, PeriodStart: start}]
+			return []models.NumberRental{{DIDID: 11, MonthlyCost: 300, ProvisionedAt: start.AddDate(0, -3, 0), RentalBilled: billed}}
+		}, nil)
+	invoiceRepository.On("CreateRentalCharge", nil, mock.Anything, 5).Return(
+		func(_ repository.Executor, charge models.RentalCharge, _ int) bool {
+			if ledger[key(charge)] {
+				return false
+			}
+			ledger[key(charge)] = true
+			return true
+		}, nil)
+
+	s := &BillingService{invoiceRepository: invoiceRepository}
+	// the run on the 1st, then the same task requeued after a card decline two days later
+	for _, at := range []time.Time{
+		time.Date(2026, 3, 1, 0, 0, 7, 0, time.UTC),
+		time.Date(2026, 3, 3, 14, 30, 0, 0, time.UTC),
+	} {
+		end := BillingPeriodEnd(at)
+		data := &BillingData{
+			Workspace:          &helpers.Workspace{Id: 3},
+			User:               &helpers.User{Id: 5},
+			BillingPeriodStart: monthlyTerm{}.PeriodStart(end),
+			BillingPeriodEnd:   end,
+		}
+		assert.NoError(t, s.createNumberRentalCharges(nil, data, logrus.WithField("test", t.Name())))
+	}
+
+	assert.Equal(t, map[models.RentalCharge]bool{
+		{DIDID: 11, Kind: models.RentalKindMonthly, PeriodStart: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)}: true,
+	}, ledger)
+}
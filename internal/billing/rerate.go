@@ -0,0 +1,219 @@
+package billing
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/scheduler/internal/ratedeck"
+	"lineblocs.com/scheduler/models"
+)
+
+// RerateLine describes how one call debit changed under the new rate version
+type RerateLine struct {
+	Number            string `json:"number"`
+	Prefix            string `json:"prefix"`
+	DebitID           int    `json:"debit_id"`
+	CallID            int    `json:"call_id"`
+	PreviousCents     int64  `json:"previous_cents"`
+	RatedCents        int64  `json:"rated_cents"`
+	AdjustmentCents   int64  `json:"adjustment_cents"`
+	AdjustmentDebitID int64  `json:"adjustment_debit_id"`
+}
+
+// RerateReport summarizes a re-rating run
+type RerateReport struct {
+	Start                time.Time    `json:"start"`
+	End                  time.Time    `json:"end"`
+	RateVersion          string       `json:"rate_version"`
+	Lines                []RerateLine `json:"lines"`
+	UnratedDebitIDs      []int        `json:"unrated_debit_ids"`
+	SkippedDebitIDs      []int        `json:"skipped_debit_ids"`
+	WorkspaceID          int          `json:"workspace_id"`
+	DebitsChecked        int          `json:"debits_checked"`
+	TotalAdjustmentCents int64        `json:"total_adjustment_cents"`
+}
+
+type rerateDebit struct {
+	billedCents       sql.NullInt64
+	chargeableSeconds sql.NullInt64
+	planID            sql.NullInt64
+	id                int
+	callID            int
+	userID            int
+	priorAdjustment   int64
+}
+
+// Rerate recomputes the CALL debits of a workspace between start (inclusive) and
+// end (exclusive) under the given rate deck. Stored debits are never modified;
+// every difference is written as a CALL_ADJUSTMENT debit (negative for a credit)
+// that is picked up by the next invoice, so invoices that were already paid are
+// left untouched. Adjustments are against what the invoice billed for each call
+// after the allowance, which covers the same seconds it covered then, on the
+// increments of the plan the call was billed under rather than the current one.
+// Calls not invoiced yet are rated by their invoice, calls priced on minute tiers
+// were not billed by their toll, and calls invoiced before the plan was kept on
+// the debit have unknown increments, so all of them are skipped.
+func (s *BillingService) Rerate(task models.RerateTask, deck *ratedeck.Deck) (*RerateReport, error) {
+	logger := logrus.WithField("component", "rerate").WithField("workspace_id", task.WorkspaceID).WithField("rate_version", deck.Version)
+
+	start, err := time.Parse(time.DateOnly, task.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start date: %w", err)
+	}
+	end, err := time.Parse(time.DateOnly, task.EndDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end date: %w", err)
+	}
+
+	workspace, err := s.workspaceRepository.GetWorkspaceFromDB(task.WorkspaceID)
+	if err != nil {
+		logger.WithError(err).Error("error getting workspace")
+		return nil, err
+	}
+
+	debits, err := s.getRerateDebits(workspace.Id, start, end)
+	if err != nil {
+		logger.WithError(err).Error("error loading call debits")
+		return nil, err
+	}
+
+	report := &RerateReport{
+		Start:       start,
+		End:         end,
+		RateVersion: deck.Version,
+		WorkspaceID: workspace.Id,
+	}
+
+	// the plan can change between periods, so each call is rated on the plan its invoice billed it under
+	callRatings := make(map[int]*models.CallRatingPlan)
+	for _, debit := range debits {
+		report.DebitsChecked++
+
+		if !debit.billedCents.Valid || !debit.chargeableSeconds.Valid || !debit.planID.Valid {
+			report.SkippedDebitIDs = append(report.SkippedDebitIDs, debit.id)
+			continue
+		}
+
+		planID := int(debit.planID.Int64)
+		callRating, ok := callRatings[planID]
+		if !ok {
+			callRating, err = s.paymentRepository.GetCallRatingPlan(planID)
+			if err != nil {
+				logger.WithError(err).Error("error getting call rating plan")
+				return nil, err
+			}
+			callRatings[planID] = callRating
+		}
+		callRater := NewCallRater(callRating, 0)
+
+		call, err := s.workspaceRepository.GetCallFromDB(debit.callID)
+		if err != nil {
+			logger.WithError(err).Errorf("error getting call %d", debit.callID)
+			report.UnratedDebitIDs = append(report.UnratedDebitIDs, debit.id)
+			continue
+		}
+
+		rate, found := deck.Lookup(call.To, call.StartedAt)
+		if !found {
+			logger.Warnf("no rate for call to %s in debit %d", call.To, debit.id)
+			report.UnratedDebitIDs = append(report.UnratedDebitIDs, debit.id)
+			continue
+		}
+
+		// the allowance covers the same seconds it covered when the call was invoiced
+		billable := callRater.BillableSeconds(call.DurationNumber)
+		allowanceRater := NewCallRater(callRating, billable-debit.chargeableSeconds.Int64)
+		previousCents := debit.billedCents.Int64 + debit.priorAdjustment
+		ratedCents := allowanceRater.RateWithConnectionFee(call.DurationNumber, rate.Price(billable), connectionFeeCents(rate, billable)).Cents
+		if ratedCents == previousCents {
+			continue
+		}
+
+		line := RerateLine{
+			Number:          call.To,
+			Prefix:          rate.Prefix,
+			DebitID:         debit.id,
+			CallID:          debit.callID,
+			PreviousCents:   previousCents,
+			RatedCents:      ratedCents,
+			AdjustmentCents: ratedCents - previousCents,
+		}
+
+		line.AdjustmentDebitID, err = s.createRerateAdjustment(workspace.Id, debit.userID, deck.Version, &line)
+		if err != nil {
+			logger.WithError(err).Errorf("error writing adjustment for debit %d", debit.id)
+			return report, err
+		}
+
+		report.Lines = append(report.Lines, line)
+		report.TotalAdjustmentCents += line.AdjustmentCents
+	}
+
+	logger.Infof("Re-rated %d call debits, %d changed, %d unrated, %d skipped, net adjustment %d cents",
+		report.DebitsChecked, len(report.Lines), len(report.UnratedDebitIDs), len(report.SkippedDebitIDs), report.TotalAdjustmentCents)
+
+	return report, nil
+}
+
+// getRerateDebits loads call debits in the range with what their invoice billed and on which plan, along with
+// any adjustments from earlier re-rating runs
+func (s *BillingService) getRerateDebits(workspaceID int, start, end time.Time) ([]rerateDebit, error) {
+	rows, err := s.db.Query(`SELECT d.id, d.module_id, d.user_id, d.billed_cents, d.chargeable_seconds, d.billed_plan_id, COALESCE(SUM(r.adjustment_cents), 0)
+		FROM users_debits d
+		LEFT JOIN users_debits_rerates r ON r.debit_id = d.id
+		WHERE d.workspace_id = ? AND d.source = ? AND d.created_at >= ? AND d.created_at < ?
+		GROUP BY d.id, d.module_id, d.user_id, d.billed_cents, d.chargeable_seconds, d.billed_plan_id
+		ORDER BY d.id`, workspaceID, models.DebitSourceCall, start.Format(time.DateTime), end.Format(time.DateTime))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	debits := make([]rerateDebit, 0)
+	for rows.Next() {
+		var debit rerateDebit
+		if err := rows.Scan(&debit.id, &debit.callID, &debit.userID, &debit.billedCents, &debit.chargeableSeconds, &debit.planID, &debit.priorAdjustment); err != nil {
+			return nil, err
+		}
+		debits = append(debits, debit)
+	}
+
+	return debits, rows.Err()
+}
+
+// createRerateAdjustment writes the adjustment debit and its audit row together
+func (s *BillingService) createRerateAdjustment(workspaceID int, userID int, rateVersion string, line *RerateLine) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	now := time.Now()
+	result, err := tx.Exec("INSERT INTO users_debits (`source`, `status`, `cents`, `module_id`, `user_id`, `workspace_id`, `created_at`) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		models.DebitSourceCallAdjustment, models.DebitStatusUnbilled, line.AdjustmentCents, line.DebitID, userID, workspaceID, now)
+	if err != nil {
+		return 0, err
+	}
+
+	adjustmentDebitID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec("INSERT INTO users_debits_rerates (`debit_id`, `adjustment_debit_id`, `rate_version`, `previous_cents`, `rated_cents`, `adjustment_cents`, `created_at`) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		line.DebitID, adjustmentDebitID, rateVersion, line.PreviousCents, line.RatedCents, line.AdjustmentCents, now)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return adjustmentDebitID, nil
+}
@@ -0,0 +1,130 @@
+package billing
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/stretchr/testify/assert"
+	"lineblocs.com/scheduler/internal/ratedeck"
+	"lineblocs.com/scheduler/mocks"
+	"lineblocs.com/scheduler/models"
+)
+
+func TestRerate(t *testing.T) {
+	t.Parallel()
+
+	startedAt := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	workspace := &helpers.Workspace{Id: 7, Plan: "pro"}
+	deck, err := ratedeck.Load(strings.NewReader("prefix,per_minute_cents,connection_fee_cents,effective_from,effective_to\n1,6,0,,\n"), "2026-01-fixed")
+	assert.NoError(t, err)
+
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT d.id, d.module_id, d.user_id, d.billed_cents, d.chargeable_seconds, d.billed_plan_id, COALESCE(SUM(r.adjustment_cents), 0)")).
+		WithArgs(7, models.DebitSourceCall, "2026-01-01 00:00:00", "2026-02-01 00:00:00").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "module_id", "user_id", "billed_cents", "chargeable_seconds", "billed_plan_id", "adjustment"}).
+			// 3 minutes, 2 of them from the allowance, billed at the old rate of 9 cents a minute
+			AddRow(1, 11, 5, 9, 60, 2, 0).
+			// covered by the allowance, so nothing was billed and nothing is adjusted
+			AddRow(2, 12, 5, 0, 0, 2, 0).
+			// not invoiced yet, or priced on minute tiers
+			AddRow(3, 13, 5, nil, nil, nil, 0).
+			// no rate in the deck
+			AddRow(4, 14, 5, 12, 120, 2, 0).
+			// 90 seconds billed per second at 9 cents a minute on the plan before the workspace moved to per-minute billing
+			AddRow(5, 15, 5, 14, 90, 1, 0).
+			// invoiced before the plan was kept on the debit, so its increments are unknown
+			AddRow(6, 16, 5, 9, 60, nil, 0))
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(regexp.QuoteMeta("INSERT INTO users_debits")).
+		WithArgs(models.DebitSourceCallAdjustment, models.DebitStatusUnbilled, int64(-3), 1, 5, 7, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(90, 1))
+	sqlMock.ExpectExec(regexp.QuoteMeta("INSERT INTO users_debits_rerates")).
+		WithArgs(1, int64(90), "2026-01-fixed", int64(9), int64(6), int64(-3), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	sqlMock.ExpectCommit()
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(regexp.QuoteMeta("INSERT INTO users_debits")).
+		WithArgs(models.DebitSourceCallAdjustment, models.DebitStatusUnbilled, int64(-5), 5, 5, 7, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(91, 1))
+	sqlMock.ExpectExec(regexp.QuoteMeta("INSERT INTO users_debits_rerates")).
+		WithArgs(5, int64(91), "2026-01-fixed", int64(14), int64(9), int64(-5), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	sqlMock.ExpectCommit()
+
+	workspaceRepository := mocks.NewWorkspaceRepository(t)
+	workspaceRepository.On("GetWorkspaceFromDB", 7).Return(workspace, nil)
+	workspaceRepository.On("GetCallFromDB", 11).Return(&helpers.Call{To: "15550001111", DurationNumber: 180, StartedAt: startedAt}, nil)
+	workspaceRepository.On("GetCallFromDB", 12).Return(&helpers.Call{To: "15550002222", DurationNumber: 60, StartedAt: startedAt}, nil)
+	workspaceRepository.On("GetCallFromDB", 14).Return(&helpers.Call{To: "445550003333", DurationNumber: 120, StartedAt: startedAt}, nil)
+	workspaceRepository.On("GetCallFromDB", 15).Return(&helpers.Call{To: "15550004444", DurationNumber: 90, StartedAt: startedAt}, nil)
+
+	// the workspace is on plan 2 now; under its per-minute billing the 90 second call would be rated 12 cents
+	paymentRepository := mocks.NewPaymentRepository(t)
+	paymentRepository.On("GetCallRatingPlan", 2).Return(models.DefaultCallRatingPlan(), nil).Once()
+	paymentRepository.On("GetCallRatingPlan", 1).Return(&models.CallRatingPlan{Rounding: models.RoundingUp, MinimumSeconds: 1, IncrementSeconds: 1}, nil).Once()
+
+	s := &BillingService{db: db, workspaceRepository: workspaceRepository, paymentRepository: paymentRepository}
+	report, err := s.Rerate(models.RerateTask{RateVersion: deck.Version, StartDate: "2026-01-01", EndDate: "2026-02-01", WorkspaceID: 7}, deck)
+	assert.NoError(t, err)
+	assert.Equal(t, []RerateLine{{
+		Number:            "15550001111",
+		Prefix:            "1",
+		DebitID:           1,
+		CallID:            11,
+		PreviousCents:     9,
+		RatedCents:        6,
+		AdjustmentCents:   -3,
+		AdjustmentDebitID: 90,
+	}, {
+		Number:            "15550004444",
+		Prefix:            "1",
+		DebitID:           5,
+		CallID:            15,
+		PreviousCents:     14,
+		RatedCents:        9,
+		AdjustmentCents:   -5,
+		AdjustmentDebitID: 91,
+	}}, report.Lines)
+	assert.Equal(t, []int{4}, report.UnratedDebitIDs)
+	assert.Equal(t, []int{3, 6}, report.SkippedDebitIDs)
+	assert.Equal(t, 6, report.DebitsChecked)
+	assert.Equal(t, int64(-8), report.TotalAdjustmentCents)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestRerateCountsPriorAdjustments(t *testing.T) {
+	t.Parallel()
+
+	deck, err := ratedeck.Load(strings.NewReader("1,6,0,,\n"), "2026-01-fixed")
+	assert.NoError(t, err)
+
+	db, sqlMock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// an earlier run already brought the 9 cents billed down to the deck's 6
+	sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT d.id, d.module_id")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "module_id", "user_id", "billed_cents", "chargeable_seconds", "billed_plan_id", "adjustment"}).
+			AddRow(1, 11, 5, 9, 60, 2, -3))
+
+	workspaceRepository := mocks.NewWorkspaceRepository(t)
+	workspaceRepository.On("GetWorkspaceFromDB", 7).Return(&helpers.Workspace{Id: 7, Plan: "pro"}, nil)
+	workspaceRepository.On("GetCallFromDB", 11).Return(&helpers.Call{To: "15550001111", DurationNumber: 180}, nil)
+
+	paymentRepository := mocks.NewPaymentRepository(t)
+	paymentRepository.On("GetCallRatingPlan", 2).Return(models.DefaultCallRatingPlan(), nil)
+
+	s := &BillingService{db: db, workspaceRepository: workspaceRepository, paymentRepository: paymentRepository}
+	report, err := s.Rerate(models.RerateTask{StartDate: "2026-01-01", EndDate: "2026-02-01", WorkspaceID: 7}, deck)
+	assert.NoError(t, err)
+	assert.Empty(t, report.Lines)
+	assert.Equal(t, int64(0), report.TotalAdjustmentCents)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
@@ -0,0 +1,64 @@
+package billing
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/scheduler/internal/invoice"
+	"lineblocs.com/scheduler/models"
+	"lineblocs.com/scheduler/repository"
+)
+
+// RetryCharge charges a card again for what an invoice still owes. The attempt is recorded in the outbox
+// with its own idempotency key and amount before the gateway call, so the reconciler can replay exactly
+// that attempt if the worker stops before it is settled. It reports whether the invoice was paid.
+func (s *BillingService) RetryCharge(invoiceID int64, at time.Time) (bool, error) {
+	logger := logrus.WithField("component", "retry_charge").WithField("invoice_id", invoiceID)
+
+	var entry *models.OutboxEntry
+	err := s.unitOfWork.Do(func(tx repository.Executor) error {
+		inv, err := s.invoiceRepository.LockOverdueInvoice(tx, invoiceID)
+		if err != nil {
+			return err
+		}
+		owed := inv.Cents - inv.CentsCollected
+		if !invoice.Status(inv.State).Collectable() || owed <= 0 {
+			logger.Infof("invoice is %s and owes %d cents, not retrying", inv.State, owed)
+			return nil
+		}
+
+		attempts, err := s.invoiceRepository.CountOutboxEntries(tx, invoiceID)
+		if err != nil {
+			return err
+		}
+		entry = &models.OutboxEntry{
+			CreatedAt:      at,
+			IdempotencyKey: RetryIdempotencyKey(invoiceID, attempts+1),
+			Status:         models.OutboxPending,
+			InvoiceID:      invoiceID,
+			WorkspaceID:    inv.WorkspaceID,
+			UserID:         inv.UserID,
+			Cents:          owed,
+		}
+		entry.Id, err = s.invoiceRepository.CreateOutboxEntry(tx, entry)
+		return err
+	})
+	if err != nil {
+		logger.WithError(err).Error("error recording retried card charge")
+		return false, err
+	}
+	if entry == nil {
+		return false, nil
+	}
+
+	logger.Infof("Retrying card charge of %d cents with key %s", entry.Cents, entry.IdempotencyKey)
+	charged, err := s.chargeOutboxEntry(entry, at, logger)
+	if err != nil {
+		return false, fmt.Errorf("error settling retried charge: %w", err)
+	}
+	if charged {
+		s.reactivateAfterPayment(entry.WorkspaceID, at, logger)
+	}
+	return charged, nil
+}
@@ -0,0 +1,119 @@
+package billing
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"lineblocs.com/scheduler/internal/invoice"
+	"lineblocs.com/scheduler/mocks"
+	"lineblocs.com/scheduler/models"
+)
+
+func TestRetryCharge(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, 6, 3, 4, 0, 0, 0, time.UTC)
+	user := &helpers.User{Id: 2}
+	workspace := &helpers.Workspace{Id: 3}
+
+	expectBillingParams := func(t *testing.T) *BillingService {
+		db, sqlMock, err := sqlmock.New()
+		assert.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT payment_gateway FROM customizations")).
+			WillReturnRows(sqlmock.NewRows([]string{"payment_gateway"}).AddRow("stripe"))
+		sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT stripe_private_key FROM api_credentials")).
+			WillReturnRows(sqlmock.NewRows([]string{"stripe_private_key"}).AddRow("sk_test"))
+		return &BillingService{db: db}
+	}
+
+	t.Run("Should charge what is owed under a new key for each attempt", func(t *testing.T) {
+		t.Parallel()
+
+		// partially paid, and the first charge and one retry were declined already
+		inv := &models.Invoice{Id: 9, WorkspaceID: 3, UserID: 2, Cents: 2500, CentsCollected: 1000, State: string(invoice.PartiallyPaid)}
+		entry := models.OutboxEntry{
+			CreatedAt: at, IdempotencyKey: "lineblocs_invoice_9_attempt_3", Status: models.OutboxPending, InvoiceID: 9, WorkspaceID: 3, UserID: 2, Cents: 1500,
+		}
+
+		invoiceRepository := mocks.NewInvoiceRepository(t)
+		invoiceRepository.On("LockOverdueInvoice", nil, int64(9)).Return(inv, nil)
+		invoiceRepository.On("CountOutboxEntries", nil, int64(9)).Return(2, nil)
+		invoiceRepository.On("CreateOutboxEntry", nil, &entry).Return(int64(21), nil)
+		invoiceRepository.On("RecordPayment", nil, int64(9), "CARD", int64(1500), mock.AnythingOfType("string"), at).Return(nil)
+		invoiceRepository.On("TransitionInvoice", nil, int64(9), invoice.Paid, "paid with card", at).Return(nil)
+		invoiceRepository.On("UpdateOutboxEntry", nil, int64(21), models.OutboxSucceeded, "", "").Return(nil)
+
+		paymentRepository := mocks.NewPaymentRepository(t)
+		paymentRepository.On("ChargeCustomer", mock.Anything, user, workspace, mock.MatchedBy(func(userInvoice *models.UserInvoice) bool {
+			return userInvoice.Cents == 1500 && userInvoice.IdempotencyKey == "lineblocs_invoice_9_attempt_3"
+		})).Return(nil)
+
+		workspaceRepository := mocks.NewWorkspaceRepository(t)
+		workspaceRepository.On("GetUserFromDB", 2).Return(user, nil)
+		workspaceRepository.On("GetWorkspaceFromDB", 3).Return(workspace, nil)
+
+		unitOfWork := mocks.NewUnitOfWork(t)
+		unitOfWork.On("Do", mock.Anything).Return(runUnitOfWork)
+
+		s := expectBillingParams(t)
+		s.invoiceRepository, s.paymentRepository, s.workspaceRepository, s.unitOfWork = invoiceRepository, paymentRepository, workspaceRepository, unitOfWork
+		paid, err := s.RetryCharge(9, at)
+		assert.NoError(t, err)
+		assert.True(t, paid)
+	})
+
+	t.Run("Should record a declined retry as a failed attempt", func(t *testing.T) {
+		t.Parallel()
+
+		inv := &models.Invoice{Id: 9, WorkspaceID: 3, UserID: 2, Cents: 2500, State: string(invoice.Open)}
+
+		invoiceRepository := mocks.NewInvoiceRepository(t)
+		invoiceRepository.On("LockOverdueInvoice", nil, int64(9)).Return(inv, nil)
+		invoiceRepository.On("CountOutboxEntries", nil, int64(9)).Return(0, nil)
+		invoiceRepository.On("CreateOutboxEntry", nil, mock.MatchedBy(func(entry *models.OutboxEntry) bool {
+			// invoices billed before the outbox get a key the first charge never used
+			return entry.IdempotencyKey == "lineblocs_invoice_9_attempt_1" && entry.Cents == 2500
+		})).Return(int64(21), nil)
+		invoiceRepository.On("RecordFailedAttempt", nil, int64(9), "CARD", at).Return(nil)
+		invoiceRepository.On("UpdateOutboxEntry", nil, int64(21), models.OutboxFailed, "", "card declined").Return(nil)
+
+		paymentRepository := mocks.NewPaymentRepository(t)
+		paymentRepository.On("ChargeCustomer", mock.Anything, user, workspace, mock.Anything).Return(errors.New("card declined"))
+
+		workspaceRepository := mocks.NewWorkspaceRepository(t)
+		workspaceRepository.On("GetUserFromDB", 2).Return(user, nil)
+		workspaceRepository.On("GetWorkspaceFromDB", 3).Return(workspace, nil)
+
+		unitOfWork := mocks.NewUnitOfWork(t)
+		unitOfWork.On("Do", mock.Anything).Return(runUnitOfWork)
+
+		s := expectBillingParams(t)
+		s.invoiceRepository, s.paymentRepository, s.workspaceRepository, s.unitOfWork = invoiceRepository, paymentRepository, workspaceRepository, unitOfWork
+		paid, err := s.RetryCharge(9, at)
+		assert.NoError(t, err)
+		assert.False(t, paid)
+	})
+
+	t.Run("Should not charge an invoice that was settled since it was listed", func(t *testing.T) {
+		t.Parallel()
+
+		invoiceRepository := mocks.NewInvoiceRepository(t)
+		invoiceRepository.On("LockOverdueInvoice", nil, int64(9)).Return(&models.Invoice{Id: 9, Cents: 2500, CentsCollected: 2500, State: string(invoice.Paid)}, nil)
+
+		unitOfWork := mocks.NewUnitOfWork(t)
+		unitOfWork.On("Do", mock.Anything).Return(runUnitOfWork)
+
+		// the strict payment mock fails the test if the card is charged
+		s := &BillingService{invoiceRepository: invoiceRepository, paymentRepository: mocks.NewPaymentRepository(t), unitOfWork: unitOfWork}
+		paid, err := s.RetryCharge(9, at)
+		assert.NoError(t, err)
+		assert.False(t, paid)
+	})
+}
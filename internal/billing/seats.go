@@ -0,0 +1,90 @@
+package billing
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/scheduler/models"
+	"lineblocs.com/scheduler/utils"
+)
+
+// billingDays counts the days between two times, a partial day counting as a whole one
+func billingDays(from, to time.Time) int {
+	if !to.After(from) {
+		return 0
+	}
+	return int(math.Ceil(to.Sub(from).Hours() / 24))
+}
+
+// ProrateSeats bills each membership for the days of the period it was held, as a share of
+// seatCents, the fee of one seat for the whole period. Each membership is its own line item.
+func ProrateSeats(memberships []models.Membership, seatCents int64, start, end time.Time) []models.InvoiceLineItem {
+	periodDays := billingDays(start, end)
+	items := make([]models.InvoiceLineItem, 0, len(memberships))
+	if periodDays == 0 {
+		return items
+	}
+
+	for _, membership := range memberships {
+		from := membership.JoinedAt
+		if from.Before(start) {
+			from = start
+		}
+		to := end
+		if !membership.LeftAt.IsZero() && membership.LeftAt.Before(end) {
+			to = membership.LeftAt
+		}
+
+		days := min(billingDays(from, to), periodDays)
+		if days == 0 {
+			continue
+		}
+
+		items = append(items, models.InvoiceLineItem{
+			PeriodStart: from,
+			PeriodEnd:   to,
+			Category:    models.LineItemMembership,
+			Description: fmt.Sprintf("Seat for user %d, %d of %d days", membership.UserID, days, periodDays),
+			Unit:        "days",
+			ReferenceID: membership.UserID,
+			Quantity:    float64(days),
+			Cents:       int64(math.Round(float64(seatCents) * float64(days) / float64(periodDays))),
+		})
+	}
+	return items
+}
+
+// calculateMembershipCosts bills the seats held during the term's seat period by the days each was held.
+// Monthly terms bill the past month; annual prepaid terms bill the year that starts with the run, so the
+// seats held on renewal are billed for the whole year and seats removed before it are not billed.
+// Workspaces without membership history are billed for their current seats.
+func (s *BillingService) calculateMembershipCosts(data *BillingData, costs *BillingCosts, logger *logrus.Entry) error {
+	seatCents := data.Term.MembershipCosts(data.Plan, 1)
+	if seatCents == 0 {
+		return nil
+	}
+
+	start, end := data.Term.SeatPeriod(data.BillingPeriodStart, data.BillingPeriodEnd)
+	memberships, err := s.workspaceRepository.GetMemberships(data.Workspace.Id, start, end)
+	if err != nil {
+		logger.WithError(err).Error("error getting workspace memberships")
+		return err
+	}
+
+	if len(memberships) == 0 {
+		userCount := utils.GetWorkspaceUserCount(s.db, data.Workspace.Id)
+		logger.Infof("Workspace has no membership history, billing its %d current users", userCount)
+		costs.MembershipCosts = data.Term.MembershipCosts(data.Plan, userCount)
+		return nil
+	}
+
+	seats := ProrateSeats(memberships, seatCents, start, end)
+	for _, seat := range seats {
+		costs.MembershipCosts += seat.Cents
+	}
+	costs.LineItems = append(costs.LineItems, seats...)
+	logger.Infof("Workspace held %d seats during the period", len(seats))
+	return nil
+}
@@ -0,0 +1,119 @@
+package billing
+
+import (
+	"testing"
+	"time"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"lineblocs.com/scheduler/mocks"
+	"lineblocs.com/scheduler/models"
+)
+
+func TestProrateSeats(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		Name          string
+		Membership    models.Membership
+		ExpectedDays  float64
+		ExpectedCents int64
+	}{
+		{
+			Name:          "member for the whole period",
+			Membership:    models.Membership{UserID: 1, JoinedAt: start.AddDate(-1, 0, 0)},
+			ExpectedDays:  30,
+			ExpectedCents: 3000,
+		},
+		{
+			Name:          "joined on the 30th",
+			Membership:    models.Membership{UserID: 2, JoinedAt: time.Date(2026, 4, 30, 9, 0, 0, 0, time.UTC)},
+			ExpectedDays:  1,
+			ExpectedCents: 100,
+		},
+		{
+			Name:          "removed on the 30th",
+			Membership:    models.Membership{UserID: 3, JoinedAt: start.AddDate(0, -2, 0), LeftAt: time.Date(2026, 4, 30, 0, 0, 0, 0, time.UTC)},
+			ExpectedDays:  29,
+			ExpectedCents: 2900,
+		},
+		{
+			Name:          "joined and left within the period",
+			Membership:    models.Membership{UserID: 4, JoinedAt: time.Date(2026, 4, 10, 0, 0, 0, 0, time.UTC), LeftAt: time.Date(2026, 4, 20, 12, 0, 0, 0, time.UTC)},
+			ExpectedDays:  11,
+			ExpectedCents: 1100,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			items := ProrateSeats([]models.Membership{tc.Membership}, 3000, start, end)
+			if assert.Len(t, items, 1) {
+				assert.Equal(t, models.LineItemMembership, items[0].Category)
+				assert.Equal(t, tc.Membership.UserID, items[0].ReferenceID)
+				assert.Equal(t, tc.ExpectedDays, items[0].Quantity)
+				assert.Equal(t, tc.ExpectedCents, items[0].Cents)
+			}
+		})
+	}
+
+	t.Run("Should skip memberships outside the period", func(t *testing.T) {
+		t.Parallel()
+
+		membership := models.Membership{UserID: 5, JoinedAt: start.AddDate(0, -2, 0), LeftAt: start.AddDate(0, 0, -1)}
+		assert.Empty(t, ProrateSeats([]models.Membership{membership}, 3000, start, end))
+	})
+}
+
+func TestCalculateMembershipCosts(t *testing.T) {
+	t.Parallel()
+
+	end := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	plan := &helpers.ServicePlan{BaseCosts: 1000, AnnualCostCents: 36500}
+
+	t.Run("Should prorate prepaid seats over the year that starts with the run", func(t *testing.T) {
+		t.Parallel()
+
+		// members who left during the past year are not returned for the upcoming one
+		memberships := []models.Membership{
+			{UserID: 1, JoinedAt: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+			{UserID: 2, JoinedAt: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), LeftAt: time.Date(2027, 1, 11, 0, 0, 0, 0, time.UTC)},
+		}
+		workspaceRepository := mocks.NewWorkspaceRepository(t)
+		workspaceRepository.On("GetMemberships", 7, end, end.AddDate(1, 0, 0)).Return(memberships, nil)
+
+		data := &BillingData{Term: annualPrepaidTerm{}, Plan: plan, Workspace: &helpers.Workspace{Id: 7}, BillingPeriodStart: end.AddDate(-1, 0, 0), BillingPeriodEnd: end}
+		costs := &BillingCosts{}
+		s := &BillingService{workspaceRepository: workspaceRepository}
+		assert.NoError(t, s.calculateMembershipCosts(data, costs, logrus.WithField("test", t.Name())))
+		assert.Equal(t, int64(36500+1000), costs.MembershipCosts)
+		if assert.Len(t, costs.LineItems, 2) {
+			assert.Equal(t, end, costs.LineItems[0].PeriodStart)
+			assert.Equal(t, end.AddDate(1, 0, 0), costs.LineItems[0].PeriodEnd)
+			assert.Equal(t, float64(365), costs.LineItems[0].Quantity)
+			assert.Equal(t, float64(10), costs.LineItems[1].Quantity)
+		}
+	})
+
+	t.Run("Should prorate monthly seats over the past month", func(t *testing.T) {
+		t.Parallel()
+
+		start := end.AddDate(0, -1, 0)
+		memberships := []models.Membership{{UserID: 1, JoinedAt: time.Date(2026, 12, 22, 0, 0, 0, 0, time.UTC)}}
+		workspaceRepository := mocks.NewWorkspaceRepository(t)
+		workspaceRepository.On("GetMemberships", 7, start, end).Return(memberships, nil)
+
+		data := &BillingData{Term: monthlyTerm{}, Plan: plan, Workspace: &helpers.Workspace{Id: 7}, BillingPeriodStart: start, BillingPeriodEnd: end}
+		costs := &BillingCosts{}
+		s := &BillingService{workspaceRepository: workspaceRepository}
+		assert.NoError(t, s.calculateMembershipCosts(data, costs, logrus.WithField("test", t.Name())))
+		// 10 of the 31 days of December
+		assert.Equal(t, int64(323), costs.MembershipCosts)
+	})
+}
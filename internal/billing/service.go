@@ -5,10 +5,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"strings"
 	"time"
 
 	helpers "github.com/Lineblocs/go-helpers"
 	"github.com/sirupsen/logrus"
+	"lineblocs.com/scheduler/internal/allowance"
+	"lineblocs.com/scheduler/internal/invoice"
+	"lineblocs.com/scheduler/internal/pricing"
+	"lineblocs.com/scheduler/internal/promotions"
+	"lineblocs.com/scheduler/internal/ratedeck"
+	"lineblocs.com/scheduler/internal/suspension"
 	"lineblocs.com/scheduler/models"
 	"lineblocs.com/scheduler/repository"
 	"lineblocs.com/scheduler/utils"
@@ -21,47 +28,96 @@ type BillingData struct {
 	Plan               *helpers.ServicePlan
 	BillingInfo        *helpers.WorkspaceBillingInfo
 	BaseCosts          *helpers.BaseCosts
+	CallRating         *models.CallRatingPlan
+	FaxRates           *models.FaxRates
+	PriceSchedules     map[string]pricing.Schedule // the plan's usage tiers by pricing category
+	Rollover           allowance.Policy
+	AddOns             []models.AddOn
+	Contract           *models.Contract
+	Catalog            *models.PriceCatalog
+	Collection         *models.CollectionTerms // nil when invoices are charged automatically
+	Term               BillingTerm
+	SubscriptionID     int
 	BillingPeriodStart time.Time
 	BillingPeriodEnd   time.Time
 	Now                time.Time
+	// Estimate prices the period without writing anything, see Estimate
+	Estimate bool
 }
 
 type BillingCosts struct {
-	MembershipCosts   int64
-	CallTollsCosts    int64
-	RecordingCosts    int64
-	FaxCosts          int64
-	NumberRentalCosts int64
-	TotalCosts        int64
-	InvoiceDesc       string
+	MembershipCosts     int64                    `json:"membership_costs"`
+	CallTollsCosts      int64                    `json:"call_tolls_costs"`
+	RecordingCosts      int64                    `json:"recording_costs"`
+	FaxCosts            int64                    `json:"fax_costs"`
+	NumberRentalCosts   int64                    `json:"number_rental_costs"`
+	AddOnCosts          int64                    `json:"addon_costs"`
+	DiscountCosts       int64                    `json:"discount_costs"`
+	TotalCosts          int64                    `json:"total_costs"`
+	InvoiceDesc         string                   `json:"invoice_desc"`
+	RatingDiscrepancies []RatingDiscrepancy      `json:"rating_discrepancies,omitempty"`
+	Discounts           []promotions.Discount    `json:"discounts,omitempty"`
+	LineItems           []models.InvoiceLineItem `json:"line_items,omitempty"`
+	// Allowance is the minute allowance the calls drew on, set when usage is billed
+	Allowance *allowance.Period `json:"allowance,omitempty"`
+	// DebitIDs are the usage debits priced into these costs, claimed by the invoice
+	DebitIDs []int `json:"debit_ids"`
+	// CallBillings are what each call was billed, for calls priced on their own tolls
+	CallBillings []models.CallBilling `json:"-"`
 }
 
 type BillingService struct {
-	db                  *sql.DB
-	workspaceRepository repository.WorkspaceRepository
-	paymentRepository   repository.PaymentRepository
-	rabbitmqPublisher   RabbitMQPublisher
+	db                   *sql.DB
+	workspaceRepository  repository.WorkspaceRepository
+	paymentRepository    repository.PaymentRepository
+	rabbitmqPublisher    RabbitMQPublisher
+	invoiceRepository    repository.InvoiceRepository
+	forecastRepository   repository.ForecastRepository
+	promotionRepository  repository.PromotionRepository
+	allowanceRepository  repository.AllowanceRepository
+	suspensionRepository repository.SuspensionRepository
+	topUpRepository      repository.TopUpRepository
+	balanceRepository    repository.BalanceRepository
+	unitOfWork           repository.UnitOfWork
+	rateDeck             *ratedeck.Deck
+	rateDeckMode         string
+	paymentStatusPolicy  *suspension.Policy
 }
 
 type RabbitMQPublisher interface {
 	Publish(queue string, message []byte) error
 }
 
-
 func NewBillingService(db *sql.DB, wRepo repository.WorkspaceRepository, pRepo repository.PaymentRepository) *BillingService {
 	return &BillingService{
-		db:                  db,
-		workspaceRepository: wRepo,
-		paymentRepository:   pRepo,
+		db:                   db,
+		workspaceRepository:  wRepo,
+		paymentRepository:    pRepo,
+		invoiceRepository:    repository.NewInvoiceRepository(),
+		forecastRepository:   repository.NewForecastRepository(db),
+		promotionRepository:  repository.NewPromotionRepository(),
+		allowanceRepository:  repository.NewAllowanceRepository(),
+		suspensionRepository: repository.NewSuspensionRepository(),
+		topUpRepository:      repository.NewTopUpRepository(),
+		balanceRepository:    repository.NewBalanceRepository(),
+		unitOfWork:           repository.NewUnitOfWork(db),
 	}
 }
 
 func NewBillingServiceWithPublisher(db *sql.DB, wRepo repository.WorkspaceRepository, pRepo repository.PaymentRepository, publisher RabbitMQPublisher) *BillingService {
 	return &BillingService{
-		db:                  db,
-		workspaceRepository: wRepo,
-		paymentRepository:   pRepo,
-		rabbitmqPublisher:   publisher,
+		db:                   db,
+		workspaceRepository:  wRepo,
+		paymentRepository:    pRepo,
+		invoiceRepository:    repository.NewInvoiceRepository(),
+		forecastRepository:   repository.NewForecastRepository(db),
+		promotionRepository:  repository.NewPromotionRepository(),
+		allowanceRepository:  repository.NewAllowanceRepository(),
+		suspensionRepository: repository.NewSuspensionRepository(),
+		topUpRepository:      repository.NewTopUpRepository(),
+		balanceRepository:    repository.NewBalanceRepository(),
+		unitOfWork:           repository.NewUnitOfWork(db),
+		rabbitmqPublisher:    publisher,
 	}
 }
 
@@ -93,46 +149,70 @@ func (s *BillingService) publishFailedPayment(task models.BillingTask, reason st
 	logger.Infof("Published failed payment event for workspace %d, subscription %d", task.WorkspaceID, task.SubscriptionID)
 }
 
-// ProcessTask routes to the correct logic based on the task type
+// ProcessTask runs a billing task through the billing pipeline
 func (s *BillingService) ProcessTask(task models.BillingTask) error {
 	logger := logrus.WithField("component", "billing").WithField("workspace_id", task.WorkspaceID).WithField("run_id", task.RunID)
-	if task.BillingType == "annual" {
-		err := s.processAnnual(task)
-		if err != nil {
-			s.publishFailedPayment(task, err.Error(), logger)
-		}
-		return err
-	}
-	err := s.processMonthly(task)
+	err := s.processBilling(task, logger)
 	if err != nil {
 		s.publishFailedPayment(task, err.Error(), logger)
 	}
 	return err
 }
 
-func (s *BillingService) processMonthly(task models.BillingTask) error {
-	logger := logrus.WithField("component", "monthly_billing").WithField("workspace_id", task.WorkspaceID)
-
-	billingData, err := s.loadBillingData(task, "MONTHLY", logger)
+// processBilling loads the workspace and bills the period chosen by the billing term
+func (s *BillingService) processBilling(task models.BillingTask, logger *logrus.Entry) error {
+	billingData, err := s.loadBillingData(task, logger)
 	if err != nil {
 		return err
 	}
-
-	costs, err := s.calculateMonthlyCosts(billingData, logger)
-	if err != nil {
+	if err := s.loadPeriodPrices(billingData, logger); err != nil {
 		return err
 	}
 
-	invoiceID, err := s.createInvoice(costs, billingData, logger)
-	if err != nil {
+	_, err = s.bill(billingData, logger)
+	return err
+}
+
+// bill prices the period, writes the invoice and claims its debits in one unit of work.
+// Card charges run after the unit of work commits. It returns the ID of the new invoice.
+func (s *BillingService) bill(billingData *BillingData, logger *logrus.Entry) (int64, error) {
+	var costs *BillingCosts
+	var invoiceID int64
+	var cardCharge *models.OutboxEntry
+	err := s.unitOfWork.Do(func(tx repository.Executor) error {
+		var err error
+		costs, err = s.calculateCosts(tx, billingData, logger)
+		if err != nil {
+			return err
+		}
+
+		invoiceID, err = s.createInvoice(tx, costs, billingData, logger)
+		if err != nil {
+			return err
+		}
+
+		settled, err := s.settleWithoutGateway(tx, invoiceID, costs, billingData, logger)
+		if err != nil || settled {
+			return err
+		}
+
+		cardCharge, err = s.recordCardCharge(tx, invoiceID, costs, billingData, logger)
 		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	if billingData.sendsInvoice() && costs.TotalCosts > 0 {
+		s.emailInvoice(invoiceID, costs, billingData, logger)
+	}
+	if cardCharge == nil {
+		return invoiceID, nil
 	}
 
-	return s.chargeInvoice(invoiceID, costs, billingData, logger)
+	return invoiceID, s.chargeWithCard(cardCharge, costs, billingData, logger)
 }
 
-
-func (s *BillingService) loadBillingData(task models.BillingTask, billingType string, logger *logrus.Entry) (*BillingData, error) {
+func (s *BillingService) loadBillingData(task models.BillingTask, logger *logrus.Entry) (*BillingData, error) {
 	conn := utils.NewDBConn(s.db)
 
 	subscription, err := s.paymentRepository.GetSubscription(task.SubscriptionID)
@@ -142,6 +222,9 @@ func (s *BillingService) loadBillingData(task models.BillingTask, billingType st
 	}
 	logger.Infof("Loaded subscription %d for billing task", subscription.Id)
 
+	term := TermFor(task, subscription)
+	logger = logger.WithField("term", term.Name())
+
 	billingParams, err := conn.GetBillingParams()
 	if err != nil {
 		logger.WithError(err).Error("error getting billing params")
@@ -149,12 +232,6 @@ func (s *BillingService) loadBillingData(task models.BillingTask, billingType st
 	}
 
 	now := time.Now()
-	var billingPeriodStart time.Time
-	if billingType == "ANNUAL" {
-		billingPeriodStart = now.AddDate(-1, 0, 0)
-	} else {
-		billingPeriodStart = now.AddDate(0, -1, 0)
-	}
 
 	workspace, err := s.workspaceRepository.GetWorkspaceFromDB(task.WorkspaceID)
 	if err != nil {
@@ -180,6 +257,36 @@ func (s *BillingService) loadBillingData(task models.BillingTask, billingType st
 		return nil, fmt.Errorf("plan not found for subscription")
 	}
 
+	addOns, err := s.paymentRepository.GetSubscriptionAddOns(subscription.Id)
+	if err != nil {
+		logger.WithError(err).Error("error getting subscription add-ons")
+		return nil, err
+	}
+
+	callRating, err := s.paymentRepository.GetCallRatingPlan(plan.Id)
+	if err != nil {
+		logger.WithError(err).Error("error getting call rating plan")
+		return nil, err
+	}
+
+	priceSchedules, err := s.paymentRepository.GetPriceSchedules(plan.Id)
+	if err != nil {
+		logger.WithError(err).Error("error getting price schedules")
+		return nil, err
+	}
+
+	rollover, err := s.paymentRepository.GetRolloverPolicy(plan.Id)
+	if err != nil {
+		logger.WithError(err).Error("error getting rollover policy")
+		return nil, err
+	}
+
+	collection, err := s.paymentRepository.GetCollectionTerms(workspace.Id)
+	if err != nil {
+		logger.WithError(err).Error("error getting collection terms")
+		return nil, err
+	}
+
 	billingInfo, err := s.workspaceRepository.GetWorkspaceBillingInfo(workspace)
 	if err != nil {
 		logger.WithError(err).Error("error getting billing info")
@@ -193,199 +300,287 @@ func (s *BillingService) loadBillingData(task models.BillingTask, billingType st
 	}
 
 	return &BillingData{
-		BillingParams:       billingParams,
-		Workspace:           workspace,
-		User:                user,
-		Plan:                plan,
-		BillingInfo:         billingInfo,
-		BaseCosts:           baseCosts,
-		BillingPeriodStart:  billingPeriodStart,
-		BillingPeriodEnd:    now,
-		Now:                 now,
+		BillingParams:      billingParams,
+		Workspace:          workspace,
+		User:               user,
+		Plan:               plan,
+		BillingInfo:        billingInfo,
+		BaseCosts:          baseCosts,
+		CallRating:         callRating,
+		PriceSchedules:     priceSchedules,
+		Rollover:           rollover,
+		AddOns:             addOns,
+		Collection:         collection,
+		Term:               term,
+		SubscriptionID:     subscription.Id,
+		BillingPeriodStart: term.PeriodStart(BillingPeriodEnd(now)),
+		BillingPeriodEnd:   BillingPeriodEnd(now),
+		Now:                now,
 	}, nil
 }
 
-func (s *BillingService) calculateMonthlyCosts(data *BillingData, logger *logrus.Entry) (*BillingCosts, error) {
-	costs := &BillingCosts{}
-	userCount := utils.GetWorkspaceUserCount(s.db, data.Workspace.Id)
-	logger.Infof("Workspace total user count %d", userCount)
+// BillingPeriodEnd is the start of the month a run happens in. Billing runs start on the 1st, and a run
+// that is retried or requeued later in the month bills the same period, so the rental ledger and claimed
+// debits line up with the first attempt.
+func BillingPeriodEnd(at time.Time) time.Time {
+	return time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
 
-	costs.MembershipCosts = int64(data.Plan.BaseCosts * float64(userCount))
-	logger.Infof("Workspace total membership costs is %d", costs.MembershipCosts)
+// loadPeriodPrices merges the prices in effect at the end of the billing period into the data: the
+// price catalog of the period replaces the current plan and base costs, the workspace's contract
+// overrides them, then the add-ons add their allowances. It runs once the period is set and only
+// once per BillingData.
+func (s *BillingService) loadPeriodPrices(data *BillingData, logger *logrus.Entry) error {
+	catalog, err := s.paymentRepository.GetPriceCatalog(data.BillingPeriodEnd)
+	if err != nil {
+		logger.WithError(err).Error("error getting price catalog")
+		return err
+	}
+	if catalog != nil {
+		logger.Infof("Rating with price catalog version %d", catalog.Version)
+		applyCatalog(data, catalog, logger)
+	}
+
+	data.FaxRates, err = s.paymentRepository.GetFaxRates(data.BaseCosts.FaxPerUsed)
+	if err != nil {
+		logger.WithError(err).Error("error getting fax rates")
+		return err
+	}
 
-	utils.CreateMonthlyNumberRentalDebit(s.db, data.Workspace.Id, data.User.Id, data.BillingPeriodStart)
+	contract, err := s.paymentRepository.GetContract(data.Workspace.Id, data.BillingPeriodEnd)
+	if err != nil {
+		logger.WithError(err).Error("error getting workspace contract")
+		return err
+	}
+	if contract != nil {
+		logger.Infof("Pricing with contract %d version %d", contract.ID, contract.Version)
+		applyContract(data, contract)
+	}
 
-	billingPeriodStartStr := data.BillingPeriodStart.Format(time.DateTime)
-	billingPeriodEndStr := data.BillingPeriodEnd.Format(time.DateTime)
+	data.Plan = planWithAddOns(data.Plan, data.AddOns)
+	return nil
+}
 
-	debitsErr := s.processDebits(data, costs, billingPeriodStartStr, billingPeriodEndStr, logger)
-	if debitsErr != nil {
-		return nil, debitsErr
+// priceSchedule returns the plan's tiers for a pricing category, or the fallback when it has none
+func (d *BillingData) priceSchedule(category string, fallback pricing.Schedule) pricing.Schedule {
+	if schedule, ok := d.PriceSchedules[category]; ok {
+		return schedule
 	}
+	return fallback
+}
 
-	recordingsErr := s.processRecordings(data, costs, billingPeriodStartStr, billingPeriodEndStr, logger)
-	if recordingsErr != nil {
-		return nil, recordingsErr
+func (s *BillingService) calculateCosts(tx repository.Executor, data *BillingData, logger *logrus.Entry) (*BillingCosts, error) {
+	costs := &BillingCosts{}
+	if err := s.calculateMembershipCosts(data, costs, logger); err != nil {
+		return nil, err
 	}
+	s.calculateAddOnCosts(data, costs, logger)
+	logger.Infof("Workspace total membership costs is %d", costs.MembershipCosts)
 
-	faxesErr := s.processFaxes(data, costs, billingPeriodStartStr, billingPeriodEndStr, logger)
-	if faxesErr != nil {
-		return nil, faxesErr
+	if data.Term.BillsUsage() {
+		if err := s.calculateUsageCosts(tx, data, costs, logger); err != nil {
+			return nil, err
+		}
 	}
 
-	costs.TotalCosts = costs.MembershipCosts + costs.CallTollsCosts + costs.RecordingCosts + costs.FaxCosts + costs.NumberRentalCosts
-	costs.InvoiceDesc = fmt.Sprintf("LineBlocs invoice for %s", data.BillingInfo.InvoiceDue)
+	if err := s.applyDiscounts(tx, data, costs, logger); err != nil {
+		return nil, err
+	}
 
-	logger.Infof("Final costs are membership: %d, call tolls: %d, recordings: %d, fax: %d, did rentals: %d, total: %d (cents)",
-		costs.MembershipCosts, costs.CallTollsCosts, costs.RecordingCosts, costs.FaxCosts, costs.NumberRentalCosts, costs.TotalCosts)
+	costs.TotalCosts = costs.MembershipCosts + costs.AddOnCosts + costs.CallTollsCosts + costs.RecordingCosts + costs.FaxCosts + costs.NumberRentalCosts + costs.DiscountCosts
+	costs.InvoiceDesc = data.Term.InvoiceDesc(data.BillingInfo)
+
+	logger.Infof("Final costs are membership: %d, add-ons: %d, call tolls: %d, recordings: %d, fax: %d, did rentals: %d, discounts: %d, total: %d (cents)",
+		costs.MembershipCosts, costs.AddOnCosts, costs.CallTollsCosts, costs.RecordingCosts, costs.FaxCosts, costs.NumberRentalCosts, costs.DiscountCosts, costs.TotalCosts)
+	if len(costs.RatingDiscrepancies) > 0 {
+		logger.Warnf("%d call debits differ from the rate deck", len(costs.RatingDiscrepancies))
+	}
 
 	return costs, nil
 }
 
-func (s *BillingService) processDebits(data *BillingData, costs *BillingCosts, startStr, endStr string, logger *logrus.Entry) error {
-	rows, err := s.db.Query("SELECT id, source, module_id, cents, created_at FROM users_debits WHERE user_id = ? AND created_at BETWEEN ? AND ?", data.Workspace.CreatorId, startStr, endStr)
-	if err != nil {
-		logger.WithError(err).Error("error running debits query")
+// calculateUsageCosts adds the number rentals, call tolls, recordings and faxes of the period
+func (s *BillingService) calculateUsageCosts(tx repository.Executor, data *BillingData, costs *BillingCosts, logger *logrus.Entry) error {
+	if data.Estimate {
+		if err := s.estimateNumberRentals(tx, data, costs, logger); err != nil {
+			return err
+		}
+	} else if err := s.createNumberRentalCharges(tx, data, logger); err != nil {
 		return err
 	}
-	defer rows.Close()
 
-	remainingMinutes := data.Plan.MinutesPerMonth
-
-	for rows.Next() {
-		var debitID int
-		var debitSource string
-		var debitModuleID int
-		var debitCostCents int64
-		var debitCreatedAt time.Time
-
-		if err := rows.Scan(&debitID, &debitSource, &debitModuleID, &debitCostCents, &debitCreatedAt); err != nil {
-			logger.WithError(err).Error("error scanning debit")
-			continue
-		}
+	if err := s.processDebits(tx, data, costs, logger); err != nil {
+		return err
+	}
 
-		switch debitSource {
-		case "CALL":
-			s.processCallDebit(data, costs, debitModuleID, debitCostCents, &remainingMinutes, logger)
-		case "NUMBER_RENTAL":
-			s.processNumberRentalDebit(data, costs, debitModuleID, logger)
-		}
+	if err := s.processRecordings(data, costs, logger); err != nil {
+		return err
 	}
 
-	return nil
+	return s.processFaxes(data, costs, logger)
 }
 
-func (s *BillingService) processCallDebit(data *BillingData, costs *BillingCosts, moduleID int, costCents int64, remainingMinutes *float64, logger *logrus.Entry) {
-	call, err := s.workspaceRepository.GetCallFromDB(moduleID)
+type usageDebit struct {
+	createdAt time.Time
+	source    string
+	id        int
+	moduleID  int
+	cents     int64
+}
+
+// processDebits prices every debit not yet claimed by an invoice that was created before the end of the period.
+// Debits that arrive late are picked up by the next invoice. Debits billed before invoices claimed them are
+// marked billed by the backfill_debits command and skipped. An estimate prices only the debits of its period.
+func (s *BillingService) processDebits(tx repository.Executor, data *BillingData, costs *BillingCosts, logger *logrus.Entry) error {
+	var since time.Time
+	if data.Estimate {
+		since = data.BillingPeriodStart
+	}
+	debits, err := getUnbilledDebits(tx, data.Workspace.CreatorId, since, data.BillingPeriodEnd)
 	if err != nil {
-		logger.WithError(err).Error("error getting call")
-		return
+		logger.WithError(err).Error("error running debits query")
+		return err
 	}
 
-	callDurationMinutes := float64(call.DurationNumber / 60)
-	logger.Infof("processing call with duration %d seconds", call.DurationNumber)
-
-	charge, err := utils.ComputeAmountToCharge(float64(costCents), *remainingMinutes, callDurationMinutes)
+	// minutes rolled over from earlier cycles are added to this cycle's allowance
+	carried, err := s.allowanceRepository.GetAllowanceBalance(tx, data.SubscriptionID)
 	if err != nil {
-		logger.WithError(err).Error("error computing charge")
-		return
+		logger.WithError(err).Error("error getting allowance balance")
+		return err
+	}
+	includedSeconds := IncludedSecondsFromMinutes(data.Plan.MinutesPerMonth)
+	availableSeconds := includedSeconds + allowance.Seconds(carried)
+	callRater := NewCallRater(data.CallRating, availableSeconds)
+	minuteTiers, tieredMinutes := data.PriceSchedules[pricing.Minutes]
+	var chargeableSeconds int64
+
+	for _, debit := range debits {
+		// debits that could not be priced are left unclaimed for the next run
+		switch debit.source {
+		case models.DebitSourceCall:
+			charge, err := s.processCallDebit(costs, debit.id, debit.moduleID, debit.cents, callRater, tieredMinutes, logger)
+			if err != nil {
+				continue
+			}
+			// tiered plans price the period's minutes together instead of each call's toll, deck price included
+			if tieredMinutes {
+				chargeableSeconds += charge.ChargeableSeconds
+			} else {
+				costs.CallTollsCosts += charge.Cents
+				costs.CallBillings = append(costs.CallBillings, models.CallBilling{DebitID: debit.id, PlanID: data.Plan.Id, ChargeableSeconds: charge.ChargeableSeconds, BilledCents: charge.Cents})
+			}
+		case models.DebitSourceNumberRental, models.DebitSourceNumberSetup:
+			logger.Infof("processing %s of %d cents for DID %d", strings.ToLower(debit.source), debit.cents, debit.moduleID)
+			costs.NumberRentalCosts += debit.cents
+		case models.DebitSourceCallAdjustment:
+			logger.Infof("applying re-rating adjustment of %d cents for debit %d", debit.cents, debit.moduleID)
+			costs.CallTollsCosts += debit.cents
+		}
+		costs.DebitIDs = append(costs.DebitIDs, debit.id)
 	}
 
-	costs.CallTollsCosts += int64(charge)
-	*remainingMinutes -= callDurationMinutes
-}
+	period := allowance.Close(data.Rollover, carried, includedSeconds, availableSeconds-callRater.RemainingSeconds())
+	logger.Infof("Calls used %d of %d allowance seconds (%d carried in), carrying %d forward and expiring %d",
+		period.ConsumedSeconds, availableSeconds, period.CarriedInSeconds, period.CarriedForwardSeconds, period.ExpiredSeconds)
+	costs.Allowance = &period
 
-func (s *BillingService) processNumberRentalDebit(data *BillingData, costs *BillingCosts, moduleID int, logger *logrus.Entry) {
-	did, err := s.workspaceRepository.GetDIDFromDB(moduleID)
-	if err != nil {
-		logger.WithError(err).Error("error getting DID")
-		return
+	if tieredMinutes {
+		minutes := float64(chargeableSeconds) / 60
+		cents := int64(math.Round(minuteTiers.Price(minutes)))
+		logger.Infof("Pricing %.2f chargeable minutes on the plan's %s tiers at %d cents", minutes, minuteTiers.Mode, cents)
+		costs.CallTollsCosts += cents
 	}
 
-	logger.Infof("processing DID rental with monthly cost %d", did.MonthlyCost)
-	costs.NumberRentalCosts += int64(did.MonthlyCost)
+	return nil
 }
 
-func (s *BillingService) processRecordings(data *BillingData, costs *BillingCosts, startStr, endStr string, logger *logrus.Entry) error {
-	rows, err := s.db.Query("SELECT id, size, created_at FROM recordings WHERE user_id = ? AND created_at BETWEEN ? AND ?", data.Workspace.CreatorId, startStr, endStr)
-	if err != sql.ErrNoRows && err != nil {
-		logger.WithError(err).Error("error running recordings query")
-		return err
+// getUnbilledDebits reads the debits up front so the transaction's connection is free while they are priced.
+// A zero since reads every unbilled debit created before end.
+func getUnbilledDebits(tx repository.Executor, userID int, since, end time.Time) ([]usageDebit, error) {
+	query := "SELECT id, source, module_id, cents, created_at FROM users_debits WHERE user_id = ? AND invoice_id IS NULL AND status = ? AND created_at < ?"
+	args := []any{userID, models.DebitStatusUnbilled, end.Format(time.DateTime)}
+	if !since.IsZero() {
+		query += " AND created_at >= ?"
+		args = append(args, since.Format(time.DateTime))
+	}
+	rows, err := tx.Query(query+" ORDER BY id", args...)
+	if err != nil {
+		return nil, err
 	}
 	defer rows.Close()
 
-	remainingRecordings := data.Plan.RecordingSpace
-
+	debits := make([]usageDebit, 0)
 	for rows.Next() {
-		var recordingID int
-		var recordingSizeBytes float64
-		var recordingCreatedAt time.Time
-
-		if err := rows.Scan(&recordingID, &recordingSizeBytes, &recordingCreatedAt); err != nil {
-			logger.WithError(err).Error("error scanning recording")
-			continue
+		var debit usageDebit
+		if err := rows.Scan(&debit.id, &debit.source, &debit.moduleID, &debit.cents, &debit.createdAt); err != nil {
+			return nil, err
 		}
+		debits = append(debits, debit)
+	}
 
-		recordingCentsPerByte := int64(math.Round(data.BaseCosts.RecordingsPerByte * recordingSizeBytes))
-		charge, err := utils.ComputeAmountToCharge(float64(recordingCentsPerByte), remainingRecordings, recordingSizeBytes)
-		if err != nil {
-			logger.WithError(err).Error("error calculating recording charge")
-			continue
-		}
+	return debits, rows.Err()
+}
+
+// processCallDebit rates a call against the allowance, leaving it to the caller to add the charge. The toll of
+// a call on a plan with minute tiers is not billed, so it is not looked up in the rate deck either.
+func (s *BillingService) processCallDebit(costs *BillingCosts, debitID int, moduleID int, costCents int64, callRater *CallRater, tieredMinutes bool, logger *logrus.Entry) (CallCharge, error) {
+	call, err := s.workspaceRepository.GetCallFromDB(moduleID)
+	if err != nil {
+		logger.WithError(err).Error("error getting call")
+		return CallCharge{}, err
+	}
 
-		costs.RecordingCosts += int64(charge)
-		remainingRecordings -= recordingSizeBytes
+	fullCents, feeCents := costCents, int64(0)
+	if !tieredMinutes {
+		fullCents, feeCents = s.callCostCents(debitID, call, costCents, callRater.BillableSeconds(call.DurationNumber), costs, logger)
 	}
+	charge := callRater.RateWithConnectionFee(call.DurationNumber, fullCents, feeCents)
+	logger.Infof("processing call with duration %d seconds, billable %d seconds, %d seconds from allowance", call.DurationNumber, charge.BillableSeconds, charge.AllowanceSeconds)
 
-	return nil
+	return charge, nil
 }
 
-func (s *BillingService) processFaxes(data *BillingData, costs *BillingCosts, startStr, endStr string, logger *logrus.Entry) error {
-	rows, err := s.db.Query("SELECT id, created_at FROM faxes WHERE workspace_id = ? AND created_at BETWEEN ? AND ?", data.Workspace.Id, startStr, endStr)
-	if err != sql.ErrNoRows && err != nil {
-		logger.WithError(err).Error("error running faxes query")
+// processRecordings bills the GB-months of storage retained over the period beyond the plan's included space
+func (s *BillingService) processRecordings(data *BillingData, costs *BillingCosts, logger *logrus.Entry) error {
+	recordings, err := s.getRetainedRecordings(data.Workspace.Id, data.BillingPeriodStart, data.BillingPeriodEnd, logger)
+	if err != nil {
 		return err
 	}
-	defer rows.Close()
-
-	remainingFaxUnits := data.Plan.Fax
-
-	for rows.Next() {
-		var faxID int
-		var faxCreatedAt time.Time
 
-		if err := rows.Scan(&faxID, &faxCreatedAt); err != nil {
-			logger.WithError(err).Error("error scanning fax")
-			continue
-		}
+	schedule := data.priceSchedule(pricing.RecordingGBMonths, pricing.Flat(bytesPerGB*data.BaseCosts.RecordingsPerByte))
+	usage := ComputeStorageUsage(recordings, data.Plan, schedule, data.BillingPeriodStart, data.BillingPeriodEnd)
+	logger.Infof("Workspace retained %.4f GB-months of recordings, %.4f included, %.4f billed", usage.RetainedGBMonths, usage.IncludedGBMonths, usage.OverageGBMonths)
 
-		planFaxLimit := float64(data.Plan.Fax)
-		faxCentsPerUnit := data.BaseCosts.FaxPerUsed
-		charge, err := utils.ComputeAmountToCharge(faxCentsPerUnit, float64(remainingFaxUnits), planFaxLimit)
-		if err != nil {
-			logger.WithError(err).Error("error calculating fax charge")
-			continue
-		}
+	costs.RecordingCosts += usage.Cents
+	return nil
+}
 
-		costs.FaxCosts += int64(charge)
-		remainingFaxUnits--
+// processFaxes bills the fax pages sent and received beyond the plan's monthly page allowance
+func (s *BillingService) processFaxes(data *BillingData, costs *BillingCosts, logger *logrus.Entry) error {
+	usage, err := s.computeFaxUsage(data.Workspace.Id, data.Plan, data.Plan.Fax, data.BillingPeriodStart, data.BillingPeriodEnd, logger)
+	if err != nil {
+		return err
 	}
 
+	usage.Cents = PriceFaxPages(usage, data.FaxRates, data.PriceSchedules)
+
+	costs.FaxCosts += usage.Cents
 	return nil
 }
 
-func (s *BillingService) createInvoice(costs *BillingCosts, data *BillingData, logger *logrus.Entry) (int64, error) {
+// createInvoice inserts the invoice and claims the debits priced into it.
+// If another run claimed any of the debits first, the error rolls back the unit of work.
+func (s *BillingService) createInvoice(tx repository.Executor, costs *BillingCosts, data *BillingData, logger *logrus.Entry) (int64, error) {
 	logger.Infof("Creating invoice for user %d, on workspace %d, plan type %s", data.User.Id, data.Workspace.Id, data.Workspace.Plan)
 
-	insertStmt, err := s.db.Prepare("INSERT INTO users_invoices (`cents`, `cents_including_taxes`, `call_costs`, `recording_costs`, `fax_costs`, `membership_costs`, `number_costs`, `status`, `user_id`, `workspace_id`, `created_at`, `updated_at`, `source`, `tax_metadata`) VALUES ( ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
-	if err != nil {
-		logger.WithError(err).Error("could not prepare invoice insert query")
-		return 0, err
-	}
-	defer insertStmt.Close()
-
-	source := "SUBSCRIPTION"
-	taxMetadata := utils.CreateTaxMetadata(costs.CallTollsCosts, costs.RecordingCosts, costs.FaxCosts, costs.MembershipCosts, costs.NumberRentalCosts)
+	// taxes apply to what is left after discounts
+	taxMetadata := utils.CreateTaxMetadata(
+		costs.CallTollsCosts+promotions.Total(costs.Discounts, promotions.CategoryCalls),
+		costs.RecordingCosts+promotions.Total(costs.Discounts, promotions.CategoryRecordings),
+		costs.FaxCosts+promotions.Total(costs.Discounts, promotions.CategoryFax),
+		costs.MembershipCosts+promotions.Total(costs.Discounts, promotions.CategoryMembership),
+		costs.NumberRentalCosts+promotions.Total(costs.Discounts, promotions.CategoryNumbers),
+		costs.AddOnCosts+promotions.Total(costs.Discounts, promotions.CategoryAddOns))
 	helpers.Log(logrus.InfoLevel, fmt.Sprintf("Tax metadata for invoice: %s", taxMetadata))
 
 	// implement code to calculate taxes here and add to cents_including_taxes when we have tax logic in place
@@ -393,499 +588,192 @@ func (s *BillingService) createInvoice(costs *BillingCosts, data *BillingData, l
 	var taxes int64
 	taxes = 0
 	centsIncludingTaxes = costs.TotalCosts + taxes
-	result, err := insertStmt.Exec(costs.TotalCosts, centsIncludingTaxes, costs.CallTollsCosts, costs.RecordingCosts, costs.FaxCosts, costs.MembershipCosts, costs.NumberRentalCosts, "INCOMPLETE", data.Workspace.CreatorId, data.Workspace.Id, data.Now, data.Now, source, taxMetadata)
+	invoiceID, err := s.invoiceRepository.CreateInvoice(tx, &models.Invoice{
+		CreatedAt:           data.Now,
+		Source:              "SUBSCRIPTION",
+		TaxMetadata:         taxMetadata,
+		UserID:              data.Workspace.CreatorId,
+		WorkspaceID:         data.Workspace.Id,
+		SubscriptionID:      data.SubscriptionID,
+		BillingTerm:         data.Term.Name(),
+		PeriodStart:         data.BillingPeriodStart,
+		PeriodEnd:           data.BillingPeriodEnd,
+		Cents:               costs.TotalCosts,
+		CentsIncludingTaxes: centsIncludingTaxes,
+		CallCosts:           costs.CallTollsCosts,
+		RecordingCosts:      costs.RecordingCosts,
+		FaxCosts:            costs.FaxCosts,
+		MembershipCosts:     costs.MembershipCosts,
+		NumberCosts:         costs.NumberRentalCosts,
+		AddOnCosts:          costs.AddOnCosts,
+		DiscountCosts:       costs.DiscountCosts,
+		ContractVersion:     data.contractVersion(),
+		CatalogVersion:      data.catalogVersion(),
+		CollectionMethod:    data.collectionMethod(),
+		DueDate:             data.dueDate(),
+	})
 	if err != nil {
 		logger.WithError(err).Error("error creating invoice")
 		return 0, err
 	}
 
-	invoiceID, err := result.LastInsertId()
-	if err != nil {
-		logger.WithError(err).Error("could not get insert id")
+	if err := s.invoiceRepository.CreateLineItems(tx, invoiceID, costs.LineItems, data.Now); err != nil {
+		logger.WithError(err).Error("error writing invoice line items")
 		return 0, err
 	}
 
-	return invoiceID, nil
-}
-
-func (s *BillingService) chargeInvoice(invoiceID int64, costs *BillingCosts, data *BillingData, logger *logrus.Entry) error {
-	logger.Infof("Charging user %d, on workspace %d, plan type %s", data.User.Id, data.Workspace.Id, data.Workspace.Plan)
-
-	if data.Plan.PayAsYouGo {
-		return s.chargeWithCredits(invoiceID, costs, data, logger)
+	if costs.Allowance != nil {
+		if err := s.allowanceRepository.SaveAllowancePeriod(tx, invoiceID, data.SubscriptionID, *costs.Allowance, data.Now); err != nil {
+			logger.WithError(err).Error("error recording allowance")
+			return 0, err
+		}
 	}
-	return s.chargeWithCard(invoiceID, costs, data, logger)
-}
 
-func (s *BillingService) chargeWithCredits(invoiceID int64, costs *BillingCosts, data *BillingData, logger *logrus.Entry) error {
-	remainingBalance := int64(data.BillingInfo.RemainingBalanceCents)
-
-	if remainingBalance >= int64(costs.TotalCosts) {
-		return s.chargeCreditsOnly(invoiceID, int64(costs.TotalCosts), logger)
+	if err := s.promotionRepository.CreateInvoiceDiscounts(tx, invoiceID, costs.Discounts, data.Now); err != nil {
+		logger.WithError(err).Error("error writing invoice discounts")
+		return 0, err
 	}
-
-	logger.Warn("Insufficient credits for payment")
-	return s.markInvoiceChargeIncomplete(invoiceID, logger)
-}
-
-func (s *BillingService) chargeCreditsOnly(invoiceID int64, totalCosts int64, logger *logrus.Entry) error {
-	logger.Info("User has enough credits. Charging balance")
-
-	confNumber, err := utils.CreateInvoiceConfirmationNumber()
-	if err != nil {
-		logger.WithError(err).Error("error generating confirmation number")
-		return err
+	if err := s.promotionRepository.MarkRedemptionsApplied(tx, promotions.RedemptionIDs(costs.Discounts)); err != nil {
+		logger.WithError(err).Error("error updating coupon redemptions")
+		return 0, err
 	}
 
-	updateStmt, err := s.db.Prepare("UPDATE users_invoices SET status = 'COMPLETE', source ='CREDITS', cents_collected = ?, confirmation_number = ? WHERE id = ?")
-	if err != nil {
-		logger.WithError(err).Error("could not prepare update query")
-		return err
+	if err := s.invoiceRepository.ClaimDebits(tx, invoiceID, costs.DebitIDs); err != nil {
+		logger.WithError(err).Error("error claiming debits for invoice")
+		return 0, err
+	}
+	if err := s.invoiceRepository.RecordCallBillings(tx, costs.CallBillings); err != nil {
+		logger.WithError(err).Error("error recording call billings")
+		return 0, err
 	}
-	defer updateStmt.Close()
 
-	_, err = updateStmt.Exec(totalCosts, confNumber, invoiceID)
-	if err != nil {
-		logger.WithError(err).Error("error updating invoice")
-		return err
+	if err := s.invoiceRepository.TransitionInvoice(tx, invoiceID, invoice.Open, "finalized", data.Now); err != nil {
+		logger.WithError(err).Error("error opening invoice")
+		return 0, err
 	}
 
-	return nil
+	logger.Infof("Invoice %d claimed %d debits", invoiceID, len(costs.DebitIDs))
+	return invoiceID, nil
 }
 
-
-
-func (s *BillingService) chargeWithCard(invoiceID int64, costs *BillingCosts, data *BillingData, logger *logrus.Entry) error {
-	logger.Info("Charging recurringly with card")
-
-	cardChargeAmount := int(math.Ceil(float64(costs.TotalCosts)))
-	cardChargeAmount = 200
-	logger.Info(fmt.Sprintf("Total costs to charge on card is %d cents", cardChargeAmount))
-
-	invoice := models.UserInvoice{
-		Id:          int(invoiceID),
-		Cents:       cardChargeAmount,
-		InvoiceDesc: costs.InvoiceDesc,
+// settleWithoutGateway settles invoices that need no card charge inside the unit of work.
+// It returns false when the invoice has to be charged on a card.
+func (s *BillingService) settleWithoutGateway(tx repository.Executor, invoiceID int64, costs *BillingCosts, data *BillingData, logger *logrus.Entry) (bool, error) {
+	if costs.TotalCosts <= 0 {
+		logger.Info("Nothing to charge for this period")
+		return true, s.settleInvoice(tx, invoiceID, "SUBSCRIPTION", 0, data.Now, logger)
 	}
 
-	err := s.paymentRepository.ChargeCustomer(data.BillingParams.(*utils.BillingParams), data.User, data.Workspace, &invoice)
-	if err != nil {
-		logger.WithError(err).Error("error charging user")
-		s.markInvoiceChargeIncomplete(invoiceID, logger)
-		return err
+	if data.sendsInvoice() {
+		logger.Infof("Invoice is due on %s, sending it for payment instead of charging", data.dueDate().Format(time.DateOnly))
+		return true, nil
 	}
 
-	return s.markInvoiceChargeSuccess(invoiceID, int64(costs.TotalCosts), logger)
-}
-
-func (s *BillingService) markInvoiceSuccess(invoiceID int64, totalCosts int64, now time.Time, logger *logrus.Entry) error {
-	successStmt, err := s.db.Prepare("UPDATE users_invoices SET status = 'COMPLETE', source ='CARD', cents_collected = ?, last_attempted = ?, num_attempts = 1 WHERE id = ?")
-	if err != nil {
-		logger.WithError(err).Error("could not prepare update query")
-		return err
+	if !data.Plan.PayAsYouGo {
+		return false, nil
 	}
-	defer successStmt.Close()
 
-	_, err = successStmt.Exec(totalCosts, now, invoiceID)
-	if err != nil {
-		logger.WithError(err).Error("error updating invoice")
-		return err
+	if data.BillingInfo.RemainingBalanceCents >= costs.TotalCosts {
+		logger.Info("User has enough credits. Charging balance")
+		return true, s.settleInvoice(tx, invoiceID, "CREDITS", costs.TotalCosts, data.Now, logger)
 	}
 
-	return nil
+	logger.Warn("Insufficient credits for payment")
+	return true, nil
 }
 
-func (s *BillingService) markInvoiceFailed(invoiceID int64, now time.Time, logger *logrus.Entry) error {
-	failStmt, err := s.db.Prepare("UPDATE users_invoices SET source = 'CARD', status = 'INCOMPLETE', num_attempts = 1, last_attempted = ? WHERE id = ?")
-	if err != nil {
-		logger.WithError(err).Error("could not prepare update query")
-		return err
+// recordCardCharge writes the outbox entry for the card charge that follows the unit of work
+func (s *BillingService) recordCardCharge(tx repository.Executor, invoiceID int64, costs *BillingCosts, data *BillingData, logger *logrus.Entry) (*models.OutboxEntry, error) {
+	entry := &models.OutboxEntry{
+		CreatedAt:      data.Now,
+		IdempotencyKey: InvoiceIdempotencyKey(invoiceID),
+		Status:         models.OutboxPending,
+		InvoiceID:      invoiceID,
+		WorkspaceID:    data.Workspace.Id,
+		UserID:         data.User.Id,
+		Cents:          costs.TotalCosts,
 	}
-	defer failStmt.Close()
 
-	_, err = failStmt.Exec(now, invoiceID)
+	id, err := s.invoiceRepository.CreateOutboxEntry(tx, entry)
 	if err != nil {
-		logger.WithError(err).Error("error updating invoice")
-		return err
+		logger.WithError(err).Error("error recording card charge in outbox")
+		return nil, err
 	}
+	entry.Id = id
 
-	return nil
+	return entry, nil
 }
 
-func (s *BillingService) markInvoiceChargeIncomplete(invoiceID int64, logger *logrus.Entry) error {
-	updateStmt, err := s.db.Prepare("UPDATE users_invoices SET status = 'INCOMPLETE' WHERE id = ?")
-	if err != nil {
-		logger.WithError(err).Error("could not prepare update query")
-		return err
-	}
-	defer updateStmt.Close()
-
-	_, err = updateStmt.Exec(invoiceID)
-	if err != nil {
-		logger.WithError(err).Error("error updating invoice")
-		return err
-	}
+// chargeWithCard runs the gateway charge outside of any transaction, then settles the
+// invoice and its outbox entry together. If the worker stops in between, the entry
+// stays pending and is picked up by ReconcileOutbox.
+func (s *BillingService) chargeWithCard(entry *models.OutboxEntry, costs *BillingCosts, data *BillingData, logger *logrus.Entry) error {
+	logger.Info("Charging recurringly with card")
 
-	return nil
-}
+	cardChargeAmount := int(math.Ceil(float64(costs.TotalCosts)))
+	logger.Info(fmt.Sprintf("Total costs to charge on card is %d cents", cardChargeAmount))
 
-func (s *BillingService) markInvoiceChargeSuccess(invoiceID int64, totalCosts int64, logger *logrus.Entry) error {
-	confirmNumber, err := utils.CreateInvoiceConfirmationNumber()
-	if err != nil {
-		logger.WithError(err).Error("error generating confirmation number")
-		return err
+	userInvoice := models.UserInvoice{
+		Id:             int(entry.InvoiceID),
+		Cents:          cardChargeAmount,
+		InvoiceDesc:    costs.InvoiceDesc,
+		IdempotencyKey: entry.IdempotencyKey,
 	}
 
-	finalStmt, err := s.db.Prepare("UPDATE users_invoices SET status = 'COMPLETE', source ='CARD', cents_collected = ?, confirmation_number = ? WHERE id = ?")
-	if err != nil {
-		logger.WithError(err).Error("could not prepare update query")
-		return err
+	chargeErr := s.paymentRepository.ChargeCustomer(data.BillingParams.(*utils.BillingParams), data.User, data.Workspace, &userInvoice)
+	if chargeErr != nil {
+		logger.WithError(chargeErr).Error("error charging user")
 	}
-	defer finalStmt.Close()
 
-	_, err = finalStmt.Exec(totalCosts, confirmNumber, invoiceID)
+	settledAt := time.Now()
+	err := s.unitOfWork.Do(func(tx repository.Executor) error {
+		return s.settleCardCharge(tx, entry, userInvoice.PaymentReference, chargeErr, settledAt, logger)
+	})
 	if err != nil {
-		logger.WithError(err).Error("error updating invoice")
+		logger.WithError(err).Error("error settling card charge")
 		return err
 	}
-
-	return nil
-}
-
-func (s *BillingService) processAnnual(task models.BillingTask) error {
-	conn := utils.NewDBConn(s.db)
-	logger := logrus.WithField("component", "annual_billing").WithField("workspace_id", task.WorkspaceID)
-
-	billingParams, err := conn.GetBillingParams()
-	if err != nil {
-		logger.WithError(err).Error("error getting billing params")
-		return err
+	if chargeErr == nil {
+		s.reactivateAfterPayment(entry.WorkspaceID, settledAt, logger)
 	}
 
-	now := time.Now()
-	billingPeriodStart := now.AddDate(-1, 0, 0)
-	billingPeriodEnd := now
-	billingPeriodStartStr := billingPeriodStart.Format(time.DateTime)
-	billingPeriodEndStr := billingPeriodEnd.Format(time.DateTime)
-
-	workspace, err := s.workspaceRepository.GetWorkspaceFromDB(task.WorkspaceID)
-	if err != nil {
-		logger.WithError(err).Error("error getting workspace")
-		return err
-	}
+	return chargeErr
+}
 
-	user, err := s.workspaceRepository.GetUserFromDB(task.CreatorID)
-	if err != nil {
-		logger.WithError(err).Error("error getting user")
-		return err
+// settleCardCharge applies the outcome of a gateway charge to the invoice and its outbox entry
+func (s *BillingService) settleCardCharge(tx repository.Executor, entry *models.OutboxEntry, paymentReference string, chargeErr error, at time.Time, logger *logrus.Entry) error {
+	if chargeErr != nil {
+		if err := s.invoiceRepository.RecordFailedAttempt(tx, entry.InvoiceID, "CARD", at); err != nil {
+			logger.WithError(err).Error("error updating invoice")
+			return err
+		}
+		return s.invoiceRepository.UpdateOutboxEntry(tx, entry.Id, models.OutboxFailed, paymentReference, chargeErr.Error())
 	}
 
-	plans, err := s.paymentRepository.GetServicePlans()
-	if err != nil {
-		logger.WithError(err).Error("error getting service plans")
+	if err := s.settleInvoice(tx, entry.InvoiceID, "CARD", entry.Cents, at, logger); err != nil {
 		return err
 	}
+	return s.invoiceRepository.UpdateOutboxEntry(tx, entry.Id, models.OutboxSucceeded, paymentReference, "")
+}
 
-	subscription, err := s.paymentRepository.GetSubscription(task.SubscriptionID)
+func (s *BillingService) settleInvoice(tx repository.Executor, invoiceID int64, source string, totalCosts int64, at time.Time, logger *logrus.Entry) error {
+	confirmNumber, err := utils.CreateInvoiceConfirmationNumber()
 	if err != nil {
-		logger.WithError(err).Error("error getting user")
+		logger.WithError(err).Error("error generating confirmation number")
 		return err
 	}
 
-	plan := utils.GetPlanBySubscription(plans, subscription)
-	if plan == nil {
-		logger.Error("plan is nil")
-		return fmt.Errorf("plan not found for subscription")
-	}
-
-	billingInfo, err := s.workspaceRepository.GetWorkspaceBillingInfo(workspace)
+	err = s.invoiceRepository.RecordPayment(tx, invoiceID, source, totalCosts, confirmNumber, at)
 	if err != nil {
-		logger.WithError(err).Error("error getting billing info")
+		logger.WithError(err).Error("error updating invoice")
 		return err
 	}
 
-	baseCosts, err := helpers.GetBaseCosts()
+	err = s.invoiceRepository.TransitionInvoice(tx, invoiceID, invoice.Paid, fmt.Sprintf("paid with %s", strings.ToLower(source)), at)
 	if err != nil {
-		logger.WithError(err).Error("error getting base costs")
+		logger.WithError(err).Error("error marking invoice paid")
 		return err
 	}
 
-	userCount := utils.GetWorkspaceUserCount(s.db, workspace.Id)
-	logger.Infof("Workspace total user count %d", userCount)
-
-	totalCosts := int64(0)
-	annualMembershipCosts := int64(plan.BaseCosts * float64(userCount) * 12.0)
-	callTollsCosts := int64(0)
-	recordingCosts := int64(0)
-	faxCosts := int64(0)
-	numberRentalCosts := int64(0)
-	invoiceDesc := fmt.Sprintf("LineBlocs annual invoice for %s", billingInfo.InvoiceDue)
-
-	logger.Infof("Workspace total annual membership costs is %d", annualMembershipCosts)
-
-	debitsRows, err := s.db.Query(
-		"SELECT id, source, module_id, cents, created_at FROM users_debits WHERE user_id = ? AND created_at BETWEEN ? AND ?",
-		workspace.CreatorId, billingPeriodStartStr, billingPeriodEndStr,
-	)
-	if err != nil {
-		logger.WithError(err).Error("error running debits query")
-		return err
-	}
-	defer debitsRows.Close()
-
-    var debitID int
-    var debitSource string
-    var debitModuleID int
-    var debitCostCents int64
-    var debitCreatedAt time.Time
-
-    remainingAnnualMinutes := plan.MinutesPerMonth * 12
-    remainingAnnualRecordings := plan.RecordingSpace * 12
-    remainingAnnualFaxUnits := plan.Fax * 12
-
-    for debitsRows.Next() {
-        if err := debitsRows.Scan(&debitID, &debitSource, &debitModuleID, &debitCostCents, &debitCreatedAt); err != nil {
-            logger.WithError(err).Error("error scanning debit row")
-            continue
-        }
-
-        switch debitSource {
-        case "CALL":
-            call, err := s.workspaceRepository.GetCallFromDB(debitModuleID)
-            if err != nil {
-                logger.WithError(err).Error("error getting call")
-                continue
-            }
-
-            callDurationMinutes := float64(call.DurationNumber) / 60.0
-            charge, err := utils.ComputeAmountToCharge(float64(debitCostCents), remainingAnnualMinutes, callDurationMinutes)
-            if err != nil {
-                logger.WithError(err).Error("error computing call charge")
-                continue
-            }
-
-            callTollsCosts += int64(charge)
-            remainingAnnualMinutes -= callDurationMinutes
-
-        case "NUMBER_RENTAL":
-            did, err := s.workspaceRepository.GetDIDFromDB(debitModuleID)
-            if err != nil {
-                logger.WithError(err).Error("error getting DID")
-                continue
-            }
-            numberRentalCosts += int64(did.MonthlyCost)
-        }
-    }
-
-	recordingsRows, err := s.db.Query(
-		"SELECT id, size, created_at FROM recordings WHERE user_id = ? AND created_at BETWEEN ? AND ?",
-		workspace.CreatorId, billingPeriodStartStr, billingPeriodEndStr,
-	)
-	if err != sql.ErrNoRows && err != nil {
-		logger.WithError(err).Error("error running recordings query")
-		return err
-	}
-	defer recordingsRows.Close()
-
-    var recordingID int
-    var recordingSizeBytes float64
-    var recordingCreatedAt time.Time
-
-    for recordingsRows.Next() {
-        if err := recordingsRows.Scan(&recordingID, &recordingSizeBytes, &recordingCreatedAt); err != nil {
-            logger.WithError(err).Error("error scanning recording row")
-            continue
-        }
-
-        recordingCentsPerByte := int64(math.Round(baseCosts.RecordingsPerByte * recordingSizeBytes))
-        charge, err := utils.ComputeAmountToCharge(float64(recordingCentsPerByte), remainingAnnualRecordings, recordingSizeBytes)
-        if err != nil {
-            logger.WithError(err).Error("error calculating recording charge")
-            continue
-        }
-
-        recordingCosts += int64(charge)
-        remainingAnnualRecordings -= recordingSizeBytes
-    }
-
-	faxesRows, err := s.db.Query(
-		"SELECT id, created_at FROM faxes WHERE workspace_id = ? AND created_at BETWEEN ? AND ?",
-		workspace.Id, billingPeriodStartStr, billingPeriodEndStr,
-	)
-	if err != sql.ErrNoRows && err != nil {
-		logger.WithError(err).Error("error running faxes query")
-		return err
-	}
-	defer faxesRows.Close()
-
-    var faxID int
-    var faxCreatedAt time.Time
-
-    for faxesRows.Next() {
-        if err := faxesRows.Scan(&faxID, &faxCreatedAt); err != nil {
-            logger.WithError(err).Error("error scanning fax row")
-            continue
-        }
-
-        faxCentsPerUnit := baseCosts.FaxPerUsed
-        charge, err := utils.ComputeAmountToCharge(faxCentsPerUnit, float64(remainingAnnualFaxUnits), float64(plan.Fax*12))
-        if err != nil {
-            logger.WithError(err).Error("error calculating fax charge")
-            continue
-        }
-
-        faxCosts += int64(charge)
-        remainingAnnualFaxUnits--
-    }
-
-    totalCosts = annualMembershipCosts + callTollsCosts + recordingCosts + faxCosts + numberRentalCosts
-
-    logger.Infof(
-        "Final annual costs are membership: %d, call tolls: %d, recordings: %d, fax: %d, did rentals: %d, total: %d (cents)",
-        annualMembershipCosts, callTollsCosts, recordingCosts, faxCosts, numberRentalCosts, totalCosts,
-    )
-
-    annualCosts := &BillingCosts{
-        MembershipCosts:   annualMembershipCosts,
-        CallTollsCosts:    callTollsCosts,
-        RecordingCosts:    recordingCosts,
-        FaxCosts:          faxCosts,
-        NumberRentalCosts: numberRentalCosts,
-        TotalCosts:        totalCosts,
-        InvoiceDesc:       invoiceDesc,
-    }
-
-    annualBillingData := &BillingData{
-        Workspace:         workspace,
-        User:              user,
-        BillingInfo:       billingInfo,
-        Now:               now,
-    }
-
-    invoiceID, err := s.createInvoice(annualCosts, annualBillingData, logger)
-    if err != nil {
-        return err
-    }
-
-    if plan.PayAsYouGo {
-        remainingBalance := billingInfo.RemainingBalanceCents
-        balanceAfterCharge := remainingBalance - int64(totalCosts)
-        chargeAmount, err := utils.ComputeAmountToCharge(float64(totalCosts), float64(remainingBalance), float64(balanceAfterCharge))
-        if err != nil {
-            logger.WithError(err).Error("error calculating charge amount")
-            return err
-        }
-
-        if remainingBalance >= int64(totalCosts) {
-            confNumber, err := utils.CreateInvoiceConfirmationNumber()
-            if err != nil {
-                logger.WithError(err).Error("error generating confirmation number")
-                return err
-            }
-
-            updateStmt, err := s.db.Prepare("UPDATE users_invoices SET status = 'COMPLETE', source ='CREDITS', cents_collected = ?, confirmation_number = ? WHERE id = ?")
-            if err != nil {
-                logger.WithError(err).Error("could not prepare update query")
-                return err
-            }
-            defer updateStmt.Close()
-            _, err = updateStmt.Exec(int64(totalCosts), confNumber, invoiceID)
-            if err != nil {
-                logger.WithError(err).Error("error updating invoice")
-                return err
-            }
-        } else {
-            updateStmt, err := s.db.Prepare("UPDATE users_invoices SET status = 'INCOMPLETE', source ='CREDITS', cents_collected = ? WHERE id = ?")
-            if err != nil {
-                logger.WithError(err).Error("could not prepare update query")
-                return err
-            }
-            defer updateStmt.Close()
-            _, err = updateStmt.Exec(int64(chargeAmount), invoiceID)
-            if err != nil {
-                logger.WithError(err).Error("error updating invoice")
-                return err
-            }
-
-            cardChargeAmount := int(math.Ceil(chargeAmount))
-            invoice := models.UserInvoice{
-                Id:          int(invoiceID),
-                Cents:       cardChargeAmount,
-                InvoiceDesc: invoiceDesc,
-            }
-
-            err = s.paymentRepository.ChargeCustomer(billingParams, user, workspace, &invoice)
-            if err != nil {
-                logger.WithError(err).Error("error charging customer card")
-                failStmt, err := s.db.Prepare("UPDATE users_invoices SET source = 'CARD', status = 'INCOMPLETE', num_attempts = 1, last_attempted = ? WHERE id = ?")
-                if err != nil {
-                    logger.WithError(err).Error("could not prepare update query")
-                    return err
-                }
-                defer failStmt.Close()
-                _, err = failStmt.Exec(now, invoiceID)
-                if err != nil {
-                    logger.WithError(err).Error("error updating invoice")
-                    return err
-                }
-                return err
-            }
-
-            successStmt, err := s.db.Prepare("UPDATE users_invoices SET status = 'COMPLETE', source ='CARD', cents_collected = ?, last_attempted = ?, num_attempts = 1 WHERE id = ?")
-            if err != nil {
-                logger.WithError(err).Error("could not prepare update query")
-                return err
-            }
-            defer successStmt.Close()
-            _, err = successStmt.Exec(int64(totalCosts), now, invoiceID)
-            if err != nil {
-                logger.WithError(err).Error("error updating invoice")
-                return err
-            }
-        }
-    } else {
-        cardChargeAmount := int(math.Ceil(float64(totalCosts)))
-        invoice := models.UserInvoice{
-            Id:          int(invoiceID),
-            Cents:       cardChargeAmount,
-            InvoiceDesc: invoiceDesc,
-        }
-
-        err := s.paymentRepository.ChargeCustomer(billingParams, user, workspace, &invoice)
-        if err != nil {
-            logger.WithError(err).Error("error charging user")
-            updateStmt, err := s.db.Prepare("UPDATE users_invoices SET status = 'INCOMPLETE', source = 'CARD', cents_collected = 0 WHERE id = ?")
-            if err != nil {
-                logger.WithError(err).Error("could not prepare update query")
-                return err
-            }
-            defer updateStmt.Close()
-            _, err = updateStmt.Exec(invoiceID)
-            if err != nil {
-                logger.WithError(err).Error("error updating invoice")
-                return err
-            }
-            return err
-        }
-
-        confirmNumber, err := utils.CreateInvoiceConfirmationNumber()
-        if err != nil {
-            logger.WithError(err).Error("error generating confirmation number")
-            return err
-        }
-
-        finalStmt, err := s.db.Prepare("UPDATE users_invoices SET status = 'COMPLETE', source ='CARD', cents_collected = ?, confirmation_number = ? WHERE id = ?")
-        if err != nil {
-            logger.WithError(err).Error("could not prepare update query")
-            return err
-        }
-        defer finalStmt.Close()
-        _, err = finalStmt.Exec(int64(totalCosts), confirmNumber, invoiceID)
-        if err != nil {
-            logger.WithError(err).Error("error updating invoice")
-            return err
-        }
-    }
-
-    return nil
-}
\ No newline at end of file
+	return nil
+}
@@ -0,0 +1,193 @@
+package billing
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"lineblocs.com/scheduler/internal/invoice"
+	"lineblocs.com/scheduler/internal/pricing"
+	"lineblocs.com/scheduler/internal/ratedeck"
+	"lineblocs.com/scheduler/mocks"
+	"lineblocs.com/scheduler/models"
+	"lineblocs.com/scheduler/utils"
+)
+
+func TestSettleCardCharge(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	entry := &models.OutboxEntry{Id: 3, InvoiceID: 9, Cents: 1500}
+	logger := logrus.WithField("test", t.Name())
+
+	t.Run("Should settle the invoice and the outbox entry after a charge", func(t *testing.T) {
+		t.Parallel()
+
+		invoiceRepository := mocks.NewInvoiceRepository(t)
+		invoiceRepository.On("RecordPayment", nil, int64(9), "CARD", int64(1500), mock.AnythingOfType("string"), at).Return(nil)
+		invoiceRepository.On("TransitionInvoice", nil, int64(9), invoice.Paid, "paid with card", at).Return(nil)
+		invoiceRepository.On("UpdateOutboxEntry", nil, int64(3), models.OutboxSucceeded, "pi_123", "").Return(nil)
+
+		s := &BillingService{invoiceRepository: invoiceRepository}
+		assert.NoError(t, s.settleCardCharge(nil, entry, "pi_123", nil, at, logger))
+	})
+
+	t.Run("Should leave the invoice unpaid when the charge failed", func(t *testing.T) {
+		t.Parallel()
+
+		invoiceRepository := mocks.NewInvoiceRepository(t)
+		invoiceRepository.On("RecordFailedAttempt", nil, int64(9), "CARD", at).Return(nil)
+		invoiceRepository.On("UpdateOutboxEntry", nil, int64(3), models.OutboxFailed, "", "card declined").Return(nil)
+
+		s := &BillingService{invoiceRepository: invoiceRepository}
+		assert.NoError(t, s.settleCardCharge(nil, entry, "", errors.New("card declined"), at, logger))
+	})
+}
+
+func TestChargeWithCard(t *testing.T) {
+	t.Parallel()
+
+	entry := &models.OutboxEntry{Id: 3, InvoiceID: 9, WorkspaceID: 7, Cents: 4321, IdempotencyKey: "charge-9"}
+	data := &BillingData{BillingParams: &utils.BillingParams{Provider: "stripe"}, User: &helpers.User{Id: 5}, Workspace: &helpers.Workspace{Id: 7}}
+
+	// the card is charged the invoice total
+	paymentRepository := mocks.NewPaymentRepository(t)
+	paymentRepository.On("ChargeCustomer", data.BillingParams, data.User, data.Workspace, mock.MatchedBy(func(userInvoice *models.UserInvoice) bool {
+		return userInvoice.Id == 9 && userInvoice.Cents == 4321 && userInvoice.IdempotencyKey == "charge-9"
+	})).Return(nil)
+
+	invoiceRepository := mocks.NewInvoiceRepository(t)
+	invoiceRepository.On("RecordPayment", nil, int64(9), "CARD", int64(4321), mock.AnythingOfType("string"), mock.Anything).Return(nil)
+	invoiceRepository.On("TransitionInvoice", nil, int64(9), invoice.Paid, "paid with card", mock.Anything).Return(nil)
+	invoiceRepository.On("UpdateOutboxEntry", nil, int64(3), models.OutboxSucceeded, "", "").Return(nil)
+
+	unitOfWork := mocks.NewUnitOfWork(t)
+	unitOfWork.On("Do", mock.Anything).Return(runUnitOfWork)
+
+	s := &BillingService{paymentRepository: paymentRepository, invoiceRepository: invoiceRepository, unitOfWork: unitOfWork}
+	assert.NoError(t, s.chargeWithCard(entry, &BillingCosts{TotalCosts: 4321}, data, logrus.WithField("test", t.Name())))
+}
+
+func TestSettleWithoutGateway(t *testing.T) {
+	t.Parallel()
+
+	logger := logrus.WithField("test", t.Name())
+
+	testCases := []struct {
+		Name           string
+		Plan           *helpers.ServicePlan
+		Collection     *models.CollectionTerms
+		BalanceCents   int64
+		TotalCosts     int64
+		ExpectedSource string
+		ExpectedSettle bool
+	}{
+		{Name: "pay as you go with enough credits", Plan: &helpers.ServicePlan{PayAsYouGo: true}, BalanceCents: 2000, TotalCosts: 1500, ExpectedSource: "CREDITS", ExpectedSettle: true},
+		{Name: "pay as you go without enough credits stays unpaid", Plan: &helpers.ServicePlan{PayAsYouGo: true}, BalanceCents: 1000, TotalCosts: 1500, ExpectedSettle: true},
+		{Name: "empty invoice is settled without a charge", Plan: &helpers.ServicePlan{}, TotalCosts: 0, ExpectedSource: "SUBSCRIPTION", ExpectedSettle: true},
+		{Name: "card plans are left for the gateway", Plan: &helpers.ServicePlan{}, TotalCosts: 1500, ExpectedSettle: false},
+		{Name: "net terms invoices wait for payment without a charge", Plan: &helpers.ServicePlan{PayAsYouGo: true}, Collection: &models.CollectionTerms{Method: models.CollectionSendInvoice, NetDays: 30}, BalanceCents: 2000, TotalCosts: 1500, ExpectedSettle: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			invoiceRepository := mocks.NewInvoiceRepository(t)
+			if tc.ExpectedSource != "" {
+				invoiceRepository.On("RecordPayment", nil, int64(9), tc.ExpectedSource, tc.TotalCosts, mock.AnythingOfType("string"), mock.Anything).Return(nil)
+				invoiceRepository.On("TransitionInvoice", nil, int64(9), invoice.Paid, mock.AnythingOfType("string"), mock.Anything).Return(nil)
+			}
+
+			s := &BillingService{invoiceRepository: invoiceRepository}
+			data := &BillingData{
+				Plan:        tc.Plan,
+				Collection:  tc.Collection,
+				BillingInfo: &helpers.WorkspaceBillingInfo{RemainingBalanceCents: tc.BalanceCents},
+			}
+
+			settled, err := s.settleWithoutGateway(nil, 9, &BillingCosts{TotalCosts: tc.TotalCosts}, data, logger)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.ExpectedSettle, settled)
+		})
+	}
+}
+
+func TestProcessDebitsMinuteTiers(t *testing.T) {
+	t.Parallel()
+
+	end := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	logger := logrus.WithField("test", t.Name())
+	deck, err := ratedeck.Load(strings.NewReader("1,6,10,,\n"), "2026-01")
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		Name           string
+		PriceSchedules map[string]pricing.Schedule
+		Expected       *BillingCosts
+	}{
+		{
+			Name: "Should charge each call its deck toll after the allowance and flag stored debits that differ",
+			Expected: &BillingCosts{
+				CallTollsCosts: 44,
+				RatingDiscrepancies: []RatingDiscrepancy{
+					{Number: "15550001111", Prefix: "1", DeckVersion: "2026-01", DebitID: 1, StoredCents: 100, RatedCents: 22},
+					{Number: "15550002222", Prefix: "1", DeckVersion: "2026-01", DebitID: 2, StoredCents: 100, RatedCents: 28},
+				},
+				DebitIDs: []int{1, 2},
+				// the allowance covers the first call's first minute but not its connection fee
+				CallBillings: []models.CallBilling{{DebitID: 1, PlanID: 2, ChargeableSeconds: 60, BilledCents: 16}, {DebitID: 2, PlanID: 2, ChargeableSeconds: 180, BilledCents: 28}},
+			},
+		},
+		{
+			Name:           "Should price the chargeable minutes on the tiers instead of the deck tolls",
+			PriceSchedules: map[string]pricing.Schedule{pricing.Minutes: pricing.Flat(2)},
+			Expected:       &BillingCosts{CallTollsCosts: 8, DebitIDs: []int{1, 2}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			db, sqlMock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			sqlMock.ExpectQuery(regexp.QuoteMeta("SELECT id, source, module_id, cents, created_at FROM users_debits")).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "source", "module_id", "cents", "created_at"}).
+					AddRow(1, "CALL", 11, 100, end.AddDate(0, 0, -5)).
+					AddRow(2, "CALL", 12, 100, end.AddDate(0, 0, -4)))
+
+			workspaceRepository := mocks.NewWorkspaceRepository(t)
+			workspaceRepository.On("GetCallFromDB", 11).Return(&helpers.Call{To: "15550001111", DurationNumber: 120}, nil)
+			workspaceRepository.On("GetCallFromDB", 12).Return(&helpers.Call{To: "15550002222", DurationNumber: 180}, nil)
+			allowanceRepository := mocks.NewAllowanceRepository(t)
+			allowanceRepository.On("GetAllowanceBalance", db, 4).Return(nil, nil)
+
+			s := &BillingService{workspaceRepository: workspaceRepository, allowanceRepository: allowanceRepository}
+			s.UseRateDeck(deck, RateDeckModeRate)
+
+			// one minute of allowance covers the first minute of the first call
+			data := &BillingData{
+				Workspace:        &helpers.Workspace{Id: 3, CreatorId: 2},
+				Plan:             &helpers.ServicePlan{Id: 2, MinutesPerMonth: 1},
+				PriceSchedules:   tc.PriceSchedules,
+				SubscriptionID:   4,
+				BillingPeriodEnd: end,
+			}
+			costs := &BillingCosts{}
+			assert.NoError(t, s.processDebits(db, data, costs, logger))
+			costs.Allowance = nil
+			assert.Equal(t, tc.Expected, costs)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
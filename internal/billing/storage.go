@@ -0,0 +1,113 @@
+package billing
+
+import (
+	"database/sql"
+	"math"
+	"time"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/scheduler/internal/pricing"
+)
+
+const (
+	bytesPerGB = 1 << 30
+	// go-helpers keeps a plan's included recording space in kilobytes (convertGbToKb)
+	recordingSpaceBytesPerUnit = 1 << 10
+	// add-ons and contracts give recording space in megabytes
+	recordingSpaceUnitsPerMB = 1 << 10
+)
+
+// RetainedRecording is a recording's size and the time it was kept in storage
+type RetainedRecording struct {
+	CreatedAt time.Time
+	DeletedAt sql.NullTime
+	SizeBytes float64
+}
+
+// StorageUsage is the retained storage of a workspace over a billing period
+type StorageUsage struct {
+	RetainedGBMonths float64
+	IncludedGBMonths float64
+	OverageGBMonths  float64
+	Cents            int64
+}
+
+// PeriodMonths returns the length of a period in calendar months, counting partial months by their days
+func PeriodMonths(start, end time.Time) float64 {
+	months := 0.0
+	cursor := start
+	for !cursor.AddDate(0, 1, 0).After(end) {
+		cursor = cursor.AddDate(0, 1, 0)
+		months++
+	}
+	if cursor.Before(end) {
+		monthLength := cursor.AddDate(0, 1, 0).Sub(cursor)
+		months += float64(end.Sub(cursor)) / float64(monthLength)
+	}
+	return months
+}
+
+// RetainedGBMonths averages the bytes kept in storage over the period and scales it
+// by the period length, so 10GB kept for half of a one month period is 5 GB-months.
+func RetainedGBMonths(recordings []RetainedRecording, start, end time.Time) float64 {
+	periodSeconds := end.Sub(start).Seconds()
+	if periodSeconds <= 0 {
+		return 0
+	}
+
+	byteSeconds := 0.0
+	for _, recording := range recordings {
+		from := recording.CreatedAt
+		if from.Before(start) {
+			from = start
+		}
+		to := end
+		if recording.DeletedAt.Valid && recording.DeletedAt.Time.Before(end) {
+			to = recording.DeletedAt.Time
+		}
+		if !to.After(from) {
+			continue
+		}
+		byteSeconds += recording.SizeBytes * to.Sub(from).Seconds()
+	}
+
+	averageGB := byteSeconds / periodSeconds / bytesPerGB
+	return averageGB * PeriodMonths(start, end)
+}
+
+// ComputeStorageUsage bills the GB-months retained beyond the plan's included space at the given price schedule
+func ComputeStorageUsage(recordings []RetainedRecording, plan *helpers.ServicePlan, schedule pricing.Schedule, start, end time.Time) StorageUsage {
+	usage := StorageUsage{
+		RetainedGBMonths: RetainedGBMonths(recordings, start, end),
+		IncludedGBMonths: plan.RecordingSpace * recordingSpaceBytesPerUnit / bytesPerGB * PeriodMonths(start, end),
+	}
+
+	usage.OverageGBMonths = math.Max(0, usage.RetainedGBMonths-usage.IncludedGBMonths)
+	usage.Cents = int64(math.Round(schedule.Price(usage.OverageGBMonths)))
+	return usage
+}
+
+// getRetainedRecordings loads every recording of the workspace that was in storage at some point in the period
+func (s *BillingService) getRetainedRecordings(workspaceID int, start, end time.Time, logger *logrus.Entry) ([]RetainedRecording, error) {
+	rows, err := s.db.Query("SELECT id, size, created_at, deleted_at FROM recordings WHERE workspace_id = ? AND created_at < ? AND (deleted_at IS NULL OR deleted_at > ?)",
+		workspaceID, end.Format(time.DateTime), start.Format(time.DateTime))
+	if err != nil {
+		logger.WithError(err).Error("error running recordings query")
+		return nil, err
+	}
+	defer rows.Close()
+
+	recordings := make([]RetainedRecording, 0)
+	for rows.Next() {
+		var recordingID int
+		var recording RetainedRecording
+		if err := rows.Scan(&recordingID, &recording.SizeBytes, &recording.CreatedAt, &recording.DeletedAt); err != nil {
+			logger.WithError(err).Error("error scanning recording")
+			return nil, err
+		}
+		recordings = append(recordings, recording)
+	}
+
+	return recordings, rows.Err()
+}
@@ -0,0 +1,158 @@
+package billing
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"lineblocs.com/scheduler/internal/pricing"
+)
+
+func TestRetainedGBMonths(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	mid := time.Date(2026, 4, 16, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		Name       string
+		Recordings []RetainedRecording
+		Expected   float64
+	}{
+		{
+			Name:       "recording kept the whole period",
+			Recordings: []RetainedRecording{{CreatedAt: start.AddDate(0, -3, 0), SizeBytes: 2 * bytesPerGB}},
+			Expected:   2,
+		},
+		{
+			Name:       "recording created halfway through the period",
+			Recordings: []RetainedRecording{{CreatedAt: mid, SizeBytes: 2 * bytesPerGB}},
+			Expected:   1,
+		},
+		{
+			Name:       "recording deleted halfway through the period",
+			Recordings: []RetainedRecording{{CreatedAt: start.AddDate(-1, 0, 0), DeletedAt: sql.NullTime{Time: mid, Valid: true}, SizeBytes: 2 * bytesPerGB}},
+			Expected:   1,
+		},
+		{
+			Name:       "recording deleted before the period",
+			Recordings: []RetainedRecording{{CreatedAt: start.AddDate(-1, 0, 0), DeletedAt: sql.NullTime{Time: start.AddDate(0, 0, -1), Valid: true}, SizeBytes: 2 * bytesPerGB}},
+			Expected:   0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.InDelta(t, tc.Expected, RetainedGBMonths(tc.Recordings, start, end), 0.0001)
+		})
+	}
+}
+
+func TestComputeStorageUsage(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	// recording_space as go-helpers loads it, in kilobytes: 1 GB
+	plan := &helpers.ServicePlan{RecordingSpace: 1 << 20}
+	schedule := pricing.Flat(10)
+	recordings := []RetainedRecording{{CreatedAt: start, SizeBytes: 3 * bytesPerGB}}
+
+	t.Run("Should count the included space against total retained storage", func(t *testing.T) {
+		t.Parallel()
+
+		usage := ComputeStorageUsage(recordings, plan, schedule, start, start.AddDate(0, 1, 0))
+		assert.InDelta(t, 3, usage.RetainedGBMonths, 0.0001)
+		assert.InDelta(t, 1, usage.IncludedGBMonths, 0.0001)
+		assert.Equal(t, int64(20), usage.Cents)
+	})
+
+	t.Run("Should scale the included space by the period length", func(t *testing.T) {
+		t.Parallel()
+
+		usage := ComputeStorageUsage(recordings, plan, schedule, start, start.AddDate(1, 0, 0))
+		assert.InDelta(t, 36, usage.RetainedGBMonths, 0.0001)
+		assert.InDelta(t, 12, usage.IncludedGBMonths, 0.0001)
+		assert.Equal(t, int64(240), usage.Cents)
+	})
+
+	t.Run("Should price the overage on the plan's tiers", func(t *testing.T) {
+		t.Parallel()
+
+		tiers := pricing.Schedule{Mode: pricing.Graduated, Tiers: []pricing.Tier{{UpTo: 10, UnitCents: 10}, {UnitCents: 5}}}
+		usage := ComputeStorageUsage(recordings, plan, tiers, start, start.AddDate(1, 0, 0))
+		assert.Equal(t, int64(100+70), usage.Cents)
+	})
+
+	t.Run("Should read the plan's recording space in kilobytes", func(t *testing.T) {
+		t.Parallel()
+
+		// the 1024.0 default of go-helpers' createPlan is one megabyte
+		usage := ComputeStorageUsage(recordings, &helpers.ServicePlan{RecordingSpace: 1024.0}, schedule, start, start.AddDate(0, 1, 0))
+		assert.InDelta(t, 1.0/1024, usage.IncludedGBMonths, 0.0000001)
+		assert.Equal(t, int64(30), usage.Cents)
+	})
+}
+
+func TestGetRetainedRecordings(t *testing.T) {
+	t.Parallel()
+
+	logger := logrus.WithField("test", t.Name())
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	query := regexp.QuoteMeta("SELECT id, size, created_at, deleted_at FROM recordings WHERE workspace_id = ? AND created_at < ? AND (deleted_at IS NULL OR deleted_at > ?)")
+
+	t.Run("Should load the recordings kept in the period", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(query).WithArgs(3, end.Format(time.DateTime), start.Format(time.DateTime)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "size", "created_at", "deleted_at"}).AddRow(1, 2048.0, start, nil))
+
+		s := &BillingService{db: db}
+		recordings, err := s.getRetainedRecordings(3, start, end, logger)
+		assert.NoError(t, err)
+		assert.Equal(t, []RetainedRecording{{CreatedAt: start, SizeBytes: 2048}}, recordings)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Should return scan errors instead of billing a partial list", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(query).WithArgs(3, end.Format(time.DateTime), start.Format(time.DateTime)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "size", "created_at", "deleted_at"}).AddRow(1, "not a size", start, nil))
+
+		s := &BillingService{db: db}
+		_, err = s.getRetainedRecordings(3, start, end, logger)
+		assert.Error(t, err)
+	})
+
+	t.Run("Should return errors hit while reading rows", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(query).WithArgs(3, end.Format(time.DateTime), start.Format(time.DateTime)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "size", "created_at", "deleted_at"}).AddRow(1, 2048.0, start, nil).RowError(0, sql.ErrConnDone))
+
+		s := &BillingService{db: db}
+		_, err = s.getRetainedRecordings(3, start, end, logger)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+}
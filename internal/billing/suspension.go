@@ -0,0 +1,180 @@
+package billing
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/scheduler/internal/suspension"
+	"lineblocs.com/scheduler/models"
+	"lineblocs.com/scheduler/repository"
+)
+
+// SuspensionReport summarizes a payment status run
+type SuspensionReport struct {
+	Restricted  []int `json:"restricted"`
+	Suspended   []int `json:"suspended"`
+	Reactivated []int `json:"reactivated"`
+	Failed      []int `json:"failed"`
+	Reviewed    int   `json:"reviewed"`
+}
+
+// statusDecision picks a workspace's billing status from its current status and unpaid invoices
+type statusDecision func(current string, invoices []suspension.Invoice) (string, string)
+
+// EnforcePaymentStatus moves every workspace with unpaid invoices, or that is not active, to the status
+// the policy gives it at the given time. Workspaces whose invoices no longer reach a threshold are reactivated.
+func (s *BillingService) EnforcePaymentStatus(at time.Time, policy suspension.Policy) (*SuspensionReport, error) {
+	logger := logrus.WithField("component", "payment_status")
+
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	workspaceIDs, err := s.suspensionRepository.GetWorkspacesToReview(s.db)
+	if err != nil {
+		logger.WithError(err).Error("error getting workspaces to review")
+		return nil, err
+	}
+
+	decide := func(current string, invoices []suspension.Invoice) (string, string) {
+		return suspension.Evaluate(policy, invoices, at)
+	}
+
+	report := &SuspensionReport{}
+	for _, workspaceID := range workspaceIDs {
+		report.Reviewed++
+		workspaceLogger := logger.WithField("workspace_id", workspaceID)
+
+		event, err := s.reviewWorkspace(workspaceID, at, decide, policy.Numbers, workspaceLogger)
+		if err != nil {
+			workspaceLogger.WithError(err).Error("error reviewing workspace billing status")
+			report.Failed = append(report.Failed, workspaceID)
+			continue
+		}
+		if event == nil {
+			continue
+		}
+
+		switch event.To {
+		case suspension.Suspended:
+			report.Suspended = append(report.Suspended, workspaceID)
+		case suspension.Restricted:
+			report.Restricted = append(report.Restricted, workspaceID)
+		default:
+			report.Reactivated = append(report.Reactivated, workspaceID)
+		}
+	}
+
+	logger.Infof("Reviewed %d workspaces: %d restricted, %d suspended, %d reactivated, %d failed",
+		report.Reviewed, len(report.Restricted), len(report.Suspended), len(report.Reactivated), len(report.Failed))
+	return report, nil
+}
+
+// UsePaymentStatusPolicy sets the policy a payment is checked against to reactivate the workspace
+func (s *BillingService) UsePaymentStatusPolicy(policy suspension.Policy) {
+	s.paymentStatusPolicy = &policy
+}
+
+// ReactivateIfPaid evaluates the payment status policy after a payment and moves a restricted or suspended workspace
+// to the less restricted status the policy now gives it. A payment never restricts or suspends a workspace further,
+// that is left to EnforcePaymentStatus. It returns nil when the status did not change.
+func (s *BillingService) ReactivateIfPaid(workspaceID int, at time.Time) (*models.WorkspaceStatusEvent, error) {
+	logger := logrus.WithField("component", "payment_status").WithField("workspace_id", workspaceID)
+
+	policy := s.paymentStatusPolicy
+	if policy == nil {
+		return nil, fmt.Errorf("no payment status policy is set")
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	decide := func(current string, invoices []suspension.Invoice) (string, string) {
+		to, reason := suspension.Evaluate(*policy, invoices, at)
+		if !suspension.Lifts(current, to) {
+			return current, ""
+		}
+		return to, "payment received, " + reason
+	}
+	return s.reviewWorkspace(workspaceID, at, decide, policy.Numbers, logger)
+}
+
+// reactivateAfterPayment runs ReactivateIfPaid for a payment that has already been recorded, so errors are only logged.
+// Services without a payment status policy leave the status to EnforcePaymentStatus.
+func (s *BillingService) reactivateAfterPayment(workspaceID int, at time.Time, logger *logrus.Entry) {
+	if s.paymentStatusPolicy == nil {
+		return
+	}
+	if _, err := s.ReactivateIfPaid(workspaceID, at); err != nil {
+		logger.WithError(err).Error("error reactivating workspace after payment")
+	}
+}
+
+// reviewWorkspace applies the decided status in one unit of work: the workspace flag, what happens to its
+// numbers and the recorded event. Suspension holds or releases the numbers as configured, and leaving it
+// puts held numbers back in service; released numbers stay released. The event is published after commit.
+func (s *BillingService) reviewWorkspace(workspaceID int, at time.Time, decide statusDecision, numbers string, logger *logrus.Entry) (*models.WorkspaceStatusEvent, error) {
+	var event *models.WorkspaceStatusEvent
+	err := s.unitOfWork.Do(func(tx repository.Executor) error {
+		current, err := s.suspensionRepository.GetBillingStatus(tx, workspaceID)
+		if err != nil {
+			return err
+		}
+		invoices, err := s.suspensionRepository.GetUnpaidInvoices(tx, workspaceID)
+		if err != nil {
+			return err
+		}
+
+		to, reason := decide(current, invoices)
+		if to == current {
+			return nil
+		}
+
+		event = &models.WorkspaceStatusEvent{CreatedAt: at, From: current, To: to, Reason: reason, WorkspaceID: workspaceID}
+		switch {
+		case to == suspension.Suspended && numbers == suspension.NumbersHold:
+			event.Numbers = "held"
+			event.NumbersAffected, err = s.suspensionRepository.HoldNumbers(tx, workspaceID, at)
+		case to == suspension.Suspended && numbers == suspension.NumbersRelease:
+			event.Numbers = "released"
+			event.NumbersAffected, err = s.suspensionRepository.ReleaseNumbers(tx, workspaceID, at)
+		case current == suspension.Suspended:
+			event.Numbers = "restored"
+			event.NumbersAffected, err = s.suspensionRepository.RestoreNumbers(tx, workspaceID)
+		}
+		if err != nil {
+			return err
+		}
+
+		return s.suspensionRepository.SetBillingStatus(tx, *event)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return nil, nil
+	}
+
+	logger.Infof("Workspace moved from %s to %s: %s", event.From, event.To, event.Reason)
+	s.publishStatusEvent(event, logger)
+	return event, nil
+}
+
+// publishStatusEvent tells the app about the change. The change is already recorded in workspace_billing_events.
+func (s *BillingService) publishStatusEvent(event *models.WorkspaceStatusEvent, logger *logrus.Entry) {
+	if s.rabbitmqPublisher == nil {
+		return
+	}
+
+	messageBytes, err := json.Marshal(event)
+	if err != nil {
+		logger.WithError(err).Error("error marshaling workspace status event")
+		return
+	}
+
+	if err := s.rabbitmqPublisher.Publish("workspace_status", messageBytes); err != nil {
+		logger.WithError(err).Error("error publishing workspace status event")
+	}
+}
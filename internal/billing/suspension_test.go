@@ -0,0 +1,167 @@
+package billing
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"lineblocs.com/scheduler/internal/suspension"
+	"lineblocs.com/scheduler/mocks"
+	"lineblocs.com/scheduler/models"
+)
+
+// recordingPublisher keeps the messages published to each queue
+type recordingPublisher struct {
+	messages map[string][][]byte
+}
+
+func (p *recordingPublisher) Publish(queue string, message []byte) error {
+	if p.messages == nil {
+		p.messages = make(map[string][][]byte)
+	}
+	p.messages[queue] = append(p.messages[queue], message)
+	return nil
+}
+
+func TestEnforcePaymentStatus(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, 6, 1, 3, 0, 0, 0, time.UTC)
+	policy := suspension.Policy{
+		Numbers:  suspension.NumbersHold,
+		Restrict: suspension.Rule{FailedAttempts: 2},
+		Suspend:  suspension.Rule{DaysOverdue: 30},
+	}
+
+	suspensionRepository := mocks.NewSuspensionRepository(t)
+	suspensionRepository.On("GetWorkspacesToReview", mock.Anything).Return([]int{3, 4, 5}, nil)
+
+	// 3 is 40 days overdue and gets suspended
+	suspensionRepository.On("GetBillingStatus", nil, 3).Return(suspension.Restricted, nil)
+	suspensionRepository.On("GetUnpaidInvoices", nil, 3).Return([]suspension.Invoice{{ID: 9, FailedAttempts: 3, DueDate: at.AddDate(0, 0, -40)}}, nil)
+	suspensionRepository.On("HoldNumbers", nil, 3, at).Return(int64(2), nil)
+	suspensionRepository.On("SetBillingStatus", nil, models.WorkspaceStatusEvent{
+		CreatedAt: at, From: suspension.Restricted, To: suspension.Suspended, Reason: "invoice 9 is 40 days overdue", Numbers: "held", WorkspaceID: 3, NumbersAffected: 2,
+	}).Return(nil)
+
+	// 4 stays restricted
+	suspensionRepository.On("GetBillingStatus", nil, 4).Return(suspension.Restricted, nil)
+	suspensionRepository.On("GetUnpaidInvoices", nil, 4).Return([]suspension.Invoice{{ID: 10, FailedAttempts: 2, DueDate: at.AddDate(0, 0, -5)}}, nil)
+
+	// 5 paid and its held numbers go back in service
+	suspensionRepository.On("GetBillingStatus", nil, 5).Return(suspension.Suspended, nil)
+	suspensionRepository.On("GetUnpaidInvoices", nil, 5).Return([]suspension.Invoice{}, nil)
+	suspensionRepository.On("RestoreNumbers", nil, 5).Return(int64(1), nil)
+	suspensionRepository.On("SetBillingStatus", nil, models.WorkspaceStatusEvent{
+		CreatedAt: at, From: suspension.Suspended, To: suspension.Active, Reason: "no unpaid invoice is past the thresholds", Numbers: "restored", WorkspaceID: 5, NumbersAffected: 1,
+	}).Return(nil)
+
+	unitOfWork := mocks.NewUnitOfWork(t)
+	unitOfWork.On("Do", mock.Anything).Return(runUnitOfWork)
+	publisher := &recordingPublisher{}
+
+	s := &BillingService{suspensionRepository: suspensionRepository, unitOfWork: unitOfWork, rabbitmqPublisher: publisher}
+	report, err := s.EnforcePaymentStatus(at, policy)
+	assert.NoError(t, err)
+	assert.Equal(t, &SuspensionReport{Suspended: []int{3}, Reactivated: []int{5}, Reviewed: 3}, report)
+
+	if assert.Len(t, publisher.messages["workspace_status"], 2) {
+		var event models.WorkspaceStatusEvent
+		assert.NoError(t, json.Unmarshal(publisher.messages["workspace_status"][0], &event))
+		assert.Equal(t, suspension.Suspended, event.To)
+		assert.Equal(t, 3, event.WorkspaceID)
+	}
+}
+
+func TestReactivateIfPaid(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, 6, 1, 3, 0, 0, 0, time.UTC)
+	policy := suspension.Policy{
+		Numbers:  suspension.NumbersHold,
+		Restrict: suspension.Rule{FailedAttempts: 2},
+		Suspend:  suspension.Rule{DaysOverdue: 30},
+	}
+
+	t.Run("Should reactivate a workspace whose unpaid invoices are under the thresholds", func(t *testing.T) {
+		t.Parallel()
+
+		suspensionRepository := mocks.NewSuspensionRepository(t)
+		suspensionRepository.On("GetBillingStatus", nil, 3).Return(suspension.Restricted, nil)
+		suspensionRepository.On("GetUnpaidInvoices", nil, 3).Return([]suspension.Invoice{{ID: 10, FailedAttempts: 1, DueDate: at.AddDate(0, 0, -5)}}, nil)
+		suspensionRepository.On("SetBillingStatus", nil, models.WorkspaceStatusEvent{
+			CreatedAt: at, From: suspension.Restricted, To: suspension.Active, Reason: "payment received, no unpaid invoice is past the thresholds", WorkspaceID: 3,
+		}).Return(nil)
+		unitOfWork := mocks.NewUnitOfWork(t)
+		unitOfWork.On("Do", mock.Anything).Return(runUnitOfWork)
+
+		s := &BillingService{suspensionRepository: suspensionRepository, unitOfWork: unitOfWork}
+		s.UsePaymentStatusPolicy(policy)
+		event, err := s.ReactivateIfPaid(3, at)
+		assert.NoError(t, err)
+		assert.Equal(t, suspension.Active, event.To)
+	})
+
+	t.Run("Should lift a suspension to a restriction the policy still gives", func(t *testing.T) {
+		t.Parallel()
+
+		suspensionRepository := mocks.NewSuspensionRepository(t)
+		suspensionRepository.On("GetBillingStatus", nil, 3).Return(suspension.Suspended, nil)
+		suspensionRepository.On("GetUnpaidInvoices", nil, 3).Return([]suspension.Invoice{{ID: 10, FailedAttempts: 2, DueDate: at.AddDate(0, 0, -5)}}, nil)
+		suspensionRepository.On("RestoreNumbers", nil, 3).Return(int64(2), nil)
+		suspensionRepository.On("SetBillingStatus", nil, models.WorkspaceStatusEvent{
+			CreatedAt: at, From: suspension.Suspended, To: suspension.Restricted, Reason: "payment received, invoice 10 failed 2 collection attempts",
+			Numbers: "restored", WorkspaceID: 3, NumbersAffected: 2,
+		}).Return(nil)
+		unitOfWork := mocks.NewUnitOfWork(t)
+		unitOfWork.On("Do", mock.Anything).Return(runUnitOfWork)
+
+		s := &BillingService{suspensionRepository: suspensionRepository, unitOfWork: unitOfWork}
+		s.UsePaymentStatusPolicy(policy)
+		event, err := s.ReactivateIfPaid(3, at)
+		assert.NoError(t, err)
+		assert.Equal(t, suspension.Restricted, event.To)
+	})
+
+	t.Run("Should keep the status while invoices are past the thresholds", func(t *testing.T) {
+		t.Parallel()
+
+		suspensionRepository := mocks.NewSuspensionRepository(t)
+		suspensionRepository.On("GetBillingStatus", nil, 3).Return(suspension.Suspended, nil)
+		suspensionRepository.On("GetUnpaidInvoices", nil, 3).Return([]suspension.Invoice{{ID: 9, DueDate: at.AddDate(0, 0, -40)}}, nil)
+		unitOfWork := mocks.NewUnitOfWork(t)
+		unitOfWork.On("Do", mock.Anything).Return(runUnitOfWork)
+
+		s := &BillingService{suspensionRepository: suspensionRepository, unitOfWork: unitOfWork}
+		s.UsePaymentStatusPolicy(policy)
+		event, err := s.ReactivateIfPaid(3, at)
+		assert.NoError(t, err)
+		assert.Nil(t, event)
+	})
+
+	t.Run("Should not restrict or suspend a workspace further", func(t *testing.T) {
+		t.Parallel()
+
+		suspensionRepository := mocks.NewSuspensionRepository(t)
+		suspensionRepository.On("GetBillingStatus", nil, 3).Return(suspension.Restricted, nil)
+		suspensionRepository.On("GetUnpaidInvoices", nil, 3).Return([]suspension.Invoice{{ID: 9, DueDate: at.AddDate(0, 0, -40)}}, nil)
+		unitOfWork := mocks.NewUnitOfWork(t)
+		unitOfWork.On("Do", mock.Anything).Return(runUnitOfWork)
+
+		s := &BillingService{suspensionRepository: suspensionRepository, unitOfWork: unitOfWork}
+		s.UsePaymentStatusPolicy(policy)
+		event, err := s.ReactivateIfPaid(3, at)
+		assert.NoError(t, err)
+		assert.Nil(t, event)
+	})
+
+	t.Run("Should fail without a policy", func(t *testing.T) {
+		t.Parallel()
+
+		s := &BillingService{}
+		_, err := s.ReactivateIfPaid(3, at)
+		assert.EqualError(t, err, "no payment status policy is set")
+	})
+}
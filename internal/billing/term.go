@@ -0,0 +1,140 @@
+package billing
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"lineblocs.com/scheduler/models"
+)
+
+// Billing cycles as sent by the distributor and stored on subscriptions
+const (
+	BillingCycleMonthly = "MONTHLY"
+	BillingCycleAnnual  = "ANNUAL"
+)
+
+// BillingTerm decides what a billing run covers. Every term goes through the same
+// pipeline; the term only chooses the period, the membership fee and whether usage is billed.
+type BillingTerm interface {
+	Name() string
+	PeriodStart(end time.Time) time.Time
+	SeatPeriod(start, end time.Time) (time.Time, time.Time)
+	MembershipCosts(plan *helpers.ServicePlan, seats int) int64
+	AddOnCosts(addOn models.AddOn) int64
+	BillsUsage() bool
+	InvoiceDesc(billingInfo *helpers.WorkspaceBillingInfo) string
+}
+
+// monthlyTerm bills the monthly membership together with the usage of the past month
+type monthlyTerm struct{}
+
+func (monthlyTerm) Name() string {
+	return "monthly"
+}
+
+func (monthlyTerm) PeriodStart(end time.Time) time.Time {
+	return end.AddDate(0, -1, 0)
+}
+
+// SeatPeriod bills the seats held during the past month, in arrears
+func (monthlyTerm) SeatPeriod(start, end time.Time) (time.Time, time.Time) {
+	return start, end
+}
+
+func (monthlyTerm) MembershipCosts(plan *helpers.ServicePlan, seats int) int64 {
+	return int64(plan.BaseCosts * float64(seats))
+}
+
+func (monthlyTerm) AddOnCosts(addOn models.AddOn) int64 {
+	return addOn.MonthlyCents * int64(addOn.Quantity)
+}
+
+func (monthlyTerm) BillsUsage() bool {
+	return true
+}
+
+func (monthlyTerm) InvoiceDesc(billingInfo *helpers.WorkspaceBillingInfo) string {
+	return fmt.Sprintf("LineBlocs invoice for %s", billingInfo.InvoiceDue)
+}
+
+// annualPrepaidTerm bills a year of membership up front. Usage is left to the monthly overage runs.
+type annualPrepaidTerm struct{}
+
+func (annualPrepaidTerm) Name() string {
+	return "annual"
+}
+
+func (annualPrepaidTerm) PeriodStart(end time.Time) time.Time {
+	return end.AddDate(-1, 0, 0)
+}
+
+// SeatPeriod bills the seats of the prepaid year that starts when the run's period ends
+func (annualPrepaidTerm) SeatPeriod(_, end time.Time) (time.Time, time.Time) {
+	return end, end.AddDate(1, 0, 0)
+}
+
+func (annualPrepaidTerm) MembershipCosts(plan *helpers.ServicePlan, seats int) int64 {
+	if plan.AnnualCostCents > 0 {
+		return int64(plan.AnnualCostCents) * int64(seats)
+	}
+	return int64(plan.BaseCosts * float64(seats) * 12)
+}
+
+func (annualPrepaidTerm) AddOnCosts(addOn models.AddOn) int64 {
+	if addOn.AnnualCents > 0 {
+		return addOn.AnnualCents * int64(addOn.Quantity)
+	}
+	return addOn.MonthlyCents * int64(addOn.Quantity) * 12
+}
+
+func (annualPrepaidTerm) BillsUsage() bool {
+	return false
+}
+
+func (annualPrepaidTerm) InvoiceDesc(billingInfo *helpers.WorkspaceBillingInfo) string {
+	return fmt.Sprintf("LineBlocs annual invoice for %s", billingInfo.InvoiceDue)
+}
+
+// annualOverageTerm bills the usage of an annual subscription each month. The membership was prepaid.
+type annualOverageTerm struct {
+	monthlyTerm
+}
+
+func (annualOverageTerm) Name() string {
+	return "annual_overage"
+}
+
+func (annualOverageTerm) MembershipCosts(plan *helpers.ServicePlan, seats int) int64 {
+	return 0
+}
+
+func (annualOverageTerm) AddOnCosts(addOn models.AddOn) int64 {
+	return 0
+}
+
+func (annualOverageTerm) InvoiceDesc(billingInfo *helpers.WorkspaceBillingInfo) string {
+	return fmt.Sprintf("LineBlocs usage invoice for %s", billingInfo.InvoiceDue)
+}
+
+// TermFor picks the billing term from the run type and the subscription's billing cycle
+func TermFor(task models.BillingTask, subscription *helpers.Subscription) BillingTerm {
+	if strings.EqualFold(task.BillingType, BillingCycleAnnual) {
+		return annualPrepaidTerm{}
+	}
+	if strings.EqualFold(subscription.BillingCycle, BillingCycleAnnual) {
+		return annualOverageTerm{}
+	}
+	return monthlyTerm{}
+}
+
+// TermByName returns the billing term recorded on an invoice
+func TermByName(name string) (BillingTerm, error) {
+	for _, term := range []BillingTerm{monthlyTerm{}, annualPrepaidTerm{}, annualOverageTerm{}} {
+		if term.Name() == name {
+			return term, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown billing term %q", name)
+}
@@ -0,0 +1,64 @@
+package billing
+
+import (
+	"testing"
+	"time"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/stretchr/testify/assert"
+	"lineblocs.com/scheduler/models"
+)
+
+func TestTermFor(t *testing.T) {
+	t.Parallel()
+
+	end := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	plan := &helpers.ServicePlan{BaseCosts: 1000, AnnualCostCents: 10000}
+
+	testCases := []struct {
+		Name               string
+		BillingType        string
+		BillingCycle       string
+		ExpectedName       string
+		ExpectedStart      time.Time
+		ExpectedMembership int64
+		ExpectedUsage      bool
+	}{
+		{Name: "monthly run on a monthly subscription", BillingType: "MONTHLY", BillingCycle: "MONTHLY", ExpectedName: "monthly", ExpectedStart: end.AddDate(0, -1, 0), ExpectedMembership: 2000, ExpectedUsage: true},
+		{Name: "monthly run on an annual subscription bills overage only", BillingType: "MONTHLY", BillingCycle: "ANNUAL", ExpectedName: "annual_overage", ExpectedStart: end.AddDate(0, -1, 0), ExpectedMembership: 0, ExpectedUsage: true},
+		{Name: "annual run prepays the membership", BillingType: "ANNUAL", BillingCycle: "ANNUAL", ExpectedName: "annual", ExpectedStart: end.AddDate(-1, 0, 0), ExpectedMembership: 20000, ExpectedUsage: false},
+		{Name: "billing type is case insensitive", BillingType: "annual", BillingCycle: "annual", ExpectedName: "annual", ExpectedStart: end.AddDate(-1, 0, 0), ExpectedMembership: 20000, ExpectedUsage: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			term := TermFor(models.BillingTask{BillingType: tc.BillingType}, &helpers.Subscription{BillingCycle: tc.BillingCycle})
+			assert.Equal(t, tc.ExpectedName, term.Name())
+			assert.Equal(t, tc.ExpectedStart, term.PeriodStart(end))
+			assert.Equal(t, tc.ExpectedMembership, term.MembershipCosts(plan, 2))
+			assert.Equal(t, tc.ExpectedUsage, term.BillsUsage())
+		})
+	}
+
+	t.Run("Should fall back to twelve monthly fees without an annual price", func(t *testing.T) {
+		t.Parallel()
+
+		term := annualPrepaidTerm{}
+		assert.Equal(t, int64(24000), term.MembershipCosts(&helpers.ServicePlan{BaseCosts: 1000}, 2))
+	})
+}
+
+func TestTermByName(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"monthly", "annual", "annual_overage"} {
+		term, err := TermByName(name)
+		assert.NoError(t, err)
+		assert.Equal(t, name, term.Name())
+	}
+
+	_, err := TermByName("weekly")
+	assert.EqualError(t, err, `unknown billing term "weekly"`)
+}
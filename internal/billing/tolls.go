@@ -0,0 +1,87 @@
+package billing
+
+import (
+	"math"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/scheduler/internal/ratedeck"
+)
+
+const (
+	// RateDeckModeRate charges calls at the price found in the rate deck
+	RateDeckModeRate = "rate"
+	// RateDeckModeVerify keeps charging the stored debit and flags calls the deck prices differently
+	RateDeckModeVerify = "verify"
+)
+
+// RatingDiscrepancy records a call debit whose stored cents differ from the rate deck price
+type RatingDiscrepancy struct {
+	Number      string `json:"number"`
+	Prefix      string `json:"prefix"`
+	DeckVersion string `json:"deck_version"`
+	DebitID     int    `json:"debit_id"`
+	StoredCents int64  `json:"stored_cents"`
+	RatedCents  int64  `json:"rated_cents"`
+}
+
+// UseRateDeck makes the service price call tolls from a destination rate deck
+func (s *BillingService) UseRateDeck(deck *ratedeck.Deck, mode string) {
+	if mode != RateDeckModeRate {
+		mode = RateDeckModeVerify
+	}
+	s.rateDeck = deck
+	s.rateDeckMode = mode
+}
+
+// callCostCents returns the full cost of a call's billable seconds before the allowance is applied, and the
+// connection fee included in it. The fee is only known for calls charged at the deck price. Calls charged from
+// their stored debit are priced at its per-second rate, so the plan's increments and minimum apply to them too.
+func (s *BillingService) callCostCents(debitID int, call *helpers.Call, storedCents int64, billableSeconds int64, costs *BillingCosts, logger *logrus.Entry) (int64, int64) {
+	storedCost := storedCostCents(storedCents, call.DurationNumber, billableSeconds)
+	if s.rateDeck == nil {
+		return storedCost, 0
+	}
+
+	rate, found := s.rateDeck.Lookup(call.To, call.StartedAt)
+	if !found {
+		logger.Warnf("no rate in deck %s for call to %s, using stored debit %d", s.rateDeck.Version, call.To, debitID)
+		return storedCost, 0
+	}
+
+	ratedCents := rate.Price(billableSeconds)
+	if ratedCents != storedCost {
+		discrepancy := RatingDiscrepancy{
+			Number:      call.To,
+			Prefix:      rate.Prefix,
+			DeckVersion: s.rateDeck.Version,
+			DebitID:     debitID,
+			StoredCents: storedCost,
+			RatedCents:  ratedCents,
+		}
+		costs.RatingDiscrepancies = append(costs.RatingDiscrepancies, discrepancy)
+		logger.WithField("debit_id", debitID).Warnf("stored debit of %d cents differs from rate deck %s price of %d cents for prefix %s", storedCost, s.rateDeck.Version, ratedCents, rate.Prefix)
+	}
+
+	if s.rateDeckMode == RateDeckModeRate {
+		return ratedCents, connectionFeeCents(rate, billableSeconds)
+	}
+	return storedCost, 0
+}
+
+// storedCostCents prices the billable seconds of a call at the rate of its stored debit, which is the cost of
+// its actual duration
+func storedCostCents(storedCents int64, durationSeconds int, billableSeconds int64) int64 {
+	if durationSeconds <= 0 {
+		return storedCents
+	}
+	return int64(math.Round(float64(storedCents) * float64(billableSeconds) / float64(durationSeconds)))
+}
+
+// connectionFeeCents is the connection fee a deck rate charges a call of the given billable seconds
+func connectionFeeCents(rate *ratedeck.Rate, billableSeconds int64) int64 {
+	if billableSeconds <= 0 {
+		return 0
+	}
+	return int64(math.Round(rate.ConnectionFeeCents))
+}
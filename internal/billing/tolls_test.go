@@ -0,0 +1,174 @@
+package billing
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"lineblocs.com/scheduler/internal/ratedeck"
+	"lineblocs.com/scheduler/mocks"
+	"lineblocs.com/scheduler/models"
+)
+
+func TestCallCostCents(t *testing.T) {
+	t.Parallel()
+
+	startedAt := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	deck, err := ratedeck.Load(strings.NewReader("1,6,10.4,,\n44,12,,,\n"), "2026-01")
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		Name                string
+		Mode                string
+		Number              string
+		StoredCents         int64
+		ExpectedCents       int64
+		ExpectedFeeCents    int64
+		ExpectedDiscrepancy *RatingDiscrepancy
+	}{
+		{
+			Name:                "rate mode charges the deck price and flags the stored debit",
+			Mode:                RateDeckModeRate,
+			Number:              "+1 555 000 1111",
+			StoredCents:         30,
+			ExpectedCents:       22,
+			ExpectedFeeCents:    10,
+			ExpectedDiscrepancy: &RatingDiscrepancy{Number: "+1 555 000 1111", Prefix: "1", DeckVersion: "2026-01", DebitID: 5, StoredCents: 30, RatedCents: 22},
+		},
+		{
+			Name:                "verify mode keeps charging the stored debit and flags it",
+			Mode:                RateDeckModeVerify,
+			Number:              "15550001111",
+			StoredCents:         30,
+			ExpectedCents:       30,
+			ExpectedDiscrepancy: &RatingDiscrepancy{Number: "15550001111", Prefix: "1", DeckVersion: "2026-01", DebitID: 5, StoredCents: 30, RatedCents: 22},
+		},
+		{
+			Name:          "a stored debit matching the deck is not flagged",
+			Mode:          RateDeckModeVerify,
+			Number:        "445550001111",
+			StoredCents:   24,
+			ExpectedCents: 24,
+		},
+		{
+			Name:          "a number without a prefix in the deck falls back to the stored debit",
+			Mode:          RateDeckModeRate,
+			Number:        "615550001111",
+			StoredCents:   30,
+			ExpectedCents: 30,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			s := &BillingService{}
+			s.UseRateDeck(deck, tc.Mode)
+			costs := &BillingCosts{}
+			call := &helpers.Call{To: tc.Number, DurationNumber: 120, StartedAt: startedAt}
+
+			cents, feeCents := s.callCostCents(5, call, tc.StoredCents, 120, costs, logrus.WithField("test", t.Name()))
+			assert.Equal(t, tc.ExpectedCents, cents)
+			assert.Equal(t, tc.ExpectedFeeCents, feeCents)
+			if tc.ExpectedDiscrepancy == nil {
+				assert.Empty(t, costs.RatingDiscrepancies)
+			} else {
+				assert.Equal(t, []RatingDiscrepancy{*tc.ExpectedDiscrepancy}, costs.RatingDiscrepancies)
+			}
+		})
+	}
+
+	t.Run("Should charge the stored debit without a deck", func(t *testing.T) {
+		t.Parallel()
+
+		costs := &BillingCosts{}
+		cents, feeCents := (&BillingService{}).callCostCents(5, &helpers.Call{To: "15550001111", DurationNumber: 120}, 30, 120, costs, logrus.WithField("test", t.Name()))
+		assert.Equal(t, int64(30), cents)
+		assert.Equal(t, int64(0), feeCents)
+		assert.Empty(t, costs.RatingDiscrepancies)
+	})
+
+	t.Run("Should verify unless rate mode is asked for", func(t *testing.T) {
+		t.Parallel()
+
+		s := &BillingService{}
+		s.UseRateDeck(deck, "")
+		assert.Equal(t, RateDeckModeVerify, s.rateDeckMode)
+	})
+}
+
+func TestProcessCallDebitIncrements(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		Name            string
+		Plan            models.CallRatingPlan
+		DurationSeconds int
+		StoredCents     int64
+		IncludedSeconds int64
+		ExpectedCharge  CallCharge
+	}{
+		{
+			Name:            "60/60 bills a 61 second call as two minutes",
+			Plan:            models.CallRatingPlan{Rounding: models.RoundingUp, MinimumSeconds: 60, IncrementSeconds: 60},
+			DurationSeconds: 61,
+			StoredCents:     61,
+			ExpectedCharge:  CallCharge{BillableSeconds: 120, ChargeableSeconds: 120, Cents: 120},
+		},
+		{
+			Name:            "30/6 bills a 61 second call as 66 seconds",
+			Plan:            models.CallRatingPlan{Rounding: models.RoundingUp, MinimumSeconds: 30, IncrementSeconds: 6},
+			DurationSeconds: 61,
+			StoredCents:     61,
+			ExpectedCharge:  CallCharge{BillableSeconds: 66, ChargeableSeconds: 66, Cents: 66},
+		},
+		{
+			Name:            "1/1 bills the actual duration",
+			Plan:            models.CallRatingPlan{Rounding: models.RoundingUp, MinimumSeconds: 1, IncrementSeconds: 1},
+			DurationSeconds: 61,
+			StoredCents:     61,
+			ExpectedCharge:  CallCharge{BillableSeconds: 61, ChargeableSeconds: 61, Cents: 61},
+		},
+		{
+			Name:            "a call shorter than the minimum duration is billed the minimum",
+			Plan:            models.CallRatingPlan{Rounding: models.RoundingUp, MinimumSeconds: 60, IncrementSeconds: 6},
+			DurationSeconds: 10,
+			StoredCents:     10,
+			ExpectedCharge:  CallCharge{BillableSeconds: 60, ChargeableSeconds: 60, Cents: 60},
+		},
+		{
+			Name:            "nearest rounding bills a 61 second call on 60/60 as one minute",
+			Plan:            models.CallRatingPlan{Rounding: models.RoundingNearest, MinimumSeconds: 0, IncrementSeconds: 60},
+			DurationSeconds: 61,
+			StoredCents:     61,
+			ExpectedCharge:  CallCharge{BillableSeconds: 60, ChargeableSeconds: 60, Cents: 60},
+		},
+		{
+			Name:            "the allowance covers the first billed minute",
+			Plan:            models.CallRatingPlan{Rounding: models.RoundingUp, MinimumSeconds: 60, IncrementSeconds: 60},
+			DurationSeconds: 61,
+			StoredCents:     61,
+			IncludedSeconds: 60,
+			ExpectedCharge:  CallCharge{BillableSeconds: 120, AllowanceSeconds: 60, ChargeableSeconds: 60, Cents: 60},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			workspaceRepository := mocks.NewWorkspaceRepository(t)
+			workspaceRepository.On("GetCallFromDB", 11).Return(&helpers.Call{To: "15550001111", DurationNumber: tc.DurationSeconds}, nil)
+
+			s := &BillingService{workspaceRepository: workspaceRepository}
+			plan := tc.Plan
+			charge, err := s.processCallDebit(&BillingCosts{}, 1, 11, tc.StoredCents, NewCallRater(&plan, tc.IncludedSeconds), false, logrus.WithField("test", t.Name()))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.ExpectedCharge, charge)
+		})
+	}
+}
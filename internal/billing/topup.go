@@ -0,0 +1,244 @@
+package billing
+
+import (
+	"fmt"
+	"time"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/scheduler/models"
+	"lineblocs.com/scheduler/repository"
+	"lineblocs.com/scheduler/utils"
+)
+
+// TopUpReport summarizes an auto top-up run
+type TopUpReport struct {
+	Charged []int `json:"charged"`
+	Failed  []int `json:"failed"`
+	Review  []int `json:"review"`
+	Checked int   `json:"checked"`
+	Resumed int   `json:"resumed"`
+	Cents   int64 `json:"cents"`
+}
+
+// RunAutoTopUps first finishes the top-ups left pending for longer than staleAfter, charging them again
+// with the same idempotency key, then tops up every workspace with auto-recharge whose balance is low.
+func (s *BillingService) RunAutoTopUps(at time.Time, staleAfter time.Duration) (*TopUpReport, error) {
+	logger := logrus.WithField("component", "auto_topup")
+	report := &TopUpReport{}
+
+	pending, err := s.topUpRepository.GetPendingTopUps(s.db, at.Add(-staleAfter))
+	if err != nil {
+		logger.WithError(err).Error("error getting pending top-ups")
+		return nil, err
+	}
+	for i := range pending {
+		topUp := &pending[i]
+		report.Resumed++
+		topUpLogger := logger.WithField("workspace_id", topUp.WorkspaceID).WithField("topup_id", topUp.ID)
+
+		if at.Sub(topUp.CreatedAt) >= idempotencyWindow {
+			topUpLogger.Warn("top-up is outside the idempotency window, flagging for review")
+			flagged, err := s.topUpRepository.SettleTopUp(s.db, topUp.ID, models.OutboxReview, "", "charge outcome unknown", at)
+			if err != nil {
+				topUpLogger.WithError(err).Error("error flagging top-up for review")
+			}
+			if flagged {
+				report.Review = append(report.Review, topUp.WorkspaceID)
+			}
+			continue
+		}
+		s.reportTopUp(report, topUp.WorkspaceID, topUp, s.chargeTopUp(topUp, at, topUpLogger), topUpLogger)
+	}
+
+	settings, err := s.topUpRepository.GetAutoTopUps(s.db)
+	if err != nil {
+		logger.WithError(err).Error("error getting auto top-up settings")
+		return nil, err
+	}
+	for _, setting := range settings {
+		report.Checked++
+		topUpLogger := logger.WithField("workspace_id", setting.WorkspaceID)
+
+		topUp, err := s.TopUpIfLow(setting.WorkspaceID, at)
+		if topUp == nil && err == nil {
+			continue
+		}
+		s.reportTopUp(report, setting.WorkspaceID, topUp, err, topUpLogger)
+	}
+
+	logger.Infof("Checked %d workspaces and resumed %d top-ups: %d charged for %d cents, %d failed, %d need review",
+		report.Checked, report.Resumed, len(report.Charged), report.Cents, len(report.Failed), len(report.Review))
+	return report, nil
+}
+
+// TopUpIfLow buys the workspace's auto-recharge amount of credits when it is on a pay-as-you-go plan and
+// its balance is below the threshold. The top-up is recorded before the card is charged and only one can
+// be pending per workspace. It returns nil when no top-up was needed.
+func (s *BillingService) TopUpIfLow(workspaceID int, at time.Time) (*models.TopUp, error) {
+	logger := logrus.WithField("component", "auto_topup").WithField("workspace_id", workspaceID)
+
+	workspace, err := s.workspaceRepository.GetWorkspaceFromDB(workspaceID)
+	if err != nil {
+		logger.WithError(err).Error("error getting workspace")
+		return nil, err
+	}
+
+	plans, err := s.paymentRepository.GetServicePlans()
+	if err != nil {
+		logger.WithError(err).Error("error getting service plans")
+		return nil, err
+	}
+	if plan := utils.GetPlan(plans, workspace); plan == nil || !plan.PayAsYouGo {
+		return nil, nil
+	}
+
+	var topUp *models.TopUp
+	err = s.unitOfWork.Do(func(tx repository.Executor) error {
+		var err error
+		topUp, err = s.startTopUp(tx, workspace, at)
+		return err
+	})
+	if err != nil || topUp == nil {
+		return nil, err
+	}
+
+	logger.Infof("Balance of %d cents is below the threshold, topping up %d cents", topUp.BalanceCents, topUp.Cents)
+	return topUp, s.chargeTopUp(topUp, at, logger)
+}
+
+// startTopUp records a pending top-up when the balance is below the threshold. The balance is read
+// after the setting is locked so a top-up settled by another run is already counted.
+func (s *BillingService) startTopUp(tx repository.Executor, workspace *helpers.Workspace, at time.Time) (*models.TopUp, error) {
+	setting, err := s.topUpRepository.LockAutoTopUp(tx, workspace.Id)
+	if err != nil || setting == nil {
+		return nil, err
+	}
+
+	pending, err := s.topUpRepository.HasPendingTopUp(tx, workspace.Id)
+	if err != nil || pending {
+		return nil, err
+	}
+
+	billingInfo, err := s.workspaceRepository.GetWorkspaceBillingInfo(workspace)
+	if err != nil {
+		return nil, err
+	}
+	if billingInfo.RemainingBalanceCents >= setting.ThresholdCents {
+		return nil, nil
+	}
+
+	topUp := &models.TopUp{
+		CreatedAt:      at,
+		IdempotencyKey: fmt.Sprintf("lineblocs_topup_%d_%d", workspace.Id, at.UnixNano()),
+		Status:         models.OutboxPending,
+		WorkspaceID:    workspace.Id,
+		UserID:         workspace.CreatorId,
+		Cents:          setting.AmountCents,
+		BalanceCents:   billingInfo.RemainingBalanceCents,
+	}
+	topUp.ID, err = s.topUpRepository.CreateTopUp(tx, topUp)
+	if err != nil {
+		return nil, err
+	}
+	return topUp, nil
+}
+
+// chargeTopUp charges the saved card and settles the top-up, granting the credits when the charge went
+// through. The receipt is emailed and the running balance updated once the credits are granted.
+func (s *BillingService) chargeTopUp(topUp *models.TopUp, at time.Time, logger *logrus.Entry) error {
+	user, err := s.workspaceRepository.GetUserFromDB(topUp.UserID)
+	if err != nil {
+		return err
+	}
+	workspace, err := s.workspaceRepository.GetWorkspaceFromDB(topUp.WorkspaceID)
+	if err != nil {
+		return err
+	}
+	billingParams, err := utils.NewDBConn(s.db).GetBillingParams()
+	if err != nil {
+		return err
+	}
+
+	userInvoice := models.UserInvoice{
+		Id:             int(topUp.ID),
+		Cents:          int(topUp.Cents),
+		InvoiceDesc:    fmt.Sprintf("LineBlocs credit top-up %d", topUp.ID),
+		IdempotencyKey: topUp.IdempotencyKey,
+	}
+	chargeErr := s.paymentRepository.ChargeCustomer(billingParams, user, workspace, &userInvoice)
+	topUp.PaymentReference = userInvoice.PaymentReference
+
+	var settled bool
+	err = s.unitOfWork.Do(func(tx repository.Executor) error {
+		var err error
+		settled, err = s.settleTopUp(tx, topUp, chargeErr, at)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if !settled {
+		// a charge with the same idempotency key is one payment, whose credits the other run granted
+		logger.Info("top-up was already settled by another run")
+		return nil
+	}
+	if chargeErr != nil {
+		return chargeErr
+	}
+
+	args := map[string]string{
+		"topUpId":          fmt.Sprintf("%d", topUp.ID),
+		"cents":            fmt.Sprintf("%d", topUp.Cents),
+		"balanceCents":     fmt.Sprintf("%d", topUp.BalanceCents+topUp.Cents),
+		"paymentReference": topUp.PaymentReference,
+	}
+	if err := utils.DispatchEmail("Credits Added", "topup_receipt", user, workspace, args); err != nil {
+		logger.WithError(err).Error("error sending top-up receipt")
+	}
+
+	// the credits can bring a workspace that was cut off back above its credit limit
+	if _, err := s.UpdateBalance(topUp.WorkspaceID, at, BalanceResyncAfter); err != nil {
+		logger.WithError(err).Error("error updating balance after top-up")
+	}
+	return nil
+}
+
+// settleTopUp records the outcome of the charge and grants the credits of a successful one. Only the run
+// that moves the top-up out of pending grants them, so a resumed charge that overlaps the original one,
+// with the same idempotency key, does not grant them twice. It reports whether this run settled the top-up.
+func (s *BillingService) settleTopUp(tx repository.Executor, topUp *models.TopUp, chargeErr error, at time.Time) (bool, error) {
+	status, errMsg := models.OutboxSucceeded, ""
+	if chargeErr != nil {
+		status, errMsg = models.OutboxFailed, chargeErr.Error()
+	}
+
+	settled, err := s.topUpRepository.SettleTopUp(tx, topUp.ID, status, topUp.PaymentReference, errMsg, at)
+	if err != nil || !settled {
+		return false, err
+	}
+	topUp.Status = status
+	if chargeErr != nil {
+		return true, nil
+	}
+
+	if err := s.invoiceRepository.CreateCredit(tx, topUp.WorkspaceID, topUp.UserID, topUp.Cents, at); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// reportTopUp adds the outcome of a top-up to the report
+func (s *BillingService) reportTopUp(report *TopUpReport, workspaceID int, topUp *models.TopUp, err error, logger *logrus.Entry) {
+	if err != nil {
+		logger.WithError(err).Error("error topping up credits")
+		report.Failed = append(report.Failed, workspaceID)
+		return
+	}
+	if topUp.Status != models.OutboxSucceeded {
+		// settled by another run
+		return
+	}
+	report.Charged = append(report.Charged, workspaceID)
+	report.Cents += topUp.Cents
+}
@@ -0,0 +1,167 @@
+package billing
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"lineblocs.com/scheduler/mocks"
+	"lineblocs.com/scheduler/models"
+)
+
+func TestTopUpIfLowSkips(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, 6, 1, 3, 0, 0, 0, time.UTC)
+	plans := []helpers.ServicePlan{{KeyName: "starter"}, {KeyName: "payg", PayAsYouGo: true}}
+	setting := &models.AutoTopUp{WorkspaceID: 3, ThresholdCents: 500, AmountCents: 2000}
+
+	testCases := []struct {
+		Name         string
+		Plan         string
+		Setting      *models.AutoTopUp
+		Pending      bool
+		BalanceCents int64
+	}{
+		{Name: "workspaces not on pay as you go are not topped up", Plan: "starter"},
+		{Name: "workspaces without auto-recharge are not topped up", Plan: "payg"},
+		{Name: "a pending top-up is not doubled", Plan: "payg", Setting: setting, Pending: true},
+		{Name: "balances at the threshold are not topped up", Plan: "payg", Setting: setting, BalanceCents: 500},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			workspace := &helpers.Workspace{Id: 3, CreatorId: 7, Plan: tc.Plan}
+			workspaceRepository := mocks.NewWorkspaceRepository(t)
+			workspaceRepository.On("GetWorkspaceFromDB", 3).Return(workspace, nil)
+			paymentRepository := mocks.NewPaymentRepository(t)
+			paymentRepository.On("GetServicePlans").Return(plans, nil)
+
+			topUpRepository := mocks.NewTopUpRepository(t)
+			unitOfWork := mocks.NewUnitOfWork(t)
+			if tc.Plan == "payg" {
+				unitOfWork.On("Do", mock.Anything).Return(runUnitOfWork)
+				topUpRepository.On("LockAutoTopUp", nil, 3).Return(tc.Setting, nil)
+			}
+			if tc.Setting != nil {
+				topUpRepository.On("HasPendingTopUp", nil, 3).Return(tc.Pending, nil)
+			}
+			if tc.Setting != nil && !tc.Pending {
+				workspaceRepository.On("GetWorkspaceBillingInfo", workspace).Return(&helpers.WorkspaceBillingInfo{RemainingBalanceCents: tc.BalanceCents}, nil)
+			}
+
+			s := &BillingService{workspaceRepository: workspaceRepository, paymentRepository: paymentRepository, topUpRepository: topUpRepository, unitOfWork: unitOfWork}
+			topUp, err := s.TopUpIfLow(3, at)
+			assert.NoError(t, err)
+			assert.Nil(t, topUp)
+		})
+	}
+}
+
+func TestStartTopUp(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, 6, 1, 3, 0, 0, 0, time.UTC)
+	workspace := &helpers.Workspace{Id: 3, CreatorId: 7, Plan: "payg"}
+
+	workspaceRepository := mocks.NewWorkspaceRepository(t)
+	workspaceRepository.On("GetWorkspaceBillingInfo", workspace).Return(&helpers.WorkspaceBillingInfo{RemainingBalanceCents: 120}, nil)
+
+	expected := &models.TopUp{
+		CreatedAt:      at,
+		IdempotencyKey: "lineblocs_topup_3_1780282800000000000",
+		Status:         models.OutboxPending,
+		WorkspaceID:    3,
+		UserID:         7,
+		Cents:          2000,
+		BalanceCents:   120,
+	}
+	topUpRepository := mocks.NewTopUpRepository(t)
+	topUpRepository.On("LockAutoTopUp", nil, 3).Return(&models.AutoTopUp{WorkspaceID: 3, ThresholdCents: 500, AmountCents: 2000}, nil)
+	topUpRepository.On("HasPendingTopUp", nil, 3).Return(false, nil)
+	topUpRepository.On("CreateTopUp", nil, expected).Return(int64(11), nil)
+
+	s := &BillingService{workspaceRepository: workspaceRepository, topUpRepository: topUpRepository}
+	topUp, err := s.startTopUp(nil, workspace, at)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), topUp.ID)
+	assert.Equal(t, int64(2000), topUp.Cents)
+}
+
+func TestSettleTopUp(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, 6, 1, 3, 0, 0, 0, time.UTC)
+
+	t.Run("Should grant the credits of a successful charge", func(t *testing.T) {
+		t.Parallel()
+
+		invoiceRepository := mocks.NewInvoiceRepository(t)
+		invoiceRepository.On("CreateCredit", nil, 3, 7, int64(2000), at).Return(nil)
+		topUpRepository := mocks.NewTopUpRepository(t)
+		topUpRepository.On("SettleTopUp", nil, int64(11), models.OutboxSucceeded, "ch_1", "", at).Return(true, nil)
+
+		topUp := &models.TopUp{ID: 11, WorkspaceID: 3, UserID: 7, Cents: 2000, PaymentReference: "ch_1", Status: models.OutboxPending}
+		s := &BillingService{invoiceRepository: invoiceRepository, topUpRepository: topUpRepository}
+		settled, err := s.settleTopUp(nil, topUp, nil, at)
+		assert.NoError(t, err)
+		assert.True(t, settled)
+		assert.Equal(t, models.OutboxSucceeded, topUp.Status)
+	})
+
+	t.Run("Should record a declined charge without granting credits", func(t *testing.T) {
+		t.Parallel()
+
+		topUpRepository := mocks.NewTopUpRepository(t)
+		topUpRepository.On("SettleTopUp", nil, int64(11), models.OutboxFailed, "", "card declined", at).Return(true, nil)
+
+		topUp := &models.TopUp{ID: 11, WorkspaceID: 3, UserID: 7, Cents: 2000, Status: models.OutboxPending}
+		s := &BillingService{invoiceRepository: mocks.NewInvoiceRepository(t), topUpRepository: topUpRepository}
+		settled, err := s.settleTopUp(nil, topUp, errors.New("card declined"), at)
+		assert.NoError(t, err)
+		assert.True(t, settled)
+		assert.Equal(t, models.OutboxFailed, topUp.Status)
+	})
+
+	t.Run("Should grant the credits once when a resumed charge overlaps the original", func(t *testing.T) {
+		t.Parallel()
+
+		// both runs charged with the same idempotency key, which is one payment; the first to settle wins
+		invoiceRepository := mocks.NewInvoiceRepository(t)
+		invoiceRepository.On("CreateCredit", nil, 3, 7, int64(2000), at).Return(nil).Once()
+		topUpRepository := mocks.NewTopUpRepository(t)
+		topUpRepository.On("SettleTopUp", nil, int64(11), models.OutboxSucceeded, "ch_1", "", at).Return(true, nil).Once()
+		topUpRepository.On("SettleTopUp", nil, int64(11), models.OutboxSucceeded, "ch_1", "", at).Return(false, nil).Once()
+		s := &BillingService{invoiceRepository: invoiceRepository, topUpRepository: topUpRepository}
+
+		original := &models.TopUp{ID: 11, WorkspaceID: 3, UserID: 7, Cents: 2000, PaymentReference: "ch_1", Status: models.OutboxPending}
+		resumed := *original
+
+		settled, err := s.settleTopUp(nil, original, nil, at)
+		assert.NoError(t, err)
+		assert.True(t, settled)
+
+		settled, err = s.settleTopUp(nil, &resumed, nil, at)
+		assert.NoError(t, err)
+		assert.False(t, settled)
+		assert.Equal(t, models.OutboxPending, resumed.Status, "the resumed run does not report a charge of its own")
+	})
+
+	t.Run("Should not overwrite a settled top-up with a failed resume", func(t *testing.T) {
+		t.Parallel()
+
+		topUpRepository := mocks.NewTopUpRepository(t)
+		topUpRepository.On("SettleTopUp", nil, int64(11), models.OutboxFailed, "", "gateway timeout", at).Return(false, nil)
+
+		topUp := &models.TopUp{ID: 11, WorkspaceID: 3, UserID: 7, Cents: 2000, Status: models.OutboxPending}
+		s := &BillingService{invoiceRepository: mocks.NewInvoiceRepository(t), topUpRepository: topUpRepository}
+		settled, err := s.settleTopUp(nil, topUp, errors.New("gateway timeout"), at)
+		assert.NoError(t, err)
+		assert.False(t, settled)
+	})
+}
@@ -0,0 +1,163 @@
+package billing
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/scheduler/internal/invoice"
+	"lineblocs.com/scheduler/models"
+	"lineblocs.com/scheduler/repository"
+	"lineblocs.com/scheduler/utils"
+)
+
+// VoidReport describes what voiding, and optionally reissuing, an invoice did
+type VoidReport struct {
+	RefundID          string `json:"refund_id,omitempty"`
+	InvoiceID         int64  `json:"invoice_id"`
+	RefundedCents     int64  `json:"refunded_cents"`
+	CreditedCents     int64  `json:"credited_cents"`
+	ReleasedDebits    int64  `json:"released_debits"`
+	ReissuedInvoiceID int64  `json:"reissued_invoice_id,omitempty"`
+}
+
+// VoidInvoice voids an invoice and gives back what was collected on it. Card payments are
+// refunded through the gateway unless toCredits is set; everything else goes to the credit
+// ledger. The debits the invoice claimed are released so the next invoice bills them again,
+// and any coupon cycle it used is given back.
+func (s *BillingService) VoidInvoice(invoiceID int64, reason string, toCredits bool) (*VoidReport, error) {
+	logger := logrus.WithField("component", "void_invoice").WithField("invoice_id", invoiceID)
+
+	inv, err := s.invoiceRepository.GetInvoice(s.db, invoiceID)
+	if err != nil {
+		logger.WithError(err).Error("error loading invoice")
+		return nil, err
+	}
+
+	return s.voidInvoice(inv, reason, toCredits, logger)
+}
+
+// ReissueInvoice voids an invoice as VoidInvoice does, then bills the same period again
+// under the same billing term. The new invoice is collected like any other.
+func (s *BillingService) ReissueInvoice(invoiceID int64, reason string, toCredits bool) (*VoidReport, error) {
+	logger := logrus.WithField("component", "reissue_invoice").WithField("invoice_id", invoiceID)
+
+	inv, err := s.invoiceRepository.GetInvoice(s.db, invoiceID)
+	if err != nil {
+		logger.WithError(err).Error("error loading invoice")
+		return nil, err
+	}
+
+	// check the invoice can be billed again before anything is refunded
+	if inv.SubscriptionID == 0 || inv.BillingTerm == "" || inv.PeriodEnd.IsZero() {
+		return nil, fmt.Errorf("invoice %d has no billing period recorded and cannot be reissued", invoiceID)
+	}
+	term, err := TermByName(inv.BillingTerm)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.voidInvoice(inv, reason, toCredits, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.loadBillingData(models.BillingTask{
+		WorkspaceID:    inv.WorkspaceID,
+		SubscriptionID: inv.SubscriptionID,
+		CreatorID:      inv.UserID,
+	}, logger)
+	if err != nil {
+		return report, err
+	}
+	data.Term = term
+	data.BillingPeriodStart = inv.PeriodStart
+	data.BillingPeriodEnd = inv.PeriodEnd
+	if err := s.loadPeriodPrices(data, logger); err != nil {
+		return report, err
+	}
+
+	report.ReissuedInvoiceID, err = s.bill(data, logger)
+	if err != nil {
+		logger.WithError(err).Error("error reissuing invoice")
+		return report, err
+	}
+
+	logger.Infof("Invoice %d was reissued as invoice %d", invoiceID, report.ReissuedInvoiceID)
+	return report, nil
+}
+
+func (s *BillingService) voidInvoice(inv *models.Invoice, reason string, toCredits bool, logger *logrus.Entry) (*VoidReport, error) {
+	if err := invoice.ValidateTransition(invoice.Status(inv.State), invoice.Void); err != nil {
+		return nil, fmt.Errorf("invoice %d: %w", inv.Id, err)
+	}
+
+	report := &VoidReport{InvoiceID: inv.Id}
+	if inv.CentsCollected > 0 && inv.Source == "CARD" && !toCredits {
+		refundID, err := s.refundCardPayment(inv, logger)
+		if err != nil {
+			return nil, err
+		}
+		report.RefundID = refundID
+		report.RefundedCents = inv.CentsCollected
+		reason = fmt.Sprintf("%s; refunded %d cents (%s)", reason, inv.CentsCollected, refundID)
+	} else if inv.CentsCollected > 0 {
+		report.CreditedCents = inv.CentsCollected
+		reason = fmt.Sprintf("%s; credited %d cents", reason, inv.CentsCollected)
+	}
+
+	// the refund above is keyed on the invoice, so rerunning after a failure here does not refund twice
+	now := time.Now()
+	err := s.unitOfWork.Do(func(tx repository.Executor) error {
+		if err := s.invoiceRepository.TransitionInvoice(tx, inv.Id, invoice.Void, reason, now); err != nil {
+			return err
+		}
+
+		released, err := s.invoiceRepository.ReleaseDebits(tx, inv.Id)
+		if err != nil {
+			return err
+		}
+		report.ReleasedDebits = released
+
+		if err := s.promotionRepository.ReleaseRedemptions(tx, inv.Id); err != nil {
+			return err
+		}
+
+		if report.CreditedCents > 0 {
+			return s.invoiceRepository.CreateCredit(tx, inv.WorkspaceID, inv.UserID, report.CreditedCents, now)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.WithError(err).Error("error voiding invoice")
+		return nil, err
+	}
+
+	logger.Infof("Voided invoice %d: refunded %d cents, credited %d cents, released %d debits",
+		inv.Id, report.RefundedCents, report.CreditedCents, report.ReleasedDebits)
+	return report, nil
+}
+
+// refundCardPayment refunds the card charge that paid an invoice through the gateway that took it
+func (s *BillingService) refundCardPayment(inv *models.Invoice, logger *logrus.Entry) (string, error) {
+	paymentReference, err := s.invoiceRepository.GetPaymentReference(s.db, inv.Id)
+	if err != nil {
+		return "", err
+	}
+	if paymentReference == "" {
+		return "", fmt.Errorf("invoice %d was paid by card but has no recorded payment reference, void it to the credit ledger instead", inv.Id)
+	}
+
+	billingParams, err := utils.NewDBConn(s.db).GetBillingParams()
+	if err != nil {
+		return "", err
+	}
+
+	refundID, err := s.paymentRepository.RefundCustomer(billingParams, paymentReference, inv.CentsCollected, fmt.Sprintf("lineblocs_refund_%d", inv.Id))
+	if err != nil {
+		logger.WithError(err).Error("error refunding card payment")
+		return "", err
+	}
+
+	return refundID, nil
+}
@@ -0,0 +1,85 @@
+package billing
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"lineblocs.com/scheduler/internal/invoice"
+	"lineblocs.com/scheduler/mocks"
+	"lineblocs.com/scheduler/models"
+	"lineblocs.com/scheduler/repository"
+)
+
+func runUnitOfWork(fn func(repository.Executor) error) error {
+	return fn(nil)
+}
+
+func TestVoidInvoice(t *testing.T) {
+	t.Parallel()
+
+	logger := logrus.WithField("test", t.Name())
+
+	t.Run("Should credit an invoice paid with credits and release its debits", func(t *testing.T) {
+		t.Parallel()
+
+		inv := &models.Invoice{Id: 9, UserID: 2, WorkspaceID: 3, State: string(invoice.Paid), Source: "CREDITS", CentsCollected: 1500}
+
+		invoiceRepository := mocks.NewInvoiceRepository(t)
+		invoiceRepository.On("TransitionInvoice", nil, int64(9), invoice.Void, "wrong seat count; credited 1500 cents", mock.Anything).Return(nil)
+		invoiceRepository.On("ReleaseDebits", nil, int64(9)).Return(int64(4), nil)
+		invoiceRepository.On("CreateCredit", nil, 3, 2, int64(1500), mock.Anything).Return(nil)
+		promotionRepository := mocks.NewPromotionRepository(t)
+		promotionRepository.On("ReleaseRedemptions", nil, int64(9)).Return(nil)
+		unitOfWork := mocks.NewUnitOfWork(t)
+		unitOfWork.On("Do", mock.Anything).Return(runUnitOfWork)
+
+		s := &BillingService{invoiceRepository: invoiceRepository, promotionRepository: promotionRepository, unitOfWork: unitOfWork}
+		report, err := s.voidInvoice(inv, "wrong seat count", false, logger)
+		assert.NoError(t, err)
+		assert.Equal(t, &VoidReport{InvoiceID: 9, CreditedCents: 1500, ReleasedDebits: 4}, report)
+	})
+
+	t.Run("Should void an unpaid invoice without giving anything back", func(t *testing.T) {
+		t.Parallel()
+
+		inv := &models.Invoice{Id: 9, State: string(invoice.Open), Source: "SUBSCRIPTION"}
+
+		invoiceRepository := mocks.NewInvoiceRepository(t)
+		invoiceRepository.On("TransitionInvoice", nil, int64(9), invoice.Void, "duplicate", mock.Anything).Return(nil)
+		invoiceRepository.On("ReleaseDebits", nil, int64(9)).Return(int64(2), nil)
+		promotionRepository := mocks.NewPromotionRepository(t)
+		promotionRepository.On("ReleaseRedemptions", nil, int64(9)).Return(nil)
+		unitOfWork := mocks.NewUnitOfWork(t)
+		unitOfWork.On("Do", mock.Anything).Return(runUnitOfWork)
+
+		s := &BillingService{invoiceRepository: invoiceRepository, promotionRepository: promotionRepository, unitOfWork: unitOfWork}
+		report, err := s.voidInvoice(inv, "duplicate", false, logger)
+		assert.NoError(t, err)
+		assert.Equal(t, &VoidReport{InvoiceID: 9, ReleasedDebits: 2}, report)
+	})
+
+	t.Run("Should refuse a card payment without a payment reference", func(t *testing.T) {
+		t.Parallel()
+
+		inv := &models.Invoice{Id: 9, State: string(invoice.Paid), Source: "CARD", CentsCollected: 1500}
+
+		invoiceRepository := mocks.NewInvoiceRepository(t)
+		invoiceRepository.On("GetPaymentReference", mock.Anything, int64(9)).Return("", nil)
+
+		s := &BillingService{invoiceRepository: invoiceRepository}
+		_, err := s.voidInvoice(inv, "wrong seat count", false, logger)
+		assert.ErrorContains(t, err, "no recorded payment reference")
+	})
+
+	t.Run("Should not void an invoice twice", func(t *testing.T) {
+		t.Parallel()
+
+		inv := &models.Invoice{Id: 9, State: string(invoice.Void)}
+
+		s := &BillingService{invoiceRepository: mocks.NewInvoiceRepository(t)}
+		_, err := s.voidInvoice(inv, "duplicate", false, logger)
+		assert.ErrorIs(t, err, invoice.ErrInvalidTransition)
+	})
+}
@@ -0,0 +1,109 @@
+package invoice
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Status is the lifecycle state of an invoice
+type Status string
+
+const (
+	Draft         Status = "draft"
+	Open          Status = "open"
+	Paid          Status = "paid"
+	PartiallyPaid Status = "partially_paid"
+	Uncollectible Status = "uncollectible"
+	Void          Status = "void"
+)
+
+// Values of users_invoices.status written before invoice states existed
+const (
+	legacyIncomplete = "INCOMPLETE"
+	legacyComplete   = "COMPLETE"
+	legacyVoid       = "VOID"
+)
+
+var ErrInvalidTransition = errors.New("invalid invoice transition")
+
+// transitions lists the states each state may move to. Void is final.
+var transitions = map[Status][]Status{
+	Draft:         {Open, Void},
+	Open:          {Paid, PartiallyPaid, Uncollectible, Void},
+	PartiallyPaid: {Paid, Uncollectible, Void},
+	Uncollectible: {Paid, PartiallyPaid, Void},
+	Paid:          {Void},
+}
+
+// Event is a transition recorded in the invoice history
+type Event struct {
+	CreatedAt time.Time
+	From      Status
+	To        Status
+	Reason    string
+	InvoiceID int64
+}
+
+// CanTransition reports whether an invoice may move from one state to another
+func CanTransition(from, to Status) bool {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTransition returns ErrInvalidTransition when the move is not allowed
+func ValidateTransition(from, to Status) error {
+	if !CanTransition(from, to) {
+		return fmt.Errorf("%w from %s to %s", ErrInvalidTransition, from, to)
+	}
+	return nil
+}
+
+// AfterPayment is the state of an open invoice once collected of its total cents have been paid
+func AfterPayment(collected, total int64) Status {
+	switch {
+	case collected >= total:
+		return Paid
+	case collected > 0:
+		return PartiallyPaid
+	default:
+		return Open
+	}
+}
+
+// Collectable reports whether payment should still be attempted
+func (s Status) Collectable() bool {
+	return s == Open || s == PartiallyPaid
+}
+
+// Legacy is the users_invoices.status value kept in sync for readers that predate invoice states
+func (s Status) Legacy() string {
+	switch s {
+	case Paid:
+		return legacyComplete
+	case Void:
+		return legacyVoid
+	default:
+		return legacyIncomplete
+	}
+}
+
+// Parse reads the state of a users_invoices row, falling back to the legacy status for rows without one
+func Parse(state string, legacyStatus string) Status {
+	if state != "" {
+		return Status(state)
+	}
+
+	switch legacyStatus {
+	case legacyComplete:
+		return Paid
+	case legacyVoid:
+		return Void
+	default:
+		return Open
+	}
+}
@@ -0,0 +1,60 @@
+package invoice
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTransition(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		Name    string
+		From    Status
+		To      Status
+		Allowed bool
+	}{
+		{Name: "draft is finalized to open", From: Draft, To: Open, Allowed: true},
+		{Name: "draft cannot be paid before it is finalized", From: Draft, To: Paid, Allowed: false},
+		{Name: "open is paid", From: Open, To: Paid, Allowed: true},
+		{Name: "open is partially paid", From: Open, To: PartiallyPaid, Allowed: true},
+		{Name: "partially paid is paid", From: PartiallyPaid, To: Paid, Allowed: true},
+		{Name: "uncollectible can still be paid", From: Uncollectible, To: Paid, Allowed: true},
+		{Name: "paid is voided for a refund", From: Paid, To: Void, Allowed: true},
+		{Name: "paid cannot reopen", From: Paid, To: Open, Allowed: false},
+		{Name: "void is final", From: Void, To: Open, Allowed: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateTransition(tc.From, tc.To)
+			if tc.Allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, ErrInvalidTransition))
+			}
+		})
+	}
+}
+
+func TestAfterPayment(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, Paid, AfterPayment(1500, 1500))
+	assert.Equal(t, PartiallyPaid, AfterPayment(500, 1500))
+	assert.Equal(t, Open, AfterPayment(0, 1500))
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, PartiallyPaid, Parse("partially_paid", "INCOMPLETE"))
+	assert.Equal(t, Paid, Parse("", "COMPLETE"))
+	assert.Equal(t, Open, Parse("", "INCOMPLETE"))
+	assert.Equal(t, "COMPLETE", Paid.Legacy())
+	assert.Equal(t, "INCOMPLETE", Uncollectible.Legacy())
+}
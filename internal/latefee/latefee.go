@@ -0,0 +1,118 @@
+package latefee
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Kinds of late charge
+const (
+	KindFee      = "fee"
+	KindInterest = "interest"
+)
+
+// Policy is what an unpaid invoice costs once it is past its due date. Zero disables a charge or a cap.
+type Policy struct {
+	// FlatCents is charged once, when the grace period ends
+	FlatCents int64
+	// MonthlyPercent is charged on the unpaid balance for each full month past the grace period
+	MonthlyPercent float64
+	// GraceDays is how long after the due date nothing is charged
+	GraceDays int
+	// CapCents limits the late charges of one invoice in total
+	CapCents int64
+	// CapPercent limits the late charges of one invoice to a share of what it billed
+	CapPercent float64
+}
+
+// Charge is a late fee or one month of interest. Period is 0 for the fee and counts the months of interest from 1.
+type Charge struct {
+	Kind   string
+	Period int
+	Cents  int64
+}
+
+// Validate checks that the policy's amounts make sense
+func (p Policy) Validate() error {
+	if p.FlatCents < 0 || p.MonthlyPercent < 0 || p.GraceDays < 0 || p.CapCents < 0 || p.CapPercent < 0 {
+		return fmt.Errorf("late fee policy values cannot be negative")
+	}
+	return nil
+}
+
+// Enabled reports whether the policy charges anything
+func (p Policy) Enabled() bool {
+	return p.FlatCents > 0 || p.MonthlyPercent > 0
+}
+
+// Assess returns the charges owed at the given time that are not in applied yet. billedCents is what the
+// invoice billed before late charges and owedCents what is still unpaid of it; interest is charged on
+// owedCents. Charges are trimmed to what is left under the caps, and charges trimmed to nothing are left out.
+func Assess(policy Policy, due time.Time, at time.Time, billedCents int64, owedCents int64, applied []Charge) []Charge {
+	start := due.AddDate(0, 0, policy.GraceDays)
+	if owedCents <= 0 || at.Before(start) {
+		return nil
+	}
+
+	done := make(map[Charge]bool)
+	var appliedCents int64
+	for _, charge := range applied {
+		done[Charge{Kind: charge.Kind, Period: charge.Period}] = true
+		appliedCents += charge.Cents
+	}
+
+	owed := make([]Charge, 0)
+	if policy.FlatCents > 0 {
+		owed = append(owed, Charge{Kind: KindFee, Cents: policy.FlatCents})
+	}
+	if policy.MonthlyPercent > 0 {
+		interest := int64(math.Round(float64(owedCents) * policy.MonthlyPercent / 100))
+		for period := 1; !at.Before(start.AddDate(0, period, 0)); period++ {
+			owed = append(owed, Charge{Kind: KindInterest, Period: period, Cents: interest})
+		}
+	}
+
+	left := remainingCap(policy, billedCents, appliedCents)
+	charges := make([]Charge, 0)
+	for _, charge := range owed {
+		if done[Charge{Kind: charge.Kind, Period: charge.Period}] {
+			continue
+		}
+		if left >= 0 {
+			charge.Cents = min(charge.Cents, left)
+			left -= charge.Cents
+		}
+		if charge.Cents > 0 {
+			charges = append(charges, charge)
+		}
+	}
+	return charges
+}
+
+// remainingCap is how much more may be charged under the caps, or -1 when there is no cap
+func remainingCap(policy Policy, billedCents int64, appliedCents int64) int64 {
+	limit := int64(-1)
+	if policy.CapCents > 0 {
+		limit = policy.CapCents
+	}
+	if policy.CapPercent > 0 {
+		byPercent := int64(math.Round(float64(billedCents) * policy.CapPercent / 100))
+		if limit < 0 || byPercent < limit {
+			limit = byPercent
+		}
+	}
+	if limit < 0 {
+		return -1
+	}
+	return max(limit-appliedCents, 0)
+}
+
+// Total sums the cents of the charges
+func Total(charges []Charge) int64 {
+	var total int64
+	for _, charge := range charges {
+		total += charge.Cents
+	}
+	return total
+}
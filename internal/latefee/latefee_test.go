@@ -0,0 +1,86 @@
+package latefee
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssess(t *testing.T) {
+	t.Parallel()
+
+	due := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	policy := Policy{FlatCents: 500, MonthlyPercent: 1.5, GraceDays: 10}
+
+	testCases := []struct {
+		Name     string
+		Policy   Policy
+		At       time.Time
+		Owed     int64
+		Applied  []Charge
+		Expected []Charge
+	}{
+		{
+			Name:   "nothing is charged during the grace period",
+			Policy: policy,
+			At:     time.Date(2026, 3, 10, 23, 0, 0, 0, time.UTC),
+			Owed:   10000,
+		},
+		{
+			Name:     "the fee is charged when the grace period ends",
+			Policy:   policy,
+			At:       time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC),
+			Owed:     10000,
+			Expected: []Charge{{Kind: KindFee, Cents: 500}},
+		},
+		{
+			Name:     "interest is charged for each full month past the grace period",
+			Policy:   policy,
+			At:       time.Date(2026, 5, 12, 0, 0, 0, 0, time.UTC),
+			Owed:     10000,
+			Applied:  []Charge{{Kind: KindFee, Cents: 500}},
+			Expected: []Charge{{Kind: KindInterest, Period: 1, Cents: 150}, {Kind: KindInterest, Period: 2, Cents: 150}},
+		},
+		{
+			Name:    "charges already applied are not charged again",
+			Policy:  policy,
+			At:      time.Date(2026, 4, 20, 0, 0, 0, 0, time.UTC),
+			Owed:    10000,
+			Applied: []Charge{{Kind: KindFee, Cents: 500}, {Kind: KindInterest, Period: 1, Cents: 150}},
+		},
+		{
+			Name:     "charges are trimmed to the cap",
+			Policy:   Policy{FlatCents: 500, MonthlyPercent: 1.5, CapCents: 1000, CapPercent: 7},
+			At:       time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+			Owed:     10000,
+			Applied:  []Charge{{Kind: KindFee, Cents: 500}},
+			Expected: []Charge{{Kind: KindInterest, Period: 1, Cents: 150}, {Kind: KindInterest, Period: 2, Cents: 50}},
+		},
+		{
+			Name:   "paid invoices are not charged",
+			Policy: policy,
+			At:     time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			charges := Assess(tc.Policy, due, tc.At, 10000, tc.Owed, tc.Applied)
+			if len(tc.Expected) == 0 {
+				assert.Empty(t, charges)
+				return
+			}
+			assert.Equal(t, tc.Expected, charges)
+		})
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, Policy{FlatCents: 500, GraceDays: 7}.Validate())
+	assert.EqualError(t, Policy{MonthlyPercent: -1}.Validate(), "late fee policy values cannot be negative")
+}
@@ -0,0 +1,100 @@
+package pricing
+
+import (
+	"fmt"
+	"math"
+)
+
+// How tiers combine
+const (
+	// Graduated prices each unit at the tier it falls in, like tax brackets
+	Graduated = "graduated"
+	// Volume prices every unit at the tier the total falls in
+	Volume = "volume"
+)
+
+// Usage categories that plans can define tiers for, with the unit they are priced in
+const (
+	Minutes           = "minutes"
+	RecordingGBMonths = "recording_gb_months"
+	FaxPages          = "fax_pages"
+)
+
+// Tier prices units up to UpTo. UpTo is zero on the last tier, which has no upper bound.
+type Tier struct {
+	UpTo      float64
+	UnitCents float64
+	FlatCents float64
+}
+
+// Schedule is the price of a usage category, as tiers ordered by UpTo
+type Schedule struct {
+	Mode  string
+	Tiers []Tier
+}
+
+// Flat is a schedule with one price for every unit
+func Flat(unitCents float64) Schedule {
+	return Schedule{Mode: Graduated, Tiers: []Tier{{UnitCents: unitCents}}}
+}
+
+// Validate checks that the tiers are ordered and that only the last one is unbounded
+func (s Schedule) Validate() error {
+	if s.Mode != Graduated && s.Mode != Volume {
+		return fmt.Errorf("unknown pricing mode %q", s.Mode)
+	}
+	if len(s.Tiers) == 0 {
+		return fmt.Errorf("pricing schedule has no tiers")
+	}
+
+	previous := 0.0
+	for i, tier := range s.Tiers {
+		last := i == len(s.Tiers)-1
+		if tier.UpTo == 0 && !last {
+			return fmt.Errorf("tier %d has no upper bound but is not the last tier", i+1)
+		}
+		if tier.UpTo != 0 && tier.UpTo <= previous {
+			return fmt.Errorf("tier %d must end above %g", i+1, previous)
+		}
+		if tier.UnitCents < 0 || tier.FlatCents < 0 {
+			return fmt.Errorf("tier %d has a negative price", i+1)
+		}
+		previous = tier.UpTo
+	}
+	return nil
+}
+
+// Price returns the cost in cents of the given units. Units beyond the last bounded
+// tier are priced at that tier when the schedule has no unbounded tier.
+func (s Schedule) Price(units float64) float64 {
+	if units <= 0 || len(s.Tiers) == 0 {
+		return 0
+	}
+
+	if s.Mode == Volume {
+		tier := s.Tiers[len(s.Tiers)-1]
+		for _, candidate := range s.Tiers {
+			if candidate.UpTo == 0 || units <= candidate.UpTo {
+				tier = candidate
+				break
+			}
+		}
+		return units*tier.UnitCents + tier.FlatCents
+	}
+
+	cents := 0.0
+	previous := 0.0
+	for i, tier := range s.Tiers {
+		upTo := tier.UpTo
+		if upTo == 0 || i == len(s.Tiers)-1 {
+			upTo = math.Inf(1)
+		}
+		inTier := math.Min(units, upTo) - previous
+		if inTier <= 0 {
+			break
+		}
+		cents += inTier*tier.UnitCents + tier.FlatCents
+		previous = upTo
+	}
+	return cents
+}
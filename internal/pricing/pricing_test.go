@@ -0,0 +1,136 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedulePrice(t *testing.T) {
+	t.Parallel()
+
+	tiers := []Tier{{UpTo: 1000, UnitCents: 2}, {UpTo: 11000, UnitCents: 1}, {UnitCents: 0.5}}
+
+	testCases := []struct {
+		Name     string
+		Schedule Schedule
+		Units    float64
+		Expected float64
+	}{
+		{
+			Name:     "flat price",
+			Schedule: Flat(1.5),
+			Units:    100,
+			Expected: 150,
+		},
+		{
+			Name:     "graduated within the first tier",
+			Schedule: Schedule{Mode: Graduated, Tiers: tiers},
+			Units:    500,
+			Expected: 1000,
+		},
+		{
+			Name:     "graduated across every tier",
+			Schedule: Schedule{Mode: Graduated, Tiers: tiers},
+			Units:    12000,
+			Expected: 2000 + 10000 + 500,
+		},
+		{
+			Name:     "graduated on a tier boundary",
+			Schedule: Schedule{Mode: Graduated, Tiers: tiers},
+			Units:    1000,
+			Expected: 2000,
+		},
+		{
+			Name:     "volume prices every unit at the tier of the total",
+			Schedule: Schedule{Mode: Volume, Tiers: tiers},
+			Units:    12000,
+			Expected: 6000,
+		},
+		{
+			Name:     "volume on a tier boundary stays in the lower tier",
+			Schedule: Schedule{Mode: Volume, Tiers: tiers},
+			Units:    1000,
+			Expected: 2000,
+		},
+		{
+			Name:     "flat fees are charged once per tier reached",
+			Schedule: Schedule{Mode: Graduated, Tiers: []Tier{{UpTo: 10, FlatCents: 500}, {UnitCents: 1, FlatCents: 100}}},
+			Units:    20,
+			Expected: 500 + 10 + 100,
+		},
+		{
+			Name:     "units beyond the last bounded tier use that tier",
+			Schedule: Schedule{Mode: Graduated, Tiers: []Tier{{UpTo: 10, UnitCents: 3}, {UpTo: 20, UnitCents: 2}}},
+			Units:    30,
+			Expected: 30 + 40,
+		},
+		{
+			Name:     "no units cost nothing",
+			Schedule: Schedule{Mode: Volume, Tiers: []Tier{{UnitCents: 1, FlatCents: 100}}},
+			Units:    0,
+			Expected: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.InDelta(t, tc.Expected, tc.Schedule.Price(tc.Units), 0.0001)
+		})
+	}
+}
+
+func TestScheduleValidate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		Name     string
+		Schedule Schedule
+		Error    string
+	}{
+		{
+			Name:     "valid graduated tiers",
+			Schedule: Schedule{Mode: Graduated, Tiers: []Tier{{UpTo: 1000, UnitCents: 2}, {UnitCents: 1}}},
+		},
+		{
+			Name:     "unknown mode",
+			Schedule: Schedule{Mode: "stairstep", Tiers: []Tier{{UnitCents: 1}}},
+			Error:    `unknown pricing mode "stairstep"`,
+		},
+		{
+			Name:     "no tiers",
+			Schedule: Schedule{Mode: Volume},
+			Error:    "pricing schedule has no tiers",
+		},
+		{
+			Name:     "unbounded tier before the last",
+			Schedule: Schedule{Mode: Graduated, Tiers: []Tier{{UnitCents: 2}, {UpTo: 1000, UnitCents: 1}}},
+			Error:    "tier 1 has no upper bound but is not the last tier",
+		},
+		{
+			Name:     "tiers out of order",
+			Schedule: Schedule{Mode: Graduated, Tiers: []Tier{{UpTo: 1000, UnitCents: 2}, {UpTo: 500, UnitCents: 1}}},
+			Error:    "tier 2 must end above 1000",
+		},
+		{
+			Name:     "negative price",
+			Schedule: Schedule{Mode: Volume, Tiers: []Tier{{UnitCents: -1}}},
+			Error:    "tier 1 has a negative price",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.Schedule.Validate()
+			if tc.Error == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tc.Error)
+		})
+	}
+}
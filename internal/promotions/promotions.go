@@ -0,0 +1,266 @@
+package promotions
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Kinds of coupon
+const (
+	KindPercent = "percent"
+	KindFixed   = "fixed"
+)
+
+// How many billing cycles a redeemed coupon applies to
+const (
+	DurationOnce      = "once"
+	DurationRepeating = "repeating"
+	DurationForever   = "forever"
+)
+
+// Invoice categories a coupon can be limited to
+const (
+	CategoryMembership = "membership"
+	CategoryCalls      = "calls"
+	CategoryRecordings = "recordings"
+	CategoryFax        = "fax"
+	CategoryNumbers    = "numbers"
+	CategoryAddOns     = "addons"
+)
+
+// Categories lists every category in the order discounts are taken from them
+var Categories = []string{CategoryMembership, CategoryAddOns, CategoryNumbers, CategoryCalls, CategoryRecordings, CategoryFax}
+
+var (
+	ErrCouponNotFound  = errors.New("coupon not found")
+	ErrCouponExpired   = errors.New("coupon has expired")
+	ErrCouponExhausted = errors.New("coupon has reached its redemption limit")
+	ErrAlreadyRedeemed = errors.New("coupon was already redeemed by this workspace")
+)
+
+// Coupon is a discount that workspaces can redeem
+type Coupon struct {
+	RedeemBy       time.Time
+	Code           string
+	Kind           string
+	Duration       string
+	Categories     []string // empty applies to every category
+	ID             int64
+	PercentOff     float64
+	AmountOffCents int64
+	DurationMonths int
+	MaxRedemptions int // 0 is unlimited
+	Stackable      bool
+}
+
+// Redemption is a coupon redeemed by a workspace. CyclesApplied counts the calendar months of the
+// invoices it was applied to, so the monthly and annual invoices of one month use a single cycle.
+type Redemption struct {
+	RedeemedAt    time.Time
+	LastCycle     time.Time // period end of the latest invoice it was applied to, zero if none
+	Coupon        Coupon
+	ID            int64
+	WorkspaceID   int
+	CyclesApplied int
+}
+
+// Discount is a negative line item on an invoice
+type Discount struct {
+	Category     string `json:"category"`
+	Description  string `json:"description"`
+	CouponID     int64  `json:"coupon_id"`
+	RedemptionID int64  `json:"redemption_id"`
+	Cents        int64  `json:"cents"`
+}
+
+// Validate checks that a coupon describes a usable discount
+func (c Coupon) Validate() error {
+	switch c.Kind {
+	case KindPercent:
+		if c.PercentOff <= 0 || c.PercentOff > 100 {
+			return fmt.Errorf("coupon %s: percent off must be between 0 and 100", c.Code)
+		}
+	case KindFixed:
+		if c.AmountOffCents <= 0 {
+			return fmt.Errorf("coupon %s: amount off must be positive", c.Code)
+		}
+	default:
+		return fmt.Errorf("coupon %s: unknown kind %q", c.Code, c.Kind)
+	}
+
+	switch c.Duration {
+	case DurationOnce, DurationForever:
+	case DurationRepeating:
+		if c.DurationMonths <= 0 {
+			return fmt.Errorf("coupon %s: repeating coupons need a number of months", c.Code)
+		}
+	default:
+		return fmt.Errorf("coupon %s: unknown duration %q", c.Code, c.Duration)
+	}
+
+	for _, category := range c.Categories {
+		if !isCategory(category) {
+			return fmt.Errorf("coupon %s: unknown category %q", c.Code, category)
+		}
+	}
+	return nil
+}
+
+// CanRedeem checks the redemption deadline and limit given how often the coupon was redeemed
+func (c Coupon) CanRedeem(redemptions int, at time.Time) error {
+	if !c.RedeemBy.IsZero() && at.After(c.RedeemBy) {
+		return ErrCouponExpired
+	}
+	if c.MaxRedemptions > 0 && redemptions >= c.MaxRedemptions {
+		return ErrCouponExhausted
+	}
+	return nil
+}
+
+// Active reports whether the redemption still applies to an invoice for the period ending at periodEnd.
+// A repeating coupon keeps applying to the other invoices of a month it already used.
+func (r Redemption) Active(periodEnd time.Time) bool {
+	switch r.Coupon.Duration {
+	case DurationOnce:
+		return r.CyclesApplied < 1
+	case DurationRepeating:
+		return r.CyclesApplied < r.Coupon.DurationMonths || sameMonth(r.LastCycle, periodEnd)
+	case DurationForever:
+		return true
+	}
+	return false
+}
+
+func (c Coupon) appliesTo(category string) bool {
+	if len(c.Categories) == 0 {
+		return true
+	}
+	for _, target := range c.Categories {
+		if target == category {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Coupon) description() string {
+	if c.Kind == KindPercent {
+		return fmt.Sprintf("%s (%g%% off)", c.Code, c.PercentOff)
+	}
+	return fmt.Sprintf("%s (%d cents off)", c.Code, c.AmountOffCents)
+}
+
+func isCategory(category string) bool {
+	for _, known := range Categories {
+		if known == category {
+			return true
+		}
+	}
+	return false
+}
+
+// sameMonth reports whether a billing cycle was already counted for the calendar month of at
+func sameMonth(cycle, at time.Time) bool {
+	return !cycle.IsZero() && cycle.Year() == at.Year() && cycle.Month() == at.Month()
+}
+
+// Select picks the redemptions that apply to an invoice. Only active redemptions count. If any of
+// them is not stackable, the earliest such redemption is used on its own; otherwise all are used.
+func Select(redemptions []Redemption, periodEnd time.Time) []Redemption {
+	active := make([]Redemption, 0, len(redemptions))
+	for _, r := range redemptions {
+		if r.Active(periodEnd) {
+			active = append(active, r)
+		}
+	}
+	sort.SliceStable(active, func(i, j int) bool {
+		if !active[i].RedeemedAt.Equal(active[j].RedeemedAt) {
+			return active[i].RedeemedAt.Before(active[j].RedeemedAt)
+		}
+		return active[i].ID < active[j].ID
+	})
+
+	for _, r := range active {
+		if !r.Coupon.Stackable {
+			return []Redemption{r}
+		}
+	}
+	return active
+}
+
+// Apply works out the discounts on the cents billed in each category of an invoice for the period
+// ending at periodEnd. Percentage coupons are applied first, each to what is left after the previous
+// ones, then fixed amounts are taken from the eligible categories in order. A category is never
+// discounted below zero.
+func Apply(amounts map[string]int64, redemptions []Redemption, periodEnd time.Time) []Discount {
+	remaining := make(map[string]int64, len(amounts))
+	for category, cents := range amounts {
+		remaining[category] = cents
+	}
+
+	selected := Select(redemptions, periodEnd)
+	discounts := make([]Discount, 0)
+	for _, kind := range []string{KindPercent, KindFixed} {
+		for _, r := range selected {
+			if r.Coupon.Kind != kind {
+				continue
+			}
+
+			amountLeft := r.Coupon.AmountOffCents
+			for _, category := range Categories {
+				if !r.Coupon.appliesTo(category) || remaining[category] <= 0 {
+					continue
+				}
+
+				var off int64
+				if kind == KindPercent {
+					off = int64(math.Round(float64(remaining[category]) * r.Coupon.PercentOff / 100))
+				} else {
+					off = min(amountLeft, remaining[category])
+					amountLeft -= off
+				}
+				if off <= 0 {
+					continue
+				}
+
+				remaining[category] -= off
+				discounts = append(discounts, Discount{
+					Category:     category,
+					Description:  r.Coupon.description(),
+					CouponID:     r.Coupon.ID,
+					RedemptionID: r.ID,
+					Cents:        -off,
+				})
+			}
+		}
+	}
+
+	return discounts
+}
+
+// Total sums the discounts, limited to one category when it is given
+func Total(discounts []Discount, category string) int64 {
+	var total int64
+	for _, d := range discounts {
+		if category == "" || d.Category == category {
+			total += d.Cents
+		}
+	}
+	return total
+}
+
+// RedemptionIDs lists the redemptions used by the discounts, once each
+func RedemptionIDs(discounts []Discount) []int64 {
+	seen := make(map[int64]bool)
+	ids := make([]int64, 0)
+	for _, d := range discounts {
+		if !seen[d.RedemptionID] {
+			seen[d.RedemptionID] = true
+			ids = append(ids, d.RedemptionID)
+		}
+	}
+	return ids
+}
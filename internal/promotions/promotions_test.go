@@ -0,0 +1,125 @@
+package promotions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	day := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	amounts := map[string]int64{CategoryMembership: 2000, CategoryCalls: 1000, CategoryFax: 100}
+
+	tenPercent := Coupon{ID: 1, Code: "TEN", Kind: KindPercent, PercentOff: 10, Duration: DurationForever, Stackable: true}
+	membershipHalf := Coupon{ID: 2, Code: "HALF", Kind: KindPercent, PercentOff: 50, Duration: DurationOnce, Categories: []string{CategoryMembership}}
+	fiveDollars := Coupon{ID: 3, Code: "FIVE", Kind: KindFixed, AmountOffCents: 500, Duration: DurationForever, Stackable: true}
+	bigCredit := Coupon{ID: 4, Code: "BIG", Kind: KindFixed, AmountOffCents: 10000, Duration: DurationRepeating, DurationMonths: 3, Stackable: true, Categories: []string{CategoryCalls}}
+
+	testCases := []struct {
+		Name          string
+		Redemptions   []Redemption
+		ExpectedLines []Discount
+	}{
+		{
+			Name:        "percentage applies to every category",
+			Redemptions: []Redemption{{ID: 10, Coupon: tenPercent, RedeemedAt: day}},
+			ExpectedLines: []Discount{
+				{Category: CategoryMembership, Description: "TEN (10% off)", CouponID: 1, RedemptionID: 10, Cents: -200},
+				{Category: CategoryCalls, Description: "TEN (10% off)", CouponID: 1, RedemptionID: 10, Cents: -100},
+				{Category: CategoryFax, Description: "TEN (10% off)", CouponID: 1, RedemptionID: 10, Cents: -10},
+			},
+		},
+		{
+			Name:        "percentage runs before fixed amounts when stacked",
+			Redemptions: []Redemption{{ID: 11, Coupon: fiveDollars, RedeemedAt: day}, {ID: 10, Coupon: tenPercent, RedeemedAt: day.Add(time.Hour)}},
+			ExpectedLines: []Discount{
+				{Category: CategoryMembership, Description: "TEN (10% off)", CouponID: 1, RedemptionID: 10, Cents: -200},
+				{Category: CategoryCalls, Description: "TEN (10% off)", CouponID: 1, RedemptionID: 10, Cents: -100},
+				{Category: CategoryFax, Description: "TEN (10% off)", CouponID: 1, RedemptionID: 10, Cents: -10},
+				{Category: CategoryMembership, Description: "FIVE (500 cents off)", CouponID: 3, RedemptionID: 11, Cents: -500},
+			},
+		},
+		{
+			Name:        "a coupon that does not stack is used on its own",
+			Redemptions: []Redemption{{ID: 10, Coupon: tenPercent, RedeemedAt: day}, {ID: 12, Coupon: membershipHalf, RedeemedAt: day.Add(time.Hour)}},
+			ExpectedLines: []Discount{
+				{Category: CategoryMembership, Description: "HALF (50% off)", CouponID: 2, RedemptionID: 12, Cents: -1000},
+			},
+		},
+		{
+			Name:        "fixed amounts never take a category below zero",
+			Redemptions: []Redemption{{ID: 13, Coupon: bigCredit, RedeemedAt: day}},
+			ExpectedLines: []Discount{
+				{Category: CategoryCalls, Description: "BIG (10000 cents off)", CouponID: 4, RedemptionID: 13, Cents: -1000},
+			},
+		},
+		{
+			Name:          "used up redemptions are skipped",
+			Redemptions:   []Redemption{{ID: 12, Coupon: membershipHalf, RedeemedAt: day, CyclesApplied: 1}, {ID: 13, Coupon: bigCredit, RedeemedAt: day, CyclesApplied: 3}},
+			ExpectedLines: []Discount{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			lines := Apply(amounts, tc.Redemptions, day)
+			assert.Equal(t, tc.ExpectedLines, lines)
+		})
+	}
+}
+
+func TestRedemptionActive(t *testing.T) {
+	t.Parallel()
+
+	march := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	threeMonths := Coupon{Duration: DurationRepeating, DurationMonths: 3}
+	once := Coupon{Duration: DurationOnce}
+
+	testCases := []struct {
+		Name       string
+		Redemption Redemption
+		PeriodEnd  time.Time
+		Expected   bool
+	}{
+		{Name: "repeating coupon with cycles left", Redemption: Redemption{Coupon: threeMonths, CyclesApplied: 2, LastCycle: march}, PeriodEnd: march.AddDate(0, 1, 0), Expected: true},
+		{Name: "repeating coupon used up", Redemption: Redemption{Coupon: threeMonths, CyclesApplied: 3, LastCycle: march}, PeriodEnd: march.AddDate(0, 1, 0), Expected: false},
+		// the annual invoice and the overage invoice of the same month share its last cycle
+		{Name: "repeating coupon on another invoice of the month it used last", Redemption: Redemption{Coupon: threeMonths, CyclesApplied: 3, LastCycle: march}, PeriodEnd: march, Expected: true},
+		{Name: "once coupon is not reused in the same month", Redemption: Redemption{Coupon: once, CyclesApplied: 1, LastCycle: march}, PeriodEnd: march, Expected: false},
+		{Name: "forever coupon", Redemption: Redemption{Coupon: Coupon{Duration: DurationForever}, CyclesApplied: 40}, PeriodEnd: march, Expected: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.Expected, tc.Redemption.Active(tc.PeriodEnd))
+		})
+	}
+}
+
+func TestCouponCanRedeem(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	coupon := Coupon{MaxRedemptions: 2, RedeemBy: at}
+
+	assert.NoError(t, coupon.CanRedeem(1, at))
+	assert.ErrorIs(t, coupon.CanRedeem(2, at), ErrCouponExhausted)
+	assert.ErrorIs(t, coupon.CanRedeem(0, at.Add(time.Second)), ErrCouponExpired)
+	assert.NoError(t, Coupon{}.CanRedeem(1000, at))
+}
+
+func TestCouponValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, Coupon{Code: "OK", Kind: KindPercent, PercentOff: 20, Duration: DurationRepeating, DurationMonths: 3}.Validate())
+	assert.Error(t, Coupon{Code: "PCT", Kind: KindPercent, PercentOff: 120, Duration: DurationOnce}.Validate())
+	assert.Error(t, Coupon{Code: "REP", Kind: KindFixed, AmountOffCents: 100, Duration: DurationRepeating}.Validate())
+	assert.Error(t, Coupon{Code: "CAT", Kind: KindFixed, AmountOffCents: 100, Duration: DurationOnce, Categories: []string{"support"}}.Validate())
+}
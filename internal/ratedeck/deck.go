@@ -0,0 +1,178 @@
+package ratedeck
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rate is the price of calls to a destination prefix for a window of time
+type Rate struct {
+	EffectiveFrom      time.Time
+	EffectiveTo        time.Time
+	Prefix             string
+	PerMinuteCents     float64
+	ConnectionFeeCents float64
+}
+
+// Deck holds per-prefix destination rates and resolves numbers by longest-prefix match
+type Deck struct {
+	rates   map[string][]Rate
+	Version string
+}
+
+// versions name a file in the rate deck directory, so they cannot hold path separators
+var versionPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// expected CSV columns, in order
+var header = []string{"prefix", "per_minute_cents", "connection_fee_cents", "effective_from", "effective_to"}
+
+// EffectiveAt reports whether the rate applies at the given time. A zero
+// EffectiveTo means the rate has no end date.
+func (r *Rate) EffectiveAt(at time.Time) bool {
+	if at.Before(r.EffectiveFrom) {
+		return false
+	}
+	return r.EffectiveTo.IsZero() || at.Before(r.EffectiveTo)
+}
+
+// Price returns the cents for a call billed for the given number of seconds
+func (r *Rate) Price(billableSeconds int64) int64 {
+	if billableSeconds <= 0 {
+		return 0
+	}
+	return int64(math.Round(r.ConnectionFeeCents + r.PerMinuteCents*float64(billableSeconds)/60))
+}
+
+// LoadFile reads a rate deck CSV from disk. The version defaults to the file name.
+func LoadFile(path string, version string) (*Deck, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if version == "" {
+		version = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return Load(f, version)
+}
+
+// LoadVersion reads the deck stored as <version>.csv in the rate deck directory. Versions are letters, digits,
+// dots, dashes and underscores, so a version from a queued task cannot name a file outside the directory.
+func LoadVersion(dir string, version string) (*Deck, error) {
+	if version == "" {
+		return nil, fmt.Errorf("rate deck version is required")
+	}
+	if !versionPattern.MatchString(version) || strings.Contains(version, "..") {
+		return nil, fmt.Errorf("invalid rate deck version %q", version)
+	}
+	return LoadFile(filepath.Join(dir, version+".csv"), version)
+}
+
+// Load parses a rate deck CSV with the columns
+// prefix, per_minute_cents, connection_fee_cents, effective_from, effective_to.
+// Dates use the YYYY-MM-DD format and an empty effective_to leaves the rate open ended.
+func Load(r io.Reader, version string) (*Deck, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	deck := &Deck{
+		rates:   make(map[string][]Rate),
+		Version: version,
+	}
+
+	for i, record := range records {
+		if i == 0 && strings.EqualFold(record[0], header[0]) {
+			continue
+		}
+		if len(record) != len(header) {
+			return nil, fmt.Errorf("rate deck line %d: expected %d columns, got %d", i+1, len(header), len(record))
+		}
+
+		rate, err := parseRate(record)
+		if err != nil {
+			return nil, fmt.Errorf("rate deck line %d: %w", i+1, err)
+		}
+		deck.rates[rate.Prefix] = append(deck.rates[rate.Prefix], *rate)
+	}
+
+	return deck, nil
+}
+
+func parseRate(record []string) (*Rate, error) {
+	prefix := normalizeNumber(record[0])
+	if prefix == "" {
+		return nil, fmt.Errorf("empty prefix")
+	}
+
+	perMinute, err := strconv.ParseFloat(record[1], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid per_minute_cents: %w", err)
+	}
+
+	connectionFee := 0.0
+	if record[2] != "" {
+		connectionFee, err = strconv.ParseFloat(record[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid connection_fee_cents: %w", err)
+		}
+	}
+
+	rate := &Rate{
+		Prefix:             prefix,
+		PerMinuteCents:     perMinute,
+		ConnectionFeeCents: connectionFee,
+	}
+
+	if record[3] != "" {
+		rate.EffectiveFrom, err = time.Parse(time.DateOnly, record[3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid effective_from: %w", err)
+		}
+	}
+	if record[4] != "" {
+		rate.EffectiveTo, err = time.Parse(time.DateOnly, record[4])
+		if err != nil {
+			return nil, fmt.Errorf("invalid effective_to: %w", err)
+		}
+	}
+
+	return rate, nil
+}
+
+// Lookup finds the rate for the longest prefix of number that is effective at the given time
+func (d *Deck) Lookup(number string, at time.Time) (*Rate, bool) {
+	digits := normalizeNumber(number)
+	for l := len(digits); l > 0; l-- {
+		for _, rate := range d.rates[digits[:l]] {
+			if rate.EffectiveAt(at) {
+				return &rate, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// normalizeNumber strips everything but digits so "+1 (555) 010" and "1555010" match the same prefix
+func normalizeNumber(number string) string {
+	var b strings.Builder
+	for _, c := range number {
+		if c >= '0' && c <= '9' {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,87 @@
+package ratedeck
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testDeckCSV = `prefix,per_minute_cents,connection_fee_cents,effective_from,effective_to
+1,1.2,0,2020-01-01,
+1416,0.8,0,2020-01-01,2026-01-01
+1416,0.6,0,2026-01-01,
+44,2.5,1,2020-01-01,
+447,9,1,2020-01-01,
+`
+
+func TestDeckLookup(t *testing.T) {
+	t.Parallel()
+
+	deck, err := Load(strings.NewReader(testDeckCSV), "v1")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", deck.Version)
+
+	testCases := []struct {
+		At             time.Time
+		Name           string
+		Number         string
+		ExpectedPrefix string
+		ExpectedRate   float64
+		ExpectedFound  bool
+	}{
+		{Name: "matches the longest prefix", Number: "+1 (416) 555-0100", At: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), ExpectedPrefix: "1416", ExpectedRate: 0.8, ExpectedFound: true},
+		{Name: "uses the rate effective at the call time", Number: "14165550100", At: time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC), ExpectedPrefix: "1416", ExpectedRate: 0.6, ExpectedFound: true},
+		{Name: "falls back to a shorter prefix", Number: "12125550100", At: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), ExpectedPrefix: "1", ExpectedRate: 1.2, ExpectedFound: true},
+		{Name: "distinguishes mobile prefixes", Number: "447700900123", At: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), ExpectedPrefix: "447", ExpectedRate: 9, ExpectedFound: true},
+		{Name: "returns nothing for unknown destinations", Number: "61255500100", At: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), ExpectedFound: false},
+		{Name: "returns nothing before the rate is effective", Number: "12125550100", At: time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC), ExpectedFound: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			rate, found := deck.Lookup(tc.Number, tc.At)
+			assert.Equal(t, tc.ExpectedFound, found)
+			if tc.ExpectedFound {
+				assert.Equal(t, tc.ExpectedPrefix, rate.Prefix)
+				assert.Equal(t, tc.ExpectedRate, rate.PerMinuteCents)
+			}
+		})
+	}
+}
+
+func TestRatePrice(t *testing.T) {
+	t.Parallel()
+
+	rate := Rate{PerMinuteCents: 2.5, ConnectionFeeCents: 1}
+	assert.Equal(t, int64(6), rate.Price(120))
+	assert.Equal(t, int64(0), rate.Price(0))
+}
+
+func TestLoadInvalidDeck(t *testing.T) {
+	t.Parallel()
+
+	_, err := Load(strings.NewReader("1,abc,0,2020-01-01,\n"), "bad")
+	assert.Error(t, err)
+}
+
+func TestLoadVersion(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "2026-01_fixed.csv"), []byte("1,6,0,,\n"), 0o600))
+
+	deck, err := LoadVersion(dir, "2026-01_fixed")
+	assert.NoError(t, err)
+	assert.Equal(t, "2026-01_fixed", deck.Version)
+
+	for _, version := range []string{"", "../../etc/x", "decks/2026-01", "..", `..\x`} {
+		_, err := LoadVersion(dir, version)
+		assert.Error(t, err, version)
+	}
+}
@@ -0,0 +1,117 @@
+package suspension
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Billing statuses of a workspace
+const (
+	Active     = "active"
+	Restricted = "restricted"
+	Suspended  = "suspended"
+)
+
+// What happens to the numbers of a suspended workspace
+const (
+	NumbersKeep    = "keep"
+	NumbersHold    = "hold"    // kept for the workspace but out of service until it is reactivated
+	NumbersRelease = "release" // given up for good
+)
+
+// Rule is reached once any unpaid invoice has failed this many collection attempts or is this
+// many days overdue. Zero disables a threshold.
+type Rule struct {
+	FailedAttempts int
+	DaysOverdue    int
+}
+
+// Policy decides the billing status of a workspace from its unpaid invoices
+type Policy struct {
+	Numbers  string
+	Restrict Rule
+	Suspend  Rule
+}
+
+// PolicyFromConfig reads the policy from the RESTRICT_AFTER_ATTEMPTS, RESTRICT_AFTER_DAYS, SUSPEND_AFTER_ATTEMPTS,
+// SUSPEND_AFTER_DAYS and SUSPEND_NUMBERS settings. Unset thresholds are disabled and numbers are held by default.
+func PolicyFromConfig(config func(key string) string) Policy {
+	setting := func(key string) int {
+		value, _ := strconv.Atoi(config(key))
+		return value
+	}
+
+	policy := Policy{
+		Numbers:  config("SUSPEND_NUMBERS"),
+		Restrict: Rule{FailedAttempts: setting("RESTRICT_AFTER_ATTEMPTS"), DaysOverdue: setting("RESTRICT_AFTER_DAYS")},
+		Suspend:  Rule{FailedAttempts: setting("SUSPEND_AFTER_ATTEMPTS"), DaysOverdue: setting("SUSPEND_AFTER_DAYS")},
+	}
+	if policy.Numbers == "" {
+		policy.Numbers = NumbersHold
+	}
+	return policy
+}
+
+// Invoice is an unpaid invoice of the workspace
+type Invoice struct {
+	DueDate        time.Time
+	ID             int64
+	FailedAttempts int
+}
+
+// Validate checks the thresholds and the numbers action
+func (p Policy) Validate() error {
+	for _, rule := range []Rule{p.Restrict, p.Suspend} {
+		if rule.FailedAttempts < 0 || rule.DaysOverdue < 0 {
+			return fmt.Errorf("suspension thresholds cannot be negative")
+		}
+	}
+
+	switch p.Numbers {
+	case NumbersKeep, NumbersHold, NumbersRelease:
+		return nil
+	default:
+		return fmt.Errorf("unknown numbers action %q", p.Numbers)
+	}
+}
+
+// Evaluate returns the status the workspace should have at the given time and why.
+// Suspension is checked first; a workspace reaching neither rule is active.
+func Evaluate(policy Policy, invoices []Invoice, at time.Time) (string, string) {
+	if reason, ok := policy.Suspend.reached(invoices, at); ok {
+		return Suspended, reason
+	}
+	if reason, ok := policy.Restrict.reached(invoices, at); ok {
+		return Restricted, reason
+	}
+	return Active, "no unpaid invoice is past the thresholds"
+}
+
+// Lifts reports whether moving between two statuses makes the workspace less restricted
+func Lifts(from, to string) bool {
+	return severity(to) < severity(from)
+}
+
+func severity(status string) int {
+	switch status {
+	case Suspended:
+		return 2
+	case Restricted:
+		return 1
+	}
+	return 0
+}
+
+// reached returns why the first invoice to reach the rule reached it
+func (r Rule) reached(invoices []Invoice, at time.Time) (string, bool) {
+	for _, inv := range invoices {
+		if r.FailedAttempts > 0 && inv.FailedAttempts >= r.FailedAttempts {
+			return fmt.Sprintf("invoice %d failed %d collection attempts", inv.ID, inv.FailedAttempts), true
+		}
+		if overdue := int(at.Sub(inv.DueDate).Hours() / 24); r.DaysOverdue > 0 && overdue >= r.DaysOverdue {
+			return fmt.Sprintf("invoice %d is %d days overdue", inv.ID, overdue), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,88 @@
+package suspension
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluate(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	policy := Policy{
+		Numbers:  NumbersHold,
+		Restrict: Rule{FailedAttempts: 2, DaysOverdue: 15},
+		Suspend:  Rule{FailedAttempts: 4, DaysOverdue: 30},
+	}
+
+	testCases := []struct {
+		Name           string
+		Invoices       []Invoice
+		ExpectedStatus string
+		ExpectedReason string
+	}{
+		{
+			Name:           "workspaces without unpaid invoices are active",
+			ExpectedStatus: Active,
+			ExpectedReason: "no unpaid invoice is past the thresholds",
+		},
+		{
+			Name:           "invoices under the thresholds keep the workspace active",
+			Invoices:       []Invoice{{ID: 9, FailedAttempts: 1, DueDate: at.AddDate(0, 0, -14)}},
+			ExpectedStatus: Active,
+			ExpectedReason: "no unpaid invoice is past the thresholds",
+		},
+		{
+			Name:           "failed attempts restrict the workspace",
+			Invoices:       []Invoice{{ID: 9, FailedAttempts: 2, DueDate: at.AddDate(0, 0, -3)}},
+			ExpectedStatus: Restricted,
+			ExpectedReason: "invoice 9 failed 2 collection attempts",
+		},
+		{
+			Name: "any invoice past the suspension threshold suspends the workspace",
+			Invoices: []Invoice{
+				{ID: 9, FailedAttempts: 2, DueDate: at.AddDate(0, 0, -3)},
+				{ID: 7, DueDate: at.AddDate(0, 0, -31)},
+			},
+			ExpectedStatus: Suspended,
+			ExpectedReason: "invoice 7 is 31 days overdue",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			status, reason := Evaluate(policy, tc.Invoices, at)
+			assert.Equal(t, tc.ExpectedStatus, status)
+			assert.Equal(t, tc.ExpectedReason, reason)
+		})
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, Policy{Numbers: NumbersRelease, Suspend: Rule{DaysOverdue: 30}}.Validate())
+	assert.EqualError(t, Policy{Numbers: "park"}.Validate(), `unknown numbers action "park"`)
+	assert.EqualError(t, Policy{Numbers: NumbersKeep, Restrict: Rule{DaysOverdue: -1}}.Validate(), "suspension thresholds cannot be negative")
+}
+
+func TestPolicyFromConfig(t *testing.T) {
+	t.Parallel()
+
+	settings := map[string]string{"RESTRICT_AFTER_ATTEMPTS": "2", "SUSPEND_AFTER_DAYS": "30"}
+	policy := PolicyFromConfig(func(key string) string { return settings[key] })
+	assert.Equal(t, Policy{Numbers: NumbersHold, Restrict: Rule{FailedAttempts: 2}, Suspend: Rule{DaysOverdue: 30}}, policy)
+}
+
+func TestLifts(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, Lifts(Suspended, Restricted))
+	assert.True(t, Lifts(Restricted, Active))
+	assert.False(t, Lifts(Restricted, Suspended))
+	assert.False(t, Lifts(Active, Active))
+}
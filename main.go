@@ -69,6 +69,84 @@ func main() {
 		if err != nil {
 			helpers.Log(logrus.ErrorLevel, err.Error())
 		}
+	case "rerate":
+		helpers.Log(logrus.InfoLevel, "re-rating call debits")
+		err = cmd.Rerate(args[1:])
+		if err != nil {
+			helpers.Log(logrus.ErrorLevel, err.Error())
+		}
+	case "reconcile_charges":
+		helpers.Log(logrus.InfoLevel, "reconciling pending card charges")
+		err = cmd.ReconcileCharges(args[1:])
+		if err != nil {
+			helpers.Log(logrus.ErrorLevel, err.Error())
+		}
+	case "estimate":
+		helpers.Log(logrus.InfoLevel, "estimating workspace bill")
+		err = cmd.Estimate(args[1:])
+		if err != nil {
+			helpers.Log(logrus.ErrorLevel, err.Error())
+		}
+	case "forecast_bills":
+		helpers.Log(logrus.InfoLevel, "forecasting month-end bills")
+		err = cmd.ForecastBills(args[1:])
+		if err != nil {
+			helpers.Log(logrus.ErrorLevel, err.Error())
+		}
+	case "invoice_reminders":
+		helpers.Log(logrus.InfoLevel, "sending invoice reminders")
+		err = cmd.SendInvoiceReminders(args[1:])
+		if err != nil {
+			helpers.Log(logrus.ErrorLevel, err.Error())
+		}
+	case "late_fees":
+		helpers.Log(logrus.InfoLevel, "applying late fees to overdue invoices")
+		err = cmd.ApplyLateFees(args[1:])
+		if err != nil {
+			helpers.Log(logrus.ErrorLevel, err.Error())
+		}
+	case "enforce_payment_status":
+		helpers.Log(logrus.InfoLevel, "enforcing payment status of workspaces")
+		err = cmd.EnforcePaymentStatus(args[1:])
+		if err != nil {
+			helpers.Log(logrus.ErrorLevel, err.Error())
+		}
+	case "auto_topup":
+		helpers.Log(logrus.InfoLevel, "topping up low credit balances")
+		err = cmd.AutoTopUp(args[1:])
+		if err != nil {
+			helpers.Log(logrus.ErrorLevel, err.Error())
+		}
+	case "enforce_balances":
+		helpers.Log(logrus.InfoLevel, "enforcing balances of prepaid workspaces")
+		err = cmd.EnforceBalances(args[1:])
+		if err != nil {
+			helpers.Log(logrus.ErrorLevel, err.Error())
+		}
+	case "backfill_debits":
+		helpers.Log(logrus.InfoLevel, "marking debits billed before debit claiming")
+		err = cmd.BackfillDebits(args[1:])
+		if err != nil {
+			helpers.Log(logrus.ErrorLevel, err.Error())
+		}
+	case "redeem_coupon":
+		helpers.Log(logrus.InfoLevel, "redeeming coupon")
+		err = cmd.RedeemCoupon(args[1:])
+		if err != nil {
+			helpers.Log(logrus.ErrorLevel, err.Error())
+		}
+	case "void-invoice":
+		helpers.Log(logrus.InfoLevel, "voiding invoice")
+		err = cmd.VoidInvoice(args[1:])
+		if err != nil {
+			helpers.Log(logrus.ErrorLevel, err.Error())
+		}
+	case "reissue-invoice":
+		helpers.Log(logrus.InfoLevel, "reissuing invoice")
+		err = cmd.ReissueInvoice(args[1:])
+		if err != nil {
+			helpers.Log(logrus.ErrorLevel, err.Error())
+		}
 	case "remove_logs":
 		helpers.Log(logrus.InfoLevel, "removing old logs")
 		err = cmd.RemoveLogs()
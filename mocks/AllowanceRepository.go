@@ -0,0 +1,148 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	mock "github.com/stretchr/testify/mock"
+	allowance "lineblocs.com/scheduler/internal/allowance"
+
+	repository "lineblocs.com/scheduler/repository"
+
+	time "time"
+)
+
+// AllowanceRepository is an autogenerated mock type for the AllowanceRepository type
+type AllowanceRepository struct {
+	mock.Mock
+}
+
+type AllowanceRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *AllowanceRepository) EXPECT() *AllowanceRepository_Expecter {
+	return &AllowanceRepository_Expecter{mock: &_m.Mock}
+}
+
+// GetAllowanceBalance provides a mock function with given fields: ex, subscriptionID
+func (_m *AllowanceRepository) GetAllowanceBalance(ex repository.Executor, subscriptionID int) ([]allowance.Bucket, error) {
+	ret := _m.Called(ex, subscriptionID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAllowanceBalance")
+	}
+
+	var r0 []allowance.Bucket
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int) ([]allowance.Bucket, error)); ok {
+		return rf(ex, subscriptionID)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int) []allowance.Bucket); ok {
+		r0 = rf(ex, subscriptionID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]allowance.Bucket)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int) error); ok {
+		r1 = rf(ex, subscriptionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AllowanceRepository_GetAllowanceBalance_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAllowanceBalance'
+type AllowanceRepository_GetAllowanceBalance_Call struct {
+	*mock.Call
+}
+
+// GetAllowanceBalance is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - subscriptionID int
+func (_e *AllowanceRepository_Expecter) GetAllowanceBalance(ex interface{}, subscriptionID interface{}) *AllowanceRepository_GetAllowanceBalance_Call {
+	return &AllowanceRepository_GetAllowanceBalance_Call{Call: _e.mock.On("GetAllowanceBalance", ex, subscriptionID)}
+}
+
+func (_c *AllowanceRepository_GetAllowanceBalance_Call) Run(run func(ex repository.Executor, subscriptionID int)) *AllowanceRepository_GetAllowanceBalance_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *AllowanceRepository_GetAllowanceBalance_Call) Return(_a0 []allowance.Bucket, _a1 error) *AllowanceRepository_GetAllowanceBalance_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *AllowanceRepository_GetAllowanceBalance_Call) RunAndReturn(run func(repository.Executor, int) ([]allowance.Bucket, error)) *AllowanceRepository_GetAllowanceBalance_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveAllowancePeriod provides a mock function with given fields: ex, invoiceID, subscriptionID, period, at
+func (_m *AllowanceRepository) SaveAllowancePeriod(ex repository.Executor, invoiceID int64, subscriptionID int, period allowance.Period, at time.Time) error {
+	ret := _m.Called(ex, invoiceID, subscriptionID, period, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveAllowancePeriod")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64, int, allowance.Period, time.Time) error); ok {
+		r0 = rf(ex, invoiceID, subscriptionID, period, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AllowanceRepository_SaveAllowancePeriod_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveAllowancePeriod'
+type AllowanceRepository_SaveAllowancePeriod_Call struct {
+	*mock.Call
+}
+
+// SaveAllowancePeriod is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - invoiceID int64
+//   - subscriptionID int
+//   - period allowance.Period
+//   - at time.Time
+func (_e *AllowanceRepository_Expecter) SaveAllowancePeriod(ex interface{}, invoiceID interface{}, subscriptionID interface{}, period interface{}, at interface{}) *AllowanceRepository_SaveAllowancePeriod_Call {
+	return &AllowanceRepository_SaveAllowancePeriod_Call{Call: _e.mock.On("SaveAllowancePeriod", ex, invoiceID, subscriptionID, period, at)}
+}
+
+func (_c *AllowanceRepository_SaveAllowancePeriod_Call) Run(run func(ex repository.Executor, invoiceID int64, subscriptionID int, period allowance.Period, at time.Time)) *AllowanceRepository_SaveAllowancePeriod_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int64), args[2].(int), args[3].(allowance.Period), args[4].(time.Time))
+	})
+	return _c
+}
+
+func (_c *AllowanceRepository_SaveAllowancePeriod_Call) Return(_a0 error) *AllowanceRepository_SaveAllowancePeriod_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *AllowanceRepository_SaveAllowancePeriod_Call) RunAndReturn(run func(repository.Executor, int64, int, allowance.Period, time.Time) error) *AllowanceRepository_SaveAllowancePeriod_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewAllowanceRepository creates a new instance of AllowanceRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAllowanceRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AllowanceRepository {
+	mock := &AllowanceRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
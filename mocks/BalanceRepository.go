@@ -0,0 +1,392 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	mock "github.com/stretchr/testify/mock"
+	models "lineblocs.com/scheduler/models"
+
+	repository "lineblocs.com/scheduler/repository"
+
+	time "time"
+)
+
+// BalanceRepository is an autogenerated mock type for the BalanceRepository type
+type BalanceRepository struct {
+	mock.Mock
+}
+
+type BalanceRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *BalanceRepository) EXPECT() *BalanceRepository_Expecter {
+	return &BalanceRepository_Expecter{mock: &_m.Mock}
+}
+
+// GetWorkspacesOnPlans provides a mock function with given fields: ex, plans
+func (_m *BalanceRepository) GetWorkspacesOnPlans(ex repository.Executor, plans []string) ([]int, error) {
+	ret := _m.Called(ex, plans)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWorkspacesOnPlans")
+	}
+
+	var r0 []int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, []string) ([]int, error)); ok {
+		return rf(ex, plans)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, []string) []int); ok {
+		r0 = rf(ex, plans)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, []string) error); ok {
+		r1 = rf(ex, plans)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BalanceRepository_GetWorkspacesOnPlans_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetWorkspacesOnPlans'
+type BalanceRepository_GetWorkspacesOnPlans_Call struct {
+	*mock.Call
+}
+
+// GetWorkspacesOnPlans is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - plans []string
+func (_e *BalanceRepository_Expecter) GetWorkspacesOnPlans(ex interface{}, plans interface{}) *BalanceRepository_GetWorkspacesOnPlans_Call {
+	return &BalanceRepository_GetWorkspacesOnPlans_Call{Call: _e.mock.On("GetWorkspacesOnPlans", ex, plans)}
+}
+
+func (_c *BalanceRepository_GetWorkspacesOnPlans_Call) Run(run func(ex repository.Executor, plans []string)) *BalanceRepository_GetWorkspacesOnPlans_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].([]string))
+	})
+	return _c
+}
+
+func (_c *BalanceRepository_GetWorkspacesOnPlans_Call) Return(_a0 []int, _a1 error) *BalanceRepository_GetWorkspacesOnPlans_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *BalanceRepository_GetWorkspacesOnPlans_Call) RunAndReturn(run func(repository.Executor, []string) ([]int, error)) *BalanceRepository_GetWorkspacesOnPlans_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// LockBalance provides a mock function with given fields: ex, workspaceID
+func (_m *BalanceRepository) LockBalance(ex repository.Executor, workspaceID int) (*models.WorkspaceBalance, error) {
+	ret := _m.Called(ex, workspaceID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LockBalance")
+	}
+
+	var r0 *models.WorkspaceBalance
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int) (*models.WorkspaceBalance, error)); ok {
+		return rf(ex, workspaceID)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int) *models.WorkspaceBalance); ok {
+		r0 = rf(ex, workspaceID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.WorkspaceBalance)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int) error); ok {
+		r1 = rf(ex, workspaceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BalanceRepository_LockBalance_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LockBalance'
+type BalanceRepository_LockBalance_Call struct {
+	*mock.Call
+}
+
+// LockBalance is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - workspaceID int
+func (_e *BalanceRepository_Expecter) LockBalance(ex interface{}, workspaceID interface{}) *BalanceRepository_LockBalance_Call {
+	return &BalanceRepository_LockBalance_Call{Call: _e.mock.On("LockBalance", ex, workspaceID)}
+}
+
+func (_c *BalanceRepository_LockBalance_Call) Run(run func(ex repository.Executor, workspaceID int)) *BalanceRepository_LockBalance_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *BalanceRepository_LockBalance_Call) Return(_a0 *models.WorkspaceBalance, _a1 error) *BalanceRepository_LockBalance_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *BalanceRepository_LockBalance_Call) RunAndReturn(run func(repository.Executor, int) (*models.WorkspaceBalance, error)) *BalanceRepository_LockBalance_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveBalance provides a mock function with given fields: ex, balance, at
+func (_m *BalanceRepository) SaveBalance(ex repository.Executor, balance models.WorkspaceBalance, at time.Time) error {
+	ret := _m.Called(ex, balance, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveBalance")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, models.WorkspaceBalance, time.Time) error); ok {
+		r0 = rf(ex, balance, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// BalanceRepository_SaveBalance_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveBalance'
+type BalanceRepository_SaveBalance_Call struct {
+	*mock.Call
+}
+
+// SaveBalance is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - balance models.WorkspaceBalance
+//   - at time.Time
+func (_e *BalanceRepository_Expecter) SaveBalance(ex interface{}, balance interface{}, at interface{}) *BalanceRepository_SaveBalance_Call {
+	return &BalanceRepository_SaveBalance_Call{Call: _e.mock.On("SaveBalance", ex, balance, at)}
+}
+
+func (_c *BalanceRepository_SaveBalance_Call) Run(run func(ex repository.Executor, balance models.WorkspaceBalance, at time.Time)) *BalanceRepository_SaveBalance_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(models.WorkspaceBalance), args[2].(time.Time))
+	})
+	return _c
+}
+
+func (_c *BalanceRepository_SaveBalance_Call) Return(_a0 error) *BalanceRepository_SaveBalance_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *BalanceRepository_SaveBalance_Call) RunAndReturn(run func(repository.Executor, models.WorkspaceBalance, time.Time) error) *BalanceRepository_SaveBalance_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SumCreditInvoices provides a mock function with given fields: ex, workspaceID
+func (_m *BalanceRepository) SumCreditInvoices(ex repository.Executor, workspaceID int) (int64, error) {
+	ret := _m.Called(ex, workspaceID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SumCreditInvoices")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int) (int64, error)); ok {
+		return rf(ex, workspaceID)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int) int64); ok {
+		r0 = rf(ex, workspaceID)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int) error); ok {
+		r1 = rf(ex, workspaceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BalanceRepository_SumCreditInvoices_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SumCreditInvoices'
+type BalanceRepository_SumCreditInvoices_Call struct {
+	*mock.Call
+}
+
+// SumCreditInvoices is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - workspaceID int
+func (_e *BalanceRepository_Expecter) SumCreditInvoices(ex interface{}, workspaceID interface{}) *BalanceRepository_SumCreditInvoices_Call {
+	return &BalanceRepository_SumCreditInvoices_Call{Call: _e.mock.On("SumCreditInvoices", ex, workspaceID)}
+}
+
+func (_c *BalanceRepository_SumCreditInvoices_Call) Run(run func(ex repository.Executor, workspaceID int)) *BalanceRepository_SumCreditInvoices_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *BalanceRepository_SumCreditInvoices_Call) Return(_a0 int64, _a1 error) *BalanceRepository_SumCreditInvoices_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *BalanceRepository_SumCreditInvoices_Call) RunAndReturn(run func(repository.Executor, int) (int64, error)) *BalanceRepository_SumCreditInvoices_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SumNewCredits provides a mock function with given fields: ex, workspaceID, afterID
+func (_m *BalanceRepository) SumNewCredits(ex repository.Executor, workspaceID int, afterID int64) (int64, int64, error) {
+	ret := _m.Called(ex, workspaceID, afterID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SumNewCredits")
+	}
+
+	var r0 int64
+	var r1 int64
+	var r2 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int, int64) (int64, int64, error)); ok {
+		return rf(ex, workspaceID, afterID)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int, int64) int64); ok {
+		r0 = rf(ex, workspaceID, afterID)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int, int64) int64); ok {
+		r1 = rf(ex, workspaceID, afterID)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if rf, ok := ret.Get(2).(func(repository.Executor, int, int64) error); ok {
+		r2 = rf(ex, workspaceID, afterID)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// BalanceRepository_SumNewCredits_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SumNewCredits'
+type BalanceRepository_SumNewCredits_Call struct {
+	*mock.Call
+}
+
+// SumNewCredits is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - workspaceID int
+//   - afterID int64
+func (_e *BalanceRepository_Expecter) SumNewCredits(ex interface{}, workspaceID interface{}, afterID interface{}) *BalanceRepository_SumNewCredits_Call {
+	return &BalanceRepository_SumNewCredits_Call{Call: _e.mock.On("SumNewCredits", ex, workspaceID, afterID)}
+}
+
+func (_c *BalanceRepository_SumNewCredits_Call) Run(run func(ex repository.Executor, workspaceID int, afterID int64)) *BalanceRepository_SumNewCredits_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int), args[2].(int64))
+	})
+	return _c
+}
+
+func (_c *BalanceRepository_SumNewCredits_Call) Return(_a0 int64, _a1 int64, _a2 error) *BalanceRepository_SumNewCredits_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *BalanceRepository_SumNewCredits_Call) RunAndReturn(run func(repository.Executor, int, int64) (int64, int64, error)) *BalanceRepository_SumNewCredits_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SumNewDebits provides a mock function with given fields: ex, workspaceID, afterID
+func (_m *BalanceRepository) SumNewDebits(ex repository.Executor, workspaceID int, afterID int64) (int64, int64, error) {
+	ret := _m.Called(ex, workspaceID, afterID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SumNewDebits")
+	}
+
+	var r0 int64
+	var r1 int64
+	var r2 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int, int64) (int64, int64, error)); ok {
+		return rf(ex, workspaceID, afterID)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int, int64) int64); ok {
+		r0 = rf(ex, workspaceID, afterID)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int, int64) int64); ok {
+		r1 = rf(ex, workspaceID, afterID)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if rf, ok := ret.Get(2).(func(repository.Executor, int, int64) error); ok {
+		r2 = rf(ex, workspaceID, afterID)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// BalanceRepository_SumNewDebits_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SumNewDebits'
+type BalanceRepository_SumNewDebits_Call struct {
+	*mock.Call
+}
+
+// SumNewDebits is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - workspaceID int
+//   - afterID int64
+func (_e *BalanceRepository_Expecter) SumNewDebits(ex interface{}, workspaceID interface{}, afterID interface{}) *BalanceRepository_SumNewDebits_Call {
+	return &BalanceRepository_SumNewDebits_Call{Call: _e.mock.On("SumNewDebits", ex, workspaceID, afterID)}
+}
+
+func (_c *BalanceRepository_SumNewDebits_Call) Run(run func(ex repository.Executor, workspaceID int, afterID int64)) *BalanceRepository_SumNewDebits_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int), args[2].(int64))
+	})
+	return _c
+}
+
+func (_c *BalanceRepository_SumNewDebits_Call) Return(_a0 int64, _a1 int64, _a2 error) *BalanceRepository_SumNewDebits_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *BalanceRepository_SumNewDebits_Call) RunAndReturn(run func(repository.Executor, int, int64) (int64, int64, error)) *BalanceRepository_SumNewDebits_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewBalanceRepository creates a new instance of BalanceRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewBalanceRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *BalanceRepository {
+	mock := &BalanceRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
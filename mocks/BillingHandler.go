@@ -70,6 +70,64 @@ func (_c *BillingHandler_ChargeCustomer_Call) RunAndReturn(run func(*lineblocs.U
 	return _c
 }
 
+// RefundPayment provides a mock function with given fields: paymentReference, cents, idempotencyKey
+func (_m *BillingHandler) RefundPayment(paymentReference string, cents int64, idempotencyKey string) (string, error) {
+	ret := _m.Called(paymentReference, cents, idempotencyKey)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RefundPayment")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, int64, string) (string, error)); ok {
+		return rf(paymentReference, cents, idempotencyKey)
+	}
+	if rf, ok := ret.Get(0).(func(string, int64, string) string); ok {
+		r0 = rf(paymentReference, cents, idempotencyKey)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, int64, string) error); ok {
+		r1 = rf(paymentReference, cents, idempotencyKey)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BillingHandler_RefundPayment_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RefundPayment'
+type BillingHandler_RefundPayment_Call struct {
+	*mock.Call
+}
+
+// RefundPayment is a helper method to define mock.On call
+//   - paymentReference string
+//   - cents int64
+//   - idempotencyKey string
+func (_e *BillingHandler_Expecter) RefundPayment(paymentReference interface{}, cents interface{}, idempotencyKey interface{}) *BillingHandler_RefundPayment_Call {
+	return &BillingHandler_RefundPayment_Call{Call: _e.mock.On("RefundPayment", paymentReference, cents, idempotencyKey)}
+}
+
+func (_c *BillingHandler_RefundPayment_Call) Run(run func(paymentReference string, cents int64, idempotencyKey string)) *BillingHandler_RefundPayment_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(int64), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *BillingHandler_RefundPayment_Call) Return(_a0 string, _a1 error) *BillingHandler_RefundPayment_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *BillingHandler_RefundPayment_Call) RunAndReturn(run func(string, int64, string) (string, error)) *BillingHandler_RefundPayment_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewBillingHandler creates a new instance of BillingHandler. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewBillingHandler(t interface {
@@ -0,0 +1,262 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	mock "github.com/stretchr/testify/mock"
+	models "lineblocs.com/scheduler/models"
+
+	time "time"
+)
+
+// ForecastRepository is an autogenerated mock type for the ForecastRepository type
+type ForecastRepository struct {
+	mock.Mock
+}
+
+type ForecastRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *ForecastRepository) EXPECT() *ForecastRepository_Expecter {
+	return &ForecastRepository_Expecter{mock: &_m.Mock}
+}
+
+// GetForecastWorkspaceIDs provides a mock function with given fields:
+func (_m *ForecastRepository) GetForecastWorkspaceIDs() ([]int, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetForecastWorkspaceIDs")
+	}
+
+	var r0 []int
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]int, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []int); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ForecastRepository_GetForecastWorkspaceIDs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetForecastWorkspaceIDs'
+type ForecastRepository_GetForecastWorkspaceIDs_Call struct {
+	*mock.Call
+}
+
+// GetForecastWorkspaceIDs is a helper method to define mock.On call
+func (_e *ForecastRepository_Expecter) GetForecastWorkspaceIDs() *ForecastRepository_GetForecastWorkspaceIDs_Call {
+	return &ForecastRepository_GetForecastWorkspaceIDs_Call{Call: _e.mock.On("GetForecastWorkspaceIDs")}
+}
+
+func (_c *ForecastRepository_GetForecastWorkspaceIDs_Call) Run(run func()) *ForecastRepository_GetForecastWorkspaceIDs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *ForecastRepository_GetForecastWorkspaceIDs_Call) Return(_a0 []int, _a1 error) *ForecastRepository_GetForecastWorkspaceIDs_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *ForecastRepository_GetForecastWorkspaceIDs_Call) RunAndReturn(run func() ([]int, error)) *ForecastRepository_GetForecastWorkspaceIDs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetPriorMonthCents provides a mock function with given fields: workspaceID, from, to
+func (_m *ForecastRepository) GetPriorMonthCents(workspaceID int, from time.Time, to time.Time) (int64, bool, error) {
+	ret := _m.Called(workspaceID, from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPriorMonthCents")
+	}
+
+	var r0 int64
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(int, time.Time, time.Time) (int64, bool, error)); ok {
+		return rf(workspaceID, from, to)
+	}
+	if rf, ok := ret.Get(0).(func(int, time.Time, time.Time) int64); ok {
+		r0 = rf(workspaceID, from, to)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(int, time.Time, time.Time) bool); ok {
+		r1 = rf(workspaceID, from, to)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(int, time.Time, time.Time) error); ok {
+		r2 = rf(workspaceID, from, to)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ForecastRepository_GetPriorMonthCents_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPriorMonthCents'
+type ForecastRepository_GetPriorMonthCents_Call struct {
+	*mock.Call
+}
+
+// GetPriorMonthCents is a helper method to define mock.On call
+//   - workspaceID int
+//   - from time.Time
+//   - to time.Time
+func (_e *ForecastRepository_Expecter) GetPriorMonthCents(workspaceID interface{}, from interface{}, to interface{}) *ForecastRepository_GetPriorMonthCents_Call {
+	return &ForecastRepository_GetPriorMonthCents_Call{Call: _e.mock.On("GetPriorMonthCents", workspaceID, from, to)}
+}
+
+func (_c *ForecastRepository_GetPriorMonthCents_Call) Run(run func(workspaceID int, from time.Time, to time.Time)) *ForecastRepository_GetPriorMonthCents_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(time.Time), args[2].(time.Time))
+	})
+	return _c
+}
+
+func (_c *ForecastRepository_GetPriorMonthCents_Call) Return(_a0 int64, _a1 bool, _a2 error) *ForecastRepository_GetPriorMonthCents_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *ForecastRepository_GetPriorMonthCents_Call) RunAndReturn(run func(int, time.Time, time.Time) (int64, bool, error)) *ForecastRepository_GetPriorMonthCents_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveForecast provides a mock function with given fields: forecast
+func (_m *ForecastRepository) SaveForecast(forecast *models.Forecast) error {
+	ret := _m.Called(forecast)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveForecast")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*models.Forecast) error); ok {
+		r0 = rf(forecast)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ForecastRepository_SaveForecast_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveForecast'
+type ForecastRepository_SaveForecast_Call struct {
+	*mock.Call
+}
+
+// SaveForecast is a helper method to define mock.On call
+//   - forecast *models.Forecast
+func (_e *ForecastRepository_Expecter) SaveForecast(forecast interface{}) *ForecastRepository_SaveForecast_Call {
+	return &ForecastRepository_SaveForecast_Call{Call: _e.mock.On("SaveForecast", forecast)}
+}
+
+func (_c *ForecastRepository_SaveForecast_Call) Run(run func(forecast *models.Forecast)) *ForecastRepository_SaveForecast_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*models.Forecast))
+	})
+	return _c
+}
+
+func (_c *ForecastRepository_SaveForecast_Call) Return(_a0 error) *ForecastRepository_SaveForecast_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *ForecastRepository_SaveForecast_Call) RunAndReturn(run func(*models.Forecast) error) *ForecastRepository_SaveForecast_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// WasAlerted provides a mock function with given fields: workspaceID, periodStart
+func (_m *ForecastRepository) WasAlerted(workspaceID int, periodStart time.Time) (bool, error) {
+	ret := _m.Called(workspaceID, periodStart)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WasAlerted")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, time.Time) (bool, error)); ok {
+		return rf(workspaceID, periodStart)
+	}
+	if rf, ok := ret.Get(0).(func(int, time.Time) bool); ok {
+		r0 = rf(workspaceID, periodStart)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(int, time.Time) error); ok {
+		r1 = rf(workspaceID, periodStart)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ForecastRepository_WasAlerted_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WasAlerted'
+type ForecastRepository_WasAlerted_Call struct {
+	*mock.Call
+}
+
+// WasAlerted is a helper method to define mock.On call
+//   - workspaceID int
+//   - periodStart time.Time
+func (_e *ForecastRepository_Expecter) WasAlerted(workspaceID interface{}, periodStart interface{}) *ForecastRepository_WasAlerted_Call {
+	return &ForecastRepository_WasAlerted_Call{Call: _e.mock.On("WasAlerted", workspaceID, periodStart)}
+}
+
+func (_c *ForecastRepository_WasAlerted_Call) Run(run func(workspaceID int, periodStart time.Time)) *ForecastRepository_WasAlerted_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *ForecastRepository_WasAlerted_Call) Return(_a0 bool, _a1 error) *ForecastRepository_WasAlerted_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *ForecastRepository_WasAlerted_Call) RunAndReturn(run func(int, time.Time) (bool, error)) *ForecastRepository_WasAlerted_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewForecastRepository creates a new instance of ForecastRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewForecastRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ForecastRepository {
+	mock := &ForecastRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
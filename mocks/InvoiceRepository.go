@@ -0,0 +1,1529 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	mock "github.com/stretchr/testify/mock"
+	invoice "lineblocs.com/scheduler/internal/invoice"
+
+	models "lineblocs.com/scheduler/models"
+
+	repository "lineblocs.com/scheduler/repository"
+
+	time "time"
+)
+
+// InvoiceRepository is an autogenerated mock type for the InvoiceRepository type
+type InvoiceRepository struct {
+	mock.Mock
+}
+
+type InvoiceRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *InvoiceRepository) EXPECT() *InvoiceRepository_Expecter {
+	return &InvoiceRepository_Expecter{mock: &_m.Mock}
+}
+
+// AddInvoiceCharges provides a mock function with given fields: ex, invoiceID, cents, at
+func (_m *InvoiceRepository) AddInvoiceCharges(ex repository.Executor, invoiceID int64, cents int64, at time.Time) error {
+	ret := _m.Called(ex, invoiceID, cents, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddInvoiceCharges")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64, int64, time.Time) error); ok {
+		r0 = rf(ex, invoiceID, cents, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InvoiceRepository_AddInvoiceCharges_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddInvoiceCharges'
+type InvoiceRepository_AddInvoiceCharges_Call struct {
+	*mock.Call
+}
+
+// AddInvoiceCharges is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - invoiceID int64
+//   - cents int64
+//   - at time.Time
+func (_e *InvoiceRepository_Expecter) AddInvoiceCharges(ex interface{}, invoiceID interface{}, cents interface{}, at interface{}) *InvoiceRepository_AddInvoiceCharges_Call {
+	return &InvoiceRepository_AddInvoiceCharges_Call{Call: _e.mock.On("AddInvoiceCharges", ex, invoiceID, cents, at)}
+}
+
+func (_c *InvoiceRepository_AddInvoiceCharges_Call) Run(run func(ex repository.Executor, invoiceID int64, cents int64, at time.Time)) *InvoiceRepository_AddInvoiceCharges_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int64), args[2].(int64), args[3].(time.Time))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_AddInvoiceCharges_Call) Return(_a0 error) *InvoiceRepository_AddInvoiceCharges_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *InvoiceRepository_AddInvoiceCharges_Call) RunAndReturn(run func(repository.Executor, int64, int64, time.Time) error) *InvoiceRepository_AddInvoiceCharges_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// BackfillBilledDebits provides a mock function with given fields: ex, before
+func (_m *InvoiceRepository) BackfillBilledDebits(ex repository.Executor, before time.Time) (int64, error) {
+	ret := _m.Called(ex, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BackfillBilledDebits")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, time.Time) (int64, error)); ok {
+		return rf(ex, before)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, time.Time) int64); ok {
+		r0 = rf(ex, before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, time.Time) error); ok {
+		r1 = rf(ex, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InvoiceRepository_BackfillBilledDebits_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'BackfillBilledDebits'
+type InvoiceRepository_BackfillBilledDebits_Call struct {
+	*mock.Call
+}
+
+// BackfillBilledDebits is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - before time.Time
+func (_e *InvoiceRepository_Expecter) BackfillBilledDebits(ex interface{}, before interface{}) *InvoiceRepository_BackfillBilledDebits_Call {
+	return &InvoiceRepository_BackfillBilledDebits_Call{Call: _e.mock.On("BackfillBilledDebits", ex, before)}
+}
+
+func (_c *InvoiceRepository_BackfillBilledDebits_Call) Run(run func(ex repository.Executor, before time.Time)) *InvoiceRepository_BackfillBilledDebits_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_BackfillBilledDebits_Call) Return(_a0 int64, _a1 error) *InvoiceRepository_BackfillBilledDebits_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *InvoiceRepository_BackfillBilledDebits_Call) RunAndReturn(run func(repository.Executor, time.Time) (int64, error)) *InvoiceRepository_BackfillBilledDebits_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ClaimDebits provides a mock function with given fields: ex, invoiceID, debitIDs
+func (_m *InvoiceRepository) ClaimDebits(ex repository.Executor, invoiceID int64, debitIDs []int) error {
+	ret := _m.Called(ex, invoiceID, debitIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimDebits")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64, []int) error); ok {
+		r0 = rf(ex, invoiceID, debitIDs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InvoiceRepository_ClaimDebits_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ClaimDebits'
+type InvoiceRepository_ClaimDebits_Call struct {
+	*mock.Call
+}
+
+// ClaimDebits is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - invoiceID int64
+//   - debitIDs []int
+func (_e *InvoiceRepository_Expecter) ClaimDebits(ex interface{}, invoiceID interface{}, debitIDs interface{}) *InvoiceRepository_ClaimDebits_Call {
+	return &InvoiceRepository_ClaimDebits_Call{Call: _e.mock.On("ClaimDebits", ex, invoiceID, debitIDs)}
+}
+
+func (_c *InvoiceRepository_ClaimDebits_Call) Run(run func(ex repository.Executor, invoiceID int64, debitIDs []int)) *InvoiceRepository_ClaimDebits_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int64), args[2].([]int))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_ClaimDebits_Call) Return(_a0 error) *InvoiceRepository_ClaimDebits_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *InvoiceRepository_ClaimDebits_Call) RunAndReturn(run func(repository.Executor, int64, []int) error) *InvoiceRepository_ClaimDebits_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CountOutboxEntries provides a mock function with given fields: ex, invoiceID
+func (_m *InvoiceRepository) CountOutboxEntries(ex repository.Executor, invoiceID int64) (int, error) {
+	ret := _m.Called(ex, invoiceID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountOutboxEntries")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64) (int, error)); ok {
+		return rf(ex, invoiceID)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64) int); ok {
+		r0 = rf(ex, invoiceID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int64) error); ok {
+		r1 = rf(ex, invoiceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InvoiceRepository_CountOutboxEntries_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CountOutboxEntries'
+type InvoiceRepository_CountOutboxEntries_Call struct {
+	*mock.Call
+}
+
+// CountOutboxEntries is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - invoiceID int64
+func (_e *InvoiceRepository_Expecter) CountOutboxEntries(ex interface{}, invoiceID interface{}) *InvoiceRepository_CountOutboxEntries_Call {
+	return &InvoiceRepository_CountOutboxEntries_Call{Call: _e.mock.On("CountOutboxEntries", ex, invoiceID)}
+}
+
+func (_c *InvoiceRepository_CountOutboxEntries_Call) Run(run func(ex repository.Executor, invoiceID int64)) *InvoiceRepository_CountOutboxEntries_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_CountOutboxEntries_Call) Return(_a0 int, _a1 error) *InvoiceRepository_CountOutboxEntries_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *InvoiceRepository_CountOutboxEntries_Call) RunAndReturn(run func(repository.Executor, int64) (int, error)) *InvoiceRepository_CountOutboxEntries_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateCredit provides a mock function with given fields: ex, workspaceID, userID, cents, at
+func (_m *InvoiceRepository) CreateCredit(ex repository.Executor, workspaceID int, userID int, cents int64, at time.Time) error {
+	ret := _m.Called(ex, workspaceID, userID, cents, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateCredit")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int, int, int64, time.Time) error); ok {
+		r0 = rf(ex, workspaceID, userID, cents, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InvoiceRepository_CreateCredit_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateCredit'
+type InvoiceRepository_CreateCredit_Call struct {
+	*mock.Call
+}
+
+// CreateCredit is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - workspaceID int
+//   - userID int
+//   - cents int64
+//   - at time.Time
+func (_e *InvoiceRepository_Expecter) CreateCredit(ex interface{}, workspaceID interface{}, userID interface{}, cents interface{}, at interface{}) *InvoiceRepository_CreateCredit_Call {
+	return &InvoiceRepository_CreateCredit_Call{Call: _e.mock.On("CreateCredit", ex, workspaceID, userID, cents, at)}
+}
+
+func (_c *InvoiceRepository_CreateCredit_Call) Run(run func(ex repository.Executor, workspaceID int, userID int, cents int64, at time.Time)) *InvoiceRepository_CreateCredit_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int), args[2].(int), args[3].(int64), args[4].(time.Time))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_CreateCredit_Call) Return(_a0 error) *InvoiceRepository_CreateCredit_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *InvoiceRepository_CreateCredit_Call) RunAndReturn(run func(repository.Executor, int, int, int64, time.Time) error) *InvoiceRepository_CreateCredit_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateInvoice provides a mock function with given fields: ex, _a1
+func (_m *InvoiceRepository) CreateInvoice(ex repository.Executor, _a1 *models.Invoice) (int64, error) {
+	ret := _m.Called(ex, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateInvoice")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, *models.Invoice) (int64, error)); ok {
+		return rf(ex, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, *models.Invoice) int64); ok {
+		r0 = rf(ex, _a1)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, *models.Invoice) error); ok {
+		r1 = rf(ex, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InvoiceRepository_CreateInvoice_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateInvoice'
+type InvoiceRepository_CreateInvoice_Call struct {
+	*mock.Call
+}
+
+// CreateInvoice is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - _a1 *models.Invoice
+func (_e *InvoiceRepository_Expecter) CreateInvoice(ex interface{}, _a1 interface{}) *InvoiceRepository_CreateInvoice_Call {
+	return &InvoiceRepository_CreateInvoice_Call{Call: _e.mock.On("CreateInvoice", ex, _a1)}
+}
+
+func (_c *InvoiceRepository_CreateInvoice_Call) Run(run func(ex repository.Executor, _a1 *models.Invoice)) *InvoiceRepository_CreateInvoice_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(*models.Invoice))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_CreateInvoice_Call) Return(_a0 int64, _a1 error) *InvoiceRepository_CreateInvoice_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *InvoiceRepository_CreateInvoice_Call) RunAndReturn(run func(repository.Executor, *models.Invoice) (int64, error)) *InvoiceRepository_CreateInvoice_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateLateFee provides a mock function with given fields: ex, fee
+func (_m *InvoiceRepository) CreateLateFee(ex repository.Executor, fee models.LateFee) (bool, error) {
+	ret := _m.Called(ex, fee)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateLateFee")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, models.LateFee) (bool, error)); ok {
+		return rf(ex, fee)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, models.LateFee) bool); ok {
+		r0 = rf(ex, fee)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, models.LateFee) error); ok {
+		r1 = rf(ex, fee)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InvoiceRepository_CreateLateFee_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateLateFee'
+type InvoiceRepository_CreateLateFee_Call struct {
+	*mock.Call
+}
+
+// CreateLateFee is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - fee models.LateFee
+func (_e *InvoiceRepository_Expecter) CreateLateFee(ex interface{}, fee interface{}) *InvoiceRepository_CreateLateFee_Call {
+	return &InvoiceRepository_CreateLateFee_Call{Call: _e.mock.On("CreateLateFee", ex, fee)}
+}
+
+func (_c *InvoiceRepository_CreateLateFee_Call) Run(run func(ex repository.Executor, fee models.LateFee)) *InvoiceRepository_CreateLateFee_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(models.LateFee))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_CreateLateFee_Call) Return(_a0 bool, _a1 error) *InvoiceRepository_CreateLateFee_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *InvoiceRepository_CreateLateFee_Call) RunAndReturn(run func(repository.Executor, models.LateFee) (bool, error)) *InvoiceRepository_CreateLateFee_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateLineItems provides a mock function with given fields: ex, invoiceID, items, at
+func (_m *InvoiceRepository) CreateLineItems(ex repository.Executor, invoiceID int64, items []models.InvoiceLineItem, at time.Time) error {
+	ret := _m.Called(ex, invoiceID, items, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateLineItems")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64, []models.InvoiceLineItem, time.Time) error); ok {
+		r0 = rf(ex, invoiceID, items, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InvoiceRepository_CreateLineItems_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateLineItems'
+type InvoiceRepository_CreateLineItems_Call struct {
+	*mock.Call
+}
+
+// CreateLineItems is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - invoiceID int64
+//   - items []models.InvoiceLineItem
+//   - at time.Time
+func (_e *InvoiceRepository_Expecter) CreateLineItems(ex interface{}, invoiceID interface{}, items interface{}, at interface{}) *InvoiceRepository_CreateLineItems_Call {
+	return &InvoiceRepository_CreateLineItems_Call{Call: _e.mock.On("CreateLineItems", ex, invoiceID, items, at)}
+}
+
+func (_c *InvoiceRepository_CreateLineItems_Call) Run(run func(ex repository.Executor, invoiceID int64, items []models.InvoiceLineItem, at time.Time)) *InvoiceRepository_CreateLineItems_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int64), args[2].([]models.InvoiceLineItem), args[3].(time.Time))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_CreateLineItems_Call) Return(_a0 error) *InvoiceRepository_CreateLineItems_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *InvoiceRepository_CreateLineItems_Call) RunAndReturn(run func(repository.Executor, int64, []models.InvoiceLineItem, time.Time) error) *InvoiceRepository_CreateLineItems_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateOutboxEntry provides a mock function with given fields: ex, entry
+func (_m *InvoiceRepository) CreateOutboxEntry(ex repository.Executor, entry *models.OutboxEntry) (int64, error) {
+	ret := _m.Called(ex, entry)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateOutboxEntry")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, *models.OutboxEntry) (int64, error)); ok {
+		return rf(ex, entry)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, *models.OutboxEntry) int64); ok {
+		r0 = rf(ex, entry)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, *models.OutboxEntry) error); ok {
+		r1 = rf(ex, entry)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InvoiceRepository_CreateOutboxEntry_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateOutboxEntry'
+type InvoiceRepository_CreateOutboxEntry_Call struct {
+	*mock.Call
+}
+
+// CreateOutboxEntry is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - entry *models.OutboxEntry
+func (_e *InvoiceRepository_Expecter) CreateOutboxEntry(ex interface{}, entry interface{}) *InvoiceRepository_CreateOutboxEntry_Call {
+	return &InvoiceRepository_CreateOutboxEntry_Call{Call: _e.mock.On("CreateOutboxEntry", ex, entry)}
+}
+
+func (_c *InvoiceRepository_CreateOutboxEntry_Call) Run(run func(ex repository.Executor, entry *models.OutboxEntry)) *InvoiceRepository_CreateOutboxEntry_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(*models.OutboxEntry))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_CreateOutboxEntry_Call) Return(_a0 int64, _a1 error) *InvoiceRepository_CreateOutboxEntry_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *InvoiceRepository_CreateOutboxEntry_Call) RunAndReturn(run func(repository.Executor, *models.OutboxEntry) (int64, error)) *InvoiceRepository_CreateOutboxEntry_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateRentalCharge provides a mock function with given fields: ex, charge, userID
+func (_m *InvoiceRepository) CreateRentalCharge(ex repository.Executor, charge models.RentalCharge, userID int) (bool, error) {
+	ret := _m.Called(ex, charge, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateRentalCharge")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, models.RentalCharge, int) (bool, error)); ok {
+		return rf(ex, charge, userID)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, models.RentalCharge, int) bool); ok {
+		r0 = rf(ex, charge, userID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, models.RentalCharge, int) error); ok {
+		r1 = rf(ex, charge, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InvoiceRepository_CreateRentalCharge_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateRentalCharge'
+type InvoiceRepository_CreateRentalCharge_Call struct {
+	*mock.Call
+}
+
+// CreateRentalCharge is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - charge models.RentalCharge
+//   - userID int
+func (_e *InvoiceRepository_Expecter) CreateRentalCharge(ex interface{}, charge interface{}, userID interface{}) *InvoiceRepository_CreateRentalCharge_Call {
+	return &InvoiceRepository_CreateRentalCharge_Call{Call: _e.mock.On("CreateRentalCharge", ex, charge, userID)}
+}
+
+func (_c *InvoiceRepository_CreateRentalCharge_Call) Run(run func(ex repository.Executor, charge models.RentalCharge, userID int)) *InvoiceRepository_CreateRentalCharge_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(models.RentalCharge), args[2].(int))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_CreateRentalCharge_Call) Return(_a0 bool, _a1 error) *InvoiceRepository_CreateRentalCharge_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *InvoiceRepository_CreateRentalCharge_Call) RunAndReturn(run func(repository.Executor, models.RentalCharge, int) (bool, error)) *InvoiceRepository_CreateRentalCharge_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetInvoice provides a mock function with given fields: ex, invoiceID
+func (_m *InvoiceRepository) GetInvoice(ex repository.Executor, invoiceID int64) (*models.Invoice, error) {
+	ret := _m.Called(ex, invoiceID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetInvoice")
+	}
+
+	var r0 *models.Invoice
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64) (*models.Invoice, error)); ok {
+		return rf(ex, invoiceID)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64) *models.Invoice); ok {
+		r0 = rf(ex, invoiceID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Invoice)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int64) error); ok {
+		r1 = rf(ex, invoiceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InvoiceRepository_GetInvoice_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetInvoice'
+type InvoiceRepository_GetInvoice_Call struct {
+	*mock.Call
+}
+
+// GetInvoice is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - invoiceID int64
+func (_e *InvoiceRepository_Expecter) GetInvoice(ex interface{}, invoiceID interface{}) *InvoiceRepository_GetInvoice_Call {
+	return &InvoiceRepository_GetInvoice_Call{Call: _e.mock.On("GetInvoice", ex, invoiceID)}
+}
+
+func (_c *InvoiceRepository_GetInvoice_Call) Run(run func(ex repository.Executor, invoiceID int64)) *InvoiceRepository_GetInvoice_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_GetInvoice_Call) Return(_a0 *models.Invoice, _a1 error) *InvoiceRepository_GetInvoice_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *InvoiceRepository_GetInvoice_Call) RunAndReturn(run func(repository.Executor, int64) (*models.Invoice, error)) *InvoiceRepository_GetInvoice_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetInvoiceState provides a mock function with given fields: ex, invoiceID
+func (_m *InvoiceRepository) GetInvoiceState(ex repository.Executor, invoiceID int64) (invoice.Status, error) {
+	ret := _m.Called(ex, invoiceID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetInvoiceState")
+	}
+
+	var r0 invoice.Status
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64) (invoice.Status, error)); ok {
+		return rf(ex, invoiceID)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64) invoice.Status); ok {
+		r0 = rf(ex, invoiceID)
+	} else {
+		r0 = ret.Get(0).(invoice.Status)
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int64) error); ok {
+		r1 = rf(ex, invoiceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InvoiceRepository_GetInvoiceState_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetInvoiceState'
+type InvoiceRepository_GetInvoiceState_Call struct {
+	*mock.Call
+}
+
+// GetInvoiceState is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - invoiceID int64
+func (_e *InvoiceRepository_Expecter) GetInvoiceState(ex interface{}, invoiceID interface{}) *InvoiceRepository_GetInvoiceState_Call {
+	return &InvoiceRepository_GetInvoiceState_Call{Call: _e.mock.On("GetInvoiceState", ex, invoiceID)}
+}
+
+func (_c *InvoiceRepository_GetInvoiceState_Call) Run(run func(ex repository.Executor, invoiceID int64)) *InvoiceRepository_GetInvoiceState_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_GetInvoiceState_Call) Return(_a0 invoice.Status, _a1 error) *InvoiceRepository_GetInvoiceState_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *InvoiceRepository_GetInvoiceState_Call) RunAndReturn(run func(repository.Executor, int64) (invoice.Status, error)) *InvoiceRepository_GetInvoiceState_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetLateFees provides a mock function with given fields: ex, invoiceID
+func (_m *InvoiceRepository) GetLateFees(ex repository.Executor, invoiceID int64) ([]models.LateFee, error) {
+	ret := _m.Called(ex, invoiceID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLateFees")
+	}
+
+	var r0 []models.LateFee
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64) ([]models.LateFee, error)); ok {
+		return rf(ex, invoiceID)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64) []models.LateFee); ok {
+		r0 = rf(ex, invoiceID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.LateFee)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int64) error); ok {
+		r1 = rf(ex, invoiceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InvoiceRepository_GetLateFees_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLateFees'
+type InvoiceRepository_GetLateFees_Call struct {
+	*mock.Call
+}
+
+// GetLateFees is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - invoiceID int64
+func (_e *InvoiceRepository_Expecter) GetLateFees(ex interface{}, invoiceID interface{}) *InvoiceRepository_GetLateFees_Call {
+	return &InvoiceRepository_GetLateFees_Call{Call: _e.mock.On("GetLateFees", ex, invoiceID)}
+}
+
+func (_c *InvoiceRepository_GetLateFees_Call) Run(run func(ex repository.Executor, invoiceID int64)) *InvoiceRepository_GetLateFees_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_GetLateFees_Call) Return(_a0 []models.LateFee, _a1 error) *InvoiceRepository_GetLateFees_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *InvoiceRepository_GetLateFees_Call) RunAndReturn(run func(repository.Executor, int64) ([]models.LateFee, error)) *InvoiceRepository_GetLateFees_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetNumberRentals provides a mock function with given fields: ex, workspaceID, start, end
+func (_m *InvoiceRepository) GetNumberRentals(ex repository.Executor, workspaceID int, start time.Time, end time.Time) ([]models.NumberRental, error) {
+	ret := _m.Called(ex, workspaceID, start, end)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetNumberRentals")
+	}
+
+	var r0 []models.NumberRental
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int, time.Time, time.Time) ([]models.NumberRental, error)); ok {
+		return rf(ex, workspaceID, start, end)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int, time.Time, time.Time) []models.NumberRental); ok {
+		r0 = rf(ex, workspaceID, start, end)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.NumberRental)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int, time.Time, time.Time) error); ok {
+		r1 = rf(ex, workspaceID, start, end)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InvoiceRepository_GetNumberRentals_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetNumberRentals'
+type InvoiceRepository_GetNumberRentals_Call struct {
+	*mock.Call
+}
+
+// GetNumberRentals is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - workspaceID int
+//   - start time.Time
+//   - end time.Time
+func (_e *InvoiceRepository_Expecter) GetNumberRentals(ex interface{}, workspaceID interface{}, start interface{}, end interface{}) *InvoiceRepository_GetNumberRentals_Call {
+	return &InvoiceRepository_GetNumberRentals_Call{Call: _e.mock.On("GetNumberRentals", ex, workspaceID, start, end)}
+}
+
+func (_c *InvoiceRepository_GetNumberRentals_Call) Run(run func(ex repository.Executor, workspaceID int, start time.Time, end time.Time)) *InvoiceRepository_GetNumberRentals_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int), args[2].(time.Time), args[3].(time.Time))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_GetNumberRentals_Call) Return(_a0 []models.NumberRental, _a1 error) *InvoiceRepository_GetNumberRentals_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *InvoiceRepository_GetNumberRentals_Call) RunAndReturn(run func(repository.Executor, int, time.Time, time.Time) ([]models.NumberRental, error)) *InvoiceRepository_GetNumberRentals_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetOverdueInvoices provides a mock function with given fields: ex, dueBefore
+func (_m *InvoiceRepository) GetOverdueInvoices(ex repository.Executor, dueBefore time.Time) ([]models.Invoice, error) {
+	ret := _m.Called(ex, dueBefore)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOverdueInvoices")
+	}
+
+	var r0 []models.Invoice
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, time.Time) ([]models.Invoice, error)); ok {
+		return rf(ex, dueBefore)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, time.Time) []models.Invoice); ok {
+		r0 = rf(ex, dueBefore)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Invoice)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, time.Time) error); ok {
+		r1 = rf(ex, dueBefore)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InvoiceRepository_GetOverdueInvoices_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetOverdueInvoices'
+type InvoiceRepository_GetOverdueInvoices_Call struct {
+	*mock.Call
+}
+
+// GetOverdueInvoices is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - dueBefore time.Time
+func (_e *InvoiceRepository_Expecter) GetOverdueInvoices(ex interface{}, dueBefore interface{}) *InvoiceRepository_GetOverdueInvoices_Call {
+	return &InvoiceRepository_GetOverdueInvoices_Call{Call: _e.mock.On("GetOverdueInvoices", ex, dueBefore)}
+}
+
+func (_c *InvoiceRepository_GetOverdueInvoices_Call) Run(run func(ex repository.Executor, dueBefore time.Time)) *InvoiceRepository_GetOverdueInvoices_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_GetOverdueInvoices_Call) Return(_a0 []models.Invoice, _a1 error) *InvoiceRepository_GetOverdueInvoices_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *InvoiceRepository_GetOverdueInvoices_Call) RunAndReturn(run func(repository.Executor, time.Time) ([]models.Invoice, error)) *InvoiceRepository_GetOverdueInvoices_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetPaymentReference provides a mock function with given fields: ex, invoiceID
+func (_m *InvoiceRepository) GetPaymentReference(ex repository.Executor, invoiceID int64) (string, error) {
+	ret := _m.Called(ex, invoiceID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPaymentReference")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64) (string, error)); ok {
+		return rf(ex, invoiceID)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64) string); ok {
+		r0 = rf(ex, invoiceID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int64) error); ok {
+		r1 = rf(ex, invoiceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InvoiceRepository_GetPaymentReference_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPaymentReference'
+type InvoiceRepository_GetPaymentReference_Call struct {
+	*mock.Call
+}
+
+// GetPaymentReference is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - invoiceID int64
+func (_e *InvoiceRepository_Expecter) GetPaymentReference(ex interface{}, invoiceID interface{}) *InvoiceRepository_GetPaymentReference_Call {
+	return &InvoiceRepository_GetPaymentReference_Call{Call: _e.mock.On("GetPaymentReference", ex, invoiceID)}
+}
+
+func (_c *InvoiceRepository_GetPaymentReference_Call) Run(run func(ex repository.Executor, invoiceID int64)) *InvoiceRepository_GetPaymentReference_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_GetPaymentReference_Call) Return(_a0 string, _a1 error) *InvoiceRepository_GetPaymentReference_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *InvoiceRepository_GetPaymentReference_Call) RunAndReturn(run func(repository.Executor, int64) (string, error)) *InvoiceRepository_GetPaymentReference_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetPendingOutboxEntries provides a mock function with given fields: ex, createdBefore
+func (_m *InvoiceRepository) GetPendingOutboxEntries(ex repository.Executor, createdBefore time.Time) ([]models.OutboxEntry, error) {
+	ret := _m.Called(ex, createdBefore)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPendingOutboxEntries")
+	}
+
+	var r0 []models.OutboxEntry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, time.Time) ([]models.OutboxEntry, error)); ok {
+		return rf(ex, createdBefore)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, time.Time) []models.OutboxEntry); ok {
+		r0 = rf(ex, createdBefore)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.OutboxEntry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, time.Time) error); ok {
+		r1 = rf(ex, createdBefore)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InvoiceRepository_GetPendingOutboxEntries_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPendingOutboxEntries'
+type InvoiceRepository_GetPendingOutboxEntries_Call struct {
+	*mock.Call
+}
+
+// GetPendingOutboxEntries is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - createdBefore time.Time
+func (_e *InvoiceRepository_Expecter) GetPendingOutboxEntries(ex interface{}, createdBefore interface{}) *InvoiceRepository_GetPendingOutboxEntries_Call {
+	return &InvoiceRepository_GetPendingOutboxEntries_Call{Call: _e.mock.On("GetPendingOutboxEntries", ex, createdBefore)}
+}
+
+func (_c *InvoiceRepository_GetPendingOutboxEntries_Call) Run(run func(ex repository.Executor, createdBefore time.Time)) *InvoiceRepository_GetPendingOutboxEntries_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_GetPendingOutboxEntries_Call) Return(_a0 []models.OutboxEntry, _a1 error) *InvoiceRepository_GetPendingOutboxEntries_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *InvoiceRepository_GetPendingOutboxEntries_Call) RunAndReturn(run func(repository.Executor, time.Time) ([]models.OutboxEntry, error)) *InvoiceRepository_GetPendingOutboxEntries_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetSentInvoicesDueBy provides a mock function with given fields: ex, dueBy
+func (_m *InvoiceRepository) GetSentInvoicesDueBy(ex repository.Executor, dueBy time.Time) ([]models.Invoice, error) {
+	ret := _m.Called(ex, dueBy)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSentInvoicesDueBy")
+	}
+
+	var r0 []models.Invoice
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, time.Time) ([]models.Invoice, error)); ok {
+		return rf(ex, dueBy)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, time.Time) []models.Invoice); ok {
+		r0 = rf(ex, dueBy)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Invoice)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, time.Time) error); ok {
+		r1 = rf(ex, dueBy)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InvoiceRepository_GetSentInvoicesDueBy_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetSentInvoicesDueBy'
+type InvoiceRepository_GetSentInvoicesDueBy_Call struct {
+	*mock.Call
+}
+
+// GetSentInvoicesDueBy is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - dueBy time.Time
+func (_e *InvoiceRepository_Expecter) GetSentInvoicesDueBy(ex interface{}, dueBy interface{}) *InvoiceRepository_GetSentInvoicesDueBy_Call {
+	return &InvoiceRepository_GetSentInvoicesDueBy_Call{Call: _e.mock.On("GetSentInvoicesDueBy", ex, dueBy)}
+}
+
+func (_c *InvoiceRepository_GetSentInvoicesDueBy_Call) Run(run func(ex repository.Executor, dueBy time.Time)) *InvoiceRepository_GetSentInvoicesDueBy_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_GetSentInvoicesDueBy_Call) Return(_a0 []models.Invoice, _a1 error) *InvoiceRepository_GetSentInvoicesDueBy_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *InvoiceRepository_GetSentInvoicesDueBy_Call) RunAndReturn(run func(repository.Executor, time.Time) ([]models.Invoice, error)) *InvoiceRepository_GetSentInvoicesDueBy_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HasPendingCharge provides a mock function with given fields: ex, invoiceID
+func (_m *InvoiceRepository) HasPendingCharge(ex repository.Executor, invoiceID int64) (bool, error) {
+	ret := _m.Called(ex, invoiceID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HasPendingCharge")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64) (bool, error)); ok {
+		return rf(ex, invoiceID)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64) bool); ok {
+		r0 = rf(ex, invoiceID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int64) error); ok {
+		r1 = rf(ex, invoiceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InvoiceRepository_HasPendingCharge_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HasPendingCharge'
+type InvoiceRepository_HasPendingCharge_Call struct {
+	*mock.Call
+}
+
+// HasPendingCharge is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - invoiceID int64
+func (_e *InvoiceRepository_Expecter) HasPendingCharge(ex interface{}, invoiceID interface{}) *InvoiceRepository_HasPendingCharge_Call {
+	return &InvoiceRepository_HasPendingCharge_Call{Call: _e.mock.On("HasPendingCharge", ex, invoiceID)}
+}
+
+func (_c *InvoiceRepository_HasPendingCharge_Call) Run(run func(ex repository.Executor, invoiceID int64)) *InvoiceRepository_HasPendingCharge_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_HasPendingCharge_Call) Return(_a0 bool, _a1 error) *InvoiceRepository_HasPendingCharge_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *InvoiceRepository_HasPendingCharge_Call) RunAndReturn(run func(repository.Executor, int64) (bool, error)) *InvoiceRepository_HasPendingCharge_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// LockOverdueInvoice provides a mock function with given fields: ex, invoiceID
+func (_m *InvoiceRepository) LockOverdueInvoice(ex repository.Executor, invoiceID int64) (*models.Invoice, error) {
+	ret := _m.Called(ex, invoiceID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LockOverdueInvoice")
+	}
+
+	var r0 *models.Invoice
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64) (*models.Invoice, error)); ok {
+		return rf(ex, invoiceID)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64) *models.Invoice); ok {
+		r0 = rf(ex, invoiceID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Invoice)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int64) error); ok {
+		r1 = rf(ex, invoiceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InvoiceRepository_LockOverdueInvoice_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LockOverdueInvoice'
+type InvoiceRepository_LockOverdueInvoice_Call struct {
+	*mock.Call
+}
+
+// LockOverdueInvoice is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - invoiceID int64
+func (_e *InvoiceRepository_Expecter) LockOverdueInvoice(ex interface{}, invoiceID interface{}) *InvoiceRepository_LockOverdueInvoice_Call {
+	return &InvoiceRepository_LockOverdueInvoice_Call{Call: _e.mock.On("LockOverdueInvoice", ex, invoiceID)}
+}
+
+func (_c *InvoiceRepository_LockOverdueInvoice_Call) Run(run func(ex repository.Executor, invoiceID int64)) *InvoiceRepository_LockOverdueInvoice_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_LockOverdueInvoice_Call) Return(_a0 *models.Invoice, _a1 error) *InvoiceRepository_LockOverdueInvoice_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *InvoiceRepository_LockOverdueInvoice_Call) RunAndReturn(run func(repository.Executor, int64) (*models.Invoice, error)) *InvoiceRepository_LockOverdueInvoice_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RecordCallBillings provides a mock function with given fields: ex, billings
+func (_m *InvoiceRepository) RecordCallBillings(ex repository.Executor, billings []models.CallBilling) error {
+	ret := _m.Called(ex, billings)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordCallBillings")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, []models.CallBilling) error); ok {
+		r0 = rf(ex, billings)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InvoiceRepository_RecordCallBillings_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RecordCallBillings'
+type InvoiceRepository_RecordCallBillings_Call struct {
+	*mock.Call
+}
+
+// RecordCallBillings is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - billings []models.CallBilling
+func (_e *InvoiceRepository_Expecter) RecordCallBillings(ex interface{}, billings interface{}) *InvoiceRepository_RecordCallBillings_Call {
+	return &InvoiceRepository_RecordCallBillings_Call{Call: _e.mock.On("RecordCallBillings", ex, billings)}
+}
+
+func (_c *InvoiceRepository_RecordCallBillings_Call) Run(run func(ex repository.Executor, billings []models.CallBilling)) *InvoiceRepository_RecordCallBillings_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].([]models.CallBilling))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_RecordCallBillings_Call) Return(_a0 error) *InvoiceRepository_RecordCallBillings_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *InvoiceRepository_RecordCallBillings_Call) RunAndReturn(run func(repository.Executor, []models.CallBilling) error) *InvoiceRepository_RecordCallBillings_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RecordFailedAttempt provides a mock function with given fields: ex, invoiceID, source, at
+func (_m *InvoiceRepository) RecordFailedAttempt(ex repository.Executor, invoiceID int64, source string, at time.Time) error {
+	ret := _m.Called(ex, invoiceID, source, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordFailedAttempt")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64, string, time.Time) error); ok {
+		r0 = rf(ex, invoiceID, source, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InvoiceRepository_RecordFailedAttempt_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RecordFailedAttempt'
+type InvoiceRepository_RecordFailedAttempt_Call struct {
+	*mock.Call
+}
+
+// RecordFailedAttempt is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - invoiceID int64
+//   - source string
+//   - at time.Time
+func (_e *InvoiceRepository_Expecter) RecordFailedAttempt(ex interface{}, invoiceID interface{}, source interface{}, at interface{}) *InvoiceRepository_RecordFailedAttempt_Call {
+	return &InvoiceRepository_RecordFailedAttempt_Call{Call: _e.mock.On("RecordFailedAttempt", ex, invoiceID, source, at)}
+}
+
+func (_c *InvoiceRepository_RecordFailedAttempt_Call) Run(run func(ex repository.Executor, invoiceID int64, source string, at time.Time)) *InvoiceRepository_RecordFailedAttempt_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int64), args[2].(string), args[3].(time.Time))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_RecordFailedAttempt_Call) Return(_a0 error) *InvoiceRepository_RecordFailedAttempt_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *InvoiceRepository_RecordFailedAttempt_Call) RunAndReturn(run func(repository.Executor, int64, string, time.Time) error) *InvoiceRepository_RecordFailedAttempt_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RecordPayment provides a mock function with given fields: ex, invoiceID, source, cents, confirmationNumber, at
+func (_m *InvoiceRepository) RecordPayment(ex repository.Executor, invoiceID int64, source string, cents int64, confirmationNumber string, at time.Time) error {
+	ret := _m.Called(ex, invoiceID, source, cents, confirmationNumber, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordPayment")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64, string, int64, string, time.Time) error); ok {
+		r0 = rf(ex, invoiceID, source, cents, confirmationNumber, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InvoiceRepository_RecordPayment_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RecordPayment'
+type InvoiceRepository_RecordPayment_Call struct {
+	*mock.Call
+}
+
+// RecordPayment is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - invoiceID int64
+//   - source string
+//   - cents int64
+//   - confirmationNumber string
+//   - at time.Time
+func (_e *InvoiceRepository_Expecter) RecordPayment(ex interface{}, invoiceID interface{}, source interface{}, cents interface{}, confirmationNumber interface{}, at interface{}) *InvoiceRepository_RecordPayment_Call {
+	return &InvoiceRepository_RecordPayment_Call{Call: _e.mock.On("RecordPayment", ex, invoiceID, source, cents, confirmationNumber, at)}
+}
+
+func (_c *InvoiceRepository_RecordPayment_Call) Run(run func(ex repository.Executor, invoiceID int64, source string, cents int64, confirmationNumber string, at time.Time)) *InvoiceRepository_RecordPayment_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int64), args[2].(string), args[3].(int64), args[4].(string), args[5].(time.Time))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_RecordPayment_Call) Return(_a0 error) *InvoiceRepository_RecordPayment_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *InvoiceRepository_RecordPayment_Call) RunAndReturn(run func(repository.Executor, int64, string, int64, string, time.Time) error) *InvoiceRepository_RecordPayment_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RecordReminder provides a mock function with given fields: ex, invoiceID, offsetDays, at
+func (_m *InvoiceRepository) RecordReminder(ex repository.Executor, invoiceID int64, offsetDays int, at time.Time) (bool, error) {
+	ret := _m.Called(ex, invoiceID, offsetDays, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordReminder")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64, int, time.Time) (bool, error)); ok {
+		return rf(ex, invoiceID, offsetDays, at)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64, int, time.Time) bool); ok {
+		r0 = rf(ex, invoiceID, offsetDays, at)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int64, int, time.Time) error); ok {
+		r1 = rf(ex, invoiceID, offsetDays, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InvoiceRepository_RecordReminder_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RecordReminder'
+type InvoiceRepository_RecordReminder_Call struct {
+	*mock.Call
+}
+
+// RecordReminder is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - invoiceID int64
+//   - offsetDays int
+//   - at time.Time
+func (_e *InvoiceRepository_Expecter) RecordReminder(ex interface{}, invoiceID interface{}, offsetDays interface{}, at interface{}) *InvoiceRepository_RecordReminder_Call {
+	return &InvoiceRepository_RecordReminder_Call{Call: _e.mock.On("RecordReminder", ex, invoiceID, offsetDays, at)}
+}
+
+func (_c *InvoiceRepository_RecordReminder_Call) Run(run func(ex repository.Executor, invoiceID int64, offsetDays int, at time.Time)) *InvoiceRepository_RecordReminder_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int64), args[2].(int), args[3].(time.Time))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_RecordReminder_Call) Return(_a0 bool, _a1 error) *InvoiceRepository_RecordReminder_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *InvoiceRepository_RecordReminder_Call) RunAndReturn(run func(repository.Executor, int64, int, time.Time) (bool, error)) *InvoiceRepository_RecordReminder_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ReleaseDebits provides a mock function with given fields: ex, invoiceID
+func (_m *InvoiceRepository) ReleaseDebits(ex repository.Executor, invoiceID int64) (int64, error) {
+	ret := _m.Called(ex, invoiceID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleaseDebits")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64) (int64, error)); ok {
+		return rf(ex, invoiceID)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64) int64); ok {
+		r0 = rf(ex, invoiceID)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int64) error); ok {
+		r1 = rf(ex, invoiceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InvoiceRepository_ReleaseDebits_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReleaseDebits'
+type InvoiceRepository_ReleaseDebits_Call struct {
+	*mock.Call
+}
+
+// ReleaseDebits is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - invoiceID int64
+func (_e *InvoiceRepository_Expecter) ReleaseDebits(ex interface{}, invoiceID interface{}) *InvoiceRepository_ReleaseDebits_Call {
+	return &InvoiceRepository_ReleaseDebits_Call{Call: _e.mock.On("ReleaseDebits", ex, invoiceID)}
+}
+
+func (_c *InvoiceRepository_ReleaseDebits_Call) Run(run func(ex repository.Executor, invoiceID int64)) *InvoiceRepository_ReleaseDebits_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_ReleaseDebits_Call) Return(_a0 int64, _a1 error) *InvoiceRepository_ReleaseDebits_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *InvoiceRepository_ReleaseDebits_Call) RunAndReturn(run func(repository.Executor, int64) (int64, error)) *InvoiceRepository_ReleaseDebits_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// TransitionInvoice provides a mock function with given fields: ex, invoiceID, to, reason, at
+func (_m *InvoiceRepository) TransitionInvoice(ex repository.Executor, invoiceID int64, to invoice.Status, reason string, at time.Time) error {
+	ret := _m.Called(ex, invoiceID, to, reason, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TransitionInvoice")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64, invoice.Status, string, time.Time) error); ok {
+		r0 = rf(ex, invoiceID, to, reason, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InvoiceRepository_TransitionInvoice_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TransitionInvoice'
+type InvoiceRepository_TransitionInvoice_Call struct {
+	*mock.Call
+}
+
+// TransitionInvoice is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - invoiceID int64
+//   - to invoice.Status
+//   - reason string
+//   - at time.Time
+func (_e *InvoiceRepository_Expecter) TransitionInvoice(ex interface{}, invoiceID interface{}, to interface{}, reason interface{}, at interface{}) *InvoiceRepository_TransitionInvoice_Call {
+	return &InvoiceRepository_TransitionInvoice_Call{Call: _e.mock.On("TransitionInvoice", ex, invoiceID, to, reason, at)}
+}
+
+func (_c *InvoiceRepository_TransitionInvoice_Call) Run(run func(ex repository.Executor, invoiceID int64, to invoice.Status, reason string, at time.Time)) *InvoiceRepository_TransitionInvoice_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int64), args[2].(invoice.Status), args[3].(string), args[4].(time.Time))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_TransitionInvoice_Call) Return(_a0 error) *InvoiceRepository_TransitionInvoice_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *InvoiceRepository_TransitionInvoice_Call) RunAndReturn(run func(repository.Executor, int64, invoice.Status, string, time.Time) error) *InvoiceRepository_TransitionInvoice_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateOutboxEntry provides a mock function with given fields: ex, id, status, paymentReference, errMsg
+func (_m *InvoiceRepository) UpdateOutboxEntry(ex repository.Executor, id int64, status string, paymentReference string, errMsg string) error {
+	ret := _m.Called(ex, id, status, paymentReference, errMsg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateOutboxEntry")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64, string, string, string) error); ok {
+		r0 = rf(ex, id, status, paymentReference, errMsg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InvoiceRepository_UpdateOutboxEntry_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateOutboxEntry'
+type InvoiceRepository_UpdateOutboxEntry_Call struct {
+	*mock.Call
+}
+
+// UpdateOutboxEntry is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - id int64
+//   - status string
+//   - paymentReference string
+//   - errMsg string
+func (_e *InvoiceRepository_Expecter) UpdateOutboxEntry(ex interface{}, id interface{}, status interface{}, paymentReference interface{}, errMsg interface{}) *InvoiceRepository_UpdateOutboxEntry_Call {
+	return &InvoiceRepository_UpdateOutboxEntry_Call{Call: _e.mock.On("UpdateOutboxEntry", ex, id, status, paymentReference, errMsg)}
+}
+
+func (_c *InvoiceRepository_UpdateOutboxEntry_Call) Run(run func(ex repository.Executor, id int64, status string, paymentReference string, errMsg string)) *InvoiceRepository_UpdateOutboxEntry_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int64), args[2].(string), args[3].(string), args[4].(string))
+	})
+	return _c
+}
+
+func (_c *InvoiceRepository_UpdateOutboxEntry_Call) Return(_a0 error) *InvoiceRepository_UpdateOutboxEntry_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *InvoiceRepository_UpdateOutboxEntry_Call) RunAndReturn(run func(repository.Executor, int64, string, string, string) error) *InvoiceRepository_UpdateOutboxEntry_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewInvoiceRepository creates a new instance of InvoiceRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewInvoiceRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *InvoiceRepository {
+	mock := &InvoiceRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -4,10 +4,16 @@ package mocks
 
 import (
 	lineblocs "github.com/Lineblocs/go-helpers"
+	allowance "lineblocs.com/scheduler/internal/allowance"
+
 	mock "github.com/stretchr/testify/mock"
 
 	models "lineblocs.com/scheduler/models"
 
+	pricing "lineblocs.com/scheduler/internal/pricing"
+
+	time "time"
+
 	utils "lineblocs.com/scheduler/utils"
 )
 
@@ -73,6 +79,467 @@ func (_c *PaymentRepository_ChargeCustomer_Call) RunAndReturn(run func(*utils.Bi
 	return _c
 }
 
+// GetActiveSubscriptionID provides a mock function with given fields: workspaceId
+func (_m *PaymentRepository) GetActiveSubscriptionID(workspaceId int) (int, error) {
+	ret := _m.Called(workspaceId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetActiveSubscriptionID")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) (int, error)); ok {
+		return rf(workspaceId)
+	}
+	if rf, ok := ret.Get(0).(func(int) int); ok {
+		r0 = rf(workspaceId)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(workspaceId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PaymentRepository_GetActiveSubscriptionID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetActiveSubscriptionID'
+type PaymentRepository_GetActiveSubscriptionID_Call struct {
+	*mock.Call
+}
+
+// GetActiveSubscriptionID is a helper method to define mock.On call
+//   - workspaceId int
+func (_e *PaymentRepository_Expecter) GetActiveSubscriptionID(workspaceId interface{}) *PaymentRepository_GetActiveSubscriptionID_Call {
+	return &PaymentRepository_GetActiveSubscriptionID_Call{Call: _e.mock.On("GetActiveSubscriptionID", workspaceId)}
+}
+
+func (_c *PaymentRepository_GetActiveSubscriptionID_Call) Run(run func(workspaceId int)) *PaymentRepository_GetActiveSubscriptionID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *PaymentRepository_GetActiveSubscriptionID_Call) Return(_a0 int, _a1 error) *PaymentRepository_GetActiveSubscriptionID_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *PaymentRepository_GetActiveSubscriptionID_Call) RunAndReturn(run func(int) (int, error)) *PaymentRepository_GetActiveSubscriptionID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetCallRatingPlan provides a mock function with given fields: planId
+func (_m *PaymentRepository) GetCallRatingPlan(planId int) (*models.CallRatingPlan, error) {
+	ret := _m.Called(planId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCallRatingPlan")
+	}
+
+	var r0 *models.CallRatingPlan
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) (*models.CallRatingPlan, error)); ok {
+		return rf(planId)
+	}
+	if rf, ok := ret.Get(0).(func(int) *models.CallRatingPlan); ok {
+		r0 = rf(planId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.CallRatingPlan)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(planId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PaymentRepository_GetCallRatingPlan_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCallRatingPlan'
+type PaymentRepository_GetCallRatingPlan_Call struct {
+	*mock.Call
+}
+
+// GetCallRatingPlan is a helper method to define mock.On call
+//   - planId int
+func (_e *PaymentRepository_Expecter) GetCallRatingPlan(planId interface{}) *PaymentRepository_GetCallRatingPlan_Call {
+	return &PaymentRepository_GetCallRatingPlan_Call{Call: _e.mock.On("GetCallRatingPlan", planId)}
+}
+
+func (_c *PaymentRepository_GetCallRatingPlan_Call) Run(run func(planId int)) *PaymentRepository_GetCallRatingPlan_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *PaymentRepository_GetCallRatingPlan_Call) Return(_a0 *models.CallRatingPlan, _a1 error) *PaymentRepository_GetCallRatingPlan_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *PaymentRepository_GetCallRatingPlan_Call) RunAndReturn(run func(int) (*models.CallRatingPlan, error)) *PaymentRepository_GetCallRatingPlan_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetCollectionTerms provides a mock function with given fields: workspaceId
+func (_m *PaymentRepository) GetCollectionTerms(workspaceId int) (*models.CollectionTerms, error) {
+	ret := _m.Called(workspaceId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCollectionTerms")
+	}
+
+	var r0 *models.CollectionTerms
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) (*models.CollectionTerms, error)); ok {
+		return rf(workspaceId)
+	}
+	if rf, ok := ret.Get(0).(func(int) *models.CollectionTerms); ok {
+		r0 = rf(workspaceId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.CollectionTerms)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(workspaceId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PaymentRepository_GetCollectionTerms_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCollectionTerms'
+type PaymentRepository_GetCollectionTerms_Call struct {
+	*mock.Call
+}
+
+// GetCollectionTerms is a helper method to define mock.On call
+//   - workspaceId int
+func (_e *PaymentRepository_Expecter) GetCollectionTerms(workspaceId interface{}) *PaymentRepository_GetCollectionTerms_Call {
+	return &PaymentRepository_GetCollectionTerms_Call{Call: _e.mock.On("GetCollectionTerms", workspaceId)}
+}
+
+func (_c *PaymentRepository_GetCollectionTerms_Call) Run(run func(workspaceId int)) *PaymentRepository_GetCollectionTerms_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *PaymentRepository_GetCollectionTerms_Call) Return(_a0 *models.CollectionTerms, _a1 error) *PaymentRepository_GetCollectionTerms_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *PaymentRepository_GetCollectionTerms_Call) RunAndReturn(run func(int) (*models.CollectionTerms, error)) *PaymentRepository_GetCollectionTerms_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetContract provides a mock function with given fields: workspaceId, at
+func (_m *PaymentRepository) GetContract(workspaceId int, at time.Time) (*models.Contract, error) {
+	ret := _m.Called(workspaceId, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetContract")
+	}
+
+	var r0 *models.Contract
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, time.Time) (*models.Contract, error)); ok {
+		return rf(workspaceId, at)
+	}
+	if rf, ok := ret.Get(0).(func(int, time.Time) *models.Contract); ok {
+		r0 = rf(workspaceId, at)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Contract)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int, time.Time) error); ok {
+		r1 = rf(workspaceId, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PaymentRepository_GetContract_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetContract'
+type PaymentRepository_GetContract_Call struct {
+	*mock.Call
+}
+
+// GetContract is a helper method to define mock.On call
+//   - workspaceId int
+//   - at time.Time
+func (_e *PaymentRepository_Expecter) GetContract(workspaceId interface{}, at interface{}) *PaymentRepository_GetContract_Call {
+	return &PaymentRepository_GetContract_Call{Call: _e.mock.On("GetContract", workspaceId, at)}
+}
+
+func (_c *PaymentRepository_GetContract_Call) Run(run func(workspaceId int, at time.Time)) *PaymentRepository_GetContract_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *PaymentRepository_GetContract_Call) Return(_a0 *models.Contract, _a1 error) *PaymentRepository_GetContract_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *PaymentRepository_GetContract_Call) RunAndReturn(run func(int, time.Time) (*models.Contract, error)) *PaymentRepository_GetContract_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetFaxRates provides a mock function with given fields: defaultCentsPerPage
+func (_m *PaymentRepository) GetFaxRates(defaultCentsPerPage float64) (*models.FaxRates, error) {
+	ret := _m.Called(defaultCentsPerPage)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetFaxRates")
+	}
+
+	var r0 *models.FaxRates
+	var r1 error
+	if rf, ok := ret.Get(0).(func(float64) (*models.FaxRates, error)); ok {
+		return rf(defaultCentsPerPage)
+	}
+	if rf, ok := ret.Get(0).(func(float64) *models.FaxRates); ok {
+		r0 = rf(defaultCentsPerPage)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.FaxRates)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(float64) error); ok {
+		r1 = rf(defaultCentsPerPage)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PaymentRepository_GetFaxRates_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetFaxRates'
+type PaymentRepository_GetFaxRates_Call struct {
+	*mock.Call
+}
+
+// GetFaxRates is a helper method to define mock.On call
+//   - defaultCentsPerPage float64
+func (_e *PaymentRepository_Expecter) GetFaxRates(defaultCentsPerPage interface{}) *PaymentRepository_GetFaxRates_Call {
+	return &PaymentRepository_GetFaxRates_Call{Call: _e.mock.On("GetFaxRates", defaultCentsPerPage)}
+}
+
+func (_c *PaymentRepository_GetFaxRates_Call) Run(run func(defaultCentsPerPage float64)) *PaymentRepository_GetFaxRates_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(float64))
+	})
+	return _c
+}
+
+func (_c *PaymentRepository_GetFaxRates_Call) Return(_a0 *models.FaxRates, _a1 error) *PaymentRepository_GetFaxRates_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *PaymentRepository_GetFaxRates_Call) RunAndReturn(run func(float64) (*models.FaxRates, error)) *PaymentRepository_GetFaxRates_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetPriceCatalog provides a mock function with given fields: at
+func (_m *PaymentRepository) GetPriceCatalog(at time.Time) (*models.PriceCatalog, error) {
+	ret := _m.Called(at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPriceCatalog")
+	}
+
+	var r0 *models.PriceCatalog
+	var r1 error
+	if rf, ok := ret.Get(0).(func(time.Time) (*models.PriceCatalog, error)); ok {
+		return rf(at)
+	}
+	if rf, ok := ret.Get(0).(func(time.Time) *models.PriceCatalog); ok {
+		r0 = rf(at)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PriceCatalog)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(at)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PaymentRepository_GetPriceCatalog_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPriceCatalog'
+type PaymentRepository_GetPriceCatalog_Call struct {
+	*mock.Call
+}
+
+// GetPriceCatalog is a helper method to define mock.On call
+//   - at time.Time
+func (_e *PaymentRepository_Expecter) GetPriceCatalog(at interface{}) *PaymentRepository_GetPriceCatalog_Call {
+	return &PaymentRepository_GetPriceCatalog_Call{Call: _e.mock.On("GetPriceCatalog", at)}
+}
+
+func (_c *PaymentRepository_GetPriceCatalog_Call) Run(run func(at time.Time)) *PaymentRepository_GetPriceCatalog_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(time.Time))
+	})
+	return _c
+}
+
+func (_c *PaymentRepository_GetPriceCatalog_Call) Return(_a0 *models.PriceCatalog, _a1 error) *PaymentRepository_GetPriceCatalog_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *PaymentRepository_GetPriceCatalog_Call) RunAndReturn(run func(time.Time) (*models.PriceCatalog, error)) *PaymentRepository_GetPriceCatalog_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetPriceSchedules provides a mock function with given fields: planId
+func (_m *PaymentRepository) GetPriceSchedules(planId int) (map[string]pricing.Schedule, error) {
+	ret := _m.Called(planId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPriceSchedules")
+	}
+
+	var r0 map[string]pricing.Schedule
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) (map[string]pricing.Schedule, error)); ok {
+		return rf(planId)
+	}
+	if rf, ok := ret.Get(0).(func(int) map[string]pricing.Schedule); ok {
+		r0 = rf(planId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]pricing.Schedule)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(planId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PaymentRepository_GetPriceSchedules_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPriceSchedules'
+type PaymentRepository_GetPriceSchedules_Call struct {
+	*mock.Call
+}
+
+// GetPriceSchedules is a helper method to define mock.On call
+//   - planId int
+func (_e *PaymentRepository_Expecter) GetPriceSchedules(planId interface{}) *PaymentRepository_GetPriceSchedules_Call {
+	return &PaymentRepository_GetPriceSchedules_Call{Call: _e.mock.On("GetPriceSchedules", planId)}
+}
+
+func (_c *PaymentRepository_GetPriceSchedules_Call) Run(run func(planId int)) *PaymentRepository_GetPriceSchedules_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *PaymentRepository_GetPriceSchedules_Call) Return(_a0 map[string]pricing.Schedule, _a1 error) *PaymentRepository_GetPriceSchedules_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *PaymentRepository_GetPriceSchedules_Call) RunAndReturn(run func(int) (map[string]pricing.Schedule, error)) *PaymentRepository_GetPriceSchedules_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetRolloverPolicy provides a mock function with given fields: planId
+func (_m *PaymentRepository) GetRolloverPolicy(planId int) (allowance.Policy, error) {
+	ret := _m.Called(planId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRolloverPolicy")
+	}
+
+	var r0 allowance.Policy
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) (allowance.Policy, error)); ok {
+		return rf(planId)
+	}
+	if rf, ok := ret.Get(0).(func(int) allowance.Policy); ok {
+		r0 = rf(planId)
+	} else {
+		r0 = ret.Get(0).(allowance.Policy)
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(planId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PaymentRepository_GetRolloverPolicy_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetRolloverPolicy'
+type PaymentRepository_GetRolloverPolicy_Call struct {
+	*mock.Call
+}
+
+// GetRolloverPolicy is a helper method to define mock.On call
+//   - planId int
+func (_e *PaymentRepository_Expecter) GetRolloverPolicy(planId interface{}) *PaymentRepository_GetRolloverPolicy_Call {
+	return &PaymentRepository_GetRolloverPolicy_Call{Call: _e.mock.On("GetRolloverPolicy", planId)}
+}
+
+func (_c *PaymentRepository_GetRolloverPolicy_Call) Run(run func(planId int)) *PaymentRepository_GetRolloverPolicy_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *PaymentRepository_GetRolloverPolicy_Call) Return(_a0 allowance.Policy, _a1 error) *PaymentRepository_GetRolloverPolicy_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *PaymentRepository_GetRolloverPolicy_Call) RunAndReturn(run func(int) (allowance.Policy, error)) *PaymentRepository_GetRolloverPolicy_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetServicePlans provides a mock function with given fields:
 func (_m *PaymentRepository) GetServicePlans() ([]lineblocs.ServicePlan, error) {
 	ret := _m.Called()
@@ -130,6 +597,181 @@ func (_c *PaymentRepository_GetServicePlans_Call) RunAndReturn(run func() ([]lin
 	return _c
 }
 
+// GetSubscription provides a mock function with given fields: subId
+func (_m *PaymentRepository) GetSubscription(subId int) (*lineblocs.Subscription, error) {
+	ret := _m.Called(subId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSubscription")
+	}
+
+	var r0 *lineblocs.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) (*lineblocs.Subscription, error)); ok {
+		return rf(subId)
+	}
+	if rf, ok := ret.Get(0).(func(int) *lineblocs.Subscription); ok {
+		r0 = rf(subId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*lineblocs.Subscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(subId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PaymentRepository_GetSubscription_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetSubscription'
+type PaymentRepository_GetSubscription_Call struct {
+	*mock.Call
+}
+
+// GetSubscription is a helper method to define mock.On call
+//   - subId int
+func (_e *PaymentRepository_Expecter) GetSubscription(subId interface{}) *PaymentRepository_GetSubscription_Call {
+	return &PaymentRepository_GetSubscription_Call{Call: _e.mock.On("GetSubscription", subId)}
+}
+
+func (_c *PaymentRepository_GetSubscription_Call) Run(run func(subId int)) *PaymentRepository_GetSubscription_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *PaymentRepository_GetSubscription_Call) Return(_a0 *lineblocs.Subscription, _a1 error) *PaymentRepository_GetSubscription_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *PaymentRepository_GetSubscription_Call) RunAndReturn(run func(int) (*lineblocs.Subscription, error)) *PaymentRepository_GetSubscription_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetSubscriptionAddOns provides a mock function with given fields: subId
+func (_m *PaymentRepository) GetSubscriptionAddOns(subId int) ([]models.AddOn, error) {
+	ret := _m.Called(subId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSubscriptionAddOns")
+	}
+
+	var r0 []models.AddOn
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) ([]models.AddOn, error)); ok {
+		return rf(subId)
+	}
+	if rf, ok := ret.Get(0).(func(int) []models.AddOn); ok {
+		r0 = rf(subId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.AddOn)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(subId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PaymentRepository_GetSubscriptionAddOns_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetSubscriptionAddOns'
+type PaymentRepository_GetSubscriptionAddOns_Call struct {
+	*mock.Call
+}
+
+// GetSubscriptionAddOns is a helper method to define mock.On call
+//   - subId int
+func (_e *PaymentRepository_Expecter) GetSubscriptionAddOns(subId interface{}) *PaymentRepository_GetSubscriptionAddOns_Call {
+	return &PaymentRepository_GetSubscriptionAddOns_Call{Call: _e.mock.On("GetSubscriptionAddOns", subId)}
+}
+
+func (_c *PaymentRepository_GetSubscriptionAddOns_Call) Run(run func(subId int)) *PaymentRepository_GetSubscriptionAddOns_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *PaymentRepository_GetSubscriptionAddOns_Call) Return(_a0 []models.AddOn, _a1 error) *PaymentRepository_GetSubscriptionAddOns_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *PaymentRepository_GetSubscriptionAddOns_Call) RunAndReturn(run func(int) ([]models.AddOn, error)) *PaymentRepository_GetSubscriptionAddOns_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RefundCustomer provides a mock function with given fields: billingParams, paymentReference, cents, idempotencyKey
+func (_m *PaymentRepository) RefundCustomer(billingParams *utils.BillingParams, paymentReference string, cents int64, idempotencyKey string) (string, error) {
+	ret := _m.Called(billingParams, paymentReference, cents, idempotencyKey)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RefundCustomer")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*utils.BillingParams, string, int64, string) (string, error)); ok {
+		return rf(billingParams, paymentReference, cents, idempotencyKey)
+	}
+	if rf, ok := ret.Get(0).(func(*utils.BillingParams, string, int64, string) string); ok {
+		r0 = rf(billingParams, paymentReference, cents, idempotencyKey)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(*utils.BillingParams, string, int64, string) error); ok {
+		r1 = rf(billingParams, paymentReference, cents, idempotencyKey)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PaymentRepository_RefundCustomer_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RefundCustomer'
+type PaymentRepository_RefundCustomer_Call struct {
+	*mock.Call
+}
+
+// RefundCustomer is a helper method to define mock.On call
+//   - billingParams *utils.BillingParams
+//   - paymentReference string
+//   - cents int64
+//   - idempotencyKey string
+func (_e *PaymentRepository_Expecter) RefundCustomer(billingParams interface{}, paymentReference interface{}, cents interface{}, idempotencyKey interface{}) *PaymentRepository_RefundCustomer_Call {
+	return &PaymentRepository_RefundCustomer_Call{Call: _e.mock.On("RefundCustomer", billingParams, paymentReference, cents, idempotencyKey)}
+}
+
+func (_c *PaymentRepository_RefundCustomer_Call) Run(run func(billingParams *utils.BillingParams, paymentReference string, cents int64, idempotencyKey string)) *PaymentRepository_RefundCustomer_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*utils.BillingParams), args[1].(string), args[2].(int64), args[3].(string))
+	})
+	return _c
+}
+
+func (_c *PaymentRepository_RefundCustomer_Call) Return(_a0 string, _a1 error) *PaymentRepository_RefundCustomer_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *PaymentRepository_RefundCustomer_Call) RunAndReturn(run func(*utils.BillingParams, string, int64, string) (string, error)) *PaymentRepository_RefundCustomer_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewPaymentRepository creates a new instance of PaymentRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewPaymentRepository(t interface {
@@ -0,0 +1,474 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	mock "github.com/stretchr/testify/mock"
+	promotions "lineblocs.com/scheduler/internal/promotions"
+
+	repository "lineblocs.com/scheduler/repository"
+
+	time "time"
+)
+
+// PromotionRepository is an autogenerated mock type for the PromotionRepository type
+type PromotionRepository struct {
+	mock.Mock
+}
+
+type PromotionRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *PromotionRepository) EXPECT() *PromotionRepository_Expecter {
+	return &PromotionRepository_Expecter{mock: &_m.Mock}
+}
+
+// CountRedemptions provides a mock function with given fields: ex, couponID
+func (_m *PromotionRepository) CountRedemptions(ex repository.Executor, couponID int64) (int, error) {
+	ret := _m.Called(ex, couponID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountRedemptions")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64) (int, error)); ok {
+		return rf(ex, couponID)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64) int); ok {
+		r0 = rf(ex, couponID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int64) error); ok {
+		r1 = rf(ex, couponID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PromotionRepository_CountRedemptions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CountRedemptions'
+type PromotionRepository_CountRedemptions_Call struct {
+	*mock.Call
+}
+
+// CountRedemptions is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - couponID int64
+func (_e *PromotionRepository_Expecter) CountRedemptions(ex interface{}, couponID interface{}) *PromotionRepository_CountRedemptions_Call {
+	return &PromotionRepository_CountRedemptions_Call{Call: _e.mock.On("CountRedemptions", ex, couponID)}
+}
+
+func (_c *PromotionRepository_CountRedemptions_Call) Run(run func(ex repository.Executor, couponID int64)) *PromotionRepository_CountRedemptions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *PromotionRepository_CountRedemptions_Call) Return(_a0 int, _a1 error) *PromotionRepository_CountRedemptions_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *PromotionRepository_CountRedemptions_Call) RunAndReturn(run func(repository.Executor, int64) (int, error)) *PromotionRepository_CountRedemptions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateInvoiceDiscounts provides a mock function with given fields: ex, invoiceID, discounts, at
+func (_m *PromotionRepository) CreateInvoiceDiscounts(ex repository.Executor, invoiceID int64, discounts []promotions.Discount, at time.Time) error {
+	ret := _m.Called(ex, invoiceID, discounts, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateInvoiceDiscounts")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64, []promotions.Discount, time.Time) error); ok {
+		r0 = rf(ex, invoiceID, discounts, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PromotionRepository_CreateInvoiceDiscounts_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateInvoiceDiscounts'
+type PromotionRepository_CreateInvoiceDiscounts_Call struct {
+	*mock.Call
+}
+
+// CreateInvoiceDiscounts is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - invoiceID int64
+//   - discounts []promotions.Discount
+//   - at time.Time
+func (_e *PromotionRepository_Expecter) CreateInvoiceDiscounts(ex interface{}, invoiceID interface{}, discounts interface{}, at interface{}) *PromotionRepository_CreateInvoiceDiscounts_Call {
+	return &PromotionRepository_CreateInvoiceDiscounts_Call{Call: _e.mock.On("CreateInvoiceDiscounts", ex, invoiceID, discounts, at)}
+}
+
+func (_c *PromotionRepository_CreateInvoiceDiscounts_Call) Run(run func(ex repository.Executor, invoiceID int64, discounts []promotions.Discount, at time.Time)) *PromotionRepository_CreateInvoiceDiscounts_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int64), args[2].([]promotions.Discount), args[3].(time.Time))
+	})
+	return _c
+}
+
+func (_c *PromotionRepository_CreateInvoiceDiscounts_Call) Return(_a0 error) *PromotionRepository_CreateInvoiceDiscounts_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *PromotionRepository_CreateInvoiceDiscounts_Call) RunAndReturn(run func(repository.Executor, int64, []promotions.Discount, time.Time) error) *PromotionRepository_CreateInvoiceDiscounts_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateRedemption provides a mock function with given fields: ex, couponID, workspaceID, at
+func (_m *PromotionRepository) CreateRedemption(ex repository.Executor, couponID int64, workspaceID int, at time.Time) (int64, error) {
+	ret := _m.Called(ex, couponID, workspaceID, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateRedemption")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64, int, time.Time) (int64, error)); ok {
+		return rf(ex, couponID, workspaceID, at)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64, int, time.Time) int64); ok {
+		r0 = rf(ex, couponID, workspaceID, at)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int64, int, time.Time) error); ok {
+		r1 = rf(ex, couponID, workspaceID, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PromotionRepository_CreateRedemption_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateRedemption'
+type PromotionRepository_CreateRedemption_Call struct {
+	*mock.Call
+}
+
+// CreateRedemption is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - couponID int64
+//   - workspaceID int
+//   - at time.Time
+func (_e *PromotionRepository_Expecter) CreateRedemption(ex interface{}, couponID interface{}, workspaceID interface{}, at interface{}) *PromotionRepository_CreateRedemption_Call {
+	return &PromotionRepository_CreateRedemption_Call{Call: _e.mock.On("CreateRedemption", ex, couponID, workspaceID, at)}
+}
+
+func (_c *PromotionRepository_CreateRedemption_Call) Run(run func(ex repository.Executor, couponID int64, workspaceID int, at time.Time)) *PromotionRepository_CreateRedemption_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int64), args[2].(int), args[3].(time.Time))
+	})
+	return _c
+}
+
+func (_c *PromotionRepository_CreateRedemption_Call) Return(_a0 int64, _a1 error) *PromotionRepository_CreateRedemption_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *PromotionRepository_CreateRedemption_Call) RunAndReturn(run func(repository.Executor, int64, int, time.Time) (int64, error)) *PromotionRepository_CreateRedemption_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetRedemptions provides a mock function with given fields: ex, workspaceID
+func (_m *PromotionRepository) GetRedemptions(ex repository.Executor, workspaceID int) ([]promotions.Redemption, error) {
+	ret := _m.Called(ex, workspaceID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRedemptions")
+	}
+
+	var r0 []promotions.Redemption
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int) ([]promotions.Redemption, error)); ok {
+		return rf(ex, workspaceID)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int) []promotions.Redemption); ok {
+		r0 = rf(ex, workspaceID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]promotions.Redemption)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int) error); ok {
+		r1 = rf(ex, workspaceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PromotionRepository_GetRedemptions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetRedemptions'
+type PromotionRepository_GetRedemptions_Call struct {
+	*mock.Call
+}
+
+// GetRedemptions is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - workspaceID int
+func (_e *PromotionRepository_Expecter) GetRedemptions(ex interface{}, workspaceID interface{}) *PromotionRepository_GetRedemptions_Call {
+	return &PromotionRepository_GetRedemptions_Call{Call: _e.mock.On("GetRedemptions", ex, workspaceID)}
+}
+
+func (_c *PromotionRepository_GetRedemptions_Call) Run(run func(ex repository.Executor, workspaceID int)) *PromotionRepository_GetRedemptions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *PromotionRepository_GetRedemptions_Call) Return(_a0 []promotions.Redemption, _a1 error) *PromotionRepository_GetRedemptions_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *PromotionRepository_GetRedemptions_Call) RunAndReturn(run func(repository.Executor, int) ([]promotions.Redemption, error)) *PromotionRepository_GetRedemptions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HasRedeemed provides a mock function with given fields: ex, couponID, workspaceID
+func (_m *PromotionRepository) HasRedeemed(ex repository.Executor, couponID int64, workspaceID int) (bool, error) {
+	ret := _m.Called(ex, couponID, workspaceID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HasRedeemed")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64, int) (bool, error)); ok {
+		return rf(ex, couponID, workspaceID)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64, int) bool); ok {
+		r0 = rf(ex, couponID, workspaceID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int64, int) error); ok {
+		r1 = rf(ex, couponID, workspaceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PromotionRepository_HasRedeemed_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HasRedeemed'
+type PromotionRepository_HasRedeemed_Call struct {
+	*mock.Call
+}
+
+// HasRedeemed is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - couponID int64
+//   - workspaceID int
+func (_e *PromotionRepository_Expecter) HasRedeemed(ex interface{}, couponID interface{}, workspaceID interface{}) *PromotionRepository_HasRedeemed_Call {
+	return &PromotionRepository_HasRedeemed_Call{Call: _e.mock.On("HasRedeemed", ex, couponID, workspaceID)}
+}
+
+func (_c *PromotionRepository_HasRedeemed_Call) Run(run func(ex repository.Executor, couponID int64, workspaceID int)) *PromotionRepository_HasRedeemed_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int64), args[2].(int))
+	})
+	return _c
+}
+
+func (_c *PromotionRepository_HasRedeemed_Call) Return(_a0 bool, _a1 error) *PromotionRepository_HasRedeemed_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *PromotionRepository_HasRedeemed_Call) RunAndReturn(run func(repository.Executor, int64, int) (bool, error)) *PromotionRepository_HasRedeemed_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// LockCouponByCode provides a mock function with given fields: ex, code
+func (_m *PromotionRepository) LockCouponByCode(ex repository.Executor, code string) (*promotions.Coupon, error) {
+	ret := _m.Called(ex, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LockCouponByCode")
+	}
+
+	var r0 *promotions.Coupon
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, string) (*promotions.Coupon, error)); ok {
+		return rf(ex, code)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, string) *promotions.Coupon); ok {
+		r0 = rf(ex, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*promotions.Coupon)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, string) error); ok {
+		r1 = rf(ex, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PromotionRepository_LockCouponByCode_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LockCouponByCode'
+type PromotionRepository_LockCouponByCode_Call struct {
+	*mock.Call
+}
+
+// LockCouponByCode is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - code string
+func (_e *PromotionRepository_Expecter) LockCouponByCode(ex interface{}, code interface{}) *PromotionRepository_LockCouponByCode_Call {
+	return &PromotionRepository_LockCouponByCode_Call{Call: _e.mock.On("LockCouponByCode", ex, code)}
+}
+
+func (_c *PromotionRepository_LockCouponByCode_Call) Run(run func(ex repository.Executor, code string)) *PromotionRepository_LockCouponByCode_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *PromotionRepository_LockCouponByCode_Call) Return(_a0 *promotions.Coupon, _a1 error) *PromotionRepository_LockCouponByCode_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *PromotionRepository_LockCouponByCode_Call) RunAndReturn(run func(repository.Executor, string) (*promotions.Coupon, error)) *PromotionRepository_LockCouponByCode_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MarkRedemptionsApplied provides a mock function with given fields: ex, redemptionIDs
+func (_m *PromotionRepository) MarkRedemptionsApplied(ex repository.Executor, redemptionIDs []int64) error {
+	ret := _m.Called(ex, redemptionIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkRedemptionsApplied")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, []int64) error); ok {
+		r0 = rf(ex, redemptionIDs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PromotionRepository_MarkRedemptionsApplied_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MarkRedemptionsApplied'
+type PromotionRepository_MarkRedemptionsApplied_Call struct {
+	*mock.Call
+}
+
+// MarkRedemptionsApplied is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - redemptionIDs []int64
+func (_e *PromotionRepository_Expecter) MarkRedemptionsApplied(ex interface{}, redemptionIDs interface{}) *PromotionRepository_MarkRedemptionsApplied_Call {
+	return &PromotionRepository_MarkRedemptionsApplied_Call{Call: _e.mock.On("MarkRedemptionsApplied", ex, redemptionIDs)}
+}
+
+func (_c *PromotionRepository_MarkRedemptionsApplied_Call) Run(run func(ex repository.Executor, redemptionIDs []int64)) *PromotionRepository_MarkRedemptionsApplied_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].([]int64))
+	})
+	return _c
+}
+
+func (_c *PromotionRepository_MarkRedemptionsApplied_Call) Return(_a0 error) *PromotionRepository_MarkRedemptionsApplied_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *PromotionRepository_MarkRedemptionsApplied_Call) RunAndReturn(run func(repository.Executor, []int64) error) *PromotionRepository_MarkRedemptionsApplied_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ReleaseRedemptions provides a mock function with given fields: ex, invoiceID
+func (_m *PromotionRepository) ReleaseRedemptions(ex repository.Executor, invoiceID int64) error {
+	ret := _m.Called(ex, invoiceID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleaseRedemptions")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64) error); ok {
+		r0 = rf(ex, invoiceID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PromotionRepository_ReleaseRedemptions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReleaseRedemptions'
+type PromotionRepository_ReleaseRedemptions_Call struct {
+	*mock.Call
+}
+
+// ReleaseRedemptions is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - invoiceID int64
+func (_e *PromotionRepository_Expecter) ReleaseRedemptions(ex interface{}, invoiceID interface{}) *PromotionRepository_ReleaseRedemptions_Call {
+	return &PromotionRepository_ReleaseRedemptions_Call{Call: _e.mock.On("ReleaseRedemptions", ex, invoiceID)}
+}
+
+func (_c *PromotionRepository_ReleaseRedemptions_Call) Run(run func(ex repository.Executor, invoiceID int64)) *PromotionRepository_ReleaseRedemptions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *PromotionRepository_ReleaseRedemptions_Call) Return(_a0 error) *PromotionRepository_ReleaseRedemptions_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *PromotionRepository_ReleaseRedemptions_Call) RunAndReturn(run func(repository.Executor, int64) error) *PromotionRepository_ReleaseRedemptions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewPromotionRepository creates a new instance of PromotionRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewPromotionRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *PromotionRepository {
+	mock := &PromotionRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
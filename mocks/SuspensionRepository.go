@@ -0,0 +1,435 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	mock "github.com/stretchr/testify/mock"
+	models "lineblocs.com/scheduler/models"
+
+	repository "lineblocs.com/scheduler/repository"
+
+	suspension "lineblocs.com/scheduler/internal/suspension"
+
+	time "time"
+)
+
+// SuspensionRepository is an autogenerated mock type for the SuspensionRepository type
+type SuspensionRepository struct {
+	mock.Mock
+}
+
+type SuspensionRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *SuspensionRepository) EXPECT() *SuspensionRepository_Expecter {
+	return &SuspensionRepository_Expecter{mock: &_m.Mock}
+}
+
+// GetBillingStatus provides a mock function with given fields: ex, workspaceID
+func (_m *SuspensionRepository) GetBillingStatus(ex repository.Executor, workspaceID int) (string, error) {
+	ret := _m.Called(ex, workspaceID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBillingStatus")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int) (string, error)); ok {
+		return rf(ex, workspaceID)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int) string); ok {
+		r0 = rf(ex, workspaceID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int) error); ok {
+		r1 = rf(ex, workspaceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SuspensionRepository_GetBillingStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetBillingStatus'
+type SuspensionRepository_GetBillingStatus_Call struct {
+	*mock.Call
+}
+
+// GetBillingStatus is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - workspaceID int
+func (_e *SuspensionRepository_Expecter) GetBillingStatus(ex interface{}, workspaceID interface{}) *SuspensionRepository_GetBillingStatus_Call {
+	return &SuspensionRepository_GetBillingStatus_Call{Call: _e.mock.On("GetBillingStatus", ex, workspaceID)}
+}
+
+func (_c *SuspensionRepository_GetBillingStatus_Call) Run(run func(ex repository.Executor, workspaceID int)) *SuspensionRepository_GetBillingStatus_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *SuspensionRepository_GetBillingStatus_Call) Return(_a0 string, _a1 error) *SuspensionRepository_GetBillingStatus_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *SuspensionRepository_GetBillingStatus_Call) RunAndReturn(run func(repository.Executor, int) (string, error)) *SuspensionRepository_GetBillingStatus_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUnpaidInvoices provides a mock function with given fields: ex, workspaceID
+func (_m *SuspensionRepository) GetUnpaidInvoices(ex repository.Executor, workspaceID int) ([]suspension.Invoice, error) {
+	ret := _m.Called(ex, workspaceID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUnpaidInvoices")
+	}
+
+	var r0 []suspension.Invoice
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int) ([]suspension.Invoice, error)); ok {
+		return rf(ex, workspaceID)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int) []suspension.Invoice); ok {
+		r0 = rf(ex, workspaceID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]suspension.Invoice)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int) error); ok {
+		r1 = rf(ex, workspaceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SuspensionRepository_GetUnpaidInvoices_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUnpaidInvoices'
+type SuspensionRepository_GetUnpaidInvoices_Call struct {
+	*mock.Call
+}
+
+// GetUnpaidInvoices is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - workspaceID int
+func (_e *SuspensionRepository_Expecter) GetUnpaidInvoices(ex interface{}, workspaceID interface{}) *SuspensionRepository_GetUnpaidInvoices_Call {
+	return &SuspensionRepository_GetUnpaidInvoices_Call{Call: _e.mock.On("GetUnpaidInvoices", ex, workspaceID)}
+}
+
+func (_c *SuspensionRepository_GetUnpaidInvoices_Call) Run(run func(ex repository.Executor, workspaceID int)) *SuspensionRepository_GetUnpaidInvoices_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *SuspensionRepository_GetUnpaidInvoices_Call) Return(_a0 []suspension.Invoice, _a1 error) *SuspensionRepository_GetUnpaidInvoices_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *SuspensionRepository_GetUnpaidInvoices_Call) RunAndReturn(run func(repository.Executor, int) ([]suspension.Invoice, error)) *SuspensionRepository_GetUnpaidInvoices_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetWorkspacesToReview provides a mock function with given fields: ex
+func (_m *SuspensionRepository) GetWorkspacesToReview(ex repository.Executor) ([]int, error) {
+	ret := _m.Called(ex)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWorkspacesToReview")
+	}
+
+	var r0 []int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor) ([]int, error)); ok {
+		return rf(ex)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor) []int); ok {
+		r0 = rf(ex)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor) error); ok {
+		r1 = rf(ex)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SuspensionRepository_GetWorkspacesToReview_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetWorkspacesToReview'
+type SuspensionRepository_GetWorkspacesToReview_Call struct {
+	*mock.Call
+}
+
+// GetWorkspacesToReview is a helper method to define mock.On call
+//   - ex repository.Executor
+func (_e *SuspensionRepository_Expecter) GetWorkspacesToReview(ex interface{}) *SuspensionRepository_GetWorkspacesToReview_Call {
+	return &SuspensionRepository_GetWorkspacesToReview_Call{Call: _e.mock.On("GetWorkspacesToReview", ex)}
+}
+
+func (_c *SuspensionRepository_GetWorkspacesToReview_Call) Run(run func(ex repository.Executor)) *SuspensionRepository_GetWorkspacesToReview_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor))
+	})
+	return _c
+}
+
+func (_c *SuspensionRepository_GetWorkspacesToReview_Call) Return(_a0 []int, _a1 error) *SuspensionRepository_GetWorkspacesToReview_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *SuspensionRepository_GetWorkspacesToReview_Call) RunAndReturn(run func(repository.Executor) ([]int, error)) *SuspensionRepository_GetWorkspacesToReview_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HoldNumbers provides a mock function with given fields: ex, workspaceID, at
+func (_m *SuspensionRepository) HoldNumbers(ex repository.Executor, workspaceID int, at time.Time) (int64, error) {
+	ret := _m.Called(ex, workspaceID, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HoldNumbers")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int, time.Time) (int64, error)); ok {
+		return rf(ex, workspaceID, at)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int, time.Time) int64); ok {
+		r0 = rf(ex, workspaceID, at)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int, time.Time) error); ok {
+		r1 = rf(ex, workspaceID, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SuspensionRepository_HoldNumbers_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HoldNumbers'
+type SuspensionRepository_HoldNumbers_Call struct {
+	*mock.Call
+}
+
+// HoldNumbers is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - workspaceID int
+//   - at time.Time
+func (_e *SuspensionRepository_Expecter) HoldNumbers(ex interface{}, workspaceID interface{}, at interface{}) *SuspensionRepository_HoldNumbers_Call {
+	return &SuspensionRepository_HoldNumbers_Call{Call: _e.mock.On("HoldNumbers", ex, workspaceID, at)}
+}
+
+func (_c *SuspensionRepository_HoldNumbers_Call) Run(run func(ex repository.Executor, workspaceID int, at time.Time)) *SuspensionRepository_HoldNumbers_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int), args[2].(time.Time))
+	})
+	return _c
+}
+
+func (_c *SuspensionRepository_HoldNumbers_Call) Return(_a0 int64, _a1 error) *SuspensionRepository_HoldNumbers_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *SuspensionRepository_HoldNumbers_Call) RunAndReturn(run func(repository.Executor, int, time.Time) (int64, error)) *SuspensionRepository_HoldNumbers_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ReleaseNumbers provides a mock function with given fields: ex, workspaceID, at
+func (_m *SuspensionRepository) ReleaseNumbers(ex repository.Executor, workspaceID int, at time.Time) (int64, error) {
+	ret := _m.Called(ex, workspaceID, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReleaseNumbers")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int, time.Time) (int64, error)); ok {
+		return rf(ex, workspaceID, at)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int, time.Time) int64); ok {
+		r0 = rf(ex, workspaceID, at)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int, time.Time) error); ok {
+		r1 = rf(ex, workspaceID, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SuspensionRepository_ReleaseNumbers_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReleaseNumbers'
+type SuspensionRepository_ReleaseNumbers_Call struct {
+	*mock.Call
+}
+
+// ReleaseNumbers is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - workspaceID int
+//   - at time.Time
+func (_e *SuspensionRepository_Expecter) ReleaseNumbers(ex interface{}, workspaceID interface{}, at interface{}) *SuspensionRepository_ReleaseNumbers_Call {
+	return &SuspensionRepository_ReleaseNumbers_Call{Call: _e.mock.On("ReleaseNumbers", ex, workspaceID, at)}
+}
+
+func (_c *SuspensionRepository_ReleaseNumbers_Call) Run(run func(ex repository.Executor, workspaceID int, at time.Time)) *SuspensionRepository_ReleaseNumbers_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int), args[2].(time.Time))
+	})
+	return _c
+}
+
+func (_c *SuspensionRepository_ReleaseNumbers_Call) Return(_a0 int64, _a1 error) *SuspensionRepository_ReleaseNumbers_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *SuspensionRepository_ReleaseNumbers_Call) RunAndReturn(run func(repository.Executor, int, time.Time) (int64, error)) *SuspensionRepository_ReleaseNumbers_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RestoreNumbers provides a mock function with given fields: ex, workspaceID
+func (_m *SuspensionRepository) RestoreNumbers(ex repository.Executor, workspaceID int) (int64, error) {
+	ret := _m.Called(ex, workspaceID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreNumbers")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int) (int64, error)); ok {
+		return rf(ex, workspaceID)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int) int64); ok {
+		r0 = rf(ex, workspaceID)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int) error); ok {
+		r1 = rf(ex, workspaceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SuspensionRepository_RestoreNumbers_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RestoreNumbers'
+type SuspensionRepository_RestoreNumbers_Call struct {
+	*mock.Call
+}
+
+// RestoreNumbers is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - workspaceID int
+func (_e *SuspensionRepository_Expecter) RestoreNumbers(ex interface{}, workspaceID interface{}) *SuspensionRepository_RestoreNumbers_Call {
+	return &SuspensionRepository_RestoreNumbers_Call{Call: _e.mock.On("RestoreNumbers", ex, workspaceID)}
+}
+
+func (_c *SuspensionRepository_RestoreNumbers_Call) Run(run func(ex repository.Executor, workspaceID int)) *SuspensionRepository_RestoreNumbers_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *SuspensionRepository_RestoreNumbers_Call) Return(_a0 int64, _a1 error) *SuspensionRepository_RestoreNumbers_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *SuspensionRepository_RestoreNumbers_Call) RunAndReturn(run func(repository.Executor, int) (int64, error)) *SuspensionRepository_RestoreNumbers_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetBillingStatus provides a mock function with given fields: ex, event
+func (_m *SuspensionRepository) SetBillingStatus(ex repository.Executor, event models.WorkspaceStatusEvent) error {
+	ret := _m.Called(ex, event)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetBillingStatus")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, models.WorkspaceStatusEvent) error); ok {
+		r0 = rf(ex, event)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SuspensionRepository_SetBillingStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetBillingStatus'
+type SuspensionRepository_SetBillingStatus_Call struct {
+	*mock.Call
+}
+
+// SetBillingStatus is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - event models.WorkspaceStatusEvent
+func (_e *SuspensionRepository_Expecter) SetBillingStatus(ex interface{}, event interface{}) *SuspensionRepository_SetBillingStatus_Call {
+	return &SuspensionRepository_SetBillingStatus_Call{Call: _e.mock.On("SetBillingStatus", ex, event)}
+}
+
+func (_c *SuspensionRepository_SetBillingStatus_Call) Run(run func(ex repository.Executor, event models.WorkspaceStatusEvent)) *SuspensionRepository_SetBillingStatus_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(models.WorkspaceStatusEvent))
+	})
+	return _c
+}
+
+func (_c *SuspensionRepository_SetBillingStatus_Call) Return(_a0 error) *SuspensionRepository_SetBillingStatus_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *SuspensionRepository_SetBillingStatus_Call) RunAndReturn(run func(repository.Executor, models.WorkspaceStatusEvent) error) *SuspensionRepository_SetBillingStatus_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewSuspensionRepository creates a new instance of SuspensionRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewSuspensionRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *SuspensionRepository {
+	mock := &SuspensionRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,390 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	mock "github.com/stretchr/testify/mock"
+	models "lineblocs.com/scheduler/models"
+
+	repository "lineblocs.com/scheduler/repository"
+
+	time "time"
+)
+
+// TopUpRepository is an autogenerated mock type for the TopUpRepository type
+type TopUpRepository struct {
+	mock.Mock
+}
+
+type TopUpRepository_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *TopUpRepository) EXPECT() *TopUpRepository_Expecter {
+	return &TopUpRepository_Expecter{mock: &_m.Mock}
+}
+
+// CreateTopUp provides a mock function with given fields: ex, topUp
+func (_m *TopUpRepository) CreateTopUp(ex repository.Executor, topUp *models.TopUp) (int64, error) {
+	ret := _m.Called(ex, topUp)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateTopUp")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, *models.TopUp) (int64, error)); ok {
+		return rf(ex, topUp)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, *models.TopUp) int64); ok {
+		r0 = rf(ex, topUp)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, *models.TopUp) error); ok {
+		r1 = rf(ex, topUp)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TopUpRepository_CreateTopUp_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateTopUp'
+type TopUpRepository_CreateTopUp_Call struct {
+	*mock.Call
+}
+
+// CreateTopUp is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - topUp *models.TopUp
+func (_e *TopUpRepository_Expecter) CreateTopUp(ex interface{}, topUp interface{}) *TopUpRepository_CreateTopUp_Call {
+	return &TopUpRepository_CreateTopUp_Call{Call: _e.mock.On("CreateTopUp", ex, topUp)}
+}
+
+func (_c *TopUpRepository_CreateTopUp_Call) Run(run func(ex repository.Executor, topUp *models.TopUp)) *TopUpRepository_CreateTopUp_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(*models.TopUp))
+	})
+	return _c
+}
+
+func (_c *TopUpRepository_CreateTopUp_Call) Return(_a0 int64, _a1 error) *TopUpRepository_CreateTopUp_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *TopUpRepository_CreateTopUp_Call) RunAndReturn(run func(repository.Executor, *models.TopUp) (int64, error)) *TopUpRepository_CreateTopUp_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetAutoTopUps provides a mock function with given fields: ex
+func (_m *TopUpRepository) GetAutoTopUps(ex repository.Executor) ([]models.AutoTopUp, error) {
+	ret := _m.Called(ex)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAutoTopUps")
+	}
+
+	var r0 []models.AutoTopUp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor) ([]models.AutoTopUp, error)); ok {
+		return rf(ex)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor) []models.AutoTopUp); ok {
+		r0 = rf(ex)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.AutoTopUp)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor) error); ok {
+		r1 = rf(ex)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TopUpRepository_GetAutoTopUps_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAutoTopUps'
+type TopUpRepository_GetAutoTopUps_Call struct {
+	*mock.Call
+}
+
+// GetAutoTopUps is a helper method to define mock.On call
+//   - ex repository.Executor
+func (_e *TopUpRepository_Expecter) GetAutoTopUps(ex interface{}) *TopUpRepository_GetAutoTopUps_Call {
+	return &TopUpRepository_GetAutoTopUps_Call{Call: _e.mock.On("GetAutoTopUps", ex)}
+}
+
+func (_c *TopUpRepository_GetAutoTopUps_Call) Run(run func(ex repository.Executor)) *TopUpRepository_GetAutoTopUps_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor))
+	})
+	return _c
+}
+
+func (_c *TopUpRepository_GetAutoTopUps_Call) Return(_a0 []models.AutoTopUp, _a1 error) *TopUpRepository_GetAutoTopUps_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *TopUpRepository_GetAutoTopUps_Call) RunAndReturn(run func(repository.Executor) ([]models.AutoTopUp, error)) *TopUpRepository_GetAutoTopUps_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetPendingTopUps provides a mock function with given fields: ex, createdBefore
+func (_m *TopUpRepository) GetPendingTopUps(ex repository.Executor, createdBefore time.Time) ([]models.TopUp, error) {
+	ret := _m.Called(ex, createdBefore)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPendingTopUps")
+	}
+
+	var r0 []models.TopUp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, time.Time) ([]models.TopUp, error)); ok {
+		return rf(ex, createdBefore)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, time.Time) []models.TopUp); ok {
+		r0 = rf(ex, createdBefore)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.TopUp)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, time.Time) error); ok {
+		r1 = rf(ex, createdBefore)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TopUpRepository_GetPendingTopUps_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPendingTopUps'
+type TopUpRepository_GetPendingTopUps_Call struct {
+	*mock.Call
+}
+
+// GetPendingTopUps is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - createdBefore time.Time
+func (_e *TopUpRepository_Expecter) GetPendingTopUps(ex interface{}, createdBefore interface{}) *TopUpRepository_GetPendingTopUps_Call {
+	return &TopUpRepository_GetPendingTopUps_Call{Call: _e.mock.On("GetPendingTopUps", ex, createdBefore)}
+}
+
+func (_c *TopUpRepository_GetPendingTopUps_Call) Run(run func(ex repository.Executor, createdBefore time.Time)) *TopUpRepository_GetPendingTopUps_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *TopUpRepository_GetPendingTopUps_Call) Return(_a0 []models.TopUp, _a1 error) *TopUpRepository_GetPendingTopUps_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *TopUpRepository_GetPendingTopUps_Call) RunAndReturn(run func(repository.Executor, time.Time) ([]models.TopUp, error)) *TopUpRepository_GetPendingTopUps_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HasPendingTopUp provides a mock function with given fields: ex, workspaceID
+func (_m *TopUpRepository) HasPendingTopUp(ex repository.Executor, workspaceID int) (bool, error) {
+	ret := _m.Called(ex, workspaceID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HasPendingTopUp")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int) (bool, error)); ok {
+		return rf(ex, workspaceID)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int) bool); ok {
+		r0 = rf(ex, workspaceID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int) error); ok {
+		r1 = rf(ex, workspaceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TopUpRepository_HasPendingTopUp_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HasPendingTopUp'
+type TopUpRepository_HasPendingTopUp_Call struct {
+	*mock.Call
+}
+
+// HasPendingTopUp is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - workspaceID int
+func (_e *TopUpRepository_Expecter) HasPendingTopUp(ex interface{}, workspaceID interface{}) *TopUpRepository_HasPendingTopUp_Call {
+	return &TopUpRepository_HasPendingTopUp_Call{Call: _e.mock.On("HasPendingTopUp", ex, workspaceID)}
+}
+
+func (_c *TopUpRepository_HasPendingTopUp_Call) Run(run func(ex repository.Executor, workspaceID int)) *TopUpRepository_HasPendingTopUp_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *TopUpRepository_HasPendingTopUp_Call) Return(_a0 bool, _a1 error) *TopUpRepository_HasPendingTopUp_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *TopUpRepository_HasPendingTopUp_Call) RunAndReturn(run func(repository.Executor, int) (bool, error)) *TopUpRepository_HasPendingTopUp_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// LockAutoTopUp provides a mock function with given fields: ex, workspaceID
+func (_m *TopUpRepository) LockAutoTopUp(ex repository.Executor, workspaceID int) (*models.AutoTopUp, error) {
+	ret := _m.Called(ex, workspaceID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LockAutoTopUp")
+	}
+
+	var r0 *models.AutoTopUp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int) (*models.AutoTopUp, error)); ok {
+		return rf(ex, workspaceID)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int) *models.AutoTopUp); ok {
+		r0 = rf(ex, workspaceID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.AutoTopUp)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int) error); ok {
+		r1 = rf(ex, workspaceID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TopUpRepository_LockAutoTopUp_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LockAutoTopUp'
+type TopUpRepository_LockAutoTopUp_Call struct {
+	*mock.Call
+}
+
+// LockAutoTopUp is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - workspaceID int
+func (_e *TopUpRepository_Expecter) LockAutoTopUp(ex interface{}, workspaceID interface{}) *TopUpRepository_LockAutoTopUp_Call {
+	return &TopUpRepository_LockAutoTopUp_Call{Call: _e.mock.On("LockAutoTopUp", ex, workspaceID)}
+}
+
+func (_c *TopUpRepository_LockAutoTopUp_Call) Run(run func(ex repository.Executor, workspaceID int)) *TopUpRepository_LockAutoTopUp_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *TopUpRepository_LockAutoTopUp_Call) Return(_a0 *models.AutoTopUp, _a1 error) *TopUpRepository_LockAutoTopUp_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *TopUpRepository_LockAutoTopUp_Call) RunAndReturn(run func(repository.Executor, int) (*models.AutoTopUp, error)) *TopUpRepository_LockAutoTopUp_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SettleTopUp provides a mock function with given fields: ex, id, status, paymentReference, errMsg, at
+func (_m *TopUpRepository) SettleTopUp(ex repository.Executor, id int64, status string, paymentReference string, errMsg string, at time.Time) (bool, error) {
+	ret := _m.Called(ex, id, status, paymentReference, errMsg, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SettleTopUp")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64, string, string, string, time.Time) (bool, error)); ok {
+		return rf(ex, id, status, paymentReference, errMsg, at)
+	}
+	if rf, ok := ret.Get(0).(func(repository.Executor, int64, string, string, string, time.Time) bool); ok {
+		r0 = rf(ex, id, status, paymentReference, errMsg, at)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(repository.Executor, int64, string, string, string, time.Time) error); ok {
+		r1 = rf(ex, id, status, paymentReference, errMsg, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TopUpRepository_SettleTopUp_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SettleTopUp'
+type TopUpRepository_SettleTopUp_Call struct {
+	*mock.Call
+}
+
+// SettleTopUp is a helper method to define mock.On call
+//   - ex repository.Executor
+//   - id int64
+//   - status string
+//   - paymentReference string
+//   - errMsg string
+//   - at time.Time
+func (_e *TopUpRepository_Expecter) SettleTopUp(ex interface{}, id interface{}, status interface{}, paymentReference interface{}, errMsg interface{}, at interface{}) *TopUpRepository_SettleTopUp_Call {
+	return &TopUpRepository_SettleTopUp_Call{Call: _e.mock.On("SettleTopUp", ex, id, status, paymentReference, errMsg, at)}
+}
+
+func (_c *TopUpRepository_SettleTopUp_Call) Run(run func(ex repository.Executor, id int64, status string, paymentReference string, errMsg string, at time.Time)) *TopUpRepository_SettleTopUp_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(repository.Executor), args[1].(int64), args[2].(string), args[3].(string), args[4].(string), args[5].(time.Time))
+	})
+	return _c
+}
+
+func (_c *TopUpRepository_SettleTopUp_Call) Return(_a0 bool, _a1 error) *TopUpRepository_SettleTopUp_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *TopUpRepository_SettleTopUp_Call) RunAndReturn(run func(repository.Executor, int64, string, string, string, time.Time) (bool, error)) *TopUpRepository_SettleTopUp_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewTopUpRepository creates a new instance of TopUpRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewTopUpRepository(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *TopUpRepository {
+	mock := &TopUpRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
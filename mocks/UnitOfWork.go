@@ -0,0 +1,81 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	mock "github.com/stretchr/testify/mock"
+	repository "lineblocs.com/scheduler/repository"
+)
+
+// UnitOfWork is an autogenerated mock type for the UnitOfWork type
+type UnitOfWork struct {
+	mock.Mock
+}
+
+type UnitOfWork_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *UnitOfWork) EXPECT() *UnitOfWork_Expecter {
+	return &UnitOfWork_Expecter{mock: &_m.Mock}
+}
+
+// Do provides a mock function with given fields: fn
+func (_m *UnitOfWork) Do(fn func(repository.Executor) error) error {
+	ret := _m.Called(fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Do")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(func(repository.Executor) error) error); ok {
+		r0 = rf(fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UnitOfWork_Do_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Do'
+type UnitOfWork_Do_Call struct {
+	*mock.Call
+}
+
+// Do is a helper method to define mock.On call
+//   - fn func(repository.Executor) error
+func (_e *UnitOfWork_Expecter) Do(fn interface{}) *UnitOfWork_Do_Call {
+	return &UnitOfWork_Do_Call{Call: _e.mock.On("Do", fn)}
+}
+
+func (_c *UnitOfWork_Do_Call) Run(run func(fn func(repository.Executor) error)) *UnitOfWork_Do_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(func(repository.Executor) error))
+	})
+	return _c
+}
+
+func (_c *UnitOfWork_Do_Call) Return(_a0 error) *UnitOfWork_Do_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *UnitOfWork_Do_Call) RunAndReturn(run func(func(repository.Executor) error) error) *UnitOfWork_Do_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewUnitOfWork creates a new instance of UnitOfWork. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewUnitOfWork(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *UnitOfWork {
+	mock := &UnitOfWork{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
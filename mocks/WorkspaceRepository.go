@@ -5,6 +5,10 @@ package mocks
 import (
 	lineblocs "github.com/Lineblocs/go-helpers"
 	mock "github.com/stretchr/testify/mock"
+
+	models "lineblocs.com/scheduler/models"
+
+	time "time"
 )
 
 // WorkspaceRepository is an autogenerated mock type for the WorkspaceRepository type
@@ -136,6 +140,66 @@ func (_c *WorkspaceRepository_GetDIDFromDB_Call) RunAndReturn(run func(int) (*li
 	return _c
 }
 
+// GetMemberships provides a mock function with given fields: workspaceId, start, end
+func (_m *WorkspaceRepository) GetMemberships(workspaceId int, start time.Time, end time.Time) ([]models.Membership, error) {
+	ret := _m.Called(workspaceId, start, end)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMemberships")
+	}
+
+	var r0 []models.Membership
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, time.Time, time.Time) ([]models.Membership, error)); ok {
+		return rf(workspaceId, start, end)
+	}
+	if rf, ok := ret.Get(0).(func(int, time.Time, time.Time) []models.Membership); ok {
+		r0 = rf(workspaceId, start, end)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Membership)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int, time.Time, time.Time) error); ok {
+		r1 = rf(workspaceId, start, end)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WorkspaceRepository_GetMemberships_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetMemberships'
+type WorkspaceRepository_GetMemberships_Call struct {
+	*mock.Call
+}
+
+// GetMemberships is a helper method to define mock.On call
+//   - workspaceId int
+//   - start time.Time
+//   - end time.Time
+func (_e *WorkspaceRepository_Expecter) GetMemberships(workspaceId interface{}, start interface{}, end interface{}) *WorkspaceRepository_GetMemberships_Call {
+	return &WorkspaceRepository_GetMemberships_Call{Call: _e.mock.On("GetMemberships", workspaceId, start, end)}
+}
+
+func (_c *WorkspaceRepository_GetMemberships_Call) Run(run func(workspaceId int, start time.Time, end time.Time)) *WorkspaceRepository_GetMemberships_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(time.Time), args[2].(time.Time))
+	})
+	return _c
+}
+
+func (_c *WorkspaceRepository_GetMemberships_Call) Return(_a0 []models.Membership, _a1 error) *WorkspaceRepository_GetMemberships_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *WorkspaceRepository_GetMemberships_Call) RunAndReturn(run func(int, time.Time, time.Time) ([]models.Membership, error)) *WorkspaceRepository_GetMemberships_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetUserFromDB provides a mock function with given fields: id
 func (_m *WorkspaceRepository) GetUserFromDB(id int) (*lineblocs.User, error) {
 	ret := _m.Called(id)
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // Workspace represents a workspace entity
 type Workspace struct {
 	Id        int
@@ -43,4 +45,340 @@ type Call struct {
 type DID struct {
 	Id          int
 	MonthlyCost int
-}
\ No newline at end of file
+}
+
+// Rounding rules applied to the portion of a call beyond its minimum duration
+const (
+	RoundingUp      = "UP"
+	RoundingNearest = "NEAREST"
+	RoundingDown    = "DOWN"
+)
+
+// CallRatingPlan describes the billing increments of a plan, e.g. 60/60, 30/6 or 1/1
+type CallRatingPlan struct {
+	Rounding         string
+	MinimumSeconds   int
+	IncrementSeconds int
+}
+
+// DefaultCallRatingPlan is used for plans without explicit increments (per-minute billing)
+func DefaultCallRatingPlan() *CallRatingPlan {
+	return &CallRatingPlan{
+		Rounding:         RoundingUp,
+		MinimumSeconds:   60,
+		IncrementSeconds: 60,
+	}
+}
+
+// NumberRental is a number held by a workspace during a billing period, with what was already charged for it
+type NumberRental struct {
+	ProvisionedAt time.Time
+	ReleasedAt    time.Time // zero while the workspace holds the number
+	DIDID         int
+	MonthlyCost   int
+	SetupCents    int64
+	// RentalBilled is set when the period's rental is already in the ledger
+	RentalBilled bool
+	SetupBilled  bool
+}
+
+// Kinds of did_rental_ledger entries
+const (
+	RentalKindMonthly = "RENTAL"
+	RentalKindSetup   = "SETUP"
+)
+
+// RentalCharge is a did_rental_ledger entry: a number's prorated rental for a period, or its setup fee
+type RentalCharge struct {
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Kind        string
+	DIDID       int
+	WorkspaceID int
+	Days        int
+	Cents       int64
+}
+
+// Fax directions as stored on the faxes table
+const (
+	FaxInbound  = "inbound"
+	FaxOutbound = "outbound"
+)
+
+// FaxRates holds the per-page price of faxes in each direction, in cents
+type FaxRates struct {
+	InboundCentsPerPage  float64
+	OutboundCentsPerPage float64
+}
+
+// CallBilling is what an invoice billed for a call debit after the allowance, kept on the debit
+// so re-rating adjusts what was billed rather than the call's full cost, on the plan it was billed under
+type CallBilling struct {
+	DebitID           int
+	PlanID            int
+	ChargeableSeconds int64
+	BilledCents       int64
+}
+
+// Statuses of usage debits. A debit is unbilled until an invoice claims it.
+const (
+	DebitStatusUnbilled = "INCOMPLETE"
+	DebitStatusBilled   = "BILLED"
+)
+
+// Sources of usage debits
+const (
+	DebitSourceCall           = "CALL"
+	DebitSourceCallAdjustment = "CALL_ADJUSTMENT" // re-rating difference on a call debit, negative for credits
+	DebitSourceNumberRental   = "NUMBER_RENTAL"
+	DebitSourceNumberSetup    = "NUMBER_SETUP"
+)
+
+// Statuses of a charge recorded in the billing outbox
+const (
+	OutboxPending   = "PENDING"
+	OutboxSucceeded = "SUCCEEDED"
+	OutboxFailed    = "FAILED"
+	OutboxReview    = "REVIEW"
+	OutboxCanceled  = "CANCELED" // not charged, the invoice was no longer collectable
+)
+
+// OutboxEntry records a gateway charge for an invoice so the result can be
+// reconciled if the worker stops between charging and settling the invoice
+type OutboxEntry struct {
+	CreatedAt        time.Time
+	IdempotencyKey   string
+	Status           string
+	PaymentReference string
+	Error            string
+	Id               int64
+	InvoiceID        int64
+	WorkspaceID      int
+	UserID           int
+	Cents            int64
+}
+
+// Invoice is a users_invoices row as written by a billing run, amounts in cents
+type Invoice struct {
+	CreatedAt   time.Time
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Source      string
+	TaxMetadata string
+	// BillingTerm is the name of the billing term that priced the invoice
+	BillingTerm         string
+	State               string
+	Id                  int64
+	UserID              int
+	WorkspaceID         int
+	SubscriptionID      int
+	CentsCollected      int64
+	Cents               int64
+	CentsIncludingTaxes int64
+	CallCosts           int64
+	RecordingCosts      int64
+	FaxCosts            int64
+	MembershipCosts     int64
+	NumberCosts         int64
+	AddOnCosts          int64
+	// ContractVersion is the version of the workspace contract the invoice was priced with, 0 for none
+	ContractVersion int
+	// CatalogVersion is the version of the price catalog the invoice was rated with, 0 for current prices
+	CatalogVersion int
+	// CollectionMethod is how the invoice is paid, empty for invoices charged automatically
+	CollectionMethod string
+	// DueDate is when an invoice sent for payment has to be paid, zero for invoices charged automatically
+	DueDate time.Time
+	// DiscountCosts is the sum of the invoice's discounts, zero or negative
+	DiscountCosts int64
+}
+
+// Forecast is a workspace's projected bill for the current month, as written to billing_forecasts
+type Forecast struct {
+	ForecastDate     time.Time
+	PeriodStart      time.Time
+	PeriodEnd        time.Time
+	AlertReason      string
+	WorkspaceID      int
+	DaysElapsed      int
+	DaysInPeriod     int
+	FixedCents       int64
+	UsageToDateCents int64
+	ProjectedCents   int64
+	PriorMonthCents  int64
+	Alerted          bool
+}
+
+// Membership is one stretch of a user's membership in a workspace, from workspace_membership_history
+type Membership struct {
+	JoinedAt time.Time
+	LeftAt   time.Time // zero while the user is still a member
+	UserID   int
+}
+
+// PriceCatalog is a version of the base costs and plan prices, in effect from EffectiveFrom until EffectiveTo
+type PriceCatalog struct {
+	EffectiveFrom     time.Time
+	EffectiveTo       time.Time // zero while open-ended
+	Plans             map[int]CatalogPlan
+	ID                int
+	Version           int
+	RecordingsPerByte float64
+	FaxPerUsed        float64
+}
+
+// CatalogPlan is a plan's prices and allowances in a price catalog
+type CatalogPlan struct {
+	BaseCosts       float64
+	MinutesPerMonth float64
+	RecordingSpace  float64
+	AnnualCostCents int
+	Fax             int
+}
+
+// Contract is a workspace's negotiated pricing, from workspace_contracts. Nil fields keep the plan's price.
+type Contract struct {
+	EffectiveFrom       time.Time
+	EffectiveTo         time.Time // zero while open-ended
+	SeatCents           *float64
+	AnnualSeatCents     *float64
+	MinutesPerMonth     *float64
+	RecordingSpaceMB    *float64
+	FaxPages            *int
+	CallCentsPerMinute  *float64
+	RecordingCentsPerGB *float64
+	FaxCentsPerPage     *float64
+	ID                  int
+	WorkspaceID         int
+	Version             int
+}
+
+// How a workspace pays its invoices
+const (
+	CollectionChargeAutomatically = "charge_automatically"
+	CollectionSendInvoice         = "send_invoice"
+)
+
+// CollectionTerms is how a workspace pays its invoices, from workspace_collection_terms.
+// Invoices sent for payment are due NetDays after they are issued.
+type CollectionTerms struct {
+	Method              string
+	PaymentInstructions string
+	WorkspaceID         int
+	NetDays             int
+}
+
+// SourceLateFee is the source of invoices that bill late charges on another invoice
+const SourceLateFee = "LATE_FEE"
+
+// SourceCredits is the payment source of invoices paid from a workspace's credits
+const SourceCredits = "CREDITS"
+
+// LateFee is a late charge on an overdue invoice, from the invoice_late_fees ledger. FeeInvoiceID
+// is the separate invoice the charge was billed on, 0 when it was added to the overdue invoice itself.
+type LateFee struct {
+	CreatedAt    time.Time
+	Kind         string
+	InvoiceID    int64
+	FeeInvoiceID int64
+	Period       int
+	Cents        int64
+}
+
+// WorkspaceStatusEvent is a change of a workspace's billing status, as recorded in workspace_billing_events
+// and published to the app. Numbers says what was done to its numbers, if anything.
+type WorkspaceStatusEvent struct {
+	CreatedAt       time.Time `json:"created_at"`
+	From            string    `json:"from"`
+	To              string    `json:"to"`
+	Reason          string    `json:"reason"`
+	Numbers         string    `json:"numbers,omitempty"`
+	WorkspaceID     int       `json:"workspace_id"`
+	NumbersAffected int64     `json:"numbers_affected"`
+}
+
+// AutoTopUp is a workspace's auto-recharge setting: when its credit balance falls below
+// ThresholdCents, AmountCents of credits are bought with its saved card
+type AutoTopUp struct {
+	WorkspaceID    int
+	ThresholdCents int64
+	AmountCents    int64
+}
+
+// TopUp records an auto-recharge card charge in workspace_topups. It is written before the card
+// is charged, with the outbox statuses, so a charge left pending can be retried with the same key.
+type TopUp struct {
+	CreatedAt        time.Time
+	IdempotencyKey   string
+	Status           string
+	PaymentReference string
+	Error            string
+	ID               int64
+	WorkspaceID      int
+	UserID           int
+	Cents            int64
+	// BalanceCents is the credit balance that triggered the top-up
+	BalanceCents int64
+}
+
+// WorkspaceBalance is the running credit balance of a prepaid workspace, kept in workspace_balances.
+// LastDebitID and LastCreditID are the last users_debits and users_credits rows counted in it.
+type WorkspaceBalance struct {
+	SyncedAt     time.Time
+	WorkspaceID  int
+	LastDebitID  int64
+	LastCreditID int64
+	// CreditInvoiceCents is the total of the invoices paid from credits counted in the balance so far
+	CreditInvoiceCents int64
+	BalanceCents       int64
+	CreditLimitCents   int64
+	CutOff             bool
+}
+
+// Balance events published to the call platform
+const (
+	BalanceCutoff  = "cutoff"  // block new outbound calls
+	BalanceRestore = "restore" // allow them again
+)
+
+// BalanceEvent tells the call platform that a prepaid workspace crossed its credit limit, either way
+type BalanceEvent struct {
+	CreatedAt        time.Time `json:"created_at"`
+	Event            string    `json:"event"`
+	WorkspaceID      int       `json:"workspace_id"`
+	BalanceCents     int64     `json:"balance_cents"`
+	CreditLimitCents int64     `json:"credit_limit_cents"`
+}
+
+// Invoice line item categories
+const (
+	LineItemMembership = "membership"
+	LineItemAddOn      = "addon"
+	LineItemLateFee    = "late_fee"
+)
+
+// AddOn is a product attached to a subscription, such as a minute bundle or a storage pack.
+// Its prices and allowances are per unit.
+type AddOn struct {
+	Name         string
+	ID           int
+	ProductID    int
+	Quantity     int
+	MonthlyCents int64
+	AnnualCents  int64 // 0 bills twelve months of MonthlyCents
+	Minutes      float64
+	RecordingMB  float64
+	FaxPages     int
+}
+
+// InvoiceLineItem is one priced line of an invoice, as written to invoice_line_items
+type InvoiceLineItem struct {
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Category    string    `json:"category"`
+	Description string    `json:"description"`
+	Unit        string    `json:"unit"`
+	ReferenceID int       `json:"reference_id"`
+	Quantity    float64   `json:"quantity"`
+	Cents       int64     `json:"cents"`
+}
@@ -3,7 +3,7 @@ package models
 // BillingTask represents the payload sent to RabbitMQ workers
 type BillingTask struct {
 	RunID                  string `json:"run_id"`
-	BillingType            string `json:"billing_type"` // "monthly" or "annual"
+	BillingType            string `json:"billing_type"` // "MONTHLY" run or "ANNUAL" prepaid run
 	WorkspaceID            int    `json:"workspace_id"`
 	CreatorID              int    `json:"creator_id"`
 	SubscriptionID         int    `json:"subscription_id"`
@@ -27,4 +27,21 @@ type FailedBillingTask struct {
 	SubscriptionID int    `json:"subscription_id"`
 	CreatorID      int    `json:"creator_id"`
 	Reason         string `json:"reason"`
-}
\ No newline at end of file
+}
+
+// RerateTask asks a worker to re-rate a workspace's call debits under a rate deck version
+type RerateTask struct {
+	RateVersion string `json:"rate_version"`
+	StartDate   string `json:"start_date"` // YYYY-MM-DD, inclusive
+	EndDate     string `json:"end_date"`   // YYYY-MM-DD, exclusive
+	WorkspaceID int    `json:"workspace_id"`
+}
+
+// EstimateTask asks a worker for a side-effect-free estimate of a workspace's bill. The
+// worker replies to the message's ReplyTo queue with the same CorrelationId.
+type EstimateTask struct {
+	BillingType string `json:"billing_type"` // "MONTHLY" (default) or "ANNUAL"
+	StartDate   string `json:"start_date"`   // YYYY-MM-DD, inclusive; defaults to the start of the term's period
+	EndDate     string `json:"end_date"`     // YYYY-MM-DD, exclusive; defaults to now
+	WorkspaceID int    `json:"workspace_id"`
+}
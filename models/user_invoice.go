@@ -5,4 +5,8 @@ type UserInvoice struct {
 	Id                 int    `json:"id"`
 	Cents              int    `json:"cents"`
 	ConfirmationNumber int    `json:"confirmation_number"`
+	// IdempotencyKey is sent to the gateway so a retried charge is not taken twice
+	IdempotencyKey string `json:"idempotency_key"`
+	// PaymentReference is set by the gateway handler once the charge went through
+	PaymentReference string `json:"payment_reference"`
 }
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"lineblocs.com/scheduler/internal/allowance"
+	"lineblocs.com/scheduler/internal/invoice"
+)
+
+// AllowanceRepository records the minute allowance each invoice consumed and carried forward.
+// A subscription's balance is what its latest invoice that was not voided carried forward.
+type AllowanceRepository interface {
+	GetAllowanceBalance(ex Executor, subscriptionID int) ([]allowance.Bucket, error)
+	SaveAllowancePeriod(ex Executor, invoiceID int64, subscriptionID int, period allowance.Period, at time.Time) error
+}
+
+type AllowanceService struct{}
+
+func NewAllowanceRepository() AllowanceRepository {
+	return &AllowanceService{}
+}
+
+// GetAllowanceBalance returns the rolled over minutes a subscription can use in its next invoice.
+// Voiding an invoice gives back the balance it started from, so a reissue draws on it again.
+func (as *AllowanceService) GetAllowanceBalance(ex Executor, subscriptionID int) ([]allowance.Bucket, error) {
+	var balance string
+	row := ex.QueryRow("SELECT a.balance FROM invoice_allowances a JOIN users_invoices i ON i.id = a.invoice_id WHERE a.subscription_id = ? AND i.state <> ? ORDER BY a.invoice_id DESC LIMIT 1",
+		subscriptionID, invoice.Void)
+	err := row.Scan(&balance)
+	if err == sql.ErrNoRows {
+		return []allowance.Bucket{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make([]allowance.Bucket, 0)
+	if err := json.Unmarshal([]byte(balance), &buckets); err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
+
+func (as *AllowanceService) SaveAllowancePeriod(ex Executor, invoiceID int64, subscriptionID int, period allowance.Period, at time.Time) error {
+	balance, err := json.Marshal(period.Balance)
+	if err != nil {
+		return err
+	}
+
+	_, err = ex.Exec("INSERT INTO invoice_allowances (`invoice_id`, `subscription_id`, `included_seconds`, `carried_in_seconds`, `consumed_seconds`, `carried_forward_seconds`, `expired_seconds`, `balance`, `created_at`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		invoiceID, subscriptionID, period.IncludedSeconds, period.CarriedInSeconds, period.ConsumedSeconds, period.CarriedForwardSeconds, period.ExpiredSeconds, string(balance), at)
+	return err
+}
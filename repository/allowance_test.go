@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"lineblocs.com/scheduler/internal/allowance"
+	"lineblocs.com/scheduler/internal/invoice"
+)
+
+func TestAllowanceServiceGetAllowanceBalance(t *testing.T) {
+	t.Parallel()
+
+	balanceQuery := regexp.QuoteMeta("SELECT a.balance FROM invoice_allowances a JOIN users_invoices i ON i.id = a.invoice_id WHERE a.subscription_id = ? AND i.state <> ? ORDER BY a.invoice_id DESC LIMIT 1")
+
+	t.Run("Should read the balance carried by the latest invoice", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(balanceQuery).WithArgs(4, invoice.Void).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(`[{"seconds":1200,"cycles_left":2}]`))
+
+		balance, err := NewAllowanceRepository().GetAllowanceBalance(db, 4)
+		assert.NoError(t, err)
+		assert.Equal(t, []allowance.Bucket{{Seconds: 1200, CyclesLeft: 2}}, balance)
+	})
+
+	t.Run("Should start empty before the first invoice", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(balanceQuery).WithArgs(4, invoice.Void).WillReturnRows(sqlmock.NewRows([]string{"balance"}))
+
+		balance, err := NewAllowanceRepository().GetAllowanceBalance(db, 4)
+		assert.NoError(t, err)
+		assert.Empty(t, balance)
+	})
+}
+
+func TestAllowanceServiceSaveAllowancePeriod(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	at := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	period := allowance.Period{IncludedSeconds: 6000, ConsumedSeconds: 4800, CarriedForwardSeconds: 1200, Balance: []allowance.Bucket{{Seconds: 1200, CyclesLeft: 2}}}
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO invoice_allowances")).
+		WithArgs(int64(9), 4, int64(6000), int64(0), int64(4800), int64(1200), int64(0), `[{"seconds":1200,"cycles_left":2}]`, at).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	assert.NoError(t, NewAllowanceRepository().SaveAllowancePeriod(db, 9, 4, period, at))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
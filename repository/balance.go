@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"lineblocs.com/scheduler/models"
+)
+
+// BalanceRepository keeps the running credit balances of prepaid workspaces
+type BalanceRepository interface {
+	GetWorkspacesOnPlans(ex Executor, plans []string) ([]int, error)
+	LockBalance(ex Executor, workspaceID int) (*models.WorkspaceBalance, error)
+	SumNewDebits(ex Executor, workspaceID int, afterID int64) (int64, int64, error)
+	SumNewCredits(ex Executor, workspaceID int, afterID int64) (int64, int64, error)
+	SumCreditInvoices(ex Executor, workspaceID int) (int64, error)
+	SaveBalance(ex Executor, balance models.WorkspaceBalance, at time.Time) error
+}
+
+type BalanceService struct{}
+
+func NewBalanceRepository() BalanceRepository {
+	return &BalanceService{}
+}
+
+// GetWorkspacesOnPlans returns the workspaces on any of the plans, by plan key
+func (bs *BalanceService) GetWorkspacesOnPlans(ex Executor, plans []string) ([]int, error) {
+	ids := make([]int, 0)
+	if len(plans) == 0 {
+		return ids, nil
+	}
+
+	args := make([]interface{}, 0, len(plans))
+	for _, plan := range plans {
+		args = append(args, plan)
+	}
+	rows, err := ex.Query("SELECT id FROM workspaces WHERE plan IN (?"+strings.Repeat(", ?", len(plans)-1)+") ORDER BY id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// LockBalance locks the workspace's running balance and returns it, or nil when it has not been started
+func (bs *BalanceService) LockBalance(ex Executor, workspaceID int) (*models.WorkspaceBalance, error) {
+	balance := models.WorkspaceBalance{WorkspaceID: workspaceID}
+	row := ex.QueryRow("SELECT balance_cents, credit_limit_cents, last_debit_id, last_credit_id, credit_invoice_cents, cut_off, synced_at FROM workspace_balances WHERE workspace_id = ? FOR UPDATE", workspaceID)
+	err := row.Scan(&balance.BalanceCents, &balance.CreditLimitCents, &balance.LastDebitID, &balance.LastCreditID, &balance.CreditInvoiceCents, &balance.CutOff, &balance.SyncedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &balance, nil
+}
+
+// SumNewDebits returns the cents of the debits after the given id, billed or not, and the last id counted
+func (bs *BalanceService) SumNewDebits(ex Executor, workspaceID int, afterID int64) (int64, int64, error) {
+	var cents, lastID int64
+	row := ex.QueryRow("SELECT COALESCE(SUM(cents), 0), COALESCE(MAX(id), ?) FROM users_debits WHERE workspace_id = ? AND id > ?",
+		afterID, workspaceID, afterID)
+	if err := row.Scan(&cents, &lastID); err != nil {
+		return 0, 0, err
+	}
+	return cents, lastID, nil
+}
+
+// SumNewCredits returns the cents of the credits after the given id and the last id counted
+func (bs *BalanceService) SumNewCredits(ex Executor, workspaceID int, afterID int64) (int64, int64, error) {
+	var cents, lastID int64
+	row := ex.QueryRow("SELECT COALESCE(SUM(cents), 0), COALESCE(MAX(id), ?) FROM users_credits WHERE workspace_id = ? AND id > ?",
+		afterID, workspaceID, afterID)
+	if err := row.Scan(&cents, &lastID); err != nil {
+		return 0, 0, err
+	}
+	return cents, lastID, nil
+}
+
+// SumCreditInvoices returns the cents of the workspace's invoices paid from credits. Invoices are marked
+// paid from credits after they are written, so they are summed in full rather than after a checkpoint.
+func (bs *BalanceService) SumCreditInvoices(ex Executor, workspaceID int) (int64, error) {
+	var cents int64
+	row := ex.QueryRow("SELECT COALESCE(SUM(cents), 0) FROM users_invoices WHERE workspace_id = ? AND source = ?", workspaceID, models.SourceCredits)
+	if err := row.Scan(&cents); err != nil {
+		return 0, err
+	}
+	return cents, nil
+}
+
+// SaveBalance writes the running balance. The credit limit is set by the app and is not overwritten.
+func (bs *BalanceService) SaveBalance(ex Executor, balance models.WorkspaceBalance, at time.Time) error {
+	_, err := ex.Exec("INSERT INTO workspace_balances (`workspace_id`, `balance_cents`, `last_debit_id`, `last_credit_id`, `credit_invoice_cents`, `cut_off`, `synced_at`, `updated_at`) VALUES (?, ?, ?, ?, ?, ?, ?, ?) "+
+		"ON DUPLICATE KEY UPDATE `balance_cents` = VALUES(`balance_cents`), `last_debit_id` = VALUES(`last_debit_id`), `last_credit_id` = VALUES(`last_credit_id`), `credit_invoice_cents` = VALUES(`credit_invoice_cents`), `cut_off` = VALUES(`cut_off`), `synced_at` = VALUES(`synced_at`), `updated_at` = VALUES(`updated_at`)",
+		balance.WorkspaceID, balance.BalanceCents, balance.LastDebitID, balance.LastCreditID, balance.CreditInvoiceCents, balance.CutOff, balance.SyncedAt, at)
+	return err
+}
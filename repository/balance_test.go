@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"lineblocs.com/scheduler/models"
+)
+
+func TestBalanceServiceGetWorkspacesOnPlans(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM workspaces WHERE plan IN (?, ?) ORDER BY id")).
+		WithArgs("payg", "payg-annual").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3).AddRow(5))
+
+	ids, err := NewBalanceRepository().GetWorkspacesOnPlans(db, []string{"payg", "payg-annual"})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{3, 5}, ids)
+
+	ids, err = NewBalanceRepository().GetWorkspacesOnPlans(db, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBalanceServiceSumNewDebits(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(cents), 0), COALESCE(MAX(id), ?) FROM users_debits WHERE workspace_id = ? AND id > ?")).
+		WithArgs(int64(40), 3, int64(40)).
+		WillReturnRows(sqlmock.NewRows([]string{"cents", "id"}).AddRow(350, 44))
+
+	cents, lastID, err := NewBalanceRepository().SumNewDebits(db, 3, 40)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(350), cents)
+	assert.Equal(t, int64(44), lastID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBalanceServiceSumCreditInvoices(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(cents), 0) FROM users_invoices WHERE workspace_id = ? AND source = ?")).
+		WithArgs(3, models.SourceCredits).
+		WillReturnRows(sqlmock.NewRows([]string{"cents"}).AddRow(500))
+
+	cents, err := NewBalanceRepository().SumCreditInvoices(db, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(500), cents)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBalanceServiceSaveBalance(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	at := time.Date(2026, 6, 1, 3, 0, 0, 0, time.UTC)
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO workspace_balances (`workspace_id`, `balance_cents`, `last_debit_id`, `last_credit_id`, `credit_invoice_cents`, `cut_off`, `synced_at`, `updated_at`)")).
+		WithArgs(3, int64(-150), int64(44), int64(8), int64(400), true, at, at).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	balance := models.WorkspaceBalance{SyncedAt: at, WorkspaceID: 3, LastDebitID: 44, LastCreditID: 8, CreditInvoiceCents: 400, BalanceCents: -150, CutOff: true}
+	assert.NoError(t, NewBalanceRepository().SaveBalance(db, balance, at))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"lineblocs.com/scheduler/internal/invoice"
+	"lineblocs.com/scheduler/models"
+)
+
+// ForecastRepository stores the daily projections of each workspace's month-end bill
+type ForecastRepository interface {
+	GetForecastWorkspaceIDs() ([]int, error)
+	GetPriorMonthCents(workspaceID int, from time.Time, to time.Time) (int64, bool, error)
+	WasAlerted(workspaceID int, periodStart time.Time) (bool, error)
+	SaveForecast(forecast *models.Forecast) error
+}
+
+type ForecastService struct {
+	db *sql.DB
+}
+
+func NewForecastRepository(db *sql.DB) ForecastRepository {
+	return &ForecastService{
+		db: db,
+	}
+}
+
+// GetForecastWorkspaceIDs returns the workspaces with an active subscription
+func (fs *ForecastService) GetForecastWorkspaceIDs() ([]int, error) {
+	rows, err := fs.db.Query("SELECT DISTINCT workspace_id FROM subscriptions WHERE status = 'ACTIVE' ORDER BY workspace_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// GetPriorMonthCents sums the invoices created between from and to that bill a month, leaving out
+// void invoices and annual prepayments. It returns false when the workspace had no such invoice.
+func (fs *ForecastService) GetPriorMonthCents(workspaceID int, from time.Time, to time.Time) (int64, bool, error) {
+	var count int
+	var cents sql.NullInt64
+	row := fs.db.QueryRow("SELECT COUNT(*), SUM(cents) FROM users_invoices WHERE workspace_id = ? AND created_at >= ? AND created_at < ? AND (state IS NULL OR state != ?) AND (billing_term IS NULL OR billing_term != ?)",
+		workspaceID, from, to, invoice.Void, "annual")
+	if err := row.Scan(&count, &cents); err != nil {
+		return 0, false, err
+	}
+
+	return cents.Int64, count > 0, nil
+}
+
+// WasAlerted reports whether the workspace was already emailed about its forecast for the period
+func (fs *ForecastService) WasAlerted(workspaceID int, periodStart time.Time) (bool, error) {
+	var count int
+	row := fs.db.QueryRow("SELECT COUNT(*) FROM billing_forecasts WHERE workspace_id = ? AND period_start = ? AND alerted = 1",
+		workspaceID, periodStart)
+	if err := row.Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// SaveForecast writes the forecast for its day, replacing one written earlier the same day
+func (fs *ForecastService) SaveForecast(f *models.Forecast) error {
+	_, err := fs.db.Exec("INSERT INTO billing_forecasts (`workspace_id`, `forecast_date`, `period_start`, `period_end`, `days_elapsed`, `days_in_period`, `fixed_cents`, `usage_to_date_cents`, `projected_cents`, `prior_month_cents`, `alerted`, `alert_reason`, `created_at`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) "+
+		"ON DUPLICATE KEY UPDATE `period_start` = VALUES(`period_start`), `period_end` = VALUES(`period_end`), `days_elapsed` = VALUES(`days_elapsed`), `days_in_period` = VALUES(`days_in_period`), `fixed_cents` = VALUES(`fixed_cents`), `usage_to_date_cents` = VALUES(`usage_to_date_cents`), `projected_cents` = VALUES(`projected_cents`), `prior_month_cents` = VALUES(`prior_month_cents`), `alerted` = `alerted` OR VALUES(`alerted`), `alert_reason` = COALESCE(NULLIF(VALUES(`alert_reason`), ''), `alert_reason`)",
+		f.WorkspaceID, f.ForecastDate.Format(time.DateOnly), f.PeriodStart, f.PeriodEnd, f.DaysElapsed, f.DaysInPeriod,
+		f.FixedCents, f.UsageToDateCents, f.ProjectedCents, f.PriorMonthCents, f.Alerted, f.AlertReason, time.Now())
+	return err
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"lineblocs.com/scheduler/internal/invoice"
+	"lineblocs.com/scheduler/models"
+)
+
+func TestForecastServiceGetPriorMonthCents(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	query := regexp.QuoteMeta("SELECT COUNT(*), SUM(cents) FROM users_invoices WHERE workspace_id = ? AND created_at >= ? AND created_at < ? AND (state IS NULL OR state != ?) AND (billing_term IS NULL OR billing_term != ?)")
+
+	t.Run("Should sum the monthly invoices of the period", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(query).WithArgs(3, from, to, invoice.Void, "annual").
+			WillReturnRows(sqlmock.NewRows([]string{"count", "cents"}).AddRow(2, 4200))
+
+		cents, hasPrior, err := NewForecastRepository(db).GetPriorMonthCents(3, from, to)
+		assert.NoError(t, err)
+		assert.True(t, hasPrior)
+		assert.Equal(t, int64(4200), cents)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Should report a workspace without invoices", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(query).WithArgs(3, from, to, invoice.Void, "annual").
+			WillReturnRows(sqlmock.NewRows([]string{"count", "cents"}).AddRow(0, nil))
+
+		cents, hasPrior, err := NewForecastRepository(db).GetPriorMonthCents(3, from, to)
+		assert.NoError(t, err)
+		assert.False(t, hasPrior)
+		assert.Equal(t, int64(0), cents)
+	})
+}
+
+func TestForecastServiceWasAlerted(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	periodStart := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	query := regexp.QuoteMeta("SELECT COUNT(*) FROM billing_forecasts WHERE workspace_id = ? AND period_start = ? AND alerted = 1")
+	mock.ExpectQuery(query).WithArgs(3, periodStart).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(query).WithArgs(4, periodStart).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	alerted, err := NewForecastRepository(db).WasAlerted(3, periodStart)
+	assert.NoError(t, err)
+	assert.True(t, alerted)
+
+	alerted, err = NewForecastRepository(db).WasAlerted(4, periodStart)
+	assert.NoError(t, err)
+	assert.False(t, alerted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestForecastServiceSaveForecast(t *testing.T) {
+	t.Parallel()
+
+	periodStart := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Should write the forecast for its day", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		forecast := &models.Forecast{
+			ForecastDate:     time.Date(2026, 5, 11, 0, 0, 0, 0, time.UTC),
+			PeriodStart:      periodStart,
+			PeriodEnd:        periodStart.AddDate(0, 1, 0),
+			AlertReason:      "projected bill of 62000 cents is over the 50000 cents threshold",
+			WorkspaceID:      3,
+			DaysElapsed:      10,
+			DaysInPeriod:     31,
+			FixedCents:       2000,
+			UsageToDateCents: 19355,
+			ProjectedCents:   62000,
+			PriorMonthCents:  41000,
+			Alerted:          true,
+		}
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO billing_forecasts (`workspace_id`, `forecast_date`, `period_start`, `period_end`, `days_elapsed`, `days_in_period`, `fixed_cents`, `usage_to_date_cents`, `projected_cents`, `prior_month_cents`, `alerted`, `alert_reason`, `created_at`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE")).
+			WithArgs(3, "2026-05-11", periodStart, periodStart.AddDate(0, 1, 0), 10, 31, int64(2000), int64(19355), int64(62000), int64(41000), true, forecast.AlertReason, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		assert.NoError(t, NewForecastRepository(db).SaveForecast(forecast))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Should keep an alert recorded earlier the same day", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(regexp.QuoteMeta("`alerted` = `alerted` OR VALUES(`alerted`), `alert_reason` = COALESCE(NULLIF(VALUES(`alert_reason`), ''), `alert_reason`)")).
+			WillReturnResult(sqlmock.NewResult(1, 2))
+
+		assert.NoError(t, NewForecastRepository(db).SaveForecast(&models.Forecast{WorkspaceID: 3, PeriodStart: periodStart}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,504 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"lineblocs.com/scheduler/internal/invoice"
+	"lineblocs.com/scheduler/models"
+)
+
+// InvoiceRepository writes invoices, the debits they claim and the outbox of gateway charges.
+// Every method takes the Executor to run on so callers can group writes in a UnitOfWork.
+// Invoice states only change through TransitionInvoice, which records each change in invoice_events.
+type InvoiceRepository interface {
+	CreateInvoice(ex Executor, invoice *models.Invoice) (int64, error)
+	CreateLineItems(ex Executor, invoiceID int64, items []models.InvoiceLineItem, at time.Time) error
+	GetInvoice(ex Executor, invoiceID int64) (*models.Invoice, error)
+	GetNumberRentals(ex Executor, workspaceID int, start time.Time, end time.Time) ([]models.NumberRental, error)
+	CreateRentalCharge(ex Executor, charge models.RentalCharge, userID int) (bool, error)
+	ClaimDebits(ex Executor, invoiceID int64, debitIDs []int) error
+	RecordCallBillings(ex Executor, billings []models.CallBilling) error
+	ReleaseDebits(ex Executor, invoiceID int64) (int64, error)
+	BackfillBilledDebits(ex Executor, before time.Time) (int64, error)
+	GetInvoiceState(ex Executor, invoiceID int64) (invoice.Status, error)
+	TransitionInvoice(ex Executor, invoiceID int64, to invoice.Status, reason string, at time.Time) error
+	RecordPayment(ex Executor, invoiceID int64, source string, cents int64, confirmationNumber string, at time.Time) error
+	RecordFailedAttempt(ex Executor, invoiceID int64, source string, at time.Time) error
+	GetPaymentReference(ex Executor, invoiceID int64) (string, error)
+	CreateCredit(ex Executor, workspaceID int, userID int, cents int64, at time.Time) error
+	CreateOutboxEntry(ex Executor, entry *models.OutboxEntry) (int64, error)
+	UpdateOutboxEntry(ex Executor, id int64, status string, paymentReference string, errMsg string) error
+	GetPendingOutboxEntries(ex Executor, createdBefore time.Time) ([]models.OutboxEntry, error)
+	CountOutboxEntries(ex Executor, invoiceID int64) (int, error)
+	HasPendingCharge(ex Executor, invoiceID int64) (bool, error)
+	GetSentInvoicesDueBy(ex Executor, dueBy time.Time) ([]models.Invoice, error)
+	RecordReminder(ex Executor, invoiceID int64, offsetDays int, at time.Time) (bool, error)
+	GetOverdueInvoices(ex Executor, dueBefore time.Time) ([]models.Invoice, error)
+	LockOverdueInvoice(ex Executor, invoiceID int64) (*models.Invoice, error)
+	GetLateFees(ex Executor, invoiceID int64) ([]models.LateFee, error)
+	CreateLateFee(ex Executor, fee models.LateFee) (bool, error)
+	AddInvoiceCharges(ex Executor, invoiceID int64, cents int64, at time.Time) error
+}
+
+type InvoiceService struct{}
+
+func NewInvoiceRepository() InvoiceRepository {
+	return &InvoiceService{}
+}
+
+// CreateInvoice inserts the invoice as a draft, to be opened once its debits are claimed
+func (is *InvoiceService) CreateInvoice(ex Executor, inv *models.Invoice) (int64, error) {
+	result, err := ex.Exec("INSERT INTO users_invoices (`cents`, `cents_including_taxes`, `call_costs`, `recording_costs`, `fax_costs`, `membership_costs`, `number_costs`, `addon_costs`, `discount_costs`, `state`, `status`, `user_id`, `workspace_id`, `subscription_id`, `billing_term`, `period_start`, `period_end`, `contract_version`, `catalog_version`, `collection_method`, `due_date`, `created_at`, `updated_at`, `source`, `tax_metadata`) VALUES ( ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		inv.Cents, inv.CentsIncludingTaxes, inv.CallCosts, inv.RecordingCosts, inv.FaxCosts, inv.MembershipCosts, inv.NumberCosts, inv.AddOnCosts, inv.DiscountCosts,
+		invoice.Draft, invoice.Draft.Legacy(), inv.UserID, inv.WorkspaceID, nullInt(inv.SubscriptionID), nullString(inv.BillingTerm), nullTime(inv.PeriodStart), nullTime(inv.PeriodEnd), nullInt(inv.ContractVersion), nullInt(inv.CatalogVersion), nullString(inv.CollectionMethod), nullTime(inv.DueDate),
+		inv.CreatedAt, inv.CreatedAt, inv.Source, inv.TaxMetadata)
+	if err != nil {
+		return 0, err
+	}
+
+	invoiceID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := insertInvoiceEvent(ex, invoice.Event{InvoiceID: invoiceID, To: invoice.Draft, Reason: "created", CreatedAt: inv.CreatedAt}); err != nil {
+		return 0, err
+	}
+
+	return invoiceID, nil
+}
+
+// CreateLineItems writes the priced lines of an invoice
+func (is *InvoiceService) CreateLineItems(ex Executor, invoiceID int64, items []models.InvoiceLineItem, at time.Time) error {
+	for _, item := range items {
+		_, err := ex.Exec("INSERT INTO invoice_line_items (`invoice_id`, `category`, `description`, `reference_id`, `quantity`, `unit`, `cents`, `period_start`, `period_end`, `created_at`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			invoiceID, item.Category, item.Description, nullInt(item.ReferenceID), item.Quantity, item.Unit, item.Cents, item.PeriodStart, item.PeriodEnd, at)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetInvoice reads an invoice with the period and term it was billed for. Invoices written
+// before those columns existed come back with a zero period and an empty term.
+func (is *InvoiceService) GetInvoice(ex Executor, invoiceID int64) (*models.Invoice, error) {
+	var inv models.Invoice
+	var state, source, billingTerm sql.NullString
+	var status string
+	var subscriptionID sql.NullInt64
+	var centsCollected sql.NullInt64
+	var periodStart, periodEnd sql.NullTime
+	row := ex.QueryRow("SELECT id, user_id, workspace_id, subscription_id, billing_term, period_start, period_end, cents, cents_collected, state, status, source, created_at FROM users_invoices WHERE id = ?", invoiceID)
+	err := row.Scan(&inv.Id, &inv.UserID, &inv.WorkspaceID, &subscriptionID, &billingTerm, &periodStart, &periodEnd,
+		&inv.Cents, &centsCollected, &state, &status, &source, &inv.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	inv.SubscriptionID = int(subscriptionID.Int64)
+	inv.BillingTerm = billingTerm.String
+	inv.PeriodStart = periodStart.Time
+	inv.PeriodEnd = periodEnd.Time
+	inv.CentsCollected = centsCollected.Int64
+	inv.State = string(invoice.Parse(state.String, status))
+	inv.Source = source.String
+	return &inv, nil
+}
+
+// GetNumberRentals returns the numbers the workspace held at some point in the period, flagging
+// the ones whose rental for the period or setup fee is already in the rental ledger
+func (is *InvoiceService) GetNumberRentals(ex Executor, workspaceID int, start time.Time, end time.Time) ([]models.NumberRental, error) {
+	rows, err := ex.Query("SELECT d.id, d.monthly_cost, COALESCE(d.setup_cost_cents, 0), COALESCE(d.provisioned_at, d.created_at), d.released_at, "+
+		"EXISTS (SELECT 1 FROM did_rental_ledger l WHERE l.did_id = d.id AND l.kind = ? AND l.period_start = ?), "+
+		"EXISTS (SELECT 1 FROM did_rental_ledger l WHERE l.did_id = d.id AND l.kind = ?) "+
+		"FROM did_numbers d WHERE d.workspace_id = ? AND COALESCE(d.provisioned_at, d.created_at) < ? AND (d.released_at IS NULL OR d.released_at > ?) ORDER BY d.id",
+		models.RentalKindMonthly, start, models.RentalKindSetup, workspaceID, end, start)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rentals := make([]models.NumberRental, 0)
+	for rows.Next() {
+		var rental models.NumberRental
+		var releasedAt sql.NullTime
+		if err := rows.Scan(&rental.DIDID, &rental.MonthlyCost, &rental.SetupCents, &rental.ProvisionedAt, &releasedAt, &rental.RentalBilled, &rental.SetupBilled); err != nil {
+			return nil, err
+		}
+		rental.ReleasedAt = releasedAt.Time
+		rentals = append(rentals, rental)
+	}
+
+	return rentals, rows.Err()
+}
+
+// CreateRentalCharge writes a charge to the rental ledger and adds its debit. The ledger is unique
+// on the number, kind and period start, so a charge that is already there is skipped and false is returned.
+func (is *InvoiceService) CreateRentalCharge(ex Executor, charge models.RentalCharge, userID int) (bool, error) {
+	result, err := ex.Exec("INSERT INTO did_rental_ledger (`did_id`, `workspace_id`, `kind`, `period_start`, `period_end`, `days`, `cents`, `created_at`) VALUES (?, ?, ?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE `id` = `id`",
+		charge.DIDID, charge.WorkspaceID, charge.Kind, charge.PeriodStart, charge.PeriodEnd, charge.Days, charge.Cents, time.Now())
+	if err != nil {
+		return false, err
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil || inserted == 0 {
+		return false, err
+	}
+
+	source := models.DebitSourceNumberRental
+	if charge.Kind == models.RentalKindSetup {
+		source = models.DebitSourceNumberSetup
+	}
+	_, err = ex.Exec("INSERT INTO users_debits (`source`, `status`, `cents`, `module_id`, `user_id`, `workspace_id`, `created_at`) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		source, models.DebitStatusUnbilled, charge.Cents, charge.DIDID, userID, charge.WorkspaceID, charge.PeriodStart)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ClaimDebits links unbilled debits to an invoice and marks them billed. It fails if
+// any debit was already claimed, so the caller's transaction can be rolled back.
+func (is *InvoiceService) ClaimDebits(ex Executor, invoiceID int64, debitIDs []int) error {
+	if len(debitIDs) == 0 {
+		return nil
+	}
+
+	claimStmt, err := ex.Prepare("UPDATE users_debits SET invoice_id = ?, status = ? WHERE id = ? AND invoice_id IS NULL")
+	if err != nil {
+		return err
+	}
+	defer claimStmt.Close()
+
+	for _, debitID := range debitIDs {
+		result, err := claimStmt.Exec(invoiceID, models.DebitStatusBilled, debitID)
+		if err != nil {
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected != 1 {
+			return fmt.Errorf("debit %d was already claimed by another invoice", debitID)
+		}
+	}
+
+	return nil
+}
+
+// RecordCallBillings keeps what the invoice billed for each call debit it claimed
+func (is *InvoiceService) RecordCallBillings(ex Executor, billings []models.CallBilling) error {
+	if len(billings) == 0 {
+		return nil
+	}
+
+	stmt, err := ex.Prepare("UPDATE users_debits SET chargeable_seconds = ?, billed_cents = ?, billed_plan_id = ? WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, billing := range billings {
+		if _, err := stmt.Exec(billing.ChargeableSeconds, billing.BilledCents, billing.PlanID, billing.DebitID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BackfillBilledDebits marks the unclaimed debits created before the given time as billed. They were billed by
+// invoices that selected debits by date, before invoices claimed them, and must not be billed again.
+func (is *InvoiceService) BackfillBilledDebits(ex Executor, before time.Time) (int64, error) {
+	result, err := ex.Exec("UPDATE users_debits SET status = ? WHERE invoice_id IS NULL AND status = ? AND created_at < ?",
+		models.DebitStatusBilled, models.DebitStatusUnbilled, before.Format(time.DateTime))
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// ReleaseDebits unlinks the debits claimed by an invoice so the next invoice bills them again
+func (is *InvoiceService) ReleaseDebits(ex Executor, invoiceID int64) (int64, error) {
+	result, err := ex.Exec("UPDATE users_debits SET invoice_id = NULL, status = ? WHERE invoice_id = ?",
+		models.DebitStatusUnbilled, invoiceID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// GetInvoiceState reads the state of an invoice, locking the row when run inside a transaction
+func (is *InvoiceService) GetInvoiceState(ex Executor, invoiceID int64) (invoice.Status, error) {
+	var state sql.NullString
+	var status string
+	row := ex.QueryRow("SELECT state, status FROM users_invoices WHERE id = ? FOR UPDATE", invoiceID)
+	if err := row.Scan(&state, &status); err != nil {
+		return "", err
+	}
+
+	return invoice.Parse(state.String, status), nil
+}
+
+// TransitionInvoice moves an invoice to a new state if the lifecycle allows it and records the event
+func (is *InvoiceService) TransitionInvoice(ex Executor, invoiceID int64, to invoice.Status, reason string, at time.Time) error {
+	from, err := is.GetInvoiceState(ex, invoiceID)
+	if err != nil {
+		return err
+	}
+	if err := invoice.ValidateTransition(from, to); err != nil {
+		return fmt.Errorf("invoice %d: %w", invoiceID, err)
+	}
+
+	_, err = ex.Exec("UPDATE users_invoices SET state = ?, status = ?, updated_at = ? WHERE id = ?",
+		to, to.Legacy(), at, invoiceID)
+	if err != nil {
+		return err
+	}
+
+	return insertInvoiceEvent(ex, invoice.Event{InvoiceID: invoiceID, From: from, To: to, Reason: reason, CreatedAt: at})
+}
+
+func insertInvoiceEvent(ex Executor, event invoice.Event) error {
+	_, err := ex.Exec("INSERT INTO invoice_events (`invoice_id`, `from_state`, `to_state`, `reason`, `created_at`) VALUES (?, ?, ?, ?, ?)",
+		event.InvoiceID, event.From, event.To, event.Reason, event.CreatedAt)
+	return err
+}
+
+// RecordPayment adds a collected amount to the invoice. The caller transitions the invoice afterwards.
+func (is *InvoiceService) RecordPayment(ex Executor, invoiceID int64, source string, cents int64, confirmationNumber string, at time.Time) error {
+	_, err := ex.Exec("UPDATE users_invoices SET source = ?, cents_collected = COALESCE(cents_collected, 0) + ?, confirmation_number = ?, last_attempted = ?, num_attempts = COALESCE(num_attempts, 0) + 1 WHERE id = ?",
+		source, cents, confirmationNumber, at, invoiceID)
+	return err
+}
+
+// RecordFailedAttempt counts a failed collection attempt without changing the invoice state
+func (is *InvoiceService) RecordFailedAttempt(ex Executor, invoiceID int64, source string, at time.Time) error {
+	_, err := ex.Exec("UPDATE users_invoices SET source = ?, last_attempted = ?, num_attempts = COALESCE(num_attempts, 0) + 1 WHERE id = ?",
+		source, at, invoiceID)
+	return err
+}
+
+// GetPaymentReference returns the gateway reference of the card charge that paid an invoice, or "" if there is none
+func (is *InvoiceService) GetPaymentReference(ex Executor, invoiceID int64) (string, error) {
+	var paymentReference sql.NullString
+	row := ex.QueryRow("SELECT payment_reference FROM billing_outbox WHERE invoice_id = ? AND status = ? ORDER BY id DESC LIMIT 1",
+		invoiceID, models.OutboxSucceeded)
+	err := row.Scan(&paymentReference)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return paymentReference.String, nil
+}
+
+// CreateCredit adds cents to the workspace's credit balance
+func (is *InvoiceService) CreateCredit(ex Executor, workspaceID int, userID int, cents int64, at time.Time) error {
+	_, err := ex.Exec("INSERT INTO users_credits (`cents`, `user_id`, `workspace_id`, `created_at`, `updated_at`) VALUES (?, ?, ?, ?, ?)",
+		cents, userID, workspaceID, at, at)
+	return err
+}
+
+func (is *InvoiceService) CreateOutboxEntry(ex Executor, entry *models.OutboxEntry) (int64, error) {
+	result, err := ex.Exec("INSERT INTO billing_outbox (`invoice_id`, `workspace_id`, `user_id`, `cents`, `idempotency_key`, `status`, `created_at`, `updated_at`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		entry.InvoiceID, entry.WorkspaceID, entry.UserID, entry.Cents, entry.IdempotencyKey, entry.Status, entry.CreatedAt, entry.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+func (is *InvoiceService) UpdateOutboxEntry(ex Executor, id int64, status string, paymentReference string, errMsg string) error {
+	_, err := ex.Exec("UPDATE billing_outbox SET status = ?, payment_reference = ?, error = ?, updated_at = ? WHERE id = ?",
+		status, paymentReference, errMsg, time.Now(), id)
+	return err
+}
+
+// GetPendingOutboxEntries returns the charges still pending that were recorded before the given time
+func (is *InvoiceService) GetPendingOutboxEntries(ex Executor, createdBefore time.Time) ([]models.OutboxEntry, error) {
+	rows, err := ex.Query("SELECT id, invoice_id, workspace_id, user_id, cents, idempotency_key, status, payment_reference, error, created_at FROM billing_outbox WHERE status = ? AND created_at < ? ORDER BY id",
+		models.OutboxPending, createdBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]models.OutboxEntry, 0)
+	for rows.Next() {
+		var entry models.OutboxEntry
+		var paymentReference, errMsg sql.NullString
+		if err := rows.Scan(&entry.Id, &entry.InvoiceID, &entry.WorkspaceID, &entry.UserID, &entry.Cents, &entry.IdempotencyKey, &entry.Status, &paymentReference, &errMsg, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entry.PaymentReference = paymentReference.String
+		entry.Error = errMsg.String
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// CountOutboxEntries returns how many card charges were recorded for an invoice
+func (is *InvoiceService) CountOutboxEntries(ex Executor, invoiceID int64) (int, error) {
+	var count int
+	err := ex.QueryRow("SELECT COUNT(*) FROM billing_outbox WHERE invoice_id = ?", invoiceID).Scan(&count)
+	return count, err
+}
+
+// HasPendingCharge reports whether a card charge recorded for the invoice has not been settled yet
+func (is *InvoiceService) HasPendingCharge(ex Executor, invoiceID int64) (bool, error) {
+	var pending bool
+	err := ex.QueryRow("SELECT EXISTS (SELECT 1 FROM billing_outbox WHERE invoice_id = ? AND status = ?)", invoiceID, models.OutboxPending).Scan(&pending)
+	return pending, err
+}
+
+func nullInt(v int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(v), Valid: v != 0}
+}
+
+func nullInt64(v int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: v, Valid: v != 0}
+}
+
+func nullString(v string) sql.NullString {
+	return sql.NullString{String: v, Valid: v != ""}
+}
+
+func nullTime(v time.Time) sql.NullTime {
+	return sql.NullTime{Time: v, Valid: !v.IsZero()}
+}
+
+// GetSentInvoicesDueBy returns the unpaid invoices sent for payment that are due by the given time
+func (is *InvoiceService) GetSentInvoicesDueBy(ex Executor, dueBy time.Time) ([]models.Invoice, error) {
+	rows, err := ex.Query("SELECT id, workspace_id, user_id, cents, COALESCE(cents_collected, 0), state, due_date FROM users_invoices WHERE collection_method = ? AND state IN (?, ?) AND due_date <= ? ORDER BY due_date, id",
+		models.CollectionSendInvoice, invoice.Open, invoice.PartiallyPaid, dueBy)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invoices := make([]models.Invoice, 0)
+	for rows.Next() {
+		inv := models.Invoice{CollectionMethod: models.CollectionSendInvoice}
+		if err := rows.Scan(&inv.Id, &inv.WorkspaceID, &inv.UserID, &inv.Cents, &inv.CentsCollected, &inv.State, &inv.DueDate); err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, inv)
+	}
+
+	return invoices, rows.Err()
+}
+
+// RecordReminder logs the reminder sent for an invoice at the given offset in days from its due date.
+// It returns false when that reminder was already sent.
+func (is *InvoiceService) RecordReminder(ex Executor, invoiceID int64, offsetDays int, at time.Time) (bool, error) {
+	result, err := ex.Exec("INSERT INTO invoice_reminders (`invoice_id`, `offset_days`, `sent_at`) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE `id` = `id`",
+		invoiceID, offsetDays, at)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// GetOverdueInvoices returns the unpaid invoices due before the given time. Invoices without a due date
+// were due when they were created. Invoices that bill late fees are left out so fees do not compound.
+func (is *InvoiceService) GetOverdueInvoices(ex Executor, dueBefore time.Time) ([]models.Invoice, error) {
+	rows, err := ex.Query("SELECT "+overdueInvoiceColumns+" FROM users_invoices "+
+		"WHERE (state IN (?, ?) OR (state IS NULL AND status = 'INCOMPLETE')) AND COALESCE(due_date, created_at) < ? AND (source IS NULL OR source != ?) ORDER BY id",
+		invoice.Open, invoice.PartiallyPaid, dueBefore, models.SourceLateFee)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invoices := make([]models.Invoice, 0)
+	for rows.Next() {
+		inv, err := scanOverdueInvoice(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, *inv)
+	}
+
+	return invoices, rows.Err()
+}
+
+// LockOverdueInvoice locks an invoice and reads it again, so charges that depend on what it owes see payments
+// recorded since it was listed
+func (is *InvoiceService) LockOverdueInvoice(ex Executor, invoiceID int64) (*models.Invoice, error) {
+	return scanOverdueInvoice(ex.QueryRow("SELECT "+overdueInvoiceColumns+" FROM users_invoices WHERE id = ? FOR UPDATE", invoiceID).Scan)
+}
+
+const overdueInvoiceColumns = "id, workspace_id, user_id, cents, COALESCE(cents_collected, 0), state, status, collection_method, COALESCE(due_date, created_at)"
+
+// scanOverdueInvoice reads the overdue invoice columns with the row or rows Scan
+func scanOverdueInvoice(scan func(dest ...any) error) (*models.Invoice, error) {
+	var inv models.Invoice
+	var state, collectionMethod sql.NullString
+	var status string
+	if err := scan(&inv.Id, &inv.WorkspaceID, &inv.UserID, &inv.Cents, &inv.CentsCollected, &state, &status, &collectionMethod, &inv.DueDate); err != nil {
+		return nil, err
+	}
+	inv.State = string(invoice.Parse(state.String, status))
+	inv.CollectionMethod = collectionMethod.String
+	return &inv, nil
+}
+
+// GetLateFees returns the late charges already applied to an invoice
+func (is *InvoiceService) GetLateFees(ex Executor, invoiceID int64) ([]models.LateFee, error) {
+	rows, err := ex.Query("SELECT kind, period, cents, COALESCE(fee_invoice_id, 0), created_at FROM invoice_late_fees WHERE invoice_id = ? ORDER BY id", invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fees := make([]models.LateFee, 0)
+	for rows.Next() {
+		fee := models.LateFee{InvoiceID: invoiceID}
+		if err := rows.Scan(&fee.Kind, &fee.Period, &fee.Cents, &fee.FeeInvoiceID, &fee.CreatedAt); err != nil {
+			return nil, err
+		}
+		fees = append(fees, fee)
+	}
+
+	return fees, rows.Err()
+}
+
+// CreateLateFee writes a late charge to the ledger, which is unique on the invoice, kind and period.
+// It returns false when the charge was already applied.
+func (is *InvoiceService) CreateLateFee(ex Executor, fee models.LateFee) (bool, error) {
+	result, err := ex.Exec("INSERT INTO invoice_late_fees (`invoice_id`, `kind`, `period`, `cents`, `fee_invoice_id`, `created_at`) VALUES (?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE `id` = `id`",
+		fee.InvoiceID, fee.Kind, fee.Period, fee.Cents, nullInt64(fee.FeeInvoiceID), fee.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// AddInvoiceCharges raises what an open invoice bills, for charges added after it was finalized
+func (is *InvoiceService) AddInvoiceCharges(ex Executor, invoiceID int64, cents int64, at time.Time) error {
+	_, err := ex.Exec("UPDATE users_invoices SET cents = cents + ?, cents_including_taxes = cents_including_taxes + ?, updated_at = ? WHERE id = ?",
+		cents, cents, at, invoiceID)
+	return err
+}
@@ -0,0 +1,380 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"lineblocs.com/scheduler/internal/invoice"
+	"lineblocs.com/scheduler/models"
+)
+
+func TestInvoiceServiceClaimDebits(t *testing.T) {
+	t.Parallel()
+
+	claimQuery := regexp.QuoteMeta("UPDATE users_debits SET invoice_id = ?, status = ? WHERE id = ? AND invoice_id IS NULL")
+
+	t.Run("Should link every debit to the invoice", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		prep := mock.ExpectPrepare(claimQuery)
+		prep.ExpectExec().WithArgs(int64(7), models.DebitStatusBilled, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		prep.ExpectExec().WithArgs(int64(7), models.DebitStatusBilled, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, NewInvoiceRepository().ClaimDebits(db, 7, []int{1, 2}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Should fail when a debit was already claimed", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		prep := mock.ExpectPrepare(claimQuery)
+		prep.ExpectExec().WithArgs(int64(7), models.DebitStatusBilled, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.EqualError(t, NewInvoiceRepository().ClaimDebits(db, 7, []int{1}), "debit 1 was already claimed by another invoice")
+	})
+
+	t.Run("Should do nothing without debits", func(t *testing.T) {
+		t.Parallel()
+
+		assert.NoError(t, NewInvoiceRepository().ClaimDebits(nil, 7, nil))
+	})
+}
+
+func TestInvoiceServiceBackfillBilledDebits(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	before := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE users_debits SET status = ? WHERE invoice_id IS NULL AND status = ? AND created_at < ?")).
+		WithArgs(models.DebitStatusBilled, models.DebitStatusUnbilled, "2026-03-01 00:00:00").
+		WillReturnResult(sqlmock.NewResult(0, 42))
+
+	marked, err := NewInvoiceRepository().BackfillBilledDebits(db, before)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), marked)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInvoiceServiceRecordCallBillings(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Should keep what each call was billed", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		prep := mock.ExpectPrepare(regexp.QuoteMeta("UPDATE users_debits SET chargeable_seconds = ?, billed_cents = ?, billed_plan_id = ? WHERE id = ?"))
+		prep.ExpectExec().WithArgs(int64(60), int64(9), 3, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		prep.ExpectExec().WithArgs(int64(0), int64(0), 3, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		billings := []models.CallBilling{{DebitID: 1, PlanID: 3, ChargeableSeconds: 60, BilledCents: 9}, {DebitID: 2, PlanID: 3}}
+		assert.NoError(t, NewInvoiceRepository().RecordCallBillings(db, billings))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Should do nothing without calls", func(t *testing.T) {
+		t.Parallel()
+
+		assert.NoError(t, NewInvoiceRepository().RecordCallBillings(nil, nil))
+	})
+}
+
+func TestInvoiceServiceTransitionInvoice(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	stateQuery := regexp.QuoteMeta("SELECT state, status FROM users_invoices WHERE id = ? FOR UPDATE")
+
+	t.Run("Should update the state and record the event", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(stateQuery).WithArgs(int64(7)).WillReturnRows(sqlmock.NewRows([]string{"state", "status"}).AddRow("open", "INCOMPLETE"))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE users_invoices SET state = ?, status = ?, updated_at = ? WHERE id = ?")).
+			WithArgs(invoice.Paid, "COMPLETE", at, int64(7)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO invoice_events")).
+			WithArgs(int64(7), invoice.Open, invoice.Paid, "paid with card", at).WillReturnResult(sqlmock.NewResult(1, 1))
+
+		assert.NoError(t, NewInvoiceRepository().TransitionInvoice(db, 7, invoice.Paid, "paid with card", at))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Should treat rows without a state by their legacy status", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(stateQuery).WithArgs(int64(7)).WillReturnRows(sqlmock.NewRows([]string{"state", "status"}).AddRow(nil, "COMPLETE"))
+
+		err = NewInvoiceRepository().TransitionInvoice(db, 7, invoice.Open, "reopened", at)
+		assert.ErrorIs(t, err, invoice.ErrInvalidTransition)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestInvoiceServiceGetInvoice(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	createdAt := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	periodStart := time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC)
+	columns := []string{"id", "user_id", "workspace_id", "subscription_id", "billing_term", "period_start", "period_end", "cents", "cents_collected", "state", "status", "source", "created_at"}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, user_id, workspace_id, subscription_id, billing_term, period_start, period_end")).
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(7, 2, 3, 4, "monthly", periodStart, createdAt, 1500, 1500, "paid", "COMPLETE", "CARD", createdAt))
+
+	inv, err := NewInvoiceRepository().GetInvoice(db, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Invoice{
+		CreatedAt:      createdAt,
+		PeriodStart:    periodStart,
+		PeriodEnd:      createdAt,
+		Source:         "CARD",
+		BillingTerm:    "monthly",
+		State:          "paid",
+		Id:             7,
+		UserID:         2,
+		WorkspaceID:    3,
+		SubscriptionID: 4,
+		CentsCollected: 1500,
+		Cents:          1500,
+	}, inv)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInvoiceServiceReleaseDebits(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE users_debits SET invoice_id = NULL, status = ? WHERE invoice_id = ?")).
+		WithArgs(models.DebitStatusUnbilled, int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	released, err := NewInvoiceRepository().ReleaseDebits(db, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), released)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInvoiceServiceCreateRentalCharge(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC)
+	charge := models.RentalCharge{PeriodStart: start, PeriodEnd: start.AddDate(0, 1, 0), Kind: models.RentalKindMonthly, DIDID: 11, WorkspaceID: 3, Days: 14, Cents: 150}
+	ledgerQuery := regexp.QuoteMeta("INSERT INTO did_rental_ledger")
+
+	t.Run("Should add a debit for a new ledger entry", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(ledgerQuery).
+			WithArgs(11, 3, models.RentalKindMonthly, start, charge.PeriodEnd, 14, int64(150), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO users_debits")).
+			WithArgs("NUMBER_RENTAL", models.DebitStatusUnbilled, int64(150), 11, 2, 3, start).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		created, err := NewInvoiceRepository().CreateRentalCharge(db, charge, 2)
+		assert.NoError(t, err)
+		assert.True(t, created)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Should skip a charge already in the ledger", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(ledgerQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		created, err := NewInvoiceRepository().CreateRentalCharge(db, charge, 2)
+		assert.NoError(t, err)
+		assert.False(t, created)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestInvoiceServiceGetSentInvoicesDueBy(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dueBy := time.Date(2026, 5, 4, 0, 0, 0, 0, time.UTC)
+	due := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, workspace_id, user_id, cents, COALESCE(cents_collected, 0), state, due_date FROM users_invoices WHERE collection_method = ? AND state IN (?, ?) AND due_date <= ?")).
+		WithArgs(models.CollectionSendInvoice, invoice.Open, invoice.PartiallyPaid, dueBy).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "workspace_id", "user_id", "cents", "cents_collected", "state", "due_date"}).
+			AddRow(9, 3, 2, 5000, 1000, string(invoice.PartiallyPaid), due))
+
+	invoices, err := NewInvoiceRepository().GetSentInvoicesDueBy(db, dueBy)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Invoice{{
+		Id: 9, WorkspaceID: 3, UserID: 2, Cents: 5000, CentsCollected: 1000, State: string(invoice.PartiallyPaid),
+		CollectionMethod: models.CollectionSendInvoice, DueDate: due,
+	}}, invoices)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInvoiceServiceRecordReminder(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, 5, 8, 9, 0, 0, 0, time.UTC)
+	reminderQuery := regexp.QuoteMeta("INSERT INTO invoice_reminders (`invoice_id`, `offset_days`, `sent_at`) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE `id` = `id`")
+
+	testCases := []struct {
+		Name     string
+		Affected int64
+		Expected bool
+	}{
+		{Name: "a new reminder is recorded", Affected: 1, Expected: true},
+		{Name: "a reminder already sent is skipped", Affected: 0, Expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			mock.ExpectExec(reminderQuery).WithArgs(int64(9), 7, at).WillReturnResult(sqlmock.NewResult(1, tc.Affected))
+
+			recorded, err := NewInvoiceRepository().RecordReminder(db, 9, 7, at)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, recorded)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestInvoiceServiceGetOverdueInvoices(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	dueBefore := time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC)
+	created := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	due := time.Date(2026, 4, 30, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, workspace_id, user_id, cents, COALESCE(cents_collected, 0), state, status, collection_method, COALESCE(due_date, created_at) FROM users_invoices")).
+		WithArgs(invoice.Open, invoice.PartiallyPaid, dueBefore, models.SourceLateFee).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "workspace_id", "user_id", "cents", "cents_collected", "state", "status", "collection_method", "due_date"}).
+			AddRow(7, 3, 2, 5000, 0, nil, "INCOMPLETE", nil, created).
+			AddRow(9, 4, 5, 8000, 1000, string(invoice.PartiallyPaid), "INCOMPLETE", models.CollectionSendInvoice, due))
+
+	invoices, err := NewInvoiceRepository().GetOverdueInvoices(db, dueBefore)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Invoice{
+		{Id: 7, WorkspaceID: 3, UserID: 2, Cents: 5000, State: string(invoice.Open), DueDate: created},
+		{Id: 9, WorkspaceID: 4, UserID: 5, Cents: 8000, CentsCollected: 1000, State: string(invoice.PartiallyPaid), CollectionMethod: models.CollectionSendInvoice, DueDate: due},
+	}, invoices)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInvoiceServiceCountOutboxEntries(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM billing_outbox WHERE invoice_id = ?")).
+		WithArgs(int64(9)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	count, err := NewInvoiceRepository().CountOutboxEntries(db, 9)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInvoiceServiceHasPendingCharge(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM billing_outbox WHERE invoice_id = ? AND status = ?)")).
+		WithArgs(int64(9), models.OutboxPending).
+		WillReturnRows(sqlmock.NewRows([]string{"pending"}).AddRow(true))
+
+	pending, err := NewInvoiceRepository().HasPendingCharge(db, 9)
+	assert.NoError(t, err)
+	assert.True(t, pending)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInvoiceServiceLockOverdueInvoice(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	due := time.Date(2026, 4, 30, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, workspace_id, user_id, cents, COALESCE(cents_collected, 0), state, status, collection_method, COALESCE(due_date, created_at) FROM users_invoices WHERE id = ? FOR UPDATE")).
+		WithArgs(int64(9)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "workspace_id", "user_id", "cents", "cents_collected", "state", "status", "collection_method", "due_date"}).
+			AddRow(9, 4, 5, 8000, 8000, string(invoice.Paid), "COMPLETE", nil, due))
+
+	inv, err := NewInvoiceRepository().LockOverdueInvoice(db, 9)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Invoice{Id: 9, WorkspaceID: 4, UserID: 5, Cents: 8000, CentsCollected: 8000, State: string(invoice.Paid), DueDate: due}, inv)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInvoiceServiceCreateLateFee(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	at := time.Date(2026, 5, 20, 0, 0, 0, 0, time.UTC)
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO invoice_late_fees (`invoice_id`, `kind`, `period`, `cents`, `fee_invoice_id`, `created_at`) VALUES (?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE `id` = `id`")).
+		WithArgs(int64(9), "interest", 2, int64(160), nil, at).
+		WillReturnResult(sqlmock.NewResult(1, 0))
+
+	created, err := NewInvoiceRepository().CreateLateFee(db, models.LateFee{CreatedAt: at, Kind: "interest", InvoiceID: 9, Period: 2, Cents: 160})
+	assert.NoError(t, err)
+	assert.False(t, created, "a charge already in the ledger is not applied again")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
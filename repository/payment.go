@@ -3,11 +3,15 @@ package repository
 import (
 	"database/sql"
 	"fmt"
+	"math"
 	"strconv"
+	"time"
 
 	helpers "github.com/Lineblocs/go-helpers"
 	"github.com/sirupsen/logrus"
 	"lineblocs.com/scheduler/handlers/billing"
+	"lineblocs.com/scheduler/internal/allowance"
+	"lineblocs.com/scheduler/internal/pricing"
 	"lineblocs.com/scheduler/models"
 	"lineblocs.com/scheduler/utils"
 )
@@ -18,8 +22,18 @@ type PaymentService struct {
 
 type PaymentRepository interface {
 	ChargeCustomer(billingParams *utils.BillingParams, user *helpers.User, workspace *helpers.Workspace, invoice *models.UserInvoice) error
+	RefundCustomer(billingParams *utils.BillingParams, paymentReference string, cents int64, idempotencyKey string) (string, error)
 	GetSubscription(subId int) (*helpers.Subscription, error)
+	GetActiveSubscriptionID(workspaceId int) (int, error)
 	GetServicePlans() ([]helpers.ServicePlan, error)
+	GetCallRatingPlan(planId int) (*models.CallRatingPlan, error)
+	GetFaxRates(defaultCentsPerPage float64) (*models.FaxRates, error)
+	GetPriceSchedules(planId int) (map[string]pricing.Schedule, error)
+	GetRolloverPolicy(planId int) (allowance.Policy, error)
+	GetSubscriptionAddOns(subId int) ([]models.AddOn, error)
+	GetContract(workspaceId int, at time.Time) (*models.Contract, error)
+	GetPriceCatalog(at time.Time) (*models.PriceCatalog, error)
+	GetCollectionTerms(workspaceId int) (*models.CollectionTerms, error)
 }
 
 func NewPaymentRepository(db *sql.DB) PaymentRepository {
@@ -41,6 +55,233 @@ func (ps *PaymentService) GetSubscription(subId int) (*helpers.Subscription, err
 	return helpers.GetSubscriptionFromDB(subId)
 }
 
+// GetActiveSubscriptionID returns the ID of the workspace's active subscription
+func (ps *PaymentService) GetActiveSubscriptionID(workspaceId int) (int, error) {
+	var subId int
+	row := ps.db.QueryRow("SELECT id FROM subscriptions WHERE workspace_id = ? AND status = 'ACTIVE' ORDER BY id DESC LIMIT 1", workspaceId)
+	if err := row.Scan(&subId); err != nil {
+		return 0, err
+	}
+
+	return subId, nil
+}
+
+// GetCallRatingPlan returns the billing increments configured for a plan, falling back to per-minute billing
+func (ps *PaymentService) GetCallRatingPlan(planId int) (*models.CallRatingPlan, error) {
+	ratingPlan := models.CallRatingPlan{}
+	row := ps.db.QueryRow("SELECT minimum_seconds, increment_seconds, rounding FROM service_plans_call_rating WHERE plan_id = ?", planId)
+	err := row.Scan(&ratingPlan.MinimumSeconds, &ratingPlan.IncrementSeconds, &ratingPlan.Rounding)
+	if err == sql.ErrNoRows {
+		return models.DefaultCallRatingPlan(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &ratingPlan, nil
+}
+
+// GetPriceSchedules returns the usage tiers configured for a plan by category. Categories
+// without tiers are left out and keep their flat price.
+func (ps *PaymentService) GetPriceSchedules(planId int) (map[string]pricing.Schedule, error) {
+	rows, err := ps.db.Query("SELECT category, mode, up_to, unit_cents, flat_cents FROM service_plans_price_tiers WHERE plan_id = ? ORDER BY category, up_to = 0, up_to", planId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := make(map[string]pricing.Schedule)
+	for rows.Next() {
+		var category, mode string
+		var tier pricing.Tier
+		if err := rows.Scan(&category, &mode, &tier.UpTo, &tier.UnitCents, &tier.FlatCents); err != nil {
+			return nil, err
+		}
+
+		schedule := schedules[category]
+		schedule.Mode = mode
+		schedule.Tiers = append(schedule.Tiers, tier)
+		schedules[category] = schedule
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for category, schedule := range schedules {
+		if err := schedule.Validate(); err != nil {
+			return nil, fmt.Errorf("plan %d %s tiers: %w", planId, category, err)
+		}
+	}
+	return schedules, nil
+}
+
+// GetRolloverPolicy returns how a plan rolls unused minutes over. Plans without a policy do not roll minutes over.
+func (ps *PaymentService) GetRolloverPolicy(planId int) (allowance.Policy, error) {
+	var capMinutes float64
+	var policy allowance.Policy
+	row := ps.db.QueryRow("SELECT cap_minutes, expiry_cycles FROM service_plans_rollover WHERE plan_id = ?", planId)
+	err := row.Scan(&capMinutes, &policy.ExpiryCycles)
+	if err == sql.ErrNoRows {
+		return allowance.Policy{}, nil
+	}
+	if err != nil {
+		return allowance.Policy{}, err
+	}
+
+	policy.CapSeconds = int64(math.Round(capMinutes * 60))
+	return policy, nil
+}
+
+// GetSubscriptionAddOns returns the add-on products attached to a subscription that were not cancelled
+func (ps *PaymentService) GetSubscriptionAddOns(subId int) ([]models.AddOn, error) {
+	rows, err := ps.db.Query("SELECT a.id, p.id, p.name, a.quantity, p.monthly_cents, COALESCE(p.annual_cents, 0), COALESCE(p.included_minutes, 0), COALESCE(p.included_recording_mb, 0), COALESCE(p.included_fax_pages, 0) "+
+		"FROM subscription_addons a JOIN addon_products p ON p.id = a.product_id WHERE a.subscription_id = ? AND a.cancelled_at IS NULL ORDER BY a.id", subId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	addOns := make([]models.AddOn, 0)
+	for rows.Next() {
+		var addOn models.AddOn
+		if err := rows.Scan(&addOn.ID, &addOn.ProductID, &addOn.Name, &addOn.Quantity, &addOn.MonthlyCents, &addOn.AnnualCents, &addOn.Minutes, &addOn.RecordingMB, &addOn.FaxPages); err != nil {
+			return nil, err
+		}
+		addOns = append(addOns, addOn)
+	}
+
+	return addOns, rows.Err()
+}
+
+// GetPriceCatalog returns the price catalog in effect at the given time with its plan prices,
+// or nil when no catalog covers it
+func (ps *PaymentService) GetPriceCatalog(at time.Time) (*models.PriceCatalog, error) {
+	catalog := models.PriceCatalog{Plans: make(map[int]models.CatalogPlan)}
+	var effectiveTo sql.NullTime
+	row := ps.db.QueryRow("SELECT id, version, effective_from, effective_to, recordings_per_byte, fax_per_used FROM price_catalogs WHERE effective_from <= ? AND (effective_to IS NULL OR effective_to > ?) ORDER BY effective_from DESC, version DESC LIMIT 1",
+		at, at)
+	err := row.Scan(&catalog.ID, &catalog.Version, &catalog.EffectiveFrom, &effectiveTo, &catalog.RecordingsPerByte, &catalog.FaxPerUsed)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	catalog.EffectiveTo = effectiveTo.Time
+
+	rows, err := ps.db.Query("SELECT plan_id, base_costs, COALESCE(annual_cost_cents, 0), minutes_per_month, recording_space, fax FROM price_catalog_plans WHERE catalog_id = ?", catalog.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var planId int
+		var plan models.CatalogPlan
+		if err := rows.Scan(&planId, &plan.BaseCosts, &plan.AnnualCostCents, &plan.MinutesPerMonth, &plan.RecordingSpace, &plan.Fax); err != nil {
+			return nil, err
+		}
+		catalog.Plans[planId] = plan
+	}
+
+	return &catalog, rows.Err()
+}
+
+// GetContract returns the workspace's contract in effect at the given time, or nil when it has none.
+// When contracts overlap, the latest to take effect wins.
+func (ps *PaymentService) GetContract(workspaceId int, at time.Time) (*models.Contract, error) {
+	contract := models.Contract{WorkspaceID: workspaceId}
+	var effectiveTo sql.NullTime
+	var seatCents, annualSeatCents, minutes, recordingSpace, callCents, recordingCents, faxCents sql.NullFloat64
+	var faxPages sql.NullInt64
+	row := ps.db.QueryRow("SELECT id, version, effective_from, effective_to, seat_cents, annual_seat_cents, minutes_per_month, recording_space_mb, fax_pages, call_cents_per_minute, recording_cents_per_gb, fax_cents_per_page "+
+		"FROM workspace_contracts WHERE workspace_id = ? AND effective_from <= ? AND (effective_to IS NULL OR effective_to > ?) ORDER BY effective_from DESC, version DESC LIMIT 1",
+		workspaceId, at, at)
+	err := row.Scan(&contract.ID, &contract.Version, &contract.EffectiveFrom, &effectiveTo, &seatCents, &annualSeatCents, &minutes, &recordingSpace, &faxPages, &callCents, &recordingCents, &faxCents)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	contract.EffectiveTo = effectiveTo.Time
+	contract.SeatCents = floatOverride(seatCents)
+	contract.AnnualSeatCents = floatOverride(annualSeatCents)
+	contract.MinutesPerMonth = floatOverride(minutes)
+	contract.RecordingSpaceMB = floatOverride(recordingSpace)
+	contract.CallCentsPerMinute = floatOverride(callCents)
+	contract.RecordingCentsPerGB = floatOverride(recordingCents)
+	contract.FaxCentsPerPage = floatOverride(faxCents)
+	if faxPages.Valid {
+		pages := int(faxPages.Int64)
+		contract.FaxPages = &pages
+	}
+	return &contract, nil
+}
+
+// GetCollectionTerms returns how the workspace pays its invoices, or nil when they are charged automatically
+func (ps *PaymentService) GetCollectionTerms(workspaceId int) (*models.CollectionTerms, error) {
+	terms := models.CollectionTerms{WorkspaceID: workspaceId}
+	var instructions sql.NullString
+	row := ps.db.QueryRow("SELECT collection_method, net_days, payment_instructions FROM workspace_collection_terms WHERE workspace_id = ?", workspaceId)
+	err := row.Scan(&terms.Method, &terms.NetDays, &instructions)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch terms.Method {
+	case models.CollectionChargeAutomatically:
+		return nil, nil
+	case models.CollectionSendInvoice:
+		if terms.NetDays <= 0 {
+			return nil, fmt.Errorf("workspace %d: net terms need a positive number of days, got %d", workspaceId, terms.NetDays)
+		}
+	default:
+		return nil, fmt.Errorf("workspace %d: unknown collection method %q", workspaceId, terms.Method)
+	}
+
+	terms.PaymentInstructions = instructions.String
+	return &terms, nil
+}
+
+func floatOverride(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Float64
+}
+
+// GetFaxRates returns the inbound and outbound per-page fax prices, using the default for any direction without a configured price
+func (ps *PaymentService) GetFaxRates(defaultCentsPerPage float64) (*models.FaxRates, error) {
+	rates := models.FaxRates{
+		InboundCentsPerPage:  defaultCentsPerPage,
+		OutboundCentsPerPage: defaultCentsPerPage,
+	}
+
+	var inbound, outbound sql.NullFloat64
+	row := ps.db.QueryRow("SELECT fax_inbound_cents_per_page, fax_outbound_cents_per_page FROM customizations")
+	err := row.Scan(&inbound, &outbound)
+	if err == sql.ErrNoRows {
+		return &rates, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if inbound.Valid {
+		rates.InboundCentsPerPage = inbound.Float64
+	}
+	if outbound.Valid {
+		rates.OutboundCentsPerPage = outbound.Float64
+	}
+
+	return &rates, nil
+}
+
 func (ps *PaymentService) ChargeCustomer(billingParams *utils.BillingParams, user *helpers.User, workspace *helpers.Workspace, invoice *models.UserInvoice) error {
 	var err error
 	var hndl billing.BillingHandler
@@ -68,6 +309,29 @@ func (ps *PaymentService) ChargeCustomer(billingParams *utils.BillingParams, use
 	return err
 }
 
+// RefundCustomer refunds an earlier charge through the configured gateway and returns the refund ID
+func (ps *PaymentService) RefundCustomer(billingParams *utils.BillingParams, paymentReference string, cents int64, idempotencyKey string) (string, error) {
+	var hndl billing.BillingHandler
+	retryAttempts := getRetryAttempts(billingParams.Data["retry_attempts"])
+
+	switch billingParams.Provider {
+	case "stripe":
+		hndl = billing.NewStripeBillingHandler(ps.db, billingParams.Data["stripe_key"], retryAttempts)
+	case "braintree":
+		hndl = billing.NewBraintreeBillingHandler(ps.db, billingParams.Data["braintree_api_key"], retryAttempts)
+	default:
+		return "", fmt.Errorf("unknown payment gateway %q", billingParams.Provider)
+	}
+
+	refundID, err := hndl.RefundPayment(paymentReference, cents, idempotencyKey)
+	if err != nil {
+		helpers.Log(logrus.ErrorLevel, "error refunding user..\r\n")
+		helpers.Log(logrus.ErrorLevel, err.Error())
+	}
+
+	return refundID, err
+}
+
 func getRetryAttempts(s string) int {
 	retryAttempts, err := strconv.Atoi(s)
 	if err != nil {
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"lineblocs.com/scheduler/internal/pricing"
+	"lineblocs.com/scheduler/models"
+)
+
+func TestPaymentServiceGetPriceSchedules(t *testing.T) {
+	t.Parallel()
+
+	tiersQuery := regexp.QuoteMeta("SELECT category, mode, up_to, unit_cents, flat_cents FROM service_plans_price_tiers WHERE plan_id = ? ORDER BY category, up_to = 0, up_to")
+	columns := []string{"category", "mode", "up_to", "unit_cents", "flat_cents"}
+
+	t.Run("Should group the tiers by category", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(tiersQuery).WithArgs(3).WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(pricing.FaxPages, pricing.Volume, 0, 4, 0).
+			AddRow(pricing.Minutes, pricing.Graduated, 1000, 2, 0).
+			AddRow(pricing.Minutes, pricing.Graduated, 0, 1, 0))
+
+		schedules, err := NewPaymentService(db).GetPriceSchedules(3)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]pricing.Schedule{
+			pricing.FaxPages: {Mode: pricing.Volume, Tiers: []pricing.Tier{{UnitCents: 4}}},
+			pricing.Minutes:  {Mode: pricing.Graduated, Tiers: []pricing.Tier{{UpTo: 1000, UnitCents: 2}, {UnitCents: 1}}},
+		}, schedules)
+	})
+
+	t.Run("Should reject tiers that do not form a schedule", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(tiersQuery).WithArgs(3).WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(pricing.Minutes, "stairstep", 0, 1, 0))
+
+		_, err = NewPaymentService(db).GetPriceSchedules(3)
+		assert.EqualError(t, err, `plan 3 minutes tiers: unknown pricing mode "stairstep"`)
+	})
+}
+
+func TestPaymentServiceGetPriceCatalog(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	catalogQuery := regexp.QuoteMeta("SELECT id, version, effective_from, effective_to, recordings_per_byte, fax_per_used FROM price_catalogs WHERE effective_from <= ? AND (effective_to IS NULL OR effective_to > ?) ORDER BY effective_from DESC, version DESC LIMIT 1")
+	plansQuery := regexp.QuoteMeta("SELECT plan_id, base_costs, COALESCE(annual_cost_cents, 0), minutes_per_month, recording_space, fax FROM price_catalog_plans WHERE catalog_id = ?")
+
+	t.Run("Should load the catalog in effect with its plan prices", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		mock.ExpectQuery(catalogQuery).WithArgs(at, at).WillReturnRows(sqlmock.NewRows([]string{"id", "version", "effective_from", "effective_to", "recordings_per_byte", "fax_per_used"}).
+			AddRow(9, 4, from, nil, 0.5, 2))
+		mock.ExpectQuery(plansQuery).WithArgs(9).WillReturnRows(sqlmock.NewRows([]string{"plan_id", "base_costs", "annual_cost_cents", "minutes_per_month", "recording_space", "fax"}).
+			AddRow(1, 1200, 12000, 2000, 1024, 50))
+
+		catalog, err := NewPaymentService(db).GetPriceCatalog(at)
+		assert.NoError(t, err)
+		assert.Equal(t, &models.PriceCatalog{
+			ID: 9, Version: 4, EffectiveFrom: from, RecordingsPerByte: 0.5, FaxPerUsed: 2,
+			Plans: map[int]models.CatalogPlan{1: {BaseCosts: 1200, AnnualCostCents: 12000, MinutesPerMonth: 2000, RecordingSpace: 1024, Fax: 50}},
+		}, catalog)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Should return nil when no catalog covers the time", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(catalogQuery).WithArgs(at, at).WillReturnError(sql.ErrNoRows)
+
+		catalog, err := NewPaymentService(db).GetPriceCatalog(at)
+		assert.NoError(t, err)
+		assert.Nil(t, catalog)
+	})
+}
+
+func TestPaymentServiceGetCollectionTerms(t *testing.T) {
+	t.Parallel()
+
+	termsQuery := regexp.QuoteMeta("SELECT collection_method, net_days, payment_instructions FROM workspace_collection_terms WHERE workspace_id = ?")
+	columns := []string{"collection_method", "net_days", "payment_instructions"}
+
+	testCases := []struct {
+		Name          string
+		Rows          *sqlmock.Rows
+		Expected      *models.CollectionTerms
+		ExpectedError string
+	}{
+		{
+			Name:     "net terms are returned with their instructions",
+			Rows:     sqlmock.NewRows(columns).AddRow(models.CollectionSendInvoice, 30, "Wire to account 123"),
+			Expected: &models.CollectionTerms{Method: models.CollectionSendInvoice, PaymentInstructions: "Wire to account 123", WorkspaceID: 3, NetDays: 30},
+		},
+		{
+			Name: "workspaces without terms are charged automatically",
+			Rows: sqlmock.NewRows(columns),
+		},
+		{
+			Name: "automatic charges need no terms",
+			Rows: sqlmock.NewRows(columns).AddRow(models.CollectionChargeAutomatically, 0, nil),
+		},
+		{
+			Name:          "net terms need a number of days",
+			Rows:          sqlmock.NewRows(columns).AddRow(models.CollectionSendInvoice, 0, nil),
+			ExpectedError: "workspace 3: net terms need a positive number of days, got 0",
+		},
+		{
+			Name:          "unknown methods are rejected",
+			Rows:          sqlmock.NewRows(columns).AddRow("cheque", 30, nil),
+			ExpectedError: `workspace 3: unknown collection method "cheque"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			mock.ExpectQuery(termsQuery).WithArgs(3).WillReturnRows(tc.Rows)
+
+			terms, err := NewPaymentService(db).GetCollectionTerms(3)
+			if tc.ExpectedError != "" {
+				assert.EqualError(t, err, tc.ExpectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, terms)
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"lineblocs.com/scheduler/internal/invoice"
+	"lineblocs.com/scheduler/internal/promotions"
+)
+
+// PromotionRepository reads coupons and their redemptions and writes the discounts applied to invoices
+type PromotionRepository interface {
+	LockCouponByCode(ex Executor, code string) (*promotions.Coupon, error)
+	CountRedemptions(ex Executor, couponID int64) (int, error)
+	HasRedeemed(ex Executor, couponID int64, workspaceID int) (bool, error)
+	CreateRedemption(ex Executor, couponID int64, workspaceID int, at time.Time) (int64, error)
+	GetRedemptions(ex Executor, workspaceID int) ([]promotions.Redemption, error)
+	CreateInvoiceDiscounts(ex Executor, invoiceID int64, discounts []promotions.Discount, at time.Time) error
+	MarkRedemptionsApplied(ex Executor, redemptionIDs []int64) error
+	ReleaseRedemptions(ex Executor, invoiceID int64) error
+}
+
+type PromotionService struct{}
+
+func NewPromotionRepository() PromotionRepository {
+	return &PromotionService{}
+}
+
+const couponColumns = "c.id, c.code, c.kind, c.percent_off, c.amount_off_cents, c.duration, c.duration_months, c.categories, c.max_redemptions, c.stackable, c.redeem_by"
+
+// scanCoupon reads the coupon columns with the row or rows Scan, followed by any extra columns
+func scanCoupon(scan func(dest ...any) error, extra ...any) (*promotions.Coupon, error) {
+	var coupon promotions.Coupon
+	var percentOff sql.NullFloat64
+	var amountOff, durationMonths, maxRedemptions sql.NullInt64
+	var categories sql.NullString
+	var redeemBy sql.NullTime
+	dest := append([]any{&coupon.ID, &coupon.Code, &coupon.Kind, &percentOff, &amountOff, &coupon.Duration, &durationMonths, &categories, &maxRedemptions, &coupon.Stackable, &redeemBy}, extra...)
+	if err := scan(dest...); err != nil {
+		return nil, err
+	}
+
+	coupon.PercentOff = percentOff.Float64
+	coupon.AmountOffCents = amountOff.Int64
+	coupon.DurationMonths = int(durationMonths.Int64)
+	coupon.MaxRedemptions = int(maxRedemptions.Int64)
+	coupon.RedeemBy = redeemBy.Time
+	if categories.String != "" {
+		coupon.Categories = strings.Split(categories.String, ",")
+	}
+	return &coupon, nil
+}
+
+// LockCouponByCode finds a coupon by code and locks its row, so redemptions of it are counted and created one at a time
+func (ps *PromotionService) LockCouponByCode(ex Executor, code string) (*promotions.Coupon, error) {
+	row := ex.QueryRow("SELECT "+couponColumns+" FROM coupons c WHERE c.code = ? FOR UPDATE", code)
+	coupon, err := scanCoupon(row.Scan)
+	if err == sql.ErrNoRows {
+		return nil, promotions.ErrCouponNotFound
+	}
+	return coupon, err
+}
+
+func (ps *PromotionService) CountRedemptions(ex Executor, couponID int64) (int, error) {
+	var count int
+	row := ex.QueryRow("SELECT COUNT(*) FROM coupon_redemptions WHERE coupon_id = ?", couponID)
+	err := row.Scan(&count)
+	return count, err
+}
+
+func (ps *PromotionService) HasRedeemed(ex Executor, couponID int64, workspaceID int) (bool, error) {
+	var count int
+	row := ex.QueryRow("SELECT COUNT(*) FROM coupon_redemptions WHERE coupon_id = ? AND workspace_id = ?", couponID, workspaceID)
+	if err := row.Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (ps *PromotionService) CreateRedemption(ex Executor, couponID int64, workspaceID int, at time.Time) (int64, error) {
+	result, err := ex.Exec("INSERT INTO coupon_redemptions (`coupon_id`, `workspace_id`, `cycles_applied`, `created_at`) VALUES (?, ?, 0, ?)",
+		couponID, workspaceID, at)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// GetRedemptions returns every coupon the workspace redeemed, including ones that no longer apply,
+// with the period end of the latest invoice that was not voided each was applied to
+func (ps *PromotionService) GetRedemptions(ex Executor, workspaceID int) ([]promotions.Redemption, error) {
+	rows, err := ex.Query("SELECT "+couponColumns+", r.id, r.workspace_id, r.cycles_applied, r.created_at, "+
+		"(SELECT MAX(i.period_end) FROM invoice_discounts d JOIN users_invoices i ON i.id = d.invoice_id WHERE d.redemption_id = r.id AND i.state <> ?) "+
+		"FROM coupon_redemptions r JOIN coupons c ON c.id = r.coupon_id WHERE r.workspace_id = ? ORDER BY r.id",
+		invoice.Void, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	redemptions := make([]promotions.Redemption, 0)
+	for rows.Next() {
+		var r promotions.Redemption
+		var lastCycle sql.NullTime
+		coupon, err := scanCoupon(rows.Scan, &r.ID, &r.WorkspaceID, &r.CyclesApplied, &r.RedeemedAt, &lastCycle)
+		if err != nil {
+			return nil, err
+		}
+		r.Coupon = *coupon
+		r.LastCycle = lastCycle.Time
+		redemptions = append(redemptions, r)
+	}
+
+	return redemptions, rows.Err()
+}
+
+// CreateInvoiceDiscounts writes the discounts of an invoice as negative line items
+func (ps *PromotionService) CreateInvoiceDiscounts(ex Executor, invoiceID int64, discounts []promotions.Discount, at time.Time) error {
+	for _, d := range discounts {
+		_, err := ex.Exec("INSERT INTO invoice_discounts (`invoice_id`, `redemption_id`, `coupon_id`, `category`, `description`, `cents`, `created_at`) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			invoiceID, d.RedemptionID, d.CouponID, d.Category, d.Description, d.Cents, at)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// countRedemptionCycles sets cycles_applied to the calendar months of the invoices that were not voided
+// and carried a discount of the redemption. The monthly and annual invoices of a month use one cycle.
+const countRedemptionCycles = "UPDATE coupon_redemptions r SET r.cycles_applied = (SELECT COUNT(DISTINCT DATE_FORMAT(i.period_end, '%Y-%m')) " +
+	"FROM invoice_discounts d JOIN users_invoices i ON i.id = d.invoice_id WHERE d.redemption_id = r.id AND i.state <> ?)"
+
+// MarkRedemptionsApplied recounts the billing cycles of each redemption once its discounts are written
+func (ps *PromotionService) MarkRedemptionsApplied(ex Executor, redemptionIDs []int64) error {
+	for _, id := range redemptionIDs {
+		_, err := ex.Exec(countRedemptionCycles+" WHERE r.id = ?", invoice.Void, id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReleaseRedemptions recounts the billing cycles of the coupons on a voided invoice, giving back the
+// cycle it used unless another invoice of the same month still uses it
+func (ps *PromotionService) ReleaseRedemptions(ex Executor, invoiceID int64) error {
+	_, err := ex.Exec(countRedemptionCycles+" WHERE r.id IN (SELECT DISTINCT redemption_id FROM invoice_discounts WHERE invoice_id = ?)",
+		invoice.Void, invoiceID)
+	return err
+}
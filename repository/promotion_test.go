@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"lineblocs.com/scheduler/internal/invoice"
+	"lineblocs.com/scheduler/internal/promotions"
+)
+
+func TestPromotionServiceLockCouponByCode(t *testing.T) {
+	t.Parallel()
+
+	query := regexp.QuoteMeta("SELECT " + couponColumns + " FROM coupons c WHERE c.code = ? FOR UPDATE")
+	columns := []string{"id", "code", "kind", "percent_off", "amount_off_cents", "duration", "duration_months", "categories", "max_redemptions", "stackable", "redeem_by"}
+
+	t.Run("Should lock and return the coupon", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(query).WithArgs("SPRING").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(4, "SPRING", promotions.KindFixed, nil, 500, promotions.DurationOnce, nil, "calls,fax", 10, false, nil))
+
+		coupon, err := NewPromotionRepository().LockCouponByCode(db, "SPRING")
+		assert.NoError(t, err)
+		assert.Equal(t, &promotions.Coupon{
+			ID:             4,
+			Code:           "SPRING",
+			Kind:           promotions.KindFixed,
+			Duration:       promotions.DurationOnce,
+			Categories:     []string{"calls", "fax"},
+			AmountOffCents: 500,
+			MaxRedemptions: 10,
+		}, coupon)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Should return ErrCouponNotFound for an unknown code", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(query).WithArgs("NOPE").WillReturnRows(sqlmock.NewRows(columns))
+
+		coupon, err := NewPromotionRepository().LockCouponByCode(db, "NOPE")
+		assert.ErrorIs(t, err, promotions.ErrCouponNotFound)
+		assert.Nil(t, coupon)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPromotionServiceGetRedemptions(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	redeemedAt := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	lastCycle := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	columns := []string{"id", "code", "kind", "percent_off", "amount_off_cents", "duration", "duration_months", "categories", "max_redemptions", "stackable", "redeem_by",
+		"redemption_id", "workspace_id", "cycles_applied", "created_at", "last_cycle"}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT "+couponColumns+", r.id, r.workspace_id, r.cycles_applied, r.created_at, (SELECT MAX(i.period_end)")).
+		WithArgs(invoice.Void, 7).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(4, "SPRING", promotions.KindPercent, 25, nil, promotions.DurationRepeating, 3, nil, nil, true, nil, 10, 7, 2, redeemedAt, lastCycle).
+			AddRow(4, "SPRING", promotions.KindPercent, 25, nil, promotions.DurationRepeating, 3, nil, nil, true, nil, 11, 7, 0, redeemedAt, nil))
+
+	redemptions, err := NewPromotionRepository().GetRedemptions(db, 7)
+	assert.NoError(t, err)
+	if assert.Len(t, redemptions, 2) {
+		assert.Equal(t, 2, redemptions[0].CyclesApplied)
+		assert.Equal(t, lastCycle, redemptions[0].LastCycle)
+		assert.True(t, redemptions[1].LastCycle.IsZero())
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPromotionServiceRedemptionCycles(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Should recount each redemption by the months of its invoices", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		query := regexp.QuoteMeta(countRedemptionCycles + " WHERE r.id = ?")
+		mock.ExpectExec(query).WithArgs(invoice.Void, int64(10)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(query).WithArgs(invoice.Void, int64(11)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, NewPromotionRepository().MarkRedemptionsApplied(db, []int64{10, 11}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Should recount the redemptions of a voided invoice", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(regexp.QuoteMeta(countRedemptionCycles+" WHERE r.id IN (SELECT DISTINCT redemption_id FROM invoice_discounts WHERE invoice_id = ?)")).
+			WithArgs(invoice.Void, int64(9)).WillReturnResult(sqlmock.NewResult(0, 2))
+
+		assert.NoError(t, NewPromotionRepository().ReleaseRedemptions(db, 9))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"time"
+
+	"lineblocs.com/scheduler/internal/invoice"
+	"lineblocs.com/scheduler/internal/suspension"
+	"lineblocs.com/scheduler/models"
+)
+
+// SuspensionRepository keeps the billing status of workspaces and takes their numbers out of service.
+// Every method takes the Executor to run on so a status change and its numbers commit together.
+type SuspensionRepository interface {
+	GetWorkspacesToReview(ex Executor) ([]int, error)
+	GetUnpaidInvoices(ex Executor, workspaceID int) ([]suspension.Invoice, error)
+	GetBillingStatus(ex Executor, workspaceID int) (string, error)
+	SetBillingStatus(ex Executor, event models.WorkspaceStatusEvent) error
+	HoldNumbers(ex Executor, workspaceID int, at time.Time) (int64, error)
+	RestoreNumbers(ex Executor, workspaceID int) (int64, error)
+	ReleaseNumbers(ex Executor, workspaceID int, at time.Time) (int64, error)
+}
+
+type SuspensionService struct{}
+
+func NewSuspensionRepository() SuspensionRepository {
+	return &SuspensionService{}
+}
+
+// unpaidInvoice matches the invoices still owed, including rows written before invoice states existed
+const unpaidInvoice = "(state IN (?, ?, ?) OR (state IS NULL AND status = 'INCOMPLETE'))"
+
+// GetWorkspacesToReview returns the workspaces with unpaid invoices and those that are not active
+func (ss *SuspensionService) GetWorkspacesToReview(ex Executor) ([]int, error) {
+	rows, err := ex.Query("SELECT workspace_id FROM users_invoices WHERE "+unpaidInvoice+" UNION SELECT id FROM workspaces WHERE billing_status IN (?, ?) ORDER BY 1",
+		invoice.Open, invoice.PartiallyPaid, invoice.Uncollectible, suspension.Restricted, suspension.Suspended)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// GetUnpaidInvoices returns what the workspace still owes. Invoices without a due date were due when they were created.
+func (ss *SuspensionService) GetUnpaidInvoices(ex Executor, workspaceID int) ([]suspension.Invoice, error) {
+	rows, err := ex.Query("SELECT id, COALESCE(num_attempts, 0), COALESCE(due_date, created_at) FROM users_invoices WHERE workspace_id = ? AND "+unpaidInvoice+" ORDER BY id",
+		workspaceID, invoice.Open, invoice.PartiallyPaid, invoice.Uncollectible)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invoices := make([]suspension.Invoice, 0)
+	for rows.Next() {
+		var inv suspension.Invoice
+		if err := rows.Scan(&inv.ID, &inv.FailedAttempts, &inv.DueDate); err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, inv)
+	}
+
+	return invoices, rows.Err()
+}
+
+// GetBillingStatus locks the workspace and returns its billing status
+func (ss *SuspensionService) GetBillingStatus(ex Executor, workspaceID int) (string, error) {
+	var status string
+	row := ex.QueryRow("SELECT COALESCE(billing_status, ?) FROM workspaces WHERE id = ? FOR UPDATE", suspension.Active, workspaceID)
+	if err := row.Scan(&status); err != nil {
+		return "", err
+	}
+	return status, nil
+}
+
+// SetBillingStatus flags the workspace with its new status and records the change
+func (ss *SuspensionService) SetBillingStatus(ex Executor, event models.WorkspaceStatusEvent) error {
+	_, err := ex.Exec("UPDATE workspaces SET billing_status = ?, billing_status_reason = ?, billing_status_changed_at = ? WHERE id = ?",
+		event.To, event.Reason, event.CreatedAt, event.WorkspaceID)
+	if err != nil {
+		return err
+	}
+
+	_, err = ex.Exec("INSERT INTO workspace_billing_events (`workspace_id`, `from_status`, `to_status`, `reason`, `numbers`, `numbers_affected`, `created_at`) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		event.WorkspaceID, event.From, event.To, event.Reason, nullString(event.Numbers), event.NumbersAffected, event.CreatedAt)
+	return err
+}
+
+// HoldNumbers takes the workspace's numbers out of service and returns how many were held
+func (ss *SuspensionService) HoldNumbers(ex Executor, workspaceID int, at time.Time) (int64, error) {
+	result, err := ex.Exec("UPDATE did_numbers SET held_at = ? WHERE workspace_id = ? AND released_at IS NULL AND held_at IS NULL", at, workspaceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// RestoreNumbers puts the workspace's held numbers back in service and returns how many were restored
+func (ss *SuspensionService) RestoreNumbers(ex Executor, workspaceID int) (int64, error) {
+	result, err := ex.Exec("UPDATE did_numbers SET held_at = NULL WHERE workspace_id = ? AND held_at IS NOT NULL", workspaceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ReleaseNumbers gives up the workspace's numbers, which also ends their rental, and returns how many were released
+func (ss *SuspensionService) ReleaseNumbers(ex Executor, workspaceID int, at time.Time) (int64, error) {
+	result, err := ex.Exec("UPDATE did_numbers SET released_at = ? WHERE workspace_id = ? AND released_at IS NULL", at, workspaceID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
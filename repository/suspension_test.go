@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"lineblocs.com/scheduler/internal/invoice"
+	"lineblocs.com/scheduler/internal/suspension"
+	"lineblocs.com/scheduler/models"
+)
+
+func TestSuspensionServiceGetUnpaidInvoices(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	due := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, COALESCE(num_attempts, 0), COALESCE(due_date, created_at) FROM users_invoices WHERE workspace_id = ? AND (state IN (?, ?, ?) OR (state IS NULL AND status = 'INCOMPLETE')) ORDER BY id")).
+		WithArgs(3, invoice.Open, invoice.PartiallyPaid, invoice.Uncollectible).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "num_attempts", "due_date"}).AddRow(9, 2, due))
+
+	invoices, err := NewSuspensionRepository().GetUnpaidInvoices(db, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []suspension.Invoice{{ID: 9, FailedAttempts: 2, DueDate: due}}, invoices)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSuspensionServiceSetBillingStatus(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	at := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	event := models.WorkspaceStatusEvent{CreatedAt: at, From: suspension.Active, To: suspension.Suspended, Reason: "invoice 9 is 40 days overdue", Numbers: "held", WorkspaceID: 3, NumbersAffected: 2}
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE workspaces SET billing_status = ?, billing_status_reason = ?, billing_status_changed_at = ? WHERE id = ?")).
+		WithArgs(suspension.Suspended, event.Reason, at, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO workspace_billing_events")).
+		WithArgs(3, suspension.Active, suspension.Suspended, event.Reason, "held", int64(2), at).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	assert.NoError(t, NewSuspensionRepository().SetBillingStatus(db, event))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSuspensionServiceHoldNumbers(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	at := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE did_numbers SET held_at = ? WHERE workspace_id = ? AND released_at IS NULL AND held_at IS NULL")).
+		WithArgs(at, 3).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	held, err := NewSuspensionRepository().HoldNumbers(db, 3, at)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), held)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"lineblocs.com/scheduler/models"
+)
+
+// TopUpRepository keeps the auto-recharge settings of workspaces and the top-ups charged for them
+type TopUpRepository interface {
+	GetAutoTopUps(ex Executor) ([]models.AutoTopUp, error)
+	LockAutoTopUp(ex Executor, workspaceID int) (*models.AutoTopUp, error)
+	HasPendingTopUp(ex Executor, workspaceID int) (bool, error)
+	CreateTopUp(ex Executor, topUp *models.TopUp) (int64, error)
+	SettleTopUp(ex Executor, id int64, status string, paymentReference string, errMsg string, at time.Time) (bool, error)
+	GetPendingTopUps(ex Executor, createdBefore time.Time) ([]models.TopUp, error)
+}
+
+type TopUpService struct{}
+
+func NewTopUpRepository() TopUpRepository {
+	return &TopUpService{}
+}
+
+// GetAutoTopUps returns the enabled auto-recharge settings
+func (ts *TopUpService) GetAutoTopUps(ex Executor) ([]models.AutoTopUp, error) {
+	rows, err := ex.Query("SELECT workspace_id, threshold_cents, amount_cents FROM workspace_auto_topups WHERE enabled = 1 AND amount_cents > 0 ORDER BY workspace_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	settings := make([]models.AutoTopUp, 0)
+	for rows.Next() {
+		var setting models.AutoTopUp
+		if err := rows.Scan(&setting.WorkspaceID, &setting.ThresholdCents, &setting.AmountCents); err != nil {
+			return nil, err
+		}
+		settings = append(settings, setting)
+	}
+
+	return settings, rows.Err()
+}
+
+// LockAutoTopUp locks the workspace's auto-recharge setting so only one top-up is started at a time.
+// It returns nil when auto-recharge is not enabled.
+func (ts *TopUpService) LockAutoTopUp(ex Executor, workspaceID int) (*models.AutoTopUp, error) {
+	setting := models.AutoTopUp{WorkspaceID: workspaceID}
+	row := ex.QueryRow("SELECT threshold_cents, amount_cents FROM workspace_auto_topups WHERE workspace_id = ? AND enabled = 1 AND amount_cents > 0 FOR UPDATE", workspaceID)
+	err := row.Scan(&setting.ThresholdCents, &setting.AmountCents)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+// HasPendingTopUp reports whether a top-up of the workspace has not been settled yet
+func (ts *TopUpService) HasPendingTopUp(ex Executor, workspaceID int) (bool, error) {
+	var count int
+	row := ex.QueryRow("SELECT COUNT(*) FROM workspace_topups WHERE workspace_id = ? AND status = ?", workspaceID, models.OutboxPending)
+	if err := row.Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (ts *TopUpService) CreateTopUp(ex Executor, topUp *models.TopUp) (int64, error) {
+	result, err := ex.Exec("INSERT INTO workspace_topups (`workspace_id`, `user_id`, `cents`, `balance_cents`, `idempotency_key`, `status`, `created_at`, `updated_at`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		topUp.WorkspaceID, topUp.UserID, topUp.Cents, topUp.BalanceCents, topUp.IdempotencyKey, topUp.Status, topUp.CreatedAt, topUp.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// SettleTopUp records the outcome of the card charge on a pending top-up. It reports false when the
+// top-up was no longer pending, having been settled by another run.
+func (ts *TopUpService) SettleTopUp(ex Executor, id int64, status string, paymentReference string, errMsg string, at time.Time) (bool, error) {
+	result, err := ex.Exec("UPDATE workspace_topups SET status = ?, payment_reference = ?, error = ?, updated_at = ? WHERE id = ? AND status = ?",
+		status, nullString(paymentReference), nullString(errMsg), at, id, models.OutboxPending)
+	if err != nil {
+		return false, err
+	}
+
+	settled, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return settled > 0, nil
+}
+
+// GetPendingTopUps returns the top-ups still pending that were recorded before the given time
+func (ts *TopUpService) GetPendingTopUps(ex Executor, createdBefore time.Time) ([]models.TopUp, error) {
+	rows, err := ex.Query("SELECT id, workspace_id, user_id, cents, balance_cents, idempotency_key, status, created_at FROM workspace_topups WHERE status = ? AND created_at < ? ORDER BY id",
+		models.OutboxPending, createdBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	topUps := make([]models.TopUp, 0)
+	for rows.Next() {
+		var topUp models.TopUp
+		if err := rows.Scan(&topUp.ID, &topUp.WorkspaceID, &topUp.UserID, &topUp.Cents, &topUp.BalanceCents, &topUp.IdempotencyKey, &topUp.Status, &topUp.CreatedAt); err != nil {
+			return nil, err
+		}
+		topUps = append(topUps, topUp)
+	}
+
+	return topUps, rows.Err()
+}
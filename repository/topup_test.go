@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"lineblocs.com/scheduler/models"
+)
+
+func TestTopUpServiceLockAutoTopUp(t *testing.T) {
+	t.Parallel()
+
+	query := regexp.QuoteMeta("SELECT threshold_cents, amount_cents FROM workspace_auto_topups WHERE workspace_id = ? AND enabled = 1 AND amount_cents > 0 FOR UPDATE")
+
+	t.Run("Should return the enabled setting", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(query).WithArgs(3).
+			WillReturnRows(sqlmock.NewRows([]string{"threshold_cents", "amount_cents"}).AddRow(500, 2000))
+
+		setting, err := NewTopUpRepository().LockAutoTopUp(db, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, &models.AutoTopUp{WorkspaceID: 3, ThresholdCents: 500, AmountCents: 2000}, setting)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Should return nil without auto-recharge", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(query).WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"threshold_cents", "amount_cents"}))
+
+		setting, err := NewTopUpRepository().LockAutoTopUp(db, 3)
+		assert.NoError(t, err)
+		assert.Nil(t, setting)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTopUpServiceSettleTopUp(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	query := regexp.QuoteMeta("UPDATE workspace_topups SET status = ?, payment_reference = ?, error = ?, updated_at = ? WHERE id = ? AND status = ?")
+
+	t.Run("Should settle a pending top-up", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(query).
+			WithArgs(models.OutboxFailed, nil, "card declined", at, int64(11), models.OutboxPending).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		settled, err := NewTopUpRepository().SettleTopUp(db, 11, models.OutboxFailed, "", "card declined", at)
+		assert.NoError(t, err)
+		assert.True(t, settled)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Should leave a top-up settled by another run", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(query).
+			WithArgs(models.OutboxSucceeded, "ch_1", nil, at, int64(11), models.OutboxPending).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		settled, err := NewTopUpRepository().SettleTopUp(db, 11, models.OutboxSucceeded, "ch_1", "", at)
+		assert.NoError(t, err)
+		assert.False(t, settled)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
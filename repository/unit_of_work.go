@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"database/sql"
+)
+
+// Executor runs statements either directly on the database or inside a transaction.
+// Both *sql.DB and *sql.Tx satisfy it.
+type Executor interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Prepare(query string) (*sql.Stmt, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// UnitOfWork runs a function inside a transaction, committing if it returns nil and rolling back otherwise
+type UnitOfWork interface {
+	Do(fn func(tx Executor) error) error
+}
+
+type SQLUnitOfWork struct {
+	db *sql.DB
+}
+
+func NewUnitOfWork(db *sql.DB) UnitOfWork {
+	return &SQLUnitOfWork{
+		db: db,
+	}
+}
+
+func (uow *SQLUnitOfWork) Do(fn func(tx Executor) error) error {
+	tx, err := uow.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLUnitOfWork(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Should commit when the work succeeds", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE users_invoices").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err = NewUnitOfWork(db).Do(func(tx Executor) error {
+			_, err := tx.Exec("UPDATE users_invoices SET status = 'COMPLETE' WHERE id = 1")
+			return err
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Should roll back when the work fails", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		err = NewUnitOfWork(db).Do(func(tx Executor) error {
+			return errors.New("gateway unavailable")
+		})
+		assert.EqualError(t, err, "gateway unavailable")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
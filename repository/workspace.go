@@ -2,7 +2,10 @@ package repository
 
 import (
 	"database/sql"
+	"time"
+
 	helpers "github.com/Lineblocs/go-helpers"
+	"lineblocs.com/scheduler/models"
 )
 
 type WorkspaceRepository interface {
@@ -11,9 +14,12 @@ type WorkspaceRepository interface {
 	GetUserFromDB(id int) (*helpers.User, error)
 	GetDIDFromDB(id int) (*helpers.DIDNumber, error)
 	GetCallFromDB(id int) (*helpers.Call, error)
+	GetMemberships(workspaceId int, start time.Time, end time.Time) ([]models.Membership, error)
 }
 
-type WorkspaceService struct{}
+type WorkspaceService struct {
+	db *sql.DB
+}
 
 
 func NewWorkspaceService() WorkspaceRepository {
@@ -21,7 +27,9 @@ func NewWorkspaceService() WorkspaceRepository {
 }
 
 func NewWorkspaceRepository(db *sql.DB) WorkspaceRepository {
-	return &WorkspaceService{}
+	return &WorkspaceService{
+		db: db,
+	}
 }
 
 func (ws *WorkspaceService) GetWorkspaceFromDB(id int) (*helpers.Workspace, error) {
@@ -40,6 +48,41 @@ func (ws *WorkspaceService) GetDIDFromDB(id int) (*helpers.DIDNumber, error) {
 	return helpers.GetDIDFromDB(id)
 }
 
+// GetCallFromDB loads a call including the numbers and direction needed to rate it
 func (ws *WorkspaceService) GetCallFromDB(id int) (*helpers.Call, error) {
-	return helpers.GetCallFromDB(id)
+	call, err := helpers.GetCallFromDB(id)
+	if err != nil || ws.db == nil {
+		return call, err
+	}
+
+	row := ws.db.QueryRow("SELECT `from`, `to`, direction FROM calls WHERE id = ?", id)
+	err = row.Scan(&call.From, &call.To, &call.Direction)
+	if err != nil {
+		return nil, err
+	}
+
+	return call, nil
+}
+
+// GetMemberships returns the memberships of a workspace that overlap the period
+func (ws *WorkspaceService) GetMemberships(workspaceId int, start time.Time, end time.Time) ([]models.Membership, error) {
+	rows, err := ws.db.Query("SELECT user_id, joined_at, left_at FROM workspace_membership_history WHERE workspace_id = ? AND joined_at < ? AND (left_at IS NULL OR left_at > ?) ORDER BY joined_at, id",
+		workspaceId, end, start)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	memberships := make([]models.Membership, 0)
+	for rows.Next() {
+		var membership models.Membership
+		var leftAt sql.NullTime
+		if err := rows.Scan(&membership.UserID, &membership.JoinedAt, &leftAt); err != nil {
+			return nil, err
+		}
+		membership.LeftAt = leftAt.Time
+		memberships = append(memberships, membership)
+	}
+
+	return memberships, rows.Err()
 }
@@ -264,13 +264,14 @@ func CreateInvoiceConfirmationNumber() (string, error) {
     return fmt.Sprintf("INV-%08X", b[:4]), nil
 }
 
-func CreateTaxMetadata(callTollsCosts, recordingCosts, faxCosts, membershipCosts, numberRentalCosts int64) string {
+func CreateTaxMetadata(callTollsCosts, recordingCosts, faxCosts, membershipCosts, numberRentalCosts, addOnCosts int64) string {
     taxMetadata := map[string]int64{
         "call_tolls_costs":    callTollsCosts,
         "recording_costs":     recordingCosts,
         "fax_costs":           faxCosts,
         "membership_costs":    membershipCosts,
         "number_rental_costs": numberRentalCosts,
+        "addon_costs":         addOnCosts,
     }
     b, _ := json.Marshal(taxMetadata)
     return string(b)